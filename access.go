@@ -0,0 +1,151 @@
+package main
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// AccessConfig 为基于来源 IP 的访问控制：deny 优先于 allow，allow 为空表示不限制；
+// admin_* 规则额外作用于 admin_paths 前缀下的请求。trusted_proxies 中的反向代理可通过 X-Forwarded-For 传递真实来源
+type AccessConfig struct {
+	Allow          []string `yaml:"allow"`
+	Deny           []string `yaml:"deny"`
+	AdminAllow     []string `yaml:"admin_allow"`
+	AdminDeny      []string `yaml:"admin_deny"`
+	AdminPaths     []string `yaml:"admin_paths"`
+	TrustedProxies []string `yaml:"trusted_proxies"`
+}
+
+// ipRules 为一组允许/拒绝的网段
+type ipRules struct {
+	allow []netip.Prefix
+	deny  []netip.Prefix
+}
+
+// permits 判断地址是否被规则放行
+func (r ipRules) permits(ip netip.Addr) bool {
+	if prefixesContain(r.deny, ip) {
+		return false
+	}
+	return len(r.allow) == 0 || prefixesContain(r.allow, ip)
+}
+
+// accessPolicy 为解析后的访问控制策略
+type accessPolicy struct {
+	global     ipRules
+	admin      ipRules
+	adminPaths []string
+	proxies    []netip.Prefix
+}
+
+// newAccessPolicy 解析访问控制配置，网段写法无效时返回错误
+func newAccessPolicy(c AccessConfig) (*accessPolicy, error) {
+	p := &accessPolicy{adminPaths: c.AdminPaths}
+	for _, item := range []struct {
+		dst *[]netip.Prefix
+		src []string
+		key string
+	}{
+		{&p.global.allow, c.Allow, "allow"},
+		{&p.global.deny, c.Deny, "deny"},
+		{&p.admin.allow, c.AdminAllow, "admin_allow"},
+		{&p.admin.deny, c.AdminDeny, "admin_deny"},
+		{&p.proxies, c.TrustedProxies, "trusted_proxies"},
+	} {
+		prefixes, err := parsePrefixes(item.src)
+		if err != nil {
+			return nil, fmt.Errorf("access.%s: %w", item.key, err)
+		}
+		*item.dst = prefixes
+	}
+	return p, nil
+}
+
+// parsePrefixes 解析 CIDR 列表，单个 IP 视为 /32 或 /128
+func parsePrefixes(items []string) ([]netip.Prefix, error) {
+	var out []netip.Prefix
+	for _, s := range items {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if !strings.Contains(s, "/") {
+			addr, err := netip.ParseAddr(s)
+			if err != nil {
+				return nil, fmt.Errorf("无效的地址 %q", s)
+			}
+			out = append(out, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(s)
+		if err != nil {
+			return nil, fmt.Errorf("无效的网段 %q", s)
+		}
+		out = append(out, prefix.Masked())
+	}
+	return out, nil
+}
+
+// prefixesContain 判断地址是否落在任一网段内
+func prefixesContain(prefixes []netip.Prefix, ip netip.Addr) bool {
+	for _, p := range prefixes {
+		if p.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isAdminPath 判断请求路径是否属于管理接口
+func (p *accessPolicy) isAdminPath(path string) bool {
+	for _, prefix := range p.adminPaths {
+		if prefix != "" && strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP 返回请求的来源地址；直连方为受信代理时，取 X-Forwarded-For 中最右侧的非代理地址
+func (p *accessPolicy) clientIP(r *http.Request) (netip.Addr, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	ip, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	ip = ip.Unmap()
+	if !prefixesContain(p.proxies, ip) {
+		return ip, true
+	}
+	hops := strings.Split(r.Header.Get("X-Forwarded-For"), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		ip = hop.Unmap()
+		if !prefixesContain(p.proxies, ip) {
+			break
+		}
+	}
+	return ip, true
+}
+
+// withAccessControl 按来源 IP 过滤请求，被拒绝时返回 403；该中间件位于其他处理之前
+func (a *App) withAccessControl(p *accessPolicy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ip, ok := p.clientIP(r)
+		if !ok || !p.global.permits(ip) || (p.isAdminPath(r.URL.Path) && !p.admin.permits(ip)) {
+			addLogAttrs(r, "client_ip", ip.String(), "access", "denied")
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "forbidden"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,61 @@
+package main
+
+import (
+	"time"
+)
+
+// agingThresholds 为某列的老化阈值（天）；StaleAfter 为 0 表示该列不计算老化
+type agingThresholds struct {
+	AgingAfter int
+	StaleAfter int
+}
+
+// defaultAgingThresholds 为各状态列的默认老化阈值，可通过列 API 覆盖
+var defaultAgingThresholds = map[string]agingThresholds{
+	"规划中": {AgingAfter: 14, StaleAfter: 30},
+	"进行中": {AgingAfter: 3, StaleAfter: 7},
+	"搁置中": {AgingAfter: 7, StaleAfter: 14},
+	"已完成": {},
+}
+
+// TaskAge 描述任务在当前状态列停留的时长与老化程度
+type TaskAge struct {
+	DaysInStatus int    `json:"days_in_status"`
+	Bucket       string `json:"bucket"` // fresh / aging / stale，不计算老化的列为空
+}
+
+// computeTaskAge 根据列阈值计算任务的老化程度
+func computeTaskAge(t Task, th agingThresholds, now time.Time) *TaskAge {
+	since := t.StatusChangedAt
+	if since.IsZero() {
+		since = t.UpdatedAt
+	}
+	days := int(now.Sub(since).Hours() / 24)
+	if days < 0 {
+		days = 0
+	}
+	age := &TaskAge{DaysInStatus: days}
+	if th.StaleAfter <= 0 {
+		return age
+	}
+	switch {
+	case days >= th.StaleAfter:
+		age.Bucket = "stale"
+	case th.AgingAfter > 0 && days >= th.AgingAfter:
+		age.Bucket = "aging"
+	default:
+		age.Bucket = "fresh"
+	}
+	return age
+}
+
+// annotateTaskAges 为看板中的任务填充老化信息
+func annotateTaskAges(tasks []Task, cols []Column, now time.Time) {
+	byStatus := map[string]agingThresholds{}
+	for _, c := range cols {
+		byStatus[c.Status] = agingThresholds{AgingAfter: c.AgingAfterDays, StaleAfter: c.StaleAfterDays}
+	}
+	for i := range tasks {
+		tasks[i].Age = computeTaskAge(tasks[i], byStatus[tasks[i].Status], now)
+	}
+}
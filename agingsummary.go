@@ -0,0 +1,138 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// AgingSummaryConfig 为停滞任务自动评论的配置：定时扫描看板上已停滞（超过所在列的 stale 阈值）且同样久没有更新的任务，
+// 以系统评论的形式写明停滞情况，提醒负责人跟进；同一任务在 RepeatDays 天内且期间没有更新时不重复评论
+type AgingSummaryConfig struct {
+	Enabled    bool     `yaml:"enabled"`
+	Interval   Duration `yaml:"interval"`    // 扫描间隔
+	RepeatDays int      `yaml:"repeat_days"` // 再次评论的最短间隔天数
+}
+
+// agingSummaryApprovalSkew 为判断审批与状态变更对应时允许的时间差
+const agingSummaryApprovalSkew = time.Minute
+
+// startAgingSummary 按配置启动停滞任务自动评论的后台任务，启动时先执行一次
+func (a *App) startAgingSummary() {
+	c := a.cfg.AgingSummary
+	if !c.Enabled {
+		return
+	}
+	interval := c.Interval.Std()
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	a.logger.Info("停滞任务自动评论已启用", "interval", interval.String(), "repeat_days", c.RepeatDays)
+	a.startBackground("aging-summary", func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if a.waitForHeadroom(ctx, "aging-summary") != nil {
+				return
+			}
+			if _, err := a.postAgingSummaries(ctx, time.Now(), c.RepeatDays); err != nil && ctx.Err() == nil {
+				a.logger.Error("停滞任务自动评论失败", "err", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// postAgingSummaries 为停滞的在板任务发布系统评论，返回发布的评论数
+func (a *App) postAgingSummaries(ctx context.Context, now time.Time, repeatDays int) (int, error) {
+	cols, err := a.fetchColumns(ctx)
+	if err != nil {
+		return 0, err
+	}
+	tasks, err := a.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks WHERE archived = 0 ORDER BY id`)
+	if err != nil {
+		return 0, err
+	}
+	annotateTaskAges(tasks, cols, now)
+	stale := map[string]int{}
+	for _, c := range cols {
+		stale[c.Status] = c.StaleAfterDays
+	}
+	posted := 0
+	for _, t := range tasks {
+		idle := int(now.Sub(t.UpdatedAt).Hours() / 24)
+		if t.Age == nil || t.Age.Bucket != "stale" || idle < stale[t.Status] {
+			continue
+		}
+		due, err := a.agingSummaryDue(ctx, t, now, repeatDays)
+		if err != nil {
+			return posted, err
+		}
+		if !due {
+			continue
+		}
+		body, err := a.agingSummaryText(ctx, t, idle)
+		if err != nil {
+			return posted, err
+		}
+		if _, err := a.addTaskComment(ctx, TaskComment{TaskID: t.ID, Author: commentAuthorSystem, Kind: commentKindAging, Body: body, CreatedAt: now}); err != nil {
+			return posted, err
+		}
+		a.logger.Info("停滞任务自动评论", "task_id", t.ID, "title", t.Title, "status", t.Status, "idle_days", idle)
+		posted++
+	}
+	return posted, nil
+}
+
+// agingSummaryDue 判断是否应为任务发布停滞评论：上次停滞评论之后任务有更新，或已超过 repeatDays 天
+func (a *App) agingSummaryDue(ctx context.Context, t Task, now time.Time, repeatDays int) (bool, error) {
+	var last sql.NullString
+	if err := a.db.QueryRowContext(ctx, `SELECT MAX(created_at) FROM task_comments WHERE task_id = ? AND kind = ?`, t.ID, commentKindAging).Scan(&last); err != nil {
+		return false, err
+	}
+	at, err := time.Parse(time.RFC3339, last.String)
+	if err != nil || at.Before(t.UpdatedAt) {
+		return true, nil
+	}
+	return !now.Before(at.AddDate(0, 0, repeatDays)), nil
+}
+
+// agingSummaryText 生成停滞评论的内容，如
+// 「已 21 天没有更新，在「进行中」停留 25 天。最近一次状态变更：2024-05-02，规划中 → 进行中，由 ci 申请、alice 审批通过」；
+// 状态变更的操作人只有经过审批的变更才有记录
+func (a *App) agingSummaryText(ctx context.Context, t Task, idle int) (string, error) {
+	text := fmt.Sprintf("已 %d 天没有更新，在「%s」停留 %d 天。", idle, t.Status, t.Age.DaysInStatus)
+	var from, to, at string
+	err := a.db.QueryRowContext(ctx, `SELECT from_status, to_status, created_at FROM task_events WHERE task_id = ? AND kind = ? ORDER BY id DESC LIMIT 1`,
+		t.ID, taskEventStatus).Scan(&from, &to, &at)
+	if err == sql.ErrNoRows {
+		return text + fmt.Sprintf("创建后一直在「%s」。", t.Status), nil
+	}
+	if err != nil {
+		return "", err
+	}
+	changed, _ := time.Parse(time.RFC3339, at)
+	text += fmt.Sprintf("最近一次状态变更：%s，%s → %s", changed.Local().Format("2006-01-02"), from, to)
+	var requestedBy, decidedBy string
+	var decided sql.NullString
+	err = a.db.QueryRowContext(ctx, `SELECT requested_by, decided_by, decided_at FROM task_approvals
+		WHERE task_id = ? AND state = ? AND from_status = ? AND to_status = ? ORDER BY id DESC LIMIT 1`,
+		t.ID, approvalApproved, from, to).Scan(&requestedBy, &decidedBy, &decided)
+	if err != nil && err != sql.ErrNoRows {
+		return "", err
+	}
+	if d, perr := time.Parse(time.RFC3339, decided.String); err == nil && perr == nil && d.Sub(changed).Abs() <= agingSummaryApprovalSkew {
+		switch {
+		case requestedBy != "" && decidedBy != "":
+			text += fmt.Sprintf("，由 %s 申请、%s 审批通过", requestedBy, decidedBy)
+		case decidedBy != "":
+			text += fmt.Sprintf("，由 %s 审批通过", decidedBy)
+		}
+	}
+	return text + "。", nil
+}
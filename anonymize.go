@@ -0,0 +1,107 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	mrand "math/rand/v2"
+	"net/url"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// anonymizer 按密钥确定性地打乱文本：相同输入得到相同输出（同名标签仍然相同），
+// 保留字符数、字符类别（大小写字母、数字、汉字）、空白与标点，因此换行、Markdown 结构与清单标记不变
+type anonymizer struct {
+	key []byte
+}
+
+// anonymizeKeepPattern 匹配打乱时原样保留的片段：任务编号引用与清单勾选框
+var anonymizeKeepPattern = regexp.MustCompile(`(?i)(?:\bTB-|#)\d+\b|\[[ x]\]`)
+
+// newAnonymizer 创建打乱器；key 为空时使用随机密钥，每次导出结果不同
+func newAnonymizer(key string) *anonymizer {
+	if key == "" {
+		b := make([]byte, 32)
+		_, _ = rand.Read(b)
+		return &anonymizer{key: b}
+	}
+	return &anonymizer{key: []byte(key)}
+}
+
+// text 打乱一段文本，保留其中的任务编号引用与清单勾选框
+func (an *anonymizer) text(s string) string {
+	if s == "" {
+		return s
+	}
+	mac := hmac.New(sha256.New, an.key)
+	mac.Write([]byte(s))
+	sum := mac.Sum(nil)
+	rng := mrand.New(mrand.NewPCG(binary.LittleEndian.Uint64(sum[:8]), binary.LittleEndian.Uint64(sum[8:16])))
+	var b strings.Builder
+	b.Grow(len(s))
+	last := 0
+	for _, loc := range anonymizeKeepPattern.FindAllStringIndex(s, -1) {
+		scrambleRunes(&b, s[last:loc[0]], rng)
+		b.WriteString(s[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	scrambleRunes(&b, s[last:], rng)
+	return b.String()
+}
+
+// scrambleRunes 将字母、数字与汉字替换为同类的随机字符，其余字符原样写入
+func scrambleRunes(b *strings.Builder, s string, rng *mrand.Rand) {
+	for _, r := range s {
+		switch {
+		case r >= 0x4E00 && r <= 0x9FA5:
+			b.WriteRune(rune(0x4E00 + rng.IntN(0x9FA5-0x4E00+1)))
+		case unicode.IsDigit(r):
+			b.WriteRune(rune('0' + rng.IntN(10)))
+		case unicode.IsUpper(r):
+			b.WriteRune(rune('A' + rng.IntN(26)))
+		case unicode.IsLetter(r):
+			b.WriteRune(rune('a' + rng.IntN(26)))
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+// url 打乱链接的主机、路径与查询，保留协议以便导入时仍能通过校验
+func (an *anonymizer) url(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return an.text(raw)
+	}
+	return u.Scheme + "://" + an.text(strings.TrimPrefix(raw, u.Scheme+"://"))
+}
+
+// board 打乱导出数据中的自由文本（标题、描述、标签、验收标准、链接、引用与列策略），
+// 保留 ID、状态、时间戳、版本与各集合的数量
+func (an *anonymizer) board(data *boardExport) {
+	for i := range data.Columns {
+		data.Columns[i].Policy = an.text(data.Columns[i].Policy)
+	}
+	for i := range data.Tasks {
+		t := &data.Tasks[i]
+		t.Title = an.text(t.Title)
+		t.Description = an.text(t.Description)
+		for j := range t.Tags {
+			t.Tags[j] = an.text(t.Tags[j])
+		}
+		for j := range t.AcceptanceCriteria {
+			t.AcceptanceCriteria[j] = an.text(t.AcceptanceCriteria[j])
+		}
+		for j := range t.ExternalLinks {
+			t.ExternalLinks[j].Title = an.text(t.ExternalLinks[j].Title)
+			t.ExternalLinks[j].URL = an.url(t.ExternalLinks[j].URL)
+		}
+		for j := range t.Refs {
+			t.Refs[j].Title = an.text(t.Refs[j].Title)
+			t.Refs[j].URL = an.url(t.Refs[j].URL)
+		}
+	}
+}
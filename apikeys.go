@@ -0,0 +1,470 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// API 密钥：脚本与 CI 通过 Authorization: Bearer <密钥> 调用接口，无需个人凭据。
+// 密钥按权限范围授权：read 只能发起 GET/HEAD 请求，write 额外可修改数据，admin 额外可访问管理接口
+// （access.admin_paths 下的路径与 /api/keys 本身）。密钥原文只在创建时返回一次，库中只保存其 SHA-256；
+// 可设置过期时间，过期或删除后立即失效。api_keys.required 开启后 /api/ 下的请求必须携带有效密钥或签名，
+// 此时第一个密钥需通过命令行 apikey 创建；已登录用户的网页会话同样可以访问。
+// 密钥可绑定来源网段（allowed_ips），从其他地址使用时返回 403；大屏使用的 display 密钥见 display.go。
+// 开启登录后只有看板 owner 能管理密钥；登录用户创建的密钥以创建者的身份与角色访问，不视为服务账号，
+// 创建者被删除时密钥随之失效。命令行创建的密钥没有创建者，仍为服务账号
+
+// API 密钥的权限范围，后者包含前者
+const (
+	scopeRead  = "read"
+	scopeWrite = "write"
+	scopeAdmin = "admin"
+	// scopeDisplay 只能读取看板快照与统计接口，不包含 read，也不能与其他范围组合
+	scopeDisplay = "display"
+)
+
+// apiKeyScopes 为权限范围及其级别
+var apiKeyScopes = map[string]int{scopeDisplay: 0, scopeRead: 1, scopeWrite: 2, scopeAdmin: 3}
+
+// apiKeyTokenPrefix 为密钥原文的前缀，便于在日志与代码仓库扫描中识别
+const apiKeyTokenPrefix = "tbk_"
+
+// apiKeyPublicPaths 为 api_keys.required 开启后仍无需密钥的路径：健康检查、接口文档与自带令牌校验的入站邮件
+var apiKeyPublicPaths = []string{"/api/health", "/api/openapi.json", "/api/docs", "/api/integrations/email/inbound"}
+
+// APIKeyConfig 为 API 密钥配置；Required 为 true 时 /api/ 下的请求必须携带有效密钥或签名
+type APIKeyConfig struct {
+	Required bool `yaml:"required"`
+}
+
+// APIKey 为一个 API 密钥；Prefix 为密钥原文的开头部分，用于辨认密钥
+type APIKey struct {
+	ID         int64      `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedBy  *int64     `json:"created_by,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// apiKeyRequest 为创建密钥的请求体；expires_at 与 expires_in（如 720h）至多提供一个，均不提供表示长期有效
+// （display 密钥缺省一年）；allowed_ips 为允许使用该密钥的地址或网段，为空表示不限制
+type apiKeyRequest struct {
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	AllowedIPs []string   `json:"allowed_ips"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	ExpiresIn  string     `json:"expires_in"`
+}
+
+// apiKeyCreatedResponse 为创建密钥的响应结构，Token 为密钥原文，只返回这一次
+type apiKeyCreatedResponse struct {
+	APIKey
+	Token string `json:"token"`
+}
+
+// apiKeyListResponse 为密钥列表的响应结构
+type apiKeyListResponse struct {
+	Items []APIKey `json:"items"`
+}
+
+// apiKeyColumns 为查询密钥时的列
+const apiKeyColumns = `id, name, prefix, scopes, allowed_ips, expires_at, last_used_at, created_by, created_at`
+
+// scanAPIKey 扫描一行密钥记录
+func scanAPIKey(row rowScanner) (APIKey, error) {
+	var k APIKey
+	var scopes, ips, created string
+	var expires, used sql.NullString
+	var createdBy sql.NullInt64
+	err := row.Scan(&k.ID, &k.Name, &k.Prefix, &scopes, &ips, &expires, &used, &createdBy, &created)
+	if createdBy.Valid {
+		k.CreatedBy = &createdBy.Int64
+	}
+	k.Scopes = strings.Split(scopes, ",")
+	if ips != "" {
+		k.AllowedIPs = strings.Split(ips, ",")
+	}
+	k.ExpiresAt = parseOptionalTime(expires)
+	k.LastUsedAt = parseOptionalTime(used)
+	k.CreatedAt, _ = time.Parse(time.RFC3339, created)
+	return k, err
+}
+
+// allows 判断密钥的权限范围是否包含 scope
+func (k APIKey) allows(scope string) bool {
+	for _, s := range k.Scopes {
+		if apiKeyScopes[s] >= apiKeyScopes[scope] {
+			return true
+		}
+	}
+	return false
+}
+
+// hashAPIKey 返回密钥原文的 SHA-256（十六进制）
+func hashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// normalizeScopes 校验并去重权限范围，按级别排序
+func normalizeScopes(scopes []string) ([]string, error) {
+	var out []string
+	for _, s := range scopes {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if _, ok := apiKeyScopes[s]; !ok {
+			return nil, fmt.Errorf("scopes must be read, write, admin or display")
+		}
+		if !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("scopes required")
+	}
+	if len(out) > 1 && slices.Contains(out, scopeDisplay) {
+		return nil, fmt.Errorf("display cannot be combined with other scopes")
+	}
+	slices.SortFunc(out, func(a, b string) int { return apiKeyScopes[a] - apiKeyScopes[b] })
+	return out, nil
+}
+
+// createAPIKey 生成并保存新密钥，返回含原文的结果；allowedIPs 须已通过 normalizeAllowedIPs 校验，
+// createdBy 为创建者，0 表示服务账号密钥
+func (a *App) createAPIKey(ctx context.Context, name string, scopes, allowedIPs []string, expiresAt *time.Time, createdBy int64) (apiKeyCreatedResponse, error) {
+	raw := make([]byte, 24)
+	if _, err := rand.Read(raw); err != nil {
+		return apiKeyCreatedResponse{}, err
+	}
+	prefix := apiKeyTokenPrefix
+	if slices.Contains(scopes, scopeDisplay) {
+		prefix = displayKeyTokenPrefix
+	}
+	token := prefix + base64.RawURLEncoding.EncodeToString(raw)
+	now := time.Now().Truncate(time.Second)
+	var expires, creator any
+	if expiresAt != nil {
+		expires = expiresAt.Format(time.RFC3339)
+	}
+	if createdBy != 0 {
+		creator = createdBy
+	}
+	res, err := a.db.ExecContext(ctx, `INSERT INTO api_keys (name, prefix, key_hash, scopes, allowed_ips, expires_at, created_by, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		name, token[:len(prefix)+8], hashAPIKey(token), strings.Join(scopes, ","), strings.Join(allowedIPs, ","), expires, creator, now.Format(time.RFC3339))
+	if err != nil {
+		return apiKeyCreatedResponse{}, err
+	}
+	id, _ := res.LastInsertId()
+	k, err := scanAPIKey(a.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = ?`, id))
+	return apiKeyCreatedResponse{APIKey: k, Token: token}, err
+}
+
+// lookupAPIKey 按原文查找有效密钥并记录使用时间（每分钟至多写一次）；不存在或已过期时返回 sql.ErrNoRows
+func (a *App) lookupAPIKey(ctx context.Context, token string, now time.Time) (APIKey, error) {
+	k, err := scanAPIKey(a.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE key_hash = ?`, hashAPIKey(token)))
+	if err != nil {
+		return k, err
+	}
+	if k.ExpiresAt != nil && !now.Before(*k.ExpiresAt) {
+		return k, sql.ErrNoRows
+	}
+	if k.LastUsedAt == nil || now.Sub(*k.LastUsedAt) >= time.Minute {
+		_, err = a.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = ? WHERE id = ?`, now.Format(time.RFC3339), k.ID)
+	}
+	return k, err
+}
+
+// requiredScope 返回请求所需的权限范围
+func requiredScope(r *http.Request, p *accessPolicy) string {
+	switch {
+	case p.isAdminPath(r.URL.Path) || strings.HasPrefix(r.URL.Path, "/api/keys"):
+		return scopeAdmin
+	case r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions:
+		return scopeRead
+	default:
+		return scopeWrite
+	}
+}
+
+// apiKeyRequired 判断开启 api_keys.required 后该路径是否必须携带密钥
+func apiKeyRequired(path string) bool {
+	return strings.HasPrefix(path, "/api/") && !slices.Contains(apiKeyPublicPaths, path)
+}
+
+// apiKeyCtxKey 为请求上下文中保存已验证密钥的键
+type apiKeyCtxKey struct{}
+
+// withAPIKeys 校验 Authorization: Bearer 密钥（或大屏的 display Cookie）：无效或过期时返回 401，
+// 权限范围不足或来源地址不在绑定网段内时返回 403；Authorization 头不是非空的 Bearer 密钥时同样返回 401，
+// withSessions 据此可以在有该头时跳过登录检查；
+// 未携带密钥的请求在 api_keys.required 开启且既未签名也未登录时返回 401，否则照常处理
+func (a *App) withAPIKeys(p *accessPolicy, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 大屏登记页需按来源地址校验绑定网段，因此在此处理
+		if r.URL.Path == "/display" {
+			a.handleDisplay(w, r, p)
+			return
+		}
+		auth := r.Header.Get("Authorization")
+		scheme, token, _ := strings.Cut(auth, " ")
+		if auth != "" && (!strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "") {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="task-board", error="invalid_request"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid authorization header"})
+			return
+		}
+		// 大屏 Cookie 只在没有登录会话时使用，否则打开过 /display 的登录用户会被降为 display 范围
+		if scheme == "" && currentUserID(r) == 0 && hasDisplayCookie(r) {
+			c, _ := r.Cookie(displayCookie)
+			scheme, token = "Bearer", c.Value
+		}
+		if !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+			if a.cfg.APIKeys.Required && apiKeyRequired(r.URL.Path) && signedClient(r) == "" && currentUserID(r) == 0 && !a.guestReadable(r) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="task-board"`)
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "api key required"})
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		k, err := a.lookupAPIKey(r.Context(), strings.TrimSpace(token), time.Now())
+		if errors.Is(err, sql.ErrNoRows) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="task-board", error="invalid_token"`)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid api key"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		addLogAttrs(r, "api_key", k.Prefix)
+		if !k.boundTo(r, p) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "api key not allowed from this address"})
+			return
+		}
+		if k.isDisplay() {
+			if !displayAllows(r) {
+				writeJSON(w, http.StatusForbidden, map[string]string{"error": "display keys can only read board snapshots and stats"})
+				return
+			}
+		} else if need := requiredScope(r, p); !k.allows(need) {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "insufficient scope", "required_scope": need})
+			return
+		}
+		ctx := context.WithValue(r.Context(), apiKeyCtxKey{}, k)
+		if k.CreatedBy != nil {
+			// 登录用户创建的密钥以创建者身份访问，团队与看板的角色检查照常生效
+			ctx = context.WithValue(ctx, userCtxKey{}, *k.CreatedBy)
+		}
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// canManageAPIKeys 判断请求能否管理密钥：服务账号可以；登录用户须为看板所属团队的 owner，
+// 看板未归属团队时登录用户之间没有角色区分，均可管理。不满足时写入 401 或 403 并返回 false
+func (a *App) canManageAPIKeys(w http.ResponseWriter, r *http.Request) bool {
+	if a.isServiceRequest(r) {
+		return true
+	}
+	userID := currentUserID(r)
+	if userID == 0 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "login required"})
+		return false
+	}
+	teamID, err := a.boardTeamID(r.Context())
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return false
+	}
+	if teamID == 0 {
+		return true
+	}
+	role, err := a.teamRole(r.Context(), teamID, userID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return false
+	}
+	if role != teamRoleOwner {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "team owner required"})
+		return false
+	}
+	return true
+}
+
+// parseAPIKeyExpiry 根据 expires_at 或 expires_in 计算过期时间
+func parseAPIKeyExpiry(at *time.Time, in string, now time.Time) (*time.Time, error) {
+	switch {
+	case at != nil && in != "":
+		return nil, fmt.Errorf("expires_at and expires_in are mutually exclusive")
+	case at != nil:
+		if !at.After(now) {
+			return nil, fmt.Errorf("expires_at must be in the future")
+		}
+		t := at.Truncate(time.Second)
+		return &t, nil
+	case in != "":
+		d, err := time.ParseDuration(in)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("expires_in must be a positive duration")
+		}
+		t := now.Add(d).Truncate(time.Second)
+		return &t, nil
+	}
+	return nil, nil
+}
+
+// handleAPIKeys 处理 /api/keys：GET 列出密钥（不含原文），POST 创建密钥并返回原文
+func (a *App) handleAPIKeys(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !a.canManageAPIKeys(w, r) {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		rows, err := a.db.QueryContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys ORDER BY id`)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+		items := []APIKey{}
+		for rows.Next() {
+			k, err := scanAPIKey(rows)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			items = append(items, k)
+		}
+		if err := rows.Err(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, apiKeyListResponse{Items: items})
+	case http.MethodPost:
+		var body apiKeyRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		name := strings.TrimSpace(body.Name)
+		if name == "" || len([]rune(name)) > 100 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name must be 1-100 characters"})
+			return
+		}
+		scopes, err := normalizeScopes(body.Scopes)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		ips, err := normalizeAllowedIPs(body.AllowedIPs)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		expires, err := parseAPIKeyExpiry(body.ExpiresAt, body.ExpiresIn, time.Now())
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		expires = defaultKeyExpiry(scopes, expires, time.Now())
+		created, err := a.createAPIKey(ctx, name, scopes, ips, expires, currentUserID(r))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		a.reqLogger(r).Info("已创建 API 密钥", "key_id", created.ID, "name", created.Name, "prefix", created.Prefix, "scopes", strings.Join(scopes, ","))
+		writeJSON(w, http.StatusCreated, created)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// handleAPIKeyItem 处理 /api/keys/{id}：GET 返回密钥信息，DELETE 吊销密钥
+func (a *App) handleAPIKeyItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !a.canManageAPIKeys(w, r) {
+		return
+	}
+	id, err := parseInt64(strings.TrimPrefix(r.URL.Path, "/api/keys/"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	addLogAttrs(r, "key_id", id)
+	k, err := scanAPIKey(a.db.QueryRowContext(ctx, `SELECT `+apiKeyColumns+` FROM api_keys WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "api key not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, k)
+	case http.MethodDelete:
+		if _, err := a.db.ExecContext(ctx, `DELETE FROM api_keys WHERE id = ?`, id); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		a.reqLogger(r).Info("已吊销 API 密钥", "name", k.Name, "prefix", k.Prefix)
+		writeJSON(w, http.StatusOK, map[string]any{"id": id, "deleted": true})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// runAPIKey 通过命令行创建 API 密钥并打印原文，用于在开启 api_keys.required 前签发第一个 admin 密钥
+func runAPIKey(cfg Config, args []string) error {
+	flags := flag.NewFlagSet("apikey", flag.ContinueOnError)
+	name := flags.String("name", "", "密钥名称，如 ci")
+	scopes := flags.String("scopes", scopeRead, "权限范围，逗号分隔：read、write、admin，或单独的 display")
+	allowedIPs := flags.String("allowed-ips", "", "允许使用的地址或网段，逗号分隔，为空表示不限制")
+	expiresIn := flags.Duration("expires-in", 0, "有效期，如 720h，0 表示长期有效（display 密钥缺省一年）")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+	if strings.TrimSpace(*name) == "" {
+		return errors.New("请通过 -name 指定密钥名称")
+	}
+	list, err := normalizeScopes(strings.Split(*scopes, ","))
+	if err != nil {
+		return err
+	}
+	var ips []string
+	if *allowedIPs != "" {
+		if ips, err = normalizeAllowedIPs(strings.Split(*allowedIPs, ",")); err != nil {
+			return err
+		}
+	}
+	var expires *time.Time
+	if *expiresIn > 0 {
+		t := time.Now().Add(*expiresIn).Truncate(time.Second)
+		expires = &t
+	}
+	expires = defaultKeyExpiry(list, expires, time.Now())
+	app := NewApp(cfg)
+	defer app.db.Close()
+	created, err := app.createAPIKey(context.Background(), strings.TrimSpace(*name), list, ips, expires, 0)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已创建 API 密钥 %s（%s），请妥善保存，之后无法再次查看:\n%s\n", created.Name, strings.Join(created.Scopes, ","), created.Token)
+	return nil
+}
@@ -0,0 +1,332 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 列策略开启 require_approval 后，任务移入该列不会立即生效，而是生成一条待审批记录；
+// 审批人通过后才变更状态，驳回则任务保持原状态。审批人以请求签名的客户端 ID 识别。
+
+// 审批记录状态
+const (
+	approvalPending   = "pending"
+	approvalApproved  = "approved"
+	approvalRejected  = "rejected"
+	approvalCancelled = "cancelled" // 审批时任务已不在原状态（被移动或归档），申请作废
+)
+
+// maxApprovalCommentLen 为审批意见的最大长度
+const maxApprovalCommentLen = 2000
+
+// errApprovalPending 表示任务已有待审批的状态变更
+var errApprovalPending = errors.New("approval already pending")
+
+// TaskApproval 为一条状态变更审批记录
+type TaskApproval struct {
+	ID          int64      `json:"id"`
+	TaskID      int64      `json:"task_id"`
+	TaskTitle   string     `json:"task_title,omitempty"`
+	FromStatus  string     `json:"from_status"`
+	ToStatus    string     `json:"to_status"`
+	State       string     `json:"state"`
+	RequestedBy string     `json:"requested_by,omitempty"`
+	DecidedBy   string     `json:"decided_by,omitempty"`
+	Comment     string     `json:"comment,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+}
+
+// approvalListResponse 为审批列表的响应结构
+type approvalListResponse struct {
+	Items []TaskApproval `json:"items"`
+}
+
+// approvalDecisionRequest 为通过或驳回审批的请求体
+type approvalDecisionRequest struct {
+	Comment string `json:"comment"`
+}
+
+// approvalColumns 为查询审批记录时选取的列，需与 scanApproval 对应
+const approvalColumns = `a.id, a.task_id, COALESCE(t.title, ''), a.from_status, a.to_status, a.state, a.requested_by, a.decided_by, a.comment, a.created_at, a.decided_at`
+
+// scanApproval 从一行结果中读取审批记录
+func scanApproval(scan func(...any) error) (TaskApproval, error) {
+	var ap TaskApproval
+	var created string
+	var decided sql.NullString
+	if err := scan(&ap.ID, &ap.TaskID, &ap.TaskTitle, &ap.FromStatus, &ap.ToStatus, &ap.State, &ap.RequestedBy, &ap.DecidedBy, &ap.Comment, &created, &decided); err != nil {
+		return ap, err
+	}
+	ap.CreatedAt, _ = time.Parse(time.RFC3339, created)
+	if t, err := time.Parse(time.RFC3339, decided.String); err == nil {
+		ap.DecidedAt = &t
+	}
+	return ap, nil
+}
+
+// fetchApproval 返回一条审批记录
+func (a *App) fetchApproval(ctx context.Context, id int64) (TaskApproval, error) {
+	row := a.db.QueryRowContext(ctx, `SELECT `+approvalColumns+` FROM task_approvals a LEFT JOIN tasks t ON t.id = a.task_id WHERE a.id = ?`, id)
+	return scanApproval(row.Scan)
+}
+
+// createApproval 为任务从 from 移到 to 创建待审批记录；expected 大于 0 时同时校验任务版本，
+// 版本不符返回 sql.ErrNoRows，已有待审批记录时返回 errApprovalPending
+func (a *App) createApproval(ctx context.Context, taskID, expected int64, from, to, requestedBy string) (int64, error) {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	if expected > 0 {
+		var version int64
+		if err := tx.QueryRowContext(ctx, `SELECT version FROM tasks WHERE id = ? AND status = ?`, taskID, from).Scan(&version); err != nil {
+			return 0, err
+		}
+		if version != expected {
+			return 0, sql.ErrNoRows
+		}
+	}
+	var pending int64
+	err = tx.QueryRowContext(ctx, `SELECT id FROM task_approvals WHERE task_id = ? AND state = ?`, taskID, approvalPending).Scan(&pending)
+	if err == nil {
+		return pending, errApprovalPending
+	}
+	if err != sql.ErrNoRows {
+		return 0, err
+	}
+	res, err := tx.ExecContext(ctx, `INSERT INTO task_approvals (task_id, from_status, to_status, state, requested_by, created_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		taskID, from, to, approvalPending, requestedBy, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return id, tx.Commit()
+}
+
+// requestStatusApproval 在状态接口中为需审批的移动创建审批记录并返回 202；任务状态与版本保持不变
+func (a *App) requestStatusApproval(w http.ResponseWriter, r *http.Request, taskID, expected int64, from, to, reason string) {
+	ctx := r.Context()
+	id, err := a.createApproval(ctx, taskID, expected, from, to, signedClient(r))
+	if err == sql.ErrNoRows {
+		a.writeVersionConflict(ctx, w, taskID)
+		return
+	}
+	if err == errApprovalPending {
+		writeJSON(w, http.StatusConflict, map[string]any{"error": err.Error(), "approval_id": id})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	ap, err := a.fetchApproval(ctx, id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	a.recordTransitionReason(ctx, r, taskID, from, to, reason)
+	a.reqLogger(r).Info("状态变更等待审批", "approval_id", id, "from", from, "to", to)
+	writeJSON(w, http.StatusAccepted, ap)
+}
+
+// handleApprovals 处理 GET /api/approvals：默认列出待审批记录，state=all 返回全部，可按 task_id 过滤
+func (a *App) handleApprovals(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	q := r.URL.Query()
+	query := `SELECT ` + approvalColumns + ` FROM task_approvals a LEFT JOIN tasks t ON t.id = a.task_id WHERE 1 = 1`
+	args := []any{}
+	switch state := q.Get("state"); state {
+	case "":
+		query += ` AND a.state = ?`
+		args = append(args, approvalPending)
+	case "all":
+	case approvalPending, approvalApproved, approvalRejected, approvalCancelled:
+		query += ` AND a.state = ?`
+		args = append(args, state)
+	default:
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid state"})
+		return
+	}
+	if v := q.Get("task_id"); v != "" {
+		taskID, err := parseInt64(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid task_id"})
+			return
+		}
+		query += ` AND a.task_id = ?`
+		args = append(args, taskID)
+	}
+	query += ` ORDER BY a.id DESC LIMIT 500`
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+	items := []TaskApproval{}
+	for rows.Next() {
+		ap, err := scanApproval(rows.Scan)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		items = append(items, ap)
+	}
+	if err := rows.Err(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, approvalListResponse{Items: items})
+}
+
+// handleApprovalItem 处理 GET /api/approvals/{id} 与 POST /api/approvals/{id}/approve|reject
+func (a *App) handleApprovalItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	idStr, action, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/approvals/"), "/")
+	id, err := parseInt64(idStr)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	addLogAttrs(r, "approval_id", id)
+	switch action {
+	case "":
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		ap, err := a.fetchApproval(ctx, id)
+		if err == sql.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "approval not found"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, ap)
+	case "approve", "reject":
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		a.decideApproval(w, r, id, action == "approve")
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	}
+}
+
+// decideApproval 通过或驳回一条待审批记录。只有以目标列审批人签名的请求可以审批，且申请人不能审批自己的申请；
+// 目标列没有审批人（开启审批的旧数据）时任何人都不能审批。
+// 通过时任务须仍处于申请时的状态且未归档，否则申请作废并返回 409
+func (a *App) decideApproval(w http.ResponseWriter, r *http.Request, id int64, approve bool) {
+	ctx := r.Context()
+	var body approvalDecisionRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	body.Comment = strings.TrimSpace(body.Comment)
+	if len([]rune(body.Comment)) > maxApprovalCommentLen {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "comment too long"})
+		return
+	}
+	ap, err := a.fetchApproval(ctx, id)
+	if err == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "approval not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	_, approvers, err := a.columnApproval(ctx, ap.ToStatus)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	client := signedClient(r)
+	if client == "" || !slices.Contains(approvers, client) {
+		a.reqLogger(r).Warn("非审批人尝试审批", "client", client)
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "not an approver"})
+		return
+	}
+	if client == ap.RequestedBy {
+		a.reqLogger(r).Warn("申请人尝试审批自己的申请", "client", client)
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "cannot decide your own approval request"})
+		return
+	}
+	state := approvalRejected
+	if approve {
+		state = approvalApproved
+		// 审批期间列中的任务数可能已变化，通过时按当前数量再次检查 WIP 限制；拒绝时审批保持待处理
+		if !a.checkWIPLimit(ctx, w, ap.ToStatus, ap.TaskID) {
+			return
+		}
+	}
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	defer tx.Rollback()
+	now := time.Now().Format(time.RFC3339)
+	if approve {
+		res, err := tx.ExecContext(ctx, `
+			UPDATE tasks SET status = ?, updated_at = ?, version = version + 1, status_changed_at = ?
+			WHERE id = ? AND status = ? AND archived = 0
+		`, ap.ToStatus, now, now, ap.TaskID, ap.FromStatus)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			state = approvalCancelled
+		}
+	}
+	res, err := tx.ExecContext(ctx, `UPDATE task_approvals SET state = ?, decided_by = ?, comment = ?, decided_at = ? WHERE id = ? AND state = ?`,
+		state, client, body.Comment, now, id, approvalPending)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "approval already decided"})
+		return
+	}
+	if err := tx.Commit(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	addLogAttrs(r, "task_id", ap.TaskID)
+	a.reqLogger(r).Info("审批已处理", "state", state, "from", ap.FromStatus, "to", ap.ToStatus)
+	if state == approvalCancelled {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "task is no longer in " + ap.FromStatus + "; approval cancelled"})
+		return
+	}
+	if state == approvalApproved && ap.ToStatus == "已完成" {
+		a.onTaskCompleted(ctx, ap.TaskID)
+	}
+	ap, err = a.fetchApproval(ctx, id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, ap)
+}
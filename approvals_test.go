@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestColumnPolicyRequiresApprover(t *testing.T) {
+	app := newTestApp(t, nil)
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPut, "/api/columns/进行中", strings.NewReader(`{"require_approval":true}`)))
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "approver") {
+		t.Errorf("require_approval without approvers = %d, want 400: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestDecideApprovalRejectsRequesterAndNonApprovers(t *testing.T) {
+	app := newTestApp(t, nil)
+	if _, err := app.db.Exec(`INSERT INTO column_policies (status, require_approval, approvers, updated_at) VALUES (?, 1, 'ci,lead', '2026-01-01T00:00:00Z')`, "进行中"); err != nil {
+		t.Fatal(err)
+	}
+	res, err := app.db.Exec(`INSERT INTO tasks (title, description, status, created_at, updated_at) VALUES ('t', '', ?, '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')`, "规划中")
+	if err != nil {
+		t.Fatal(err)
+	}
+	taskID, _ := res.LastInsertId()
+	res, err = app.db.Exec(`INSERT INTO task_approvals (task_id, from_status, to_status, requested_by, created_at) VALUES (?, ?, ?, 'ci', '2026-01-01T00:00:00Z')`, taskID, "规划中", "进行中")
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	path := "/api/approvals/" + strconv.FormatInt(id, 10) + "/approve"
+
+	for _, tc := range []struct {
+		client string
+		want   int
+	}{
+		{"", http.StatusForbidden},
+		{"other", http.StatusForbidden},
+		{"ci", http.StatusForbidden},
+		{"lead", http.StatusOK},
+	} {
+		req := httptest.NewRequest(http.MethodPost, path, nil)
+		if tc.client != "" {
+			req = req.WithContext(context.WithValue(req.Context(), signedClientKey{}, tc.client))
+		}
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("approve as %q = %d, want %d: %s", tc.client, rec.Code, tc.want, rec.Body.String())
+		}
+	}
+}
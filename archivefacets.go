@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 归档列表的分面：按归档时的状态、标签与归档月份统计匹配的任务数，并支持以 status、tag、month 参数多选过滤。
+// 同一分面内的多个取值满足其一即可，不同分面之间同时满足；每个分面的计数忽略该分面自身的选择、
+// 只应用其他分面与 q 的过滤，这样已勾选的分面中其余取值仍显示可选的数量。
+
+// archivedAtExpr 为任务的归档时间：最近一次归档事件，缺失时以更新时间近似（与归档压缩一致）
+const archivedAtExpr = `COALESCE((SELECT MAX(e.created_at) FROM task_events e WHERE e.task_id = tasks.id AND e.kind = 'archived'), tasks.updated_at)`
+
+// archiveMonthExpr 为按本地时区计算的归档月份 YYYY-MM
+const archiveMonthExpr = `strftime('%Y-%m', ` + archivedAtExpr + `, 'localtime')`
+
+// 归档分面名称
+const (
+	facetStatus = "status"
+	facetTag    = "tag"
+	facetMonth  = "month"
+)
+
+// facetCount 为分面中的一个取值及匹配的任务数
+type facetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// archiveFacets 为归档列表的分面计数：状态按看板列顺序，标签按数量从多到少，月份从新到旧
+type archiveFacets struct {
+	Status []facetCount `json:"status"`
+	Tag    []facetCount `json:"tag"`
+	Month  []facetCount `json:"month"`
+}
+
+// archiveFacetFilter 为归档列表的分面选择
+type archiveFacetFilter struct {
+	statuses []string
+	tags     []string
+	months   []string
+}
+
+// parseArchiveFacetFilter 解析可重复的 status、tag、month 查询参数
+func parseArchiveFacetFilter(query url.Values, tags TagsConfig) (archiveFacetFilter, error) {
+	var f archiveFacetFilter
+	for _, s := range query[facetStatus] {
+		if !validStatus(s) {
+			return f, fmt.Errorf("invalid status %q", s)
+		}
+		f.statuses = append(f.statuses, s)
+	}
+	f.tags = tags.normalizeAll(query[facetTag])
+	for _, m := range query[facetMonth] {
+		if _, err := time.Parse("2006-01", m); err != nil {
+			return f, fmt.Errorf("invalid month %q, want YYYY-MM", m)
+		}
+		f.months = append(f.months, m)
+	}
+	return f, nil
+}
+
+// cond 返回分面选择对应的 SQL 条件（以 " AND " 开头），skip 指定的分面不参与过滤
+func (f archiveFacetFilter) cond(skip string) (string, []any) {
+	var cond string
+	var args []any
+	in := func(values []string) string {
+		for _, v := range values {
+			args = append(args, v)
+		}
+		return "(?" + strings.Repeat(", ?", len(values)-1) + ")"
+	}
+	if len(f.statuses) > 0 && skip != facetStatus {
+		cond += " AND status IN " + in(f.statuses)
+	}
+	if len(f.tags) > 0 && skip != facetTag {
+		cond += " AND id IN (SELECT task_id FROM task_tags WHERE tag IN " + in(f.tags) + ")"
+	}
+	if len(f.months) > 0 && skip != facetMonth {
+		cond += " AND " + archiveMonthExpr + " IN " + in(f.months)
+	}
+	return cond, args
+}
+
+// queryArchiveFacets 统计归档任务的分面计数；cond 与 args 为分面以外的过滤条件（以 WHERE 开头）
+func (a *App) queryArchiveFacets(ctx context.Context, cond string, args []any, f archiveFacetFilter) (archiveFacets, error) {
+	facets := archiveFacets{Status: []facetCount{}, Tag: []facetCount{}, Month: []facetCount{}}
+	count := func(query string, skip string) ([]facetCount, error) {
+		fc, fargs := f.cond(skip)
+		rows, err := a.db.QueryContext(ctx, strings.Replace(query, "{cond}", cond+fc, 1), append(append([]any{}, args...), fargs...)...)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+		out := []facetCount{}
+		for rows.Next() {
+			var c facetCount
+			if err := rows.Scan(&c.Value, &c.Count); err != nil {
+				return nil, err
+			}
+			out = append(out, c)
+		}
+		return out, rows.Err()
+	}
+	statuses, err := count(`SELECT status, COUNT(*) FROM tasks {cond} GROUP BY status`, facetStatus)
+	if err != nil {
+		return facets, err
+	}
+	for _, s := range taskStatuses {
+		for _, c := range statuses {
+			if c.Value == s {
+				facets.Status = append(facets.Status, c)
+			}
+		}
+	}
+	if facets.Tag, err = count(`SELECT tag, COUNT(DISTINCT task_id) AS n FROM task_tags WHERE task_id IN (SELECT id FROM tasks {cond}) GROUP BY tag ORDER BY n DESC, tag`, facetTag); err != nil {
+		return facets, err
+	}
+	if facets.Month, err = count(`SELECT `+archiveMonthExpr+` AS m, COUNT(*) FROM tasks {cond} GROUP BY m ORDER BY m DESC`, facetMonth); err != nil {
+		return facets, err
+	}
+	return facets, nil
+}
@@ -0,0 +1,382 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 审计日志：开启后每个写请求（POST/PUT/PATCH/DELETE，含 Telegram 命令与快捷操作）都以一行 CSV 或 JSONL
+// 追加到独立于数据库的文件中，即使数据库从备份恢复，审计记录也不会随之回退。
+// 每行带有 prev_hash 与 hash，hash 为本行字段与上一行 hash 的 SHA-256，构成跨文件连续的哈希链，
+// 任一行被修改、删除或插入都能由 audit-verify 命令发现。文件超过 max_size_mb 后改名为带时间戳的文件并设为只读，
+// 新文件的第一行接续上一文件的哈希。自动归档等后台任务不经过 HTTP 接口，不在审计范围内
+
+// 审计日志格式
+const (
+	auditCSV   = "csv"
+	auditJSONL = "jsonl"
+)
+
+// auditFields 为审计记录的列，CSV 文件以此为表头
+var auditFields = []string{"time", "request_id", "actor", "client_ip", "method", "path", "status", "prev_hash", "hash"}
+
+// AuditConfig 为审计日志配置；Path 为空表示关闭，MaxSizeMB 为 0 表示不轮转
+type AuditConfig struct {
+	Path      string `yaml:"path"`
+	Format    string `yaml:"format"`
+	MaxSizeMB int    `yaml:"max_size_mb"`
+}
+
+// validate 校验审计日志格式
+func (c AuditConfig) validate() error {
+	switch c.Format {
+	case "", auditCSV, auditJSONL:
+		return nil
+	}
+	return fmt.Errorf("audit.format 必须为 csv 或 jsonl: %q", c.Format)
+}
+
+// auditRecord 为一条审计记录
+type auditRecord struct {
+	Time      string `json:"time"`
+	RequestID string `json:"request_id"`
+	Actor     string `json:"actor"`
+	ClientIP  string `json:"client_ip"`
+	Method    string `json:"method"`
+	Path      string `json:"path"`
+	Status    int    `json:"status"`
+	PrevHash  string `json:"prev_hash"`
+	Hash      string `json:"hash"`
+}
+
+// values 按 auditFields 的顺序返回字段值
+func (rec auditRecord) values() []string {
+	return []string{rec.Time, rec.RequestID, rec.Actor, rec.ClientIP, rec.Method, rec.Path, strconv.Itoa(rec.Status), rec.PrevHash, rec.Hash}
+}
+
+// digest 计算记录的哈希：对除 hash 外的字段做 JSON 数组编码后取 SHA-256
+func (rec auditRecord) digest() string {
+	fields := rec.values()
+	raw, _ := json.Marshal(fields[:len(fields)-1])
+	sum := sha256.Sum256(raw)
+	return hex.EncodeToString(sum[:])
+}
+
+// auditSink 为追加写入的审计文件
+type auditSink struct {
+	path    string
+	format  string
+	maxSize int64
+
+	mu   sync.Mutex
+	f    *os.File
+	size int64
+	last string // 最后一行的 hash
+}
+
+// openAuditSink 打开（或创建）审计文件并读出最后一行的哈希；未配置路径时返回 nil
+func openAuditSink(c AuditConfig) (*auditSink, error) {
+	if c.Path == "" {
+		return nil, nil
+	}
+	s := &auditSink{path: c.Path, format: c.Format, maxSize: int64(c.MaxSizeMB) << 20}
+	if s.format == "" {
+		s.format = auditFormatOf(c.Path)
+	}
+	if err := os.MkdirAll(filepath.Dir(c.Path), 0o755); err != nil {
+		return nil, err
+	}
+	last, err := lastAuditHash(c.Path, s.format)
+	if err != nil {
+		return nil, fmt.Errorf("读取审计日志 %s: %w", c.Path, err)
+	}
+	if last == "" {
+		// 当前文件为空时接续最近一个轮转文件
+		if last, err = lastAuditHash(s.latestRotated(), s.format); err != nil {
+			return nil, err
+		}
+	}
+	s.last = last
+	return s, s.open()
+}
+
+// auditFormatOf 根据扩展名推断格式，.jsonl 与 .json 为 JSONL，其他为 CSV
+func auditFormatOf(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".jsonl", ".json":
+		return auditJSONL
+	}
+	return auditCSV
+}
+
+// open 以追加方式打开当前文件，新文件先写入 CSV 表头
+func (s *auditSink) open() error {
+	f, err := os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640)
+	if err != nil {
+		return err
+	}
+	st, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.f, s.size = f, st.Size()
+	if s.size == 0 && s.format == auditCSV {
+		return s.writeLine(strings.Join(auditFields, ",") + "\n")
+	}
+	return nil
+}
+
+// writeLine 写入一行并累计文件大小
+func (s *auditSink) writeLine(line string) error {
+	n, err := io.WriteString(s.f, line)
+	s.size += int64(n)
+	return err
+}
+
+// rotatedName 返回轮转后的文件名，如 audit.csv → audit-20261015T080000.csv
+func (s *auditSink) rotatedName(now time.Time) string {
+	ext := filepath.Ext(s.path)
+	return strings.TrimSuffix(s.path, ext) + "-" + now.UTC().Format("20060102T150405") + ext
+}
+
+// latestRotated 返回最近一个轮转文件，没有时返回空串
+func (s *auditSink) latestRotated() string {
+	ext := filepath.Ext(s.path)
+	matches, _ := filepath.Glob(strings.TrimSuffix(s.path, ext) + "-*" + ext)
+	if len(matches) == 0 {
+		return ""
+	}
+	// 时间戳定长，按文件名排序即按时间排序
+	latest := matches[0]
+	for _, m := range matches[1:] {
+		if m > latest {
+			latest = m
+		}
+	}
+	return latest
+}
+
+// rotate 关闭当前文件，改名并设为只读，再打开新文件
+func (s *auditSink) rotate(now time.Time) error {
+	if err := s.f.Close(); err != nil {
+		return err
+	}
+	name := s.rotatedName(now)
+	if err := os.Rename(s.path, name); err != nil {
+		return err
+	}
+	if err := os.Chmod(name, 0o440); err != nil {
+		return err
+	}
+	return s.open()
+}
+
+// append 追加一条记录，填充哈希链字段
+func (s *auditSink) append(rec auditRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.maxSize > 0 && s.size >= s.maxSize {
+		if err := s.rotate(time.Now()); err != nil {
+			return fmt.Errorf("审计日志轮转失败: %w", err)
+		}
+	}
+	rec.PrevHash = s.last
+	rec.Hash = rec.digest()
+	var line string
+	if s.format == auditJSONL {
+		raw, err := json.Marshal(rec)
+		if err != nil {
+			return err
+		}
+		line = string(raw) + "\n"
+	} else {
+		var b strings.Builder
+		w := csv.NewWriter(&b)
+		_ = w.Write(rec.values())
+		w.Flush()
+		line = b.String()
+	}
+	if err := s.writeLine(line); err != nil {
+		return err
+	}
+	s.last = rec.Hash
+	return nil
+}
+
+// Close 关闭审计文件
+func (s *auditSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
+
+// readAuditFile 逐条读取审计文件；文件不存在时不返回记录
+func readAuditFile(path, format string, fn func(line int, rec auditRecord) error) error {
+	if path == "" {
+		return nil
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if format == auditJSONL {
+		sc := bufio.NewScanner(f)
+		sc.Buffer(make([]byte, 64<<10), 1<<20)
+		for n := 1; sc.Scan(); n++ {
+			var rec auditRecord
+			if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+				return fmt.Errorf("第 %d 行: %w", n, err)
+			}
+			if err := fn(n, rec); err != nil {
+				return err
+			}
+		}
+		return sc.Err()
+	}
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = len(auditFields)
+	for n := 1; ; n++ {
+		row, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if n == 1 && row[0] == auditFields[0] {
+			continue
+		}
+		status, _ := strconv.Atoi(row[6])
+		rec := auditRecord{Time: row[0], RequestID: row[1], Actor: row[2], ClientIP: row[3], Method: row[4], Path: row[5], Status: status, PrevHash: row[7], Hash: row[8]}
+		if err := fn(n, rec); err != nil {
+			return err
+		}
+	}
+}
+
+// lastAuditHash 返回审计文件最后一行的哈希，文件不存在或为空时返回空串
+func lastAuditHash(path, format string) (string, error) {
+	var last string
+	err := readAuditFile(path, format, func(_ int, rec auditRecord) error {
+		last = rec.Hash
+		return nil
+	})
+	return last, err
+}
+
+// auditActorKey 为请求上下文中标识进程内调用方（如 telegram）的键
+type auditActorKey struct{}
+
+// withAuditActor 为进程内调用设置审计日志中的调用方
+func withAuditActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, auditActorKey{}, actor)
+}
+
+// auditActor 返回发起请求的身份：API 密钥、签名客户端、登录用户或进程内调用方，匿名请求返回空串
+func auditActor(r *http.Request) string {
+	if k, ok := r.Context().Value(apiKeyCtxKey{}).(APIKey); ok {
+		return "key:" + k.Prefix
+	}
+	if client := signedClient(r); client != "" {
+		return "client:" + client
+	}
+	if id := currentUserID(r); id != 0 {
+		return "user:" + strconv.FormatInt(id, 10)
+	}
+	actor, _ := r.Context().Value(auditActorKey{}).(string)
+	return actor
+}
+
+// withAudit 将写请求记录到审计日志；p 为 nil 时（进程内调用）不记录来源地址。写入失败只记录错误日志
+func (a *App) withAudit(p *accessPolicy, next http.Handler) http.Handler {
+	if a.audit == nil {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		entry := auditRecord{
+			Time:      time.Now().UTC().Format(time.RFC3339Nano),
+			RequestID: w.Header().Get("X-Request-ID"),
+			Actor:     auditActor(r),
+			Method:    r.Method,
+			Path:      r.URL.Path,
+			Status:    rec.status,
+		}
+		if p != nil {
+			if ip, ok := p.clientIP(r); ok {
+				entry.ClientIP = ip.String()
+			}
+		}
+		if err := a.audit.append(entry); err != nil {
+			a.reqLogger(r).Error("写入审计日志失败", "err", err)
+		}
+	})
+}
+
+// runAuditVerify 按顺序校验一个或多个审计文件的哈希链，文件之间须首尾相接
+func runAuditVerify(cfg Config, args []string) error {
+	flags := flag.NewFlagSet("audit-verify", flag.ContinueOnError)
+	format := flags.String("format", cfg.Audit.Format, "文件格式 csv 或 jsonl，缺省按扩展名判断")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+	files := flags.Args()
+	if len(files) == 0 && cfg.Audit.Path != "" {
+		files = []string{cfg.Audit.Path}
+	}
+	if len(files) == 0 {
+		return errors.New("请指定要校验的审计文件（按时间顺序，轮转文件在前）")
+	}
+	var prev string
+	total := 0
+	for i, path := range files {
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+		fileFormat := *format
+		if fileFormat == "" {
+			fileFormat = auditFormatOf(path)
+		}
+		err := readAuditFile(path, fileFormat, func(line int, rec auditRecord) error {
+			if (total > 0 || i > 0) && rec.PrevHash != prev {
+				return fmt.Errorf("%s 第 %d 行: prev_hash 与上一行不符，记录可能被删除或插入", path, line)
+			}
+			if rec.digest() != rec.Hash {
+				return fmt.Errorf("%s 第 %d 行: 哈希不符，记录可能被修改", path, line)
+			}
+			prev = rec.Hash
+			total++
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	fmt.Printf("审计日志校验通过：%d 个文件，%d 条记录\n", len(files), total)
+	return nil
+}
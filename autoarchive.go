@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"time"
+)
+
+// AutoArchiveConfig 为已完成任务的定时自动归档配置；AfterDays 为 0 表示关闭
+type AutoArchiveConfig struct {
+	AfterDays int      `yaml:"after_days"` // 在「已完成」列停留超过该天数的任务将被归档
+	Interval  Duration `yaml:"interval"`   // 扫描间隔
+	DryRun    bool     `yaml:"dry_run"`    // 仅记录将被归档的任务，不实际修改
+}
+
+// autoArchiveCandidate 为一个待自动归档的任务
+type autoArchiveCandidate struct {
+	ID    int64
+	Title string
+	Since time.Time
+}
+
+// startAutoArchive 按配置启动自动归档后台任务，启动时先执行一次
+func (a *App) startAutoArchive() {
+	c := a.cfg.AutoArchive
+	if c.AfterDays <= 0 {
+		return
+	}
+	interval := c.Interval.Std()
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	a.logger.Info("自动归档已启用", "after_days", c.AfterDays, "interval", interval.String(), "dry_run", c.DryRun)
+	a.startBackground("auto-archive", func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if a.waitForHeadroom(ctx, "auto-archive") != nil {
+				return
+			}
+			if _, err := a.autoArchive(ctx, time.Now(), c.AfterDays, c.DryRun); err != nil && ctx.Err() == nil {
+				a.logger.Error("自动归档失败", "err", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// autoArchive 归档在「已完成」列停留超过 afterDays 天的在板任务，并逐条记录日志；dryRun 时只记录不修改
+func (a *App) autoArchive(ctx context.Context, now time.Time, afterDays int, dryRun bool) ([]autoArchiveCandidate, error) {
+	candidates, err := a.autoArchiveCandidates(ctx, now.AddDate(0, 0, -afterDays))
+	if err != nil || len(candidates) == 0 {
+		return candidates, err
+	}
+	if dryRun {
+		for _, c := range candidates {
+			a.logger.Info("自动归档（演练）", "task_id", c.ID, "title", c.Title, "done_since", c.Since.Format(time.RFC3339))
+		}
+		return candidates, nil
+	}
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	stamp := now.Format(time.RFC3339)
+	moved := candidates[:0]
+	for _, c := range candidates {
+		// 期间任务可能已被移出「已完成」或手动归档，条件更新避免覆盖
+		res, err := tx.ExecContext(ctx, `
+			UPDATE tasks SET archived = 1, updated_at = ?, version = version + 1
+			WHERE id = ? AND archived = 0 AND status = '已完成'
+		`, stamp, c.ID)
+		if err != nil {
+			return nil, err
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			moved = append(moved, c)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	for _, c := range moved {
+		a.logger.Info("自动归档", "task_id", c.ID, "title", c.Title, "done_since", c.Since.Format(time.RFC3339))
+	}
+	a.logger.Info("自动归档完成", "count", len(moved))
+	a.tagCache.invalidate()
+	return moved, nil
+}
+
+// autoArchiveCandidates 查询在 cutoff 之前进入「已完成」且仍在板上的任务
+func (a *App) autoArchiveCandidates(ctx context.Context, cutoff time.Time) ([]autoArchiveCandidate, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT id, title, status_changed_at FROM tasks
+		WHERE archived = 0 AND status = '已完成'
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []autoArchiveCandidate
+	for rows.Next() {
+		var c autoArchiveCandidate
+		var since string
+		if err := rows.Scan(&c.ID, &c.Title, &since); err != nil {
+			return nil, err
+		}
+		// 时间以 RFC3339 字符串存储且可能带不同时区，解析后再比较
+		t, err := time.Parse(time.RFC3339, since)
+		if err != nil || !t.Before(cutoff) {
+			continue
+		}
+		c.Since = t
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,269 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 看板分享链接让没有账号的干系人查看整个看板：持有链接即可看到各列未归档任务的编号、标题与标签
+// （public.descriptions 开启时附带描述），无法修改任何内容，也看不到归档、评论与历史。
+// 一个看板可以有多个链接（如分别发给不同的人），各自可设置过期时间并单独撤销；
+// 链接在服务端按当前数据渲染，看板变化后刷新即可看到最新状态
+
+// BoardShareLink 为看板的一个分享链接；Token 与 URL 只在创建时返回
+type BoardShareLink struct {
+	ID        int64      `json:"id"`
+	BoardID   int64      `json:"board_id"`
+	Label     string     `json:"label"`
+	Token     string     `json:"token,omitempty"`
+	URL       string     `json:"url,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// boardShareRequest 为创建看板分享链接的请求体；expires_at 与 expires_in 至多指定一个，均为空表示长期有效
+type boardShareRequest struct {
+	Label     string     `json:"label"`
+	ExpiresAt *time.Time `json:"expires_at"`
+	ExpiresIn string     `json:"expires_in"`
+}
+
+// boardShareListResponse 为看板分享链接列表的响应结构
+type boardShareListResponse struct {
+	Items []BoardShareLink `json:"items"`
+}
+
+// sharedBoardColumn 为分享页中的一列
+type sharedBoardColumn struct {
+	Status string       `json:"status"`
+	Tasks  []publicTask `json:"tasks"`
+}
+
+// sharedBoardView 为分享页展示的内容，format=json 时原样返回
+type sharedBoardView struct {
+	Columns     []sharedBoardColumn `json:"columns"`
+	GeneratedAt time.Time           `json:"generated_at"`
+	ExpiresAt   *time.Time          `json:"expires_at,omitempty"`
+}
+
+// boardShareURL 根据当前请求拼出看板分享页的完整地址
+func boardShareURL(r *http.Request, token string) string {
+	return requestOrigin(r) + "/share/board/" + token
+}
+
+// handleBoards 处理 /api/boards/{id}/share[/{linkId}]：GET 列出链接，POST 生成链接，DELETE 撤销链接
+func (a *App) handleBoards(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/boards/"), "/"), "/")
+	id, err := parseInt64(parts[0])
+	if err != nil || id != boardID {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "board not found"})
+		return
+	}
+	if len(parts) < 2 || len(parts) > 3 || parts[1] != "share" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if len(parts) == 3 {
+		linkID, err := parseInt64(parts[2])
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid link id"})
+			return
+		}
+		a.handleBoardShareItem(w, r, linkID)
+		return
+	}
+	a.handleBoardShare(w, r)
+}
+
+// handleBoardShare 处理 /api/boards/{id}/share：GET 列出未过期的链接（不含令牌），POST 生成新链接
+func (a *App) handleBoardShare(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodGet:
+		rows, err := a.db.QueryContext(ctx, `SELECT id, label, expires_at, created_at FROM board_share_links
+			WHERE board_id = ? AND (expires_at IS NULL OR expires_at > ?) ORDER BY id`, boardID, time.Now().Format(time.RFC3339))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+		items := []BoardShareLink{}
+		for rows.Next() {
+			l := BoardShareLink{BoardID: boardID}
+			var expires sql.NullString
+			var created string
+			if err := rows.Scan(&l.ID, &l.Label, &expires, &created); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			l.ExpiresAt = parseOptionalTime(expires)
+			l.CreatedAt, _ = time.Parse(time.RFC3339, created)
+			items = append(items, l)
+		}
+		if err := rows.Err(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, boardShareListResponse{Items: items})
+	case http.MethodPost:
+		var body boardShareRequest
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+				return
+			}
+		}
+		body.Label = strings.TrimSpace(body.Label)
+		if len([]rune(body.Label)) > 100 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "label too long"})
+			return
+		}
+		now := time.Now()
+		expires, err := parseAPIKeyExpiry(body.ExpiresAt, body.ExpiresIn, now)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		var expiresArg any
+		if expires != nil {
+			expiresArg = expires.Format(time.RFC3339)
+		}
+		link := BoardShareLink{BoardID: boardID, Label: body.Label, Token: newShareToken(), ExpiresAt: expires, CreatedAt: now.Truncate(time.Second)}
+		res, err := a.db.ExecContext(ctx, `INSERT INTO board_share_links (board_id, token, label, expires_at, created_at) VALUES (?, ?, ?, ?, ?)`,
+			boardID, link.Token, link.Label, expiresArg, now.Format(time.RFC3339))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		link.ID, _ = res.LastInsertId()
+		link.URL = boardShareURL(r, link.Token)
+		a.reqLogger(r).Info("已生成看板分享链接", "link_id", link.ID, "label", link.Label)
+		writeJSON(w, http.StatusCreated, link)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// handleBoardShareItem 处理 DELETE /api/boards/{id}/share/{linkId}：撤销链接，立即失效
+func (a *App) handleBoardShareItem(w http.ResponseWriter, r *http.Request, linkID int64) {
+	if r.Method != http.MethodDelete {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	res, err := a.db.ExecContext(r.Context(), `DELETE FROM board_share_links WHERE id = ? AND board_id = ?`, linkID, boardID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "share link not found"})
+		return
+	}
+	a.reqLogger(r).Info("已撤销看板分享链接", "link_id", linkID)
+	writeJSON(w, http.StatusOK, map[string]any{"id": linkID, "deleted": true})
+}
+
+// handleBoardSharePage 处理 GET /share/board/{token}，渲染只读的看板页，format=json 时返回 JSON；
+// 令牌无效或已过期时返回 404，不区分是否曾经存在
+func (a *App) handleBoardSharePage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := strings.TrimPrefix(r.URL.Path, "/share/board/")
+	// 链接本身即凭据：禁止缓存、收录与通过 Referer 外泄
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	now := time.Now()
+	var linkID int64
+	var expires sql.NullString
+	err := a.db.QueryRowContext(ctx, `SELECT id, expires_at FROM board_share_links WHERE token = ? AND (expires_at IS NULL OR expires_at > ?)`,
+		token, now.Format(time.RFC3339)).Scan(&linkID, &expires)
+	if err == sql.ErrNoRows {
+		http.Error(w, "链接无效或已失效", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		a.reqLogger(r).Error("读取看板分享链接失败", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	addLogAttrs(r, "share_link_id", linkID)
+	tasks, err := a.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks WHERE archived = 0 ORDER BY id`)
+	if err != nil {
+		a.reqLogger(r).Error("读取分享看板失败", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	v := sharedBoardView{GeneratedAt: now.Truncate(time.Second), ExpiresAt: parseOptionalTime(expires)}
+	index := map[string]int{}
+	for i, s := range taskStatuses {
+		index[s] = i
+		v.Columns = append(v.Columns, sharedBoardColumn{Status: s, Tasks: []publicTask{}})
+	}
+	for _, t := range tasks {
+		i, ok := index[t.Status]
+		if !ok {
+			continue
+		}
+		pt := publicTask{ID: t.ID, Title: t.Title, Status: t.Status, Tags: t.Tags, UpdatedAt: t.UpdatedAt}
+		if a.publicDescriptions {
+			pt.Description = t.Description
+		}
+		if pt.Tags == nil {
+			pt.Tags = []string{}
+		}
+		v.Columns[i].Tasks = append(v.Columns[i].Tasks, pt)
+	}
+	if r.URL.Query().Get("format") == "json" {
+		writeJSON(w, http.StatusOK, v)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := boardSharePageTmpl.Execute(w, v); err != nil {
+		a.reqLogger(r).Warn("渲染看板分享页失败", "err", err)
+	}
+}
+
+var boardSharePageTmpl = template.Must(template.New("board-share").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>看板</title>
+<style>
+body{font-family:system-ui,-apple-system,"PingFang SC","Microsoft YaHei",sans-serif;margin:0;background:#f5f6f8;color:#222}
+header{padding:16px 24px;color:#888;font-size:13px}
+main{display:flex;gap:16px;padding:0 24px 24px;overflow-x:auto;align-items:flex-start}
+section{flex:1 0 220px;background:#eceef2;border-radius:8px;padding:12px}
+h2{font-size:15px;margin:0 0 12px}
+h2 span{color:#888;font-weight:normal}
+.card{background:#fff;border-radius:6px;padding:10px;margin-bottom:8px;box-shadow:0 1px 2px rgba(0,0,0,.06)}
+.title{font-size:14px}
+.id{color:#888;font-size:12px;margin-right:4px}
+.desc{color:#555;font-size:13px;margin-top:6px;white-space:pre-wrap}
+.tag{display:inline-block;background:#e0e7ff;color:#3730a3;border-radius:4px;padding:0 6px;font-size:12px;margin:6px 4px 0 0}
+</style>
+</head>
+<body>
+<header>只读视图 · 生成于 {{.GeneratedAt.Local.Format "2006-01-02 15:04"}}{{if .ExpiresAt}} · 链接有效期至 {{.ExpiresAt.Local.Format "2006-01-02 15:04"}}{{end}}</header>
+<main>
+{{range .Columns}}<section>
+<h2>{{.Status}} <span>{{len .Tasks}}</span></h2>
+{{range .Tasks}}<div class="card">
+<div class="title"><span class="id">TB-{{.ID}}</span>{{.Title}}</div>
+{{if .Description}}<div class="desc">{{.Description}}</div>{{end}}
+{{range .Tags}}<span class="tag">{{.}}</span>{{end}}
+</div>
+{{end}}</section>
+{{end}}</main>
+</body>
+</html>
+`))
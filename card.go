@@ -0,0 +1,172 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"html"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/width"
+)
+
+// 可打印的任务卡片：100×60 mm 的 SVG，包含任务编号、标题、标签与指回看板中该任务的二维码，
+// 供同时维护实体看板的团队打印后贴到墙上。卡片内容为生成时的快照，标题与标签变化后需重新打印
+
+// 卡片版面，单位为 viewBox 坐标（1 单位 = 0.25 mm）
+const (
+	cardWidth      = 400
+	cardHeight     = 240
+	cardMargin     = 20
+	cardQRSize     = 140
+	cardTitleSize  = 20
+	cardTitleLines = 4
+	cardTagSize    = 14
+	cardFontFamily = `'PingFang SC', 'Microsoft YaHei', 'Noto Sans CJK SC', sans-serif`
+)
+
+// handleTaskCard 处理 GET /api/tasks/{id}/card.svg
+func (a *App) handleTaskCard(w http.ResponseWriter, r *http.Request, taskID int64) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	t, err := a.fetchTaskDetail(r.Context(), taskID)
+	if err == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	svg, err := renderTaskCard(t, taskLinkURL(r, t.ID))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`inline; filename="TB-%d.svg"`, t.ID))
+	w.Header().Set("Cache-Control", "no-cache")
+	_, _ = w.Write([]byte(svg))
+}
+
+// taskLinkURL 返回在看板中打开任务的地址
+func taskLinkURL(r *http.Request, taskID int64) string {
+	return requestOrigin(r) + "/?task=" + strconv.FormatInt(taskID, 10)
+}
+
+// renderTaskCard 生成任务卡片的 SVG；二维码位于右上角，标题在左侧自动换行，标签排在底部
+func renderTaskCard(t Task, link string) (string, error) {
+	qr, err := qrEncode([]byte(link))
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="100mm" height="60mm" viewBox="0 0 %d %d" font-family="%s">`+"\n",
+		cardWidth, cardHeight, html.EscapeString(cardFontFamily))
+	fmt.Fprintf(&b, `<rect x="2" y="2" width="%d" height="%d" rx="12" fill="#fff" stroke="#333" stroke-width="2"/>`+"\n", cardWidth-4, cardHeight-4)
+	fmt.Fprintf(&b, `<text x="%d" y="46" font-size="28" font-weight="bold" font-family="monospace">TB-%d</text>`+"\n", cardMargin, t.ID)
+	titleWidth := float64(cardWidth - cardQRSize - cardMargin*3)
+	for i, line := range wrapCardText(t.Title, titleWidth/cardTitleSize, cardTitleLines) {
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="%d">%s</text>`+"\n", cardMargin, 88+i*26, cardTitleSize, html.EscapeString(line))
+	}
+	if len(t.Tags) > 0 {
+		tags := make([]string, len(t.Tags))
+		for i, tag := range t.Tags {
+			tags[i] = "#" + tag
+		}
+		line := wrapCardText(strings.Join(tags, " "), float64(cardWidth-cardMargin*2)/cardTagSize, 1)
+		fmt.Fprintf(&b, `<text x="%d" y="%d" font-size="%d" fill="#555">%s</text>`+"\n", cardMargin, cardHeight-cardMargin, cardTagSize, html.EscapeString(line[0]))
+	}
+	// 二维码四周保留 4 个模块宽的空白区
+	unit := float64(cardQRSize) / float64(qr.size+8)
+	x0, y0 := float64(cardWidth-cardMargin-cardQRSize)+4*unit, float64(cardMargin)+4*unit
+	fmt.Fprintf(&b, `<path fill="#000" shape-rendering="crispEdges" transform="translate(%.3f %.3f) scale(%.4f)" d="%s"/>`+"\n</svg>\n", x0, y0, unit, qr.svgPath())
+	return b.String(), nil
+}
+
+// cardRuneWidth 返回字符的大致宽度，以字号为单位：全角字符为 1，其余按 0.6 估算
+func cardRuneWidth(r rune) float64 {
+	switch width.LookupRune(r).Kind() {
+	case width.EastAsianWide, width.EastAsianFullwidth:
+		return 1
+	}
+	return 0.6
+}
+
+// cardTextWidth 返回文本的大致宽度，以字号为单位
+func cardTextWidth(rs []rune) float64 {
+	w := 0.0
+	for _, r := range rs {
+		w += cardRuneWidth(r)
+	}
+	return w
+}
+
+// wrapCardText 将文本按宽度 limit（以字号为单位）折成至多 maxLines 行，超出部分以 … 截断；
+// 西文单词尽量整体换行，汉字可在任意位置换行
+func wrapCardText(s string, limit float64, maxLines int) []string {
+	var lines []string
+	var line []rune
+	used := 0.0
+	for _, word := range splitCardWords(strings.Join(strings.Fields(s), " ")) {
+		w := cardTextWidth([]rune(word))
+		if used+w > limit && len(line) > 0 && w <= limit {
+			lines = append(lines, strings.TrimRight(string(line), " "))
+			line, used = nil, 0
+			if word == " " {
+				continue
+			}
+		}
+		for _, r := range word {
+			if used+cardRuneWidth(r) > limit {
+				lines = append(lines, string(line))
+				line, used = nil, 0
+			}
+			line = append(line, r)
+			used += cardRuneWidth(r)
+		}
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+	if len(lines) <= maxLines {
+		if len(lines) == 0 {
+			return []string{""}
+		}
+		return lines
+	}
+	// 截断最后一行并留出省略号的位置
+	last := []rune(lines[maxLines-1])
+	for len(last) > 0 && cardTextWidth(last)+cardRuneWidth('…') > limit {
+		last = last[:len(last)-1]
+	}
+	lines = lines[:maxLines]
+	lines[maxLines-1] = strings.TrimRight(string(last), " ") + "…"
+	return lines
+}
+
+// splitCardWords 将文本拆成换行单元：连续的西文字母数字为一个单元，空格与其他字符各自成为一个单元
+func splitCardWords(s string) []string {
+	var out []string
+	var cur []rune
+	flush := func() {
+		if len(cur) > 0 {
+			out = append(out, string(cur))
+			cur = nil
+		}
+	}
+	for _, r := range s {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r) || strings.ContainsRune("-_./:#@", r)) {
+			cur = append(cur, r)
+			continue
+		}
+		flush()
+		out = append(out, string(r))
+	}
+	flush()
+	return out
+}
@@ -0,0 +1,878 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// 本文件实现校验规则使用的表达式语言，语法与语义取 CEL 的一个子集：
+//
+//	字面量    true false null 123 "str" 'str' [a, b]
+//	运算符    ?: || && ! == != < <= > >= in + - * / %
+//	成员      task.tags  task.external_links[0].url
+//	函数      size(x) timestamp("2024-01-01T00:00:00Z") duration("72h") string(x) int(x)
+//	方法      s.contains(t) s.startsWith(t) s.endsWith(t) s.matches(re) s.lowerAscii() x.size()
+//	宏        list.exists(x, 条件) list.all(x, 条件) list.exists_one(x, 条件)
+//
+// 值的类型为 nil、bool、int64、string、time.Time、time.Duration、[]any 与 map[string]any。
+// 与 CEL 一致，|| 与 && 在一侧已能确定结果时忽略另一侧的错误；不同类型之间的 == 结果为 false。
+
+// maxExprLen 与 maxExprDepth 限制表达式长度与嵌套深度
+const (
+	maxExprLen   = 2000
+	maxExprDepth = 50
+)
+
+// exprNode 为表达式语法树节点
+type exprNode interface {
+	eval(env exprEnv) (any, error)
+}
+
+// exprEnv 为表达式求值时可见的变量
+type exprEnv map[string]any
+
+type (
+	exprLit   struct{ v any }
+	exprIdent struct{ name string }
+	exprList  struct{ items []exprNode }
+	exprUnary struct {
+		op string
+		x  exprNode
+	}
+	exprBinary struct {
+		op   string
+		l, r exprNode
+	}
+	exprCond   struct{ cond, then, els exprNode }
+	exprSelect struct {
+		x     exprNode
+		field string
+	}
+	exprIndex struct{ x, index exprNode }
+	// exprCall 为函数或方法调用，recv 为 nil 表示全局函数
+	exprCall struct {
+		recv exprNode
+		name string
+		args []exprNode
+	}
+	// exprMacro 为 exists、all、exists_one 宏，对列表元素（或 map 的键）逐个求值 body
+	exprMacro struct {
+		recv exprNode
+		name string
+		v    string
+		body exprNode
+	}
+)
+
+// compileExpr 解析表达式
+func compileExpr(src string) (exprNode, error) {
+	if len(src) > maxExprLen {
+		return nil, fmt.Errorf("expression too long (max %d)", maxExprLen)
+	}
+	p := &exprParser{src: src}
+	n, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpace()
+	if p.pos < len(p.src) {
+		return nil, p.errorf("unexpected %q", p.src[p.pos:p.pos+1])
+	}
+	return n, nil
+}
+
+// exprParser 为表达式的递归下降解析器
+type exprParser struct {
+	src   string
+	pos   int
+	depth int
+}
+
+// errorf 返回带位置信息的解析错误
+func (p *exprParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("syntax error at %d: %s", p.pos+1, fmt.Sprintf(format, args...))
+}
+
+// skipSpace 跳过空白
+func (p *exprParser) skipSpace() {
+	for p.pos < len(p.src) && strings.IndexByte(" \t\r\n", p.src[p.pos]) >= 0 {
+		p.pos++
+	}
+}
+
+// accept 跳过空白后若下一个记号为 tok 则消费它；字母记号要求完整的单词
+func (p *exprParser) accept(tok string) bool {
+	p.skipSpace()
+	if !strings.HasPrefix(p.src[p.pos:], tok) {
+		return false
+	}
+	end := p.pos + len(tok)
+	if isExprIdentByte(tok[0]) && end < len(p.src) && isExprIdentByte(p.src[end]) {
+		return false
+	}
+	// 避免将 <= 中的 < 或 == 中的 = 当作单独的运算符
+	if (tok == "<" || tok == ">" || tok == "!") && end < len(p.src) && p.src[end] == '=' {
+		return false
+	}
+	p.pos = end
+	return true
+}
+
+// expect 要求下一个记号为 tok
+func (p *exprParser) expect(tok string) error {
+	if !p.accept(tok) {
+		return p.errorf("expected %q", tok)
+	}
+	return nil
+}
+
+// isExprIdentByte 判断字符能否出现在标识符中
+func isExprIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
+
+// enter 记录嵌套深度，过深时返回错误
+func (p *exprParser) enter() error {
+	if p.depth++; p.depth > maxExprDepth {
+		return p.errorf("expression nested too deeply")
+	}
+	return nil
+}
+
+// ternary 解析 cond ? a : b
+func (p *exprParser) ternary() (exprNode, error) {
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer func() { p.depth-- }()
+	cond, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if !p.accept("?") {
+		return cond, nil
+	}
+	then, err := p.or()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.expect(":"); err != nil {
+		return nil, err
+	}
+	els, err := p.ternary()
+	if err != nil {
+		return nil, err
+	}
+	return &exprCond{cond, then, els}, nil
+}
+
+// binaryLevel 解析一层左结合的二元运算
+func (p *exprParser) binaryLevel(next func() (exprNode, error), ops ...string) (exprNode, error) {
+	l, err := next()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, o := range ops {
+			if p.accept(o) {
+				op = o
+				break
+			}
+		}
+		if op == "" {
+			return l, nil
+		}
+		r, err := next()
+		if err != nil {
+			return nil, err
+		}
+		l = &exprBinary{op, l, r}
+	}
+}
+
+func (p *exprParser) or() (exprNode, error)  { return p.binaryLevel(p.and, "||") }
+func (p *exprParser) and() (exprNode, error) { return p.binaryLevel(p.rel, "&&") }
+func (p *exprParser) rel() (exprNode, error) {
+	return p.binaryLevel(p.add, "==", "!=", "<=", ">=", "<", ">", "in")
+}
+func (p *exprParser) add() (exprNode, error) { return p.binaryLevel(p.mul, "+", "-") }
+func (p *exprParser) mul() (exprNode, error) { return p.binaryLevel(p.unary, "*", "/", "%") }
+
+// unary 解析 !x 与 -x
+func (p *exprParser) unary() (exprNode, error) {
+	for _, op := range []string{"!", "-"} {
+		if p.accept(op) {
+			if err := p.enter(); err != nil {
+				return nil, err
+			}
+			x, err := p.unary()
+			p.depth--
+			if err != nil {
+				return nil, err
+			}
+			return &exprUnary{op, x}, nil
+		}
+	}
+	return p.member()
+}
+
+// member 解析成员访问、下标与方法调用
+func (p *exprParser) member() (exprNode, error) {
+	x, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("."):
+			p.skipSpace()
+			name := p.ident()
+			if name == "" {
+				return nil, p.errorf("expected field name")
+			}
+			if !p.accept("(") {
+				x = &exprSelect{x, name}
+				continue
+			}
+			if name == "exists" || name == "all" || name == "exists_one" {
+				p.skipSpace()
+				v := p.ident()
+				if v == "" {
+					return nil, p.errorf("%s: expected variable name", name)
+				}
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+				body, err := p.ternary()
+				if err != nil {
+					return nil, err
+				}
+				if err := p.expect(")"); err != nil {
+					return nil, err
+				}
+				x = &exprMacro{x, name, v, body}
+				continue
+			}
+			args, err := p.args(")")
+			if err != nil {
+				return nil, err
+			}
+			x = &exprCall{x, name, args}
+		case p.accept("["):
+			idx, err := p.ternary()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			x = &exprIndex{x, idx}
+		default:
+			return x, nil
+		}
+	}
+}
+
+// args 解析逗号分隔的表达式列表，直到 end
+func (p *exprParser) args(end string) ([]exprNode, error) {
+	var out []exprNode
+	if p.accept(end) {
+		return out, nil
+	}
+	for {
+		n, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+		if p.accept(end) {
+			return out, nil
+		}
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ident 读取标识符
+func (p *exprParser) ident() string {
+	start := p.pos
+	if p.pos < len(p.src) && (p.src[p.pos] < '0' || p.src[p.pos] > '9') {
+		for p.pos < len(p.src) && isExprIdentByte(p.src[p.pos]) {
+			p.pos++
+		}
+	}
+	return p.src[start:p.pos]
+}
+
+// primary 解析字面量、变量、全局函数调用、括号与列表
+func (p *exprParser) primary() (exprNode, error) {
+	p.skipSpace()
+	if p.pos >= len(p.src) {
+		return nil, p.errorf("unexpected end of expression")
+	}
+	c := p.src[p.pos]
+	switch {
+	case c == '(':
+		p.pos++
+		x, err := p.ternary()
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+	case c == '[':
+		p.pos++
+		items, err := p.args("]")
+		if err != nil {
+			return nil, err
+		}
+		return &exprList{items}, nil
+	case c == '"' || c == '\'':
+		s, err := p.stringLit(c)
+		if err != nil {
+			return nil, err
+		}
+		return &exprLit{s}, nil
+	case c >= '0' && c <= '9':
+		start := p.pos
+		for p.pos < len(p.src) && p.src[p.pos] >= '0' && p.src[p.pos] <= '9' {
+			p.pos++
+		}
+		n, err := strconv.ParseInt(p.src[start:p.pos], 10, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", p.src[start:p.pos])
+		}
+		return &exprLit{n}, nil
+	}
+	name := p.ident()
+	switch name {
+	case "":
+		return nil, p.errorf("unexpected %q", string(c))
+	case "true", "false":
+		return &exprLit{name == "true"}, nil
+	case "null":
+		return &exprLit{nil}, nil
+	}
+	if p.accept("(") {
+		args, err := p.args(")")
+		if err != nil {
+			return nil, err
+		}
+		return &exprCall{nil, name, args}, nil
+	}
+	return &exprIdent{name}, nil
+}
+
+// stringLit 解析以 quote 包围的字符串，支持 \\ \" \' \n \t 转义
+func (p *exprParser) stringLit(quote byte) (string, error) {
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return b.String(), nil
+		case c == '\\' && p.pos < len(p.src):
+			e := p.src[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				b.WriteByte('\n')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"', '\'':
+				b.WriteByte(e)
+			default:
+				return "", p.errorf("invalid escape \\%c", e)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
+
+// exprTypeName 返回值的类型名，用于错误信息
+func exprTypeName(v any) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "bool"
+	case int64:
+		return "int"
+	case string:
+		return "string"
+	case time.Time:
+		return "timestamp"
+	case time.Duration:
+		return "duration"
+	case []any:
+		return "list"
+	case map[string]any:
+		return "map"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+func (n *exprLit) eval(exprEnv) (any, error) { return n.v, nil }
+
+func (n *exprIdent) eval(env exprEnv) (any, error) {
+	v, ok := env[n.name]
+	if !ok {
+		return nil, fmt.Errorf("undeclared reference to %q", n.name)
+	}
+	return v, nil
+}
+
+func (n *exprList) eval(env exprEnv) (any, error) {
+	out := make([]any, len(n.items))
+	for i, item := range n.items {
+		v, err := item.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = v
+	}
+	return out, nil
+}
+
+func (n *exprUnary) eval(env exprEnv) (any, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch x := v.(type) {
+	case bool:
+		if n.op == "!" {
+			return !x, nil
+		}
+	case int64:
+		if n.op == "-" {
+			return -x, nil
+		}
+	case time.Duration:
+		if n.op == "-" {
+			return -x, nil
+		}
+	}
+	return nil, fmt.Errorf("no such overload: %s%s", n.op, exprTypeName(v))
+}
+
+func (n *exprCond) eval(env exprEnv) (any, error) {
+	c, err := n.cond.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	b, ok := c.(bool)
+	if !ok {
+		return nil, fmt.Errorf("condition must be bool, got %s", exprTypeName(c))
+	}
+	if b {
+		return n.then.eval(env)
+	}
+	return n.els.eval(env)
+}
+
+func (n *exprBinary) eval(env exprEnv) (any, error) {
+	if n.op == "||" || n.op == "&&" {
+		return n.logical(env)
+	}
+	l, err := n.l.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	r, err := n.r.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch n.op {
+	case "==":
+		return exprEqual(l, r), nil
+	case "!=":
+		return !exprEqual(l, r), nil
+	case "in":
+		switch c := r.(type) {
+		case []any:
+			for _, item := range c {
+				if exprEqual(l, item) {
+					return true, nil
+				}
+			}
+			return false, nil
+		case map[string]any:
+			if k, ok := l.(string); ok {
+				_, found := c[k]
+				return found, nil
+			}
+		}
+	case "<", "<=", ">", ">=":
+		if cmp, ok := exprCompare(l, r); ok {
+			switch n.op {
+			case "<":
+				return cmp < 0, nil
+			case "<=":
+				return cmp <= 0, nil
+			case ">":
+				return cmp > 0, nil
+			}
+			return cmp >= 0, nil
+		}
+	default:
+		if v, ok, err := exprArith(n.op, l, r); ok || err != nil {
+			return v, err
+		}
+	}
+	return nil, fmt.Errorf("no such overload: %s %s %s", exprTypeName(l), n.op, exprTypeName(r))
+}
+
+// logical 求值 || 与 &&：一侧已能确定结果时忽略另一侧的错误
+func (n *exprBinary) logical(env exprEnv) (any, error) {
+	decisive := n.op == "||"
+	l, lerr := n.l.eval(env)
+	lb, lok := l.(bool)
+	if lerr == nil && !lok {
+		lerr = fmt.Errorf("no such overload: %s %s", exprTypeName(l), n.op)
+	}
+	if lerr == nil && lb == decisive {
+		return decisive, nil
+	}
+	r, rerr := n.r.eval(env)
+	rb, rok := r.(bool)
+	if rerr == nil && !rok {
+		rerr = fmt.Errorf("no such overload: %s %s", n.op, exprTypeName(r))
+	}
+	if rerr == nil && rb == decisive {
+		return decisive, nil
+	}
+	if lerr != nil {
+		return nil, lerr
+	}
+	if rerr != nil {
+		return nil, rerr
+	}
+	return !decisive, nil
+}
+
+// exprEqual 比较两个值是否相等，不同类型视为不等
+func exprEqual(l, r any) bool {
+	if lt, ok := l.(time.Time); ok {
+		rt, ok := r.(time.Time)
+		return ok && lt.Equal(rt)
+	}
+	return reflect.DeepEqual(l, r)
+}
+
+// exprCompare 比较同类型的可排序值
+func exprCompare(l, r any) (int, bool) {
+	switch x := l.(type) {
+	case int64:
+		if y, ok := r.(int64); ok {
+			return cmpOrdered(x, y), true
+		}
+	case string:
+		if y, ok := r.(string); ok {
+			return strings.Compare(x, y), true
+		}
+	case time.Time:
+		if y, ok := r.(time.Time); ok {
+			return x.Compare(y), true
+		}
+	case time.Duration:
+		if y, ok := r.(time.Duration); ok {
+			return cmpOrdered(x, y), true
+		}
+	}
+	return 0, false
+}
+
+// cmpOrdered 比较两个有序值
+func cmpOrdered[T int64 | time.Duration](x, y T) int {
+	switch {
+	case x < y:
+		return -1
+	case x > y:
+		return 1
+	}
+	return 0
+}
+
+// exprArith 计算算术运算；ok 为 false 表示没有匹配的重载
+func exprArith(op string, l, r any) (any, bool, error) {
+	switch x := l.(type) {
+	case int64:
+		y, ok := r.(int64)
+		if !ok {
+			if d, ok := r.(time.Duration); ok && op == "*" {
+				return time.Duration(x) * d, true, nil
+			}
+			return nil, false, nil
+		}
+		switch op {
+		case "+":
+			return x + y, true, nil
+		case "-":
+			return x - y, true, nil
+		case "*":
+			return x * y, true, nil
+		case "/", "%":
+			if y == 0 {
+				return nil, true, fmt.Errorf("division by zero")
+			}
+			if op == "/" {
+				return x / y, true, nil
+			}
+			return x % y, true, nil
+		}
+	case string:
+		if y, ok := r.(string); ok && op == "+" {
+			return x + y, true, nil
+		}
+	case []any:
+		if y, ok := r.([]any); ok && op == "+" {
+			return append(append([]any{}, x...), y...), true, nil
+		}
+	case time.Time:
+		switch y := r.(type) {
+		case time.Duration:
+			if op == "+" {
+				return x.Add(y), true, nil
+			}
+			if op == "-" {
+				return x.Add(-y), true, nil
+			}
+		case time.Time:
+			if op == "-" {
+				return x.Sub(y), true, nil
+			}
+		}
+	case time.Duration:
+		switch y := r.(type) {
+		case time.Duration:
+			if op == "+" {
+				return x + y, true, nil
+			}
+			if op == "-" {
+				return x - y, true, nil
+			}
+		case time.Time:
+			if op == "+" {
+				return y.Add(x), true, nil
+			}
+		}
+	}
+	return nil, false, nil
+}
+
+func (n *exprSelect) eval(env exprEnv) (any, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("cannot select field %q from %s", n.field, exprTypeName(v))
+	}
+	f, ok := m[n.field]
+	if !ok {
+		return nil, fmt.Errorf("no such key: %q", n.field)
+	}
+	return f, nil
+}
+
+func (n *exprIndex) eval(env exprEnv) (any, error) {
+	v, err := n.x.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	idx, err := n.index.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	switch c := v.(type) {
+	case []any:
+		i, ok := idx.(int64)
+		if !ok {
+			return nil, fmt.Errorf("list index must be int, got %s", exprTypeName(idx))
+		}
+		if i < 0 || i >= int64(len(c)) {
+			return nil, fmt.Errorf("index %d out of range", i)
+		}
+		return c[i], nil
+	case map[string]any:
+		k, ok := idx.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key must be string, got %s", exprTypeName(idx))
+		}
+		f, ok := c[k]
+		if !ok {
+			return nil, fmt.Errorf("no such key: %q", k)
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("cannot index %s", exprTypeName(v))
+}
+
+func (n *exprMacro) eval(env exprEnv) (any, error) {
+	v, err := n.recv.eval(env)
+	if err != nil {
+		return nil, err
+	}
+	var items []any
+	switch c := v.(type) {
+	case []any:
+		items = c
+	case map[string]any:
+		for k := range c {
+			items = append(items, k)
+		}
+	default:
+		return nil, fmt.Errorf("%s: cannot iterate %s", n.name, exprTypeName(v))
+	}
+	inner := make(exprEnv, len(env)+1)
+	for k, val := range env {
+		inner[k] = val
+	}
+	matched := 0
+	for _, item := range items {
+		inner[n.v] = item
+		r, err := n.body.eval(inner)
+		if err != nil {
+			return nil, err
+		}
+		b, ok := r.(bool)
+		if !ok {
+			return nil, fmt.Errorf("%s: predicate must be bool, got %s", n.name, exprTypeName(r))
+		}
+		if b {
+			matched++
+			if n.name == "exists" {
+				return true, nil
+			}
+		} else if n.name == "all" {
+			return false, nil
+		}
+	}
+	switch n.name {
+	case "exists":
+		return false, nil
+	case "all":
+		return true, nil
+	}
+	return matched == 1, nil
+}
+
+func (n *exprCall) eval(env exprEnv) (any, error) {
+	var args []any
+	if n.recv != nil {
+		v, err := n.recv.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	for _, a := range n.args {
+		v, err := a.eval(env)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, v)
+	}
+	name := n.name
+	if n.recv != nil {
+		name = "." + name
+	}
+	switch {
+	case (name == "size" || name == ".size") && len(args) == 1:
+		switch x := args[0].(type) {
+		case string:
+			return int64(utf8.RuneCountInString(x)), nil
+		case []any:
+			return int64(len(x)), nil
+		case map[string]any:
+			return int64(len(x)), nil
+		}
+	case name == "timestamp" && len(args) == 1:
+		if s, ok := args[0].(string); ok {
+			t, err := time.Parse(time.RFC3339, s)
+			if err != nil {
+				return nil, fmt.Errorf("timestamp: %w", err)
+			}
+			return t, nil
+		}
+	case name == "duration" && len(args) == 1:
+		if s, ok := args[0].(string); ok {
+			d, err := time.ParseDuration(s)
+			if err != nil {
+				return nil, fmt.Errorf("duration: %w", err)
+			}
+			return d, nil
+		}
+	case name == "string" && len(args) == 1:
+		switch x := args[0].(type) {
+		case string:
+			return x, nil
+		case int64:
+			return strconv.FormatInt(x, 10), nil
+		case bool:
+			return strconv.FormatBool(x), nil
+		case time.Time:
+			return x.UTC().Format(time.RFC3339), nil
+		case time.Duration:
+			return x.String(), nil
+		}
+	case name == "int" && len(args) == 1:
+		switch x := args[0].(type) {
+		case int64:
+			return x, nil
+		case string:
+			n, err := strconv.ParseInt(x, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("int: invalid %q", x)
+			}
+			return n, nil
+		case time.Time:
+			return x.Unix(), nil
+		}
+	case name == ".lowerAscii" && len(args) == 1:
+		if s, ok := args[0].(string); ok {
+			return strings.Map(func(r rune) rune {
+				if r >= 'A' && r <= 'Z' {
+					return r + 'a' - 'A'
+				}
+				return r
+			}, s), nil
+		}
+	case len(args) == 2:
+		s, ok1 := args[0].(string)
+		t, ok2 := args[1].(string)
+		if ok1 && ok2 {
+			switch name {
+			case ".contains":
+				return strings.Contains(s, t), nil
+			case ".startsWith":
+				return strings.HasPrefix(s, t), nil
+			case ".endsWith":
+				return strings.HasSuffix(s, t), nil
+			case ".matches":
+				re, err := regexp.Compile(t)
+				if err != nil {
+					return nil, fmt.Errorf("matches: %w", err)
+				}
+				return re.MatchString(s), nil
+			}
+		}
+	}
+	types := make([]string, len(args))
+	for i, a := range args {
+		types[i] = exprTypeName(a)
+	}
+	return nil, fmt.Errorf("no such overload: %s(%s)", strings.TrimPrefix(name, "."), strings.Join(types, ", "))
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	mrand "math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DevConfig 为开发模式配置：开启后启用故障注入（/api/dev/chaos 与 X-Taskboard-Chaos 请求头），
+// 用于测试前端与 CLI 的重试逻辑，切勿在生产环境开启
+type DevConfig struct {
+	Enabled bool `yaml:"enabled"`
+}
+
+// chaosHeader 为单个请求按需注入故障的请求头，如 "latency=2s,error" 或 "drop"
+const chaosHeader = "X-Taskboard-Chaos"
+
+// chaosSettings 为全局故障注入设置，作用于 Paths 前缀下的请求（为空时作用于全部 API）
+type chaosSettings struct {
+	LatencyMS int      `json:"latency_ms"` // 固定附加延迟
+	JitterMS  int      `json:"jitter_ms"`  // 在固定延迟之上随机追加 0~jitter 的延迟
+	ErrorRate float64  `json:"error_rate"` // 以该概率返回 500
+	DropRate  float64  `json:"drop_rate"`  // 以该概率在响应体写出一半时断开连接
+	Paths     []string `json:"paths"`
+}
+
+// validate 校验故障注入设置
+func (s chaosSettings) validate() error {
+	if s.LatencyMS < 0 || s.JitterMS < 0 {
+		return fmt.Errorf("latency_ms 与 jitter_ms 不能为负数")
+	}
+	if s.ErrorRate < 0 || s.ErrorRate > 1 || s.DropRate < 0 || s.DropRate > 1 {
+		return fmt.Errorf("error_rate 与 drop_rate 必须在 0 到 1 之间")
+	}
+	return nil
+}
+
+// chaosFault 为对单个请求实际注入的故障
+type chaosFault struct {
+	latency time.Duration
+	fail    bool
+	drop    bool
+}
+
+// chaosInjector 保存运行时可调整的故障注入设置
+type chaosInjector struct {
+	mu       sync.Mutex
+	settings chaosSettings
+}
+
+// get 返回当前设置
+func (c *chaosInjector) get() chaosSettings {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.settings
+}
+
+// set 替换当前设置
+func (c *chaosInjector) set(s chaosSettings) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.settings = s
+}
+
+// chaosExempt 判断请求是否不受全局设置影响：故障注入接口本身与健康检查始终正常
+func chaosExempt(path string) bool {
+	return strings.HasPrefix(path, "/api/dev/") || path == "/api/health" || path == "/readyz"
+}
+
+// fault 按全局设置为请求抽取故障
+func (s chaosSettings) fault(path string) chaosFault {
+	var f chaosFault
+	if chaosExempt(path) {
+		return f
+	}
+	if len(s.Paths) == 0 {
+		if !strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/public/api/") {
+			return f
+		}
+	} else if !hasAnyPrefix(path, s.Paths) {
+		return f
+	}
+	f.latency = time.Duration(s.LatencyMS) * time.Millisecond
+	if s.JitterMS > 0 {
+		f.latency += time.Duration(mrand.IntN(s.JitterMS+1)) * time.Millisecond
+	}
+	f.fail = s.ErrorRate > 0 && mrand.Float64() < s.ErrorRate
+	f.drop = !f.fail && s.DropRate > 0 && mrand.Float64() < s.DropRate
+	return f
+}
+
+// hasAnyPrefix 判断路径是否以任一非空前缀开头
+func hasAnyPrefix(path string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if p != "" && strings.HasPrefix(path, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseChaosHeader 解析 X-Taskboard-Chaos 请求头，逗号分隔 latency=<时长>、error、drop
+func parseChaosHeader(v string) (chaosFault, error) {
+	var f chaosFault
+	for _, item := range splitList(v) {
+		key, val, _ := strings.Cut(item, "=")
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "latency":
+			d, err := time.ParseDuration(strings.TrimSpace(val))
+			if err != nil || d < 0 {
+				return f, fmt.Errorf("invalid %s latency %q", chaosHeader, val)
+			}
+			f.latency = d
+		case "error":
+			f.fail = true
+		case "drop":
+			f.drop = true
+		default:
+			return f, fmt.Errorf("unknown %s fault %q", chaosHeader, key)
+		}
+	}
+	return f, nil
+}
+
+// withChaos 在开发模式下为请求注入延迟、500 错误或中途断开；非开发模式时原样返回
+func (a *App) withChaos(next http.Handler) http.Handler {
+	if !a.cfg.Dev.Enabled {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		f := a.chaos.get().fault(r.URL.Path)
+		if v := r.Header.Get(chaosHeader); v != "" {
+			var err error
+			if f, err = parseChaosHeader(v); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		if f == (chaosFault{}) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		addLogAttrs(r, "chaos_latency_ms", f.latency.Milliseconds(), "chaos_error", f.fail, "chaos_drop", f.drop)
+		if f.latency > 0 {
+			timer := time.NewTimer(f.latency)
+			select {
+			case <-timer.C:
+			case <-r.Context().Done():
+				timer.Stop()
+				return
+			}
+		}
+		switch {
+		case f.fail:
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "injected failure"})
+		case f.drop:
+			a.dropMidResponse(w, r, next)
+		default:
+			next.ServeHTTP(w, r)
+		}
+	})
+}
+
+// dropMidResponse 完整执行处理器后只写出一半响应体即断开连接，客户端会读到不完整的响应
+// （声明的 Content-Length 与实际不符），模拟长连接或流式响应中途掉线
+func (a *App) dropMidResponse(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	buf := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+	next.ServeHTTP(buf, r)
+	for k, v := range buf.header {
+		w.Header()[k] = v
+	}
+	body := buf.body.Bytes()
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(buf.status)
+	_, _ = w.Write(body[:len(body)/2])
+	_ = http.NewResponseController(w).Flush()
+	a.reqLogger(r).Info("故障注入：断开连接", "method", r.Method, "path", r.URL.Path, "status", buf.status, "written", len(body)/2, "length", len(body))
+	panic(http.ErrAbortHandler)
+}
+
+// bufferedResponse 在内存中缓存处理器的完整响应
+type bufferedResponse struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+// Header 返回缓存的响应头
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+// WriteHeader 记录状态码
+func (b *bufferedResponse) WriteHeader(code int) { b.status = code }
+
+// Write 缓存响应体
+func (b *bufferedResponse) Write(p []byte) (int, error) { return b.body.Write(p) }
+
+// handleChaos 查看（GET）、替换（PUT）或清除（DELETE）全局故障注入设置；非开发模式时返回 404
+func (a *App) handleChaos(w http.ResponseWriter, r *http.Request) {
+	if !a.cfg.Dev.Enabled {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, a.chaos.get())
+	case http.MethodPut:
+		var body chaosSettings
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		if err := body.validate(); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		a.chaos.set(body)
+		a.reqLogger(r).Warn("故障注入设置已更新", "latency_ms", body.LatencyMS, "jitter_ms", body.JitterMS,
+			"error_rate", body.ErrorRate, "drop_rate", body.DropRate, "paths", body.Paths)
+		writeJSON(w, http.StatusOK, body)
+	case http.MethodDelete:
+		a.chaos.set(chaosSettings{})
+		a.reqLogger(r).Info("故障注入已清除")
+		writeJSON(w, http.StatusOK, map[string]string{"status": "cleared"})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
@@ -0,0 +1,403 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// cliUsage 为命令行帮助信息
+const cliUsage = `用法: task-board [命令] [参数]
+
+命令:
+  serve        启动 HTTP 服务（缺省命令）
+  migrate      初始化或迁移数据库结构后退出
+  doctor       执行自检，并将数据库导出后导入临时库逐表比对，确认导出文件中的数据能原样恢复，并列出导出未覆盖的表（-roundtrip=false 跳过比对，-keep 保留临时库，-json）
+  export       导出看板为 JSON（-out 文件，缺省输出到标准输出；-anonymize 打乱文本内容）
+  import       从 export 生成的 JSON 导入任务（-in 文件，- 表示标准输入）
+  apikey       创建 API 密钥并打印原文（-name 名称，-scopes read,write,admin，-expires-in 有效期）
+  audit-verify 校验审计日志的哈希链（参数为按时间顺序排列的文件，缺省为 audit.path）
+  seed         写入示例数据（-demo 演示任务，-onboarding 新看板引导示例，-locale 指定语言）
+  compact-archive  将早于 -before 日期归档的任务迁移到按年拆分的归档库
+  export-site  导出静态 HTML 站点（-out 目录）
+  rebuild-mentions  按任务描述与评论重建 #编号 提及关系与 @ 提及（升级或导入后使用）
+  vapid-keys   生成浏览器推送（web_push）使用的 VAPID 密钥对并打印配置片段
+  move-data    将数据目录复制到 -to 指定的新目录，校验后替换原目录并更新配置文件（须先停止服务）
+
+配置通过 config.yaml（或 CONFIG_FILE）与环境变量提供。
+`
+
+// runCLI 解析子命令并执行，未指定子命令时启动 HTTP 服务
+func runCLI(cfg Config, args []string) error {
+	cmd := "serve"
+	if len(args) > 0 {
+		cmd, args = args[0], args[1:]
+	}
+	var err error
+	switch cmd {
+	case "serve":
+		err = runServe(cfg)
+	case "migrate":
+		err = runMigrate(cfg)
+	case "doctor":
+		err = runDoctor(cfg, args)
+	case "export":
+		err = runExport(cfg, args)
+	case "import":
+		err = runImport(cfg, args)
+	case "apikey":
+		err = runAPIKey(cfg, args)
+	case "audit-verify":
+		err = runAuditVerify(cfg, args)
+	case "seed":
+		err = runSeed(cfg, args)
+	case "export-site":
+		err = runExportSite(cfg, args)
+	case "move-data":
+		err = runMoveData(cfg, args)
+	case "compact-archive":
+		err = runCompactArchive(cfg, args)
+	case "rebuild-mentions":
+		err = runRebuildMentions(cfg)
+	case "vapid-keys":
+		err = runVAPIDKeys()
+	case "help", "-h", "-help", "--help":
+		fmt.Print(cliUsage)
+		return nil
+	default:
+		fmt.Fprint(os.Stderr, cliUsage)
+		return fmt.Errorf("未知命令: %s", cmd)
+	}
+	if err != nil {
+		return fmt.Errorf("%s 失败: %w", cmd, err)
+	}
+	return nil
+}
+
+// parseFlags 解析子命令参数；-h 打印帮助后视为成功
+func parseFlags(flags *flag.FlagSet, args []string) (bool, error) {
+	if err := flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return false, nil
+		}
+		return false, err
+	}
+	return true, nil
+}
+
+// runMigrate 打开数据库并执行建表与补列迁移
+func runMigrate(cfg Config) error {
+	app := NewApp(cfg)
+	defer app.db.Close()
+	fmt.Println("数据库迁移完成:", cfg.Data.dbPath())
+	return nil
+}
+
+// runRebuildMentions 重建全部任务的提及关系
+func runRebuildMentions(cfg Config) error {
+	app := NewApp(cfg)
+	defer app.db.Close()
+	relations, users, err := app.rebuildTaskMentions(context.Background())
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已重建提及关系: %d，@ 提及: %d\n", relations, users)
+	return nil
+}
+
+// boardExport 为看板 JSON 导出格式
+type boardExport struct {
+	Version    int            `json:"version"`
+	ExportedAt time.Time      `json:"exported_at"`
+	Columns    []Column       `json:"columns"`
+	Tasks      []exportedTask `json:"tasks"`
+}
+
+// exportedTask 为导出的任务，附带外部引用
+type exportedTask struct {
+	Task
+	Refs []TaskRef `json:"refs,omitempty"`
+}
+
+// boardExportVersion 为当前导出格式版本
+const boardExportVersion = 1
+
+// runExport 将全部任务（含归档）、列策略与外部引用导出为 JSON
+func runExport(cfg Config, args []string) error {
+	flags := flag.NewFlagSet("export", flag.ContinueOnError)
+	out := flags.String("out", "-", "输出文件，- 表示标准输出")
+	includeCompacted := flags.Bool("include-compacted", false, "同时导出已迁移到按年归档库中的任务")
+	anonymize := flags.Bool("anonymize", false, "打乱标题、描述、标签等文本，保留结构、长度与时间，便于提交问题复现数据")
+	anonymizeKey := flags.String("anonymize-key", "", "打乱使用的密钥；相同密钥的多次导出结果一致，缺省为随机密钥")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+	app := NewApp(cfg)
+	defer app.db.Close()
+	ctx := context.Background()
+	data, err := app.exportBoard(ctx)
+	if err != nil {
+		return err
+	}
+	if *includeCompacted {
+		years, err := cfg.Data.listArchivePartitionYears()
+		if err != nil {
+			return err
+		}
+		for _, y := range years {
+			pa, err := app.openArchivePartition(y)
+			if err != nil {
+				return err
+			}
+			tasks, err := pa.exportTasks(ctx)
+			pa.db.Close()
+			if err != nil {
+				return fmt.Errorf("读取 %d 年归档库失败: %w", y, err)
+			}
+			data.Tasks = append(data.Tasks, tasks...)
+		}
+	}
+	if *anonymize || *anonymizeKey != "" {
+		newAnonymizer(*anonymizeKey).board(&data)
+	}
+	var w io.Writer = os.Stdout
+	if *out != "-" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(data); err != nil {
+		return err
+	}
+	if *out != "-" {
+		fmt.Printf("已导出 %d 个任务到 %s\n", len(data.Tasks), *out)
+	}
+	return nil
+}
+
+// exportBoard 读取看板的完整数据
+func (a *App) exportBoard(ctx context.Context) (boardExport, error) {
+	data := boardExport{Version: boardExportVersion, ExportedAt: time.Now().UTC()}
+	cols, err := a.fetchColumns(ctx)
+	if err != nil {
+		return data, err
+	}
+	data.Columns = cols
+	data.Tasks, err = a.exportTasks(ctx)
+	return data, err
+}
+
+// exportTasks 读取全部任务及其外部引用
+func (a *App) exportTasks(ctx context.Context) ([]exportedTask, error) {
+	tasks, err := a.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]exportedTask, 0, len(tasks))
+	for _, t := range tasks {
+		refs, err := a.fetchTaskRefs(ctx, t.ID)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, exportedTask{Task: t, Refs: refs})
+	}
+	return out, nil
+}
+
+// runImport 从 JSON 导出文件导入任务；任务获得新的 ID，列策略按文件覆盖
+func runImport(cfg Config, args []string) error {
+	flags := flag.NewFlagSet("import", flag.ContinueOnError)
+	in := flags.String("in", "", "导入文件，- 表示标准输入")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+	if *in == "" {
+		return errors.New("缺少 -in 参数")
+	}
+	var r io.Reader = os.Stdin
+	if *in != "-" {
+		f, err := os.Open(*in)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+	var data boardExport
+	if err := json.NewDecoder(r).Decode(&data); err != nil {
+		return fmt.Errorf("解析导入文件失败: %w", err)
+	}
+	if data.Version > boardExportVersion {
+		return fmt.Errorf("不支持的导出格式版本 %d", data.Version)
+	}
+	app := NewApp(cfg)
+	defer app.db.Close()
+	n, err := app.importBoard(context.Background(), data)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已导入 %d 个任务\n", n)
+	return nil
+}
+
+// importBoard 在同一事务中写入列策略与任务及其关联数据，任一失败则全部回滚
+func (a *App) importBoard(ctx context.Context, data boardExport) (int, error) {
+	for _, t := range data.Tasks {
+		if t.Title == "" || !validStatus(t.Status) {
+			return 0, fmt.Errorf("任务 %d 的标题或状态无效", t.ID)
+		}
+	}
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	now := time.Now()
+	for _, c := range data.Columns {
+		if !validStatus(c.Status) {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO column_policies (status, policy, require_confirmation, aging_after_days, stale_after_days, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(status) DO UPDATE SET
+				policy = excluded.policy,
+				require_confirmation = excluded.require_confirmation,
+				aging_after_days = excluded.aging_after_days,
+				stale_after_days = excluded.stale_after_days,
+				updated_at = excluded.updated_at
+		`, c.Status, c.Policy, boolToInt(c.RequireConfirmation), c.AgingAfterDays, c.StaleAfterDays, now.Format(time.RFC3339)); err != nil {
+			return 0, err
+		}
+	}
+	for _, t := range data.Tasks {
+		created := timeOr(t.CreatedAt, now)
+		updated := timeOr(t.UpdatedAt, created)
+		statusChanged := timeOr(t.StatusChangedAt, updated)
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO tasks (title, description, status, archived, created_at, updated_at, start_at, due_at, status_changed_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, t.Title, t.Description, t.Status, boolToInt(t.Archived), created.Format(time.RFC3339), updated.Format(time.RFC3339), timeArg(t.StartAt), timeArg(t.DueAt), statusChanged.Format(time.RFC3339))
+		if err != nil {
+			return 0, err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return 0, err
+		}
+		for _, tag := range a.cfg.Tags.normalizeAll(t.Tags) {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO task_tags (task_id, tag) VALUES (?, ?)`, id, tag); err != nil {
+				return 0, err
+			}
+		}
+		for i, item := range t.AcceptanceCriteria {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO task_acceptance_criteria (task_id, position, text) VALUES (?, ?, ?)`, id, i, item); err != nil {
+				return 0, err
+			}
+		}
+		for i, l := range t.ExternalLinks {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO task_links (task_id, position, title, url) VALUES (?, ?, ?, ?)`, id, i, l.Title, l.URL); err != nil {
+				return 0, err
+			}
+		}
+		for _, ref := range t.Refs {
+			if _, err := tx.ExecContext(ctx, `
+				INSERT INTO task_refs (task_id, kind, url, title, created_at)
+				VALUES (?, ?, ?, ?, ?)
+			`, id, ref.Kind, ref.URL, ref.Title, timeOr(ref.CreatedAt, now).Format(time.RFC3339)); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return len(data.Tasks), nil
+}
+
+// timeOr 返回 t，零值时返回 fallback
+func timeOr(t, fallback time.Time) time.Time {
+	if t.IsZero() {
+		return fallback
+	}
+	return t
+}
+
+// runSeed 写入示例数据；看板已有任务时需 -force
+func runSeed(cfg Config, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	demo := flags.Bool("demo", false, "写入演示任务")
+	onboarding := flags.Bool("onboarding", false, "写入新看板的引导示例")
+	locale := flags.String("locale", cfg.Onboarding.Locale, "引导示例的语言")
+	force := flags.Bool("force", false, "看板非空时仍然写入")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+	if *demo == *onboarding {
+		return errors.New("请指定 -demo 或 -onboarding 之一")
+	}
+	app := NewApp(cfg)
+	defer app.db.Close()
+	ctx := context.Background()
+	var count int
+	if err := app.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks`).Scan(&count); err != nil {
+		return err
+	}
+	if count > 0 && !*force {
+		return fmt.Errorf("看板已有 %d 个任务，如需追加示例数据请加 -force", count)
+	}
+	data := demoBoard(time.Now())
+	if *onboarding {
+		var err error
+		if data, err = onboardingBoard(*locale); err != nil {
+			return err
+		}
+	}
+	n, err := app.importBoard(ctx, data)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("已写入 %d 个示例任务\n", n)
+	return nil
+}
+
+// demoBoard 返回演示用的看板数据
+func demoBoard(now time.Time) boardExport {
+	day := 24 * time.Hour
+	due := now.Add(7 * day)
+	task := func(title, status string, ageDays int, tags ...string) exportedTask {
+		at := now.Add(-time.Duration(ageDays) * day)
+		return exportedTask{Task: Task{Title: title, Status: status, Tags: tags, CreatedAt: at, UpdatedAt: at, StatusChangedAt: at}}
+	}
+	login := task("设计登录页面", "进行中", 2, "前端", "设计")
+	login.AcceptanceCriteria = []string{"支持邮箱与密码登录", "错误提示清晰"}
+	login.DueAt = &due
+	api := task("实现任务导出接口", "规划中", 5, "后端")
+	api.Description = "导出为 JSON，包含归档任务与外部引用"
+	api.ExternalLinks = []TaskLink{{Title: "需求讨论", URL: "https://example.com/docs/export"}}
+	done := task("搭建持续集成流水线", "已完成", 10, "运维")
+	old := task("整理上季度需求池", "已完成", 40, "日常")
+	old.Archived = true
+	return boardExport{
+		Version: boardExportVersion,
+		Tasks: []exportedTask{
+			login,
+			api,
+			task("调研全文搜索方案", "规划中", 20, "后端", "调研"),
+			task("修复移动端拖拽卡顿", "进行中", 9, "前端", "缺陷"),
+			task("等待设计稿确认", "搁置中", 4, "设计"),
+			done,
+			old,
+		},
+	}
+}
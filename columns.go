@@ -0,0 +1,275 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// taskStatuses 为看板的状态列，按展示顺序排列
+var taskStatuses = []string{"规划中", "进行中", "搁置中", "已完成"}
+
+// maxColumnPolicyLen 为列策略文本的最大长度
+const maxColumnPolicyLen = 4000
+
+// Column 表示一个状态列及其策略（完成定义 / 进入条件）
+type Column struct {
+	Status              string     `json:"status"`
+	Policy              string     `json:"policy"`
+	RequireConfirmation bool       `json:"require_confirmation"`
+	AgingAfterDays      int        `json:"aging_after_days"`
+	StaleAfterDays      int        `json:"stale_after_days"`
+	RequireApproval     bool       `json:"require_approval"`
+	Approvers           []string   `json:"approvers"`
+	WIPLimit            int        `json:"wip_limit"`
+	WIPMode             string     `json:"wip_mode"`
+	UpdatedAt           *time.Time `json:"updated_at"`
+}
+
+// columnPolicyRequest 为更新列策略的请求体；老化阈值传 0 表示该列不计算老化
+type columnPolicyRequest struct {
+	Policy              *string `json:"policy"`
+	RequireConfirmation *bool   `json:"require_confirmation"`
+	AgingAfterDays      *int    `json:"aging_after_days"`
+	StaleAfterDays      *int    `json:"stale_after_days"`
+	// RequireApproval 为真时，任务移入该列需经审批；Approvers 为可审批的签名客户端 ID，开启审批时至少需要一个
+	RequireApproval *bool     `json:"require_approval"`
+	Approvers       *[]string `json:"approvers"`
+	// WIPLimit 为列中在板任务数的上限，0 表示不限制；WIPMode 为超限时的处理方式：reject 或 warn
+	WIPLimit *int    `json:"wip_limit"`
+	WIPMode  *string `json:"wip_mode"`
+}
+
+// newColumn 返回使用默认配置的状态列
+func newColumn(status string) Column {
+	th := defaultAgingThresholds[status]
+	return Column{Status: status, AgingAfterDays: th.AgingAfter, StaleAfterDays: th.StaleAfter, Approvers: []string{}, WIPMode: wipModeReject}
+}
+
+// fetchColumns 按展示顺序返回所有状态列及其策略，未配置的项使用默认值
+func (a *App) fetchColumns(ctx context.Context) ([]Column, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT status, policy, require_confirmation, aging_after_days, stale_after_days, require_approval, approvers, wip_limit, wip_mode, updated_at FROM column_policies`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	byStatus := map[string]Column{}
+	for rows.Next() {
+		var status, policy, approvers, wipMode, updated string
+		var confirm, approval, wipLimit int
+		var agingAfter, staleAfter sql.NullInt64
+		if err := rows.Scan(&status, &policy, &confirm, &agingAfter, &staleAfter, &approval, &approvers, &wipLimit, &wipMode, &updated); err != nil {
+			return nil, err
+		}
+		c := newColumn(status)
+		c.WIPLimit, c.WIPMode = wipLimit, wipMode
+		c.Policy = policy
+		c.RequireConfirmation = confirm != 0
+		c.RequireApproval = approval != 0
+		c.Approvers = splitApprovers(approvers)
+		if agingAfter.Valid {
+			c.AgingAfterDays = int(agingAfter.Int64)
+		}
+		if staleAfter.Valid {
+			c.StaleAfterDays = int(staleAfter.Int64)
+		}
+		if t, err := time.Parse(time.RFC3339, updated); err == nil {
+			c.UpdatedAt = &t
+		}
+		byStatus[c.Status] = c
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]Column, 0, len(taskStatuses))
+	for _, s := range taskStatuses {
+		c, ok := byStatus[s]
+		if !ok {
+			c = newColumn(s)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// columnPolicy 返回进入某列需要确认时的策略文本；无需确认时返回空串
+func (a *App) columnPolicy(ctx context.Context, status string) (string, error) {
+	var policy string
+	var confirm int
+	err := a.db.QueryRowContext(ctx, `SELECT policy, require_confirmation FROM column_policies WHERE status = ?`, status).Scan(&policy, &confirm)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	if confirm == 0 || strings.TrimSpace(policy) == "" {
+		return "", nil
+	}
+	return policy, nil
+}
+
+// columnApproval 返回进入某列是否需要审批及可审批的客户端 ID
+func (a *App) columnApproval(ctx context.Context, status string) (bool, []string, error) {
+	var approval int
+	var approvers string
+	err := a.db.QueryRowContext(ctx, `SELECT require_approval, approvers FROM column_policies WHERE status = ?`, status).Scan(&approval, &approvers)
+	if err == sql.ErrNoRows {
+		return false, nil, nil
+	}
+	if err != nil {
+		return false, nil, err
+	}
+	return approval != 0, splitApprovers(approvers), nil
+}
+
+// splitApprovers 解析以逗号分隔保存的审批人列表
+func splitApprovers(s string) []string {
+	out := []string{}
+	for _, id := range strings.Split(s, ",") {
+		if id = strings.TrimSpace(id); id != "" {
+			out = append(out, id)
+		}
+	}
+	return out
+}
+
+// handleColumns 返回状态列及其策略
+func (a *App) handleColumns(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	cols, err := a.fetchColumns(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	// 列配置很少变化，按内容生成 ETag 供客户端协商缓存
+	raw, _ := json.Marshal(cols)
+	if checkNotModified(w, r, weakETag("columns", string(raw)), "no-cache") {
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": cols})
+}
+
+// handleColumnItem 处理 PUT /api/columns/{status}，更新列的策略文本、确认要求、审批要求、老化阈值与 WIP 限制；
+// /api/columns/{status}/subscriptions 下的请求交由列订阅处理
+func (a *App) handleColumnItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	status, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/columns/"), "/")
+	if !validStatus(status) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown column"})
+		return
+	}
+	if sub != "" {
+		if rest, ok := strings.CutPrefix(sub, "subscriptions"); ok && (rest == "" || rest[0] == '/') {
+			a.handleColumnSubscriptions(w, r, status, strings.TrimPrefix(rest, "/"))
+			return
+		}
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if r.Method != http.MethodPut && r.Method != http.MethodPatch {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var body columnPolicyRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	var policy, approvers string
+	var confirm, approval, wipLimit int
+	wipMode := wipModeReject
+	var agingAfter, staleAfter sql.NullInt64
+	err := a.db.QueryRowContext(ctx, `SELECT policy, require_confirmation, aging_after_days, stale_after_days, require_approval, approvers, wip_limit, wip_mode FROM column_policies WHERE status = ?`, status).
+		Scan(&policy, &confirm, &agingAfter, &staleAfter, &approval, &approvers, &wipLimit, &wipMode)
+	if err != nil && err != sql.ErrNoRows {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if body.Policy != nil {
+		policy = strings.TrimSpace(*body.Policy)
+		if len([]rune(policy)) > maxColumnPolicyLen {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "policy too long"})
+			return
+		}
+	}
+	if body.RequireConfirmation != nil {
+		confirm = boolToInt(*body.RequireConfirmation)
+	}
+	if body.AgingAfterDays != nil {
+		agingAfter = sql.NullInt64{Int64: int64(*body.AgingAfterDays), Valid: true}
+	}
+	if body.StaleAfterDays != nil {
+		staleAfter = sql.NullInt64{Int64: int64(*body.StaleAfterDays), Valid: true}
+	}
+	if body.RequireApproval != nil {
+		approval = boolToInt(*body.RequireApproval)
+	}
+	if body.Approvers != nil {
+		var ids []string
+		for _, id := range *body.Approvers {
+			if id = strings.TrimSpace(id); id != "" {
+				if strings.Contains(id, ",") {
+					writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid approver"})
+					return
+				}
+				ids = append(ids, id)
+			}
+		}
+		approvers = strings.Join(ids, ",")
+	}
+	if body.WIPLimit != nil {
+		wipLimit = *body.WIPLimit
+	}
+	if body.WIPMode != nil {
+		wipMode = strings.TrimSpace(*body.WIPMode)
+	}
+	if (agingAfter.Valid && agingAfter.Int64 < 0) || (staleAfter.Valid && staleAfter.Int64 < 0) || wipLimit < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "thresholds must not be negative"})
+		return
+	}
+	if approval != 0 && approvers == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "require_approval needs at least one approver"})
+		return
+	}
+	if !validWIPMode(wipMode) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "wip_mode must be reject or warn"})
+		return
+	}
+	now := time.Now().Format(time.RFC3339)
+	if _, err := a.db.ExecContext(ctx, `
+		INSERT INTO column_policies (status, policy, require_confirmation, aging_after_days, stale_after_days, require_approval, approvers, wip_limit, wip_mode, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(status) DO UPDATE SET
+			policy = excluded.policy,
+			require_confirmation = excluded.require_confirmation,
+			aging_after_days = excluded.aging_after_days,
+			stale_after_days = excluded.stale_after_days,
+			require_approval = excluded.require_approval,
+			approvers = excluded.approvers,
+			wip_limit = excluded.wip_limit,
+			wip_mode = excluded.wip_mode,
+			updated_at = excluded.updated_at
+	`, status, policy, confirm, agingAfter, staleAfter, approval, approvers, wipLimit, wipMode, now); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	cols, err := a.fetchColumns(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	for _, c := range cols {
+		if c.Status == status {
+			writeJSON(w, http.StatusOK, c)
+			return
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"time"
+)
+
+// 任务评论。目前由系统自动发布（如停滞提醒，Author 为 system）或随状态变更记录原因（Author 为签名客户端），Kind 标明来源；
+// 评论不修改任务本身，因此不会递增版本或影响老化计算
+
+// 评论作者与类型
+const (
+	commentAuthorSystem = "system"
+	commentKindAging    = "aging_summary"
+	// commentKindStatusReason 为变更状态时填写的原因
+	commentKindStatusReason = "status_reason"
+)
+
+// TaskComment 为任务的一条评论
+type TaskComment struct {
+	ID        int64     `json:"id"`
+	TaskID    int64     `json:"task_id"`
+	Author    string    `json:"author"`
+	Kind      string    `json:"kind,omitempty"`
+	Body      string    `json:"body"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// taskCommentListResponse 为任务评论列表的响应结构，按时间从旧到新排列
+type taskCommentListResponse struct {
+	Items []TaskComment `json:"items"`
+}
+
+// addTaskComment 为任务添加一条评论，并记录评论中提及的任务
+func (a *App) addTaskComment(ctx context.Context, c TaskComment) (int64, error) {
+	res, err := a.db.ExecContext(ctx, `INSERT INTO task_comments (task_id, author, kind, body, created_at) VALUES (?, ?, ?, ?, ?)`,
+		c.TaskID, c.Author, c.Kind, c.Body, c.CreatedAt.Format(time.RFC3339))
+	if err != nil {
+		return 0, err
+	}
+	id, _ := res.LastInsertId()
+	if err := a.syncTaskMentions(ctx, c.TaskID, mentionOriginComment, id, c.Body); err != nil {
+		a.logger.Warn("更新评论提及关系失败", "task_id", c.TaskID, "comment_id", id, "err", err)
+	}
+	return id, nil
+}
+
+// fetchTaskComments 按时间从旧到新返回任务的评论
+func (a *App) fetchTaskComments(ctx context.Context, taskID int64) ([]TaskComment, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT id, task_id, author, kind, body, created_at FROM task_comments WHERE task_id = ? ORDER BY id`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TaskComment{}
+	for rows.Next() {
+		var c TaskComment
+		var created string
+		if err := rows.Scan(&c.ID, &c.TaskID, &c.Author, &c.Kind, &c.Body, &created); err != nil {
+			return nil, err
+		}
+		c.CreatedAt, _ = time.Parse(time.RFC3339, created)
+		items = append(items, c)
+	}
+	return items, rows.Err()
+}
+
+// handleTaskComments 处理 GET /api/tasks/{id}/comments
+func (a *App) handleTaskComments(w http.ResponseWriter, r *http.Request, taskID int64) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	_, err := a.fetchTaskDetail(ctx, taskID)
+	if err == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	items, err := a.fetchTaskComments(ctx, taskID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, taskCommentListResponse{Items: items})
+}
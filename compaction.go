@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// archivePartitionTables 为压缩归档时随任务一起迁移的表及其关联任务的列，tasks 须在首位；
+// 对 tasks 有级联外键而不在此列的表（重复规则、提醒、分享链接与站内通知）在压缩时随任务删除。
+// 活动任务提及被迁移任务的 task_relations 记录不迁移，留在主库（related_id 没有外键）
+var archivePartitionTables = []struct{ name, taskCol string }{
+	{"tasks", "id"},
+	{"task_tags", "task_id"},
+	{"task_acceptance_criteria", "task_id"},
+	{"task_links", "task_id"},
+	{"task_refs", "task_id"},
+	{"task_events", "task_id"},
+	{"task_occurrences", "task_id"},
+	{"task_versions", "task_id"},
+	{"task_approvals", "task_id"},
+	{"task_incidents", "task_id"},
+	{"task_comments", "task_id"},
+	{"task_relations", "task_id"},
+	{"user_mentions", "task_id"},
+	{"task_watchers", "task_id"},
+}
+
+// partitionForeignKeyPattern 匹配建表语句中的外键子句，第一组为被引用的表
+var partitionForeignKeyPattern = regexp.MustCompile(`(?i),\s*FOREIGN KEY\s*\(\s*\w+\s*\)\s*REFERENCES\s+(\w+)\s*\([^)]*\)(?:\s+ON\s+(?:DELETE|UPDATE)\s+(?:CASCADE|SET NULL|SET DEFAULT|RESTRICT|NO ACTION))*`)
+
+// compactBatchSize 为单条语句中 IN 列表的任务数量上限
+const compactBatchSize = 500
+
+// archivePartition 描述一个按年份拆分的归档库文件
+type archivePartition struct {
+	Year  int    `json:"year"`
+	File  string `json:"file"`
+	Tasks int    `json:"tasks"`
+}
+
+// archivePartitionPath 返回指定年份归档库的文件路径
+func (d DataConfig) archivePartitionPath(year int) string {
+	return filepath.Join(d.Dir, fmt.Sprintf("archive-%d.db", year))
+}
+
+// listArchivePartitionYears 返回数据目录中已存在的归档库年份（升序）
+func (d DataConfig) listArchivePartitionYears() ([]int, error) {
+	matches, err := filepath.Glob(filepath.Join(d.Dir, "archive-*.db"))
+	if err != nil {
+		return nil, err
+	}
+	var years []int
+	for _, m := range matches {
+		y, err := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(filepath.Base(m), "archive-"), ".db"))
+		if err == nil {
+			years = append(years, y)
+		}
+	}
+	sort.Ints(years)
+	return years, nil
+}
+
+// openArchivePartition 以只读方式打开某年的归档库，返回共享配置与日志、使用该库连接的应用实例，
+// 以便直接复用任务查询代码；调用方负责关闭其 db
+func (a *App) openArchivePartition(year int) (*App, error) {
+	path := a.cfg.Data.archivePartitionPath(year)
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	// 与主库一样注册搜索函数，使 q 的拼音与容错匹配同样适用于归档库
+	db := sql.OpenDB(&timedConnector{dsn: "file:" + path + "?mode=ro", drv: &sqlite3.SQLiteDriver{ConnectHook: registerSearchFuncs}, stats: a.queryStats})
+	return &App{cfg: a.cfg, logger: a.logger, db: db}, nil
+}
+
+// compactArchive 将归档时间早于 before 的任务按归档年份迁移到独立的归档库，返回各年份迁移的任务数
+func (a *App) compactArchive(ctx context.Context, before time.Time, dryRun bool) (map[int]int, error) {
+	// 归档时间取最近一次归档事件，缺失时以更新时间近似
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT t.id, COALESCE((SELECT MAX(e.created_at) FROM task_events e WHERE e.task_id = t.id AND e.kind = 'archived'), t.updated_at)
+		FROM tasks t WHERE t.archived = 1
+	`)
+	if err != nil {
+		return nil, err
+	}
+	byYear := map[int][]int64{}
+	for rows.Next() {
+		var id int64
+		var at string
+		if err := rows.Scan(&id, &at); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		ts, err := time.Parse(time.RFC3339, at)
+		if err != nil || !ts.Before(before) {
+			continue
+		}
+		year := ts.In(before.Location()).Year()
+		byYear[year] = append(byYear[year], id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	moved := map[int]int{}
+	for year, ids := range byYear {
+		if !dryRun {
+			if err := a.moveToPartition(ctx, year, ids); err != nil {
+				return moved, fmt.Errorf("迁移 %d 年归档失败: %w", year, err)
+			}
+		}
+		moved[year] = len(ids)
+	}
+	return moved, nil
+}
+
+// moveToPartition 在单个连接上挂载归档库，于同一事务中复制任务及关联数据后从主库删除
+func (a *App) moveToPartition(ctx context.Context, year int, ids []int64) error {
+	conn, err := a.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `ATTACH DATABASE ? AS arc`, a.cfg.Data.archivePartitionPath(year)); err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), `DETACH DATABASE arc`)
+	columns := map[string][]string{}
+	for _, t := range archivePartitionTables {
+		cols, err := preparePartitionTable(ctx, conn, t.name)
+		if err != nil {
+			return err
+		}
+		columns[t.name] = cols
+	}
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for start := 0; start < len(ids); start += compactBatchSize {
+		batch := ids[start:min(start+compactBatchSize, len(ids))]
+		in := strings.TrimSuffix(strings.Repeat("?,", len(batch)), ",")
+		args := make([]any, len(batch))
+		for i, id := range batch {
+			args[i] = id
+		}
+		for _, t := range archivePartitionTables {
+			cols := strings.Join(columns[t.name], ", ")
+			q := `INSERT INTO arc.` + t.name + ` (` + cols + `) SELECT ` + cols + ` FROM main.` + t.name + ` WHERE ` + t.taskCol + ` IN (` + in + `)`
+			if _, err := tx.ExecContext(ctx, q, args...); err != nil {
+				return err
+			}
+		}
+		// 关联表通过外键级联删除
+		if _, err := tx.ExecContext(ctx, `DELETE FROM main.tasks WHERE id IN (`+in+`)`, args...); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// preparePartitionTable 按主库当前的表结构在归档库中建表，并补齐旧归档库缺少的列；返回主库的列名
+func preparePartitionTable(ctx context.Context, conn *sql.Conn, table string) ([]string, error) {
+	var ddl string
+	if err := conn.QueryRowContext(ctx, `SELECT sql FROM main.sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&ddl); err != nil {
+		return nil, err
+	}
+	ddl = strings.Replace(ddl, "CREATE TABLE ", "CREATE TABLE IF NOT EXISTS arc.", 1)
+	// 归档库只有随任务迁移的表，引用其他表（如 users）的外键须去掉，否则写入时找不到被引用的表
+	ddl = partitionForeignKeyPattern.ReplaceAllStringFunc(ddl, func(fk string) string {
+		ref := partitionForeignKeyPattern.FindStringSubmatch(fk)[1]
+		for _, t := range archivePartitionTables {
+			if strings.EqualFold(t.name, ref) {
+				return fk
+			}
+		}
+		return ""
+	})
+	if _, err := conn.ExecContext(ctx, ddl); err != nil {
+		return nil, err
+	}
+	mainCols, err := tableColumns(ctx, conn, "main", table)
+	if err != nil {
+		return nil, err
+	}
+	arcCols, err := tableColumns(ctx, conn, "arc", table)
+	if err != nil {
+		return nil, err
+	}
+	var names []string
+	for _, c := range mainCols {
+		names = append(names, c.name)
+		if !arcCols.has(c.name) {
+			if _, err := conn.ExecContext(ctx, `ALTER TABLE arc.`+table+` ADD COLUMN `+c.name+` `+c.typ); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return names, nil
+}
+
+// columnInfo 为 PRAGMA table_info 返回的列名与类型
+type columnInfo struct{ name, typ string }
+
+// columnList 为一张表的列信息
+type columnList []columnInfo
+
+// has 判断是否包含指定列
+func (l columnList) has(name string) bool {
+	for _, c := range l {
+		if c.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// tableColumns 读取指定库中表的列信息
+func tableColumns(ctx context.Context, conn *sql.Conn, schema, table string) (columnList, error) {
+	rows, err := conn.QueryContext(ctx, `PRAGMA `+schema+`.table_info(`+table+`)`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out columnList
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, typ string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return nil, err
+		}
+		out = append(out, columnInfo{name: name, typ: typ})
+	}
+	return out, rows.Err()
+}
+
+// runCompactArchive 为 compact-archive 子命令：将指定日期前归档的任务迁移到按年拆分的归档库
+func runCompactArchive(cfg Config, args []string) error {
+	flags := flag.NewFlagSet("compact-archive", flag.ContinueOnError)
+	beforeStr := flags.String("before", "", "迁移在该日期（YYYY-MM-DD）之前归档的任务")
+	dryRun := flags.Bool("dry-run", false, "仅统计，不迁移")
+	vacuum := flags.Bool("vacuum", false, "迁移后执行 VACUUM 回收主库空间")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+	before, err := time.ParseInLocation("2006-01-02", *beforeStr, time.Local)
+	if err != nil {
+		return fmt.Errorf("缺少或无效的 -before 参数: %q", *beforeStr)
+	}
+	app := NewApp(cfg)
+	defer app.db.Close()
+	ctx := context.Background()
+	moved, err := app.compactArchive(ctx, before, *dryRun)
+	if err != nil {
+		return err
+	}
+	years := make([]int, 0, len(moved))
+	for y := range moved {
+		years = append(years, y)
+	}
+	sort.Ints(years)
+	for _, y := range years {
+		fmt.Printf("%d 年: %d 个任务 → %s\n", y, moved[y], cfg.Data.archivePartitionPath(y))
+	}
+	if len(years) == 0 {
+		fmt.Println("没有需要迁移的归档任务")
+	}
+	if *vacuum && !*dryRun && len(years) > 0 {
+		if _, err := app.db.ExecContext(ctx, `VACUUM`); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleArchivePartitions 处理归档库的只读查询：
+// GET /api/archive/partitions 列出各年份归档库；GET /api/archive/partitions/{year}/tasks 分页搜索其中的任务
+func (a *App) handleArchivePartitions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	rest := strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/archive/partitions"), "/")
+	if rest == "" {
+		years, err := a.cfg.Data.listArchivePartitionYears()
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		items := []archivePartition{}
+		for _, y := range years {
+			p := archivePartition{Year: y, File: filepath.Base(a.cfg.Data.archivePartitionPath(y))}
+			if pa, err := a.openArchivePartition(y); err == nil {
+				_ = pa.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks`).Scan(&p.Tasks)
+				pa.db.Close()
+			}
+			items = append(items, p)
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"items": items})
+		return
+	}
+	parts := strings.Split(rest, "/")
+	year, err := strconv.Atoi(parts[0])
+	if err != nil || len(parts) != 2 || parts[1] != "tasks" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	pa, err := a.openArchivePartition(year)
+	if err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "partition not found"})
+		return
+	}
+	defer pa.db.Close()
+	page, size := int64(1), int64(20)
+	if v, err := parseInt64(r.URL.Query().Get("page")); err == nil && v > 0 {
+		page = v
+	}
+	if v, err := parseInt64(r.URL.Query().Get("page_size")); err == nil && v > 0 && v <= 200 {
+		size = v
+	}
+	cond, args, err := a.taskSearchCond(r.URL.Query().Get("q"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid query: " + err.Error()})
+		return
+	}
+	if cond != "" {
+		cond = "WHERE " + cond
+	}
+	var total int64
+	if err := pa.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks "+cond, args...).Scan(&total); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	offset := (page - 1) * size
+	out, err := pa.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks `+cond+` ORDER BY id DESC LIMIT ? OFFSET ?`, append(args, size, offset)...)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"items":     out,
+		"total":     total,
+		"page":      page,
+		"page_size": size,
+		"has_more":  offset+int64(len(out)) < total,
+	})
+}
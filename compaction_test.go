@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCompactArchiveKeepsRelations(t *testing.T) {
+	app := newTestApp(t, nil)
+	ctx := context.Background()
+	insert := func(title string, archived int, at string) int64 {
+		t.Helper()
+		res, err := app.db.Exec(`INSERT INTO tasks (title, description, status, archived, created_at, updated_at) VALUES (?, '', '已完成', ?, ?, ?)`, title, archived, at, at)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := res.LastInsertId()
+		return id
+	}
+	active := insert("active", 0, "2026-01-01T00:00:00Z")
+	old := insert("old", 1, "2020-03-01T00:00:00Z")
+	older := insert("older", 1, "2020-02-01T00:00:00Z")
+	for _, rel := range [][2]int64{{active, old}, {old, active}, {old, older}} {
+		if _, err := app.db.Exec(`INSERT INTO task_relations (task_id, related_id, origin, origin_id, created_at) VALUES (?, ?, 'description', 0, '2020-01-01T00:00:00Z')`, rel[0], rel[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := app.db.Exec(`INSERT INTO users (id, name, email, created_at) VALUES (1, 'u', 'u@example.com', '2020-01-01T00:00:00Z')`); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.db.Exec(`INSERT INTO task_watchers (task_id, user_id, created_at) VALUES (?, 1, '2020-01-01T00:00:00Z')`, old); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := app.db.Exec(`INSERT INTO user_mentions (task_id, user_id, origin, origin_id, created_at) VALUES (?, 1, 'description', 0, '2020-01-01T00:00:00Z')`, old); err != nil {
+		t.Fatal(err)
+	}
+
+	moved, err := app.compactArchive(ctx, time.Date(2021, 1, 1, 0, 0, 0, 0, time.Local), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if moved[2020] != 2 {
+		t.Fatalf("moved = %v, want 2 tasks in 2020", moved)
+	}
+
+	// 活动任务指向已迁移任务的关系留在主库
+	mentions, err := app.fetchMentions(ctx, active, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mentions) != 1 || mentions[0].ID != old || !mentions[0].Archived {
+		t.Errorf("mentions of active task = %+v, want archived task %d", mentions, old)
+	}
+	// 已迁移任务发出的关系随任务进入归档库
+	pa, err := app.openArchivePartition(2020)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer pa.db.Close()
+	var n int
+	if err := pa.db.QueryRow(`SELECT COUNT(*) FROM task_relations WHERE task_id = ?`, old).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 2 {
+		t.Errorf("archived relations from task %d = %d, want 2", old, n)
+	}
+	if err := app.db.QueryRow(`SELECT COUNT(*) FROM task_relations WHERE task_id = ?`, old).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 0 {
+		t.Errorf("relations from moved task left in main = %d, want 0", n)
+	}
+	if err := pa.db.QueryRow(`SELECT COUNT(*) FROM user_mentions WHERE task_id = ?`, old).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("archived @mentions of task %d = %d, want 1", old, n)
+	}
+	if err := pa.db.QueryRow(`SELECT COUNT(*) FROM task_watchers WHERE task_id = ?`, old).Scan(&n); err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("archived watchers of task %d = %d, want 1", old, n)
+	}
+}
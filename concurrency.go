@@ -0,0 +1,38 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// requestedVersion 读取客户端期望的任务版本：优先 If-Match 头（如 "3" 或 W/"3"），其次请求体中的 expected_version
+func requestedVersion(r *http.Request, bodyVersion *int64) (int64, bool) {
+	if h := strings.TrimSpace(r.Header.Get("If-Match")); h != "" {
+		h = strings.Trim(strings.TrimPrefix(h, "W/"), `"`)
+		if v, err := parseInt64(h); err == nil {
+			return v, true
+		}
+		return 0, false
+	}
+	if bodyVersion != nil {
+		return *bodyVersion, true
+	}
+	return 0, false
+}
+
+// versionETag 将任务版本号格式化为强 ETag
+func versionETag(v int64) string {
+	return `"` + itoa64(v) + `"`
+}
+
+// writeVersionConflict 在条件更新未命中时区分任务不存在（404）与版本冲突（409）
+func (a *App) writeVersionConflict(ctx context.Context, w http.ResponseWriter, id int64) {
+	var current int64
+	if err := a.db.QueryRowContext(ctx, `SELECT version FROM tasks WHERE id = ?`, id).Scan(&current); err != nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	}
+	w.Header().Set("ETag", versionETag(current))
+	writeJSON(w, http.StatusConflict, map[string]any{"error": "version conflict", "current_version": current})
+}
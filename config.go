@@ -0,0 +1,427 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Config 为应用的全部可配置项，先读取 YAML 配置文件，再由环境变量覆盖
+type Config struct {
+	// Locale 为邮件、Telegram 消息与站内通知的缺省语言（zh-CN 或 en），为空时为 zh-CN；接口错误消息按请求协商
+	Locale       string             `yaml:"locale"`
+	Server       ServerConfig       `yaml:"server"`
+	Data         DataConfig         `yaml:"data"`
+	Log          LogConfig          `yaml:"log"`
+	TLS          TLSConfig          `yaml:"tls"`
+	Public       PublicConfig       `yaml:"public"`
+	Limits       LimitsConfig       `yaml:"limits"`
+	Tags         TagsConfig         `yaml:"tags"`
+	Search       SearchConfig       `yaml:"search"`
+	JSON         JSONConfig         `yaml:"json"`
+	Access       AccessConfig       `yaml:"access"`
+	AutoArchive  AutoArchiveConfig  `yaml:"auto_archive"`
+	AgingSummary AgingSummaryConfig `yaml:"aging_summary"`
+	Signing      SigningConfig      `yaml:"signing"`
+	APIKeys      APIKeyConfig       `yaml:"api_keys"`
+	Audit        AuditConfig        `yaml:"audit"`
+	Login        LoginConfig        `yaml:"login"`
+	Guest        GuestConfig        `yaml:"guest"`
+	QuickActions QuickActionConfig  `yaml:"quick_actions"`
+	Onboarding   OnboardingConfig   `yaml:"onboarding"`
+	Reminders    ReminderConfig     `yaml:"reminders"`
+	Throttle     ThrottleConfig     `yaml:"throttle"`
+	SMTP         SMTPConfig         `yaml:"smtp"`
+	Telegram     TelegramConfig     `yaml:"telegram"`
+	WebPush      WebPushConfig      `yaml:"web_push"`
+	Inbox        InboxConfig        `yaml:"inbox"`
+	Paging       PagingConfig       `yaml:"paging"`
+	Dev          DevConfig          `yaml:"dev"`
+}
+
+// ServerConfig 为 HTTP 服务配置
+type ServerConfig struct {
+	Port            string   `yaml:"port"`
+	StaticDir       string   `yaml:"static_dir"`
+	ReadTimeout     Duration `yaml:"read_timeout"`
+	WriteTimeout    Duration `yaml:"write_timeout"`
+	IdleTimeout     Duration `yaml:"idle_timeout"`
+	ShutdownTimeout Duration `yaml:"shutdown_timeout"`
+}
+
+// DataConfig 为数据存储配置：Dir 为数据目录，Path 为数据库文件路径（缺省为 <dir>/app.db），
+// DSN 为完整的 SQLite 连接串，设置后优先于 Path；其余为 SQLite 调优参数与连接池上限
+type DataConfig struct {
+	Dir          string   `yaml:"dir"`
+	Path         string   `yaml:"path"`
+	DSN          string   `yaml:"dsn"`
+	JournalMode  string   `yaml:"journal_mode"`
+	Synchronous  string   `yaml:"synchronous"`
+	BusyTimeout  Duration `yaml:"busy_timeout"`
+	MaxOpenConns int      `yaml:"max_open_conns"`
+	MaxIdleConns int      `yaml:"max_idle_conns"`
+	SlowQuery    Duration `yaml:"slow_query"` // 超过该时长的语句记为慢查询，0 表示不记录
+}
+
+// dbPath 返回数据库文件路径
+func (d DataConfig) dbPath() string {
+	if d.Path != "" {
+		return d.Path
+	}
+	return filepath.Join(d.Dir, "app.db")
+}
+
+// dataSource 返回传给 sql.Open 的数据源，并以连接参数附加调优 pragma，
+// 使连接池中每个连接都生效；DSN 中已显式给出的参数保持不变
+func (d DataConfig) dataSource() string {
+	src := d.DSN
+	if src == "" {
+		src = d.dbPath()
+	}
+	base, query, _ := strings.Cut(src, "?")
+	params, err := url.ParseQuery(query)
+	if err != nil {
+		return src
+	}
+	setDefault := func(value string, keys ...string) {
+		if value == "" {
+			return
+		}
+		for _, k := range keys {
+			if params.Has(k) {
+				return
+			}
+		}
+		params.Set(keys[0], value)
+	}
+	setDefault("1", "_foreign_keys", "_fk")
+	setDefault(d.JournalMode, "_journal_mode", "_journal")
+	setDefault(d.Synchronous, "_synchronous", "_sync")
+	if d.BusyTimeout > 0 {
+		setDefault(strconv.FormatInt(d.BusyTimeout.Std().Milliseconds(), 10), "_busy_timeout", "_timeout")
+	}
+	return base + "?" + params.Encode()
+}
+
+// LogConfig 为日志配置
+type LogConfig struct {
+	Level  string `yaml:"level"`
+	Format string `yaml:"format"`
+}
+
+// TLSConfig 为 HTTPS 配置：证书文件模式或 Let's Encrypt 自动证书模式
+type TLSConfig struct {
+	CertFile        string   `yaml:"cert_file"`
+	KeyFile         string   `yaml:"key_file"`
+	AutocertDomains []string `yaml:"autocert_domains"`
+	AutocertCache   string   `yaml:"autocert_cache"`
+	AutocertEmail   string   `yaml:"autocert_email"`
+	HTTPAddr        string   `yaml:"http_addr"`
+}
+
+// PublicConfig 为公开只读 API 配置
+type PublicConfig struct {
+	Enabled      bool `yaml:"enabled"`
+	Descriptions bool `yaml:"descriptions"`
+}
+
+// Duration 为支持 "10s"、"1m" 写法的时长类型
+type Duration time.Duration
+
+// UnmarshalYAML 解析 Go 时长字符串
+func (d *Duration) UnmarshalYAML(node *yaml.Node) error {
+	v, err := time.ParseDuration(strings.TrimSpace(node.Value))
+	if err != nil {
+		return fmt.Errorf("invalid duration %q: %w", node.Value, err)
+	}
+	*d = Duration(v)
+	return nil
+}
+
+// Std 返回标准库时长
+func (d Duration) Std() time.Duration { return time.Duration(d) }
+
+// defaultConfig 返回内置默认配置
+func defaultConfig() Config {
+	return Config{
+		Server: ServerConfig{
+			Port:            "8080",
+			ReadTimeout:     Duration(10 * time.Second),
+			WriteTimeout:    Duration(10 * time.Second),
+			IdleTimeout:     Duration(60 * time.Second),
+			ShutdownTimeout: Duration(15 * time.Second),
+		},
+		Data: DataConfig{
+			Dir:          "data",
+			JournalMode:  "WAL",
+			Synchronous:  "NORMAL",
+			BusyTimeout:  Duration(5 * time.Second),
+			MaxOpenConns: 8,
+			MaxIdleConns: 8,
+			SlowQuery:    Duration(200 * time.Millisecond),
+		},
+		Log:    LogConfig{Level: "info", Format: "text"},
+		Tags:   TagsConfig{CaseFold: true},
+		Access: AccessConfig{AdminPaths: []string{"/api/admin/", "/api/keys"}},
+		AutoArchive: AutoArchiveConfig{
+			Interval: Duration(time.Hour),
+		},
+		AgingSummary: AgingSummaryConfig{
+			Interval:   Duration(time.Hour),
+			RepeatDays: 7,
+		},
+		QuickActions: QuickActionConfig{TTL: Duration(30 * 24 * time.Hour)},
+		Reminders: ReminderConfig{
+			Before:   []Duration{Duration(24 * time.Hour)},
+			Interval: Duration(time.Minute),
+		},
+		Throttle: ThrottleConfig{
+			DBLatency:     Duration(200 * time.Millisecond),
+			MinFreeDiskMB: 512,
+			MaxWait:       Duration(15 * time.Minute),
+		},
+		Audit: AuditConfig{MaxSizeMB: 100},
+		Login: LoginConfig{SessionTTL: Duration(30 * 24 * time.Hour)},
+		SMTP:  SMTPConfig{Port: 587, DigestHour: 8},
+		Telegram: TelegramConfig{
+			APIURL:        "https://api.telegram.org",
+			PollTimeout:   Duration(30 * time.Second),
+			EventInterval: Duration(5 * time.Second),
+		},
+		WebPush: WebPushConfig{TTL: Duration(24 * time.Hour)},
+		TLS: TLSConfig{
+			AutocertCache: "",
+			HTTPAddr:      ":80",
+		},
+	}
+}
+
+// LoadConfig 加载配置：默认值 → 配置文件（CONFIG_FILE，缺省为 config.yaml，不存在则跳过）→ 环境变量
+func LoadConfig() (Config, error) {
+	cfg := defaultConfig()
+	path := getEnv("CONFIG_FILE", "config.yaml")
+	raw, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := yaml.Unmarshal(raw, &cfg); err != nil {
+			return cfg, fmt.Errorf("解析配置文件 %s 失败: %w", path, err)
+		}
+	case errors.Is(err, fs.ErrNotExist) && os.Getenv("CONFIG_FILE") == "":
+		// 未显式指定配置文件时允许缺省
+	default:
+		return cfg, fmt.Errorf("读取配置文件 %s 失败: %w", path, err)
+	}
+	if err := cfg.applyEnv(); err != nil {
+		return cfg, err
+	}
+	if cfg.TLS.AutocertCache == "" {
+		cfg.TLS.AutocertCache = filepath.Join(cfg.Data.Dir, "autocert")
+	}
+	return cfg, nil
+}
+
+// applyEnv 使用环境变量覆盖配置项
+func (c *Config) applyEnv() error {
+	envString(&c.Server.Port, "PORT")
+	envString(&c.Server.StaticDir, "STATIC_DIR")
+	for key, d := range map[string]*Duration{
+		"READ_TIMEOUT":           &c.Server.ReadTimeout,
+		"WRITE_TIMEOUT":          &c.Server.WriteTimeout,
+		"IDLE_TIMEOUT":           &c.Server.IdleTimeout,
+		"SHUTDOWN_TIMEOUT":       &c.Server.ShutdownTimeout,
+		"DB_BUSY_TIMEOUT":        &c.Data.BusyTimeout,
+		"DB_SLOW_QUERY":          &c.Data.SlowQuery,
+		"AUTO_ARCHIVE_INTERVAL":  &c.AutoArchive.Interval,
+		"AGING_SUMMARY_INTERVAL": &c.AgingSummary.Interval,
+		"SIGNING_MAX_SKEW":       &c.Signing.MaxSkew,
+		"QUICK_ACTIONS_TTL":      &c.QuickActions.TTL,
+		"REMINDER_INTERVAL":      &c.Reminders.Interval,
+		"LOGIN_SESSION_TTL":      &c.Login.SessionTTL,
+		"THROTTLE_DB_LATENCY":    &c.Throttle.DBLatency,
+		"THROTTLE_MAX_WAIT":      &c.Throttle.MaxWait,
+		"TELEGRAM_POLL_TIMEOUT":  &c.Telegram.PollTimeout,
+	} {
+		if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+			parsed, err := time.ParseDuration(v)
+			if err != nil {
+				return fmt.Errorf("环境变量 %s 无效: %w", key, err)
+			}
+			*d = Duration(parsed)
+		}
+	}
+	envString(&c.Locale, "LOCALE")
+	envString(&c.Data.Dir, "DATA_DIR")
+	envString(&c.Data.Path, "DB_PATH")
+	envString(&c.Data.DSN, "DB_DSN")
+	envString(&c.Data.JournalMode, "DB_JOURNAL_MODE")
+	envString(&c.Data.Synchronous, "DB_SYNCHRONOUS")
+	if err := envInt(&c.Data.MaxOpenConns, "DB_MAX_OPEN_CONNS"); err != nil {
+		return err
+	}
+	if err := envInt(&c.Data.MaxIdleConns, "DB_MAX_IDLE_CONNS"); err != nil {
+		return err
+	}
+	envString(&c.Log.Level, "LOG_LEVEL")
+	envString(&c.Log.Format, "LOG_FORMAT")
+	envString(&c.JSON.FieldNaming, "JSON_FIELD_NAMING")
+	envString(&c.TLS.CertFile, "TLS_CERT")
+	envString(&c.TLS.KeyFile, "TLS_KEY")
+	envString(&c.TLS.AutocertCache, "TLS_AUTOCERT_CACHE")
+	envString(&c.TLS.AutocertEmail, "TLS_AUTOCERT_EMAIL")
+	envString(&c.TLS.HTTPAddr, "TLS_HTTP_ADDR")
+	if v, ok := os.LookupEnv("TLS_AUTOCERT_DOMAINS"); ok {
+		c.TLS.AutocertDomains = splitList(v)
+	}
+	for key, dst := range map[string]*int{
+		"LIMIT_TASKS_WARN":          &c.Limits.TasksWarn,
+		"LIMIT_TASKS_MAX":           &c.Limits.TasksMax,
+		"LIMIT_TAGS_PER_TASK_WARN":  &c.Limits.TagsPerTaskWarn,
+		"LIMIT_TAGS_PER_TASK_MAX":   &c.Limits.TagsPerTaskMax,
+		"AUTO_ARCHIVE_AFTER_DAYS":   &c.AutoArchive.AfterDays,
+		"AGING_SUMMARY_REPEAT_DAYS": &c.AgingSummary.RepeatDays,
+		"SMTP_PORT":                 &c.SMTP.Port,
+		"SMTP_DIGEST_HOUR":          &c.SMTP.DigestHour,
+		"AUDIT_MAX_SIZE_MB":         &c.Audit.MaxSizeMB,
+		"THROTTLE_MIN_FREE_DISK_MB": &c.Throttle.MinFreeDiskMB,
+	} {
+		if err := envInt(dst, key); err != nil {
+			return err
+		}
+	}
+	for key, dst := range map[string]*[]string{
+		"ACCESS_ALLOW":           &c.Access.Allow,
+		"ACCESS_DENY":            &c.Access.Deny,
+		"ACCESS_ADMIN_ALLOW":     &c.Access.AdminAllow,
+		"ACCESS_ADMIN_DENY":      &c.Access.AdminDeny,
+		"TRUSTED_PROXIES":        &c.Access.TrustedProxies,
+		"SIGNING_REQUIRED_PATHS": &c.Signing.RequiredPaths,
+		"INBOX_DEFAULT_TAGS":     &c.Inbox.DefaultTags,
+		"INBOX_ALLOWED_SENDERS":  &c.Inbox.AllowedSenders,
+		"LOGIN_ALLOWED_DOMAINS":  &c.Login.AllowedDomains,
+	} {
+		if v, ok := os.LookupEnv(key); ok {
+			*dst = splitList(v)
+		}
+	}
+	// SIGNING_CLIENTS 格式为 "id=secret,id2=secret2"
+	if v, ok := os.LookupEnv("SIGNING_CLIENTS"); ok {
+		c.Signing.Clients = nil
+		for _, item := range splitList(v) {
+			id, secret, found := strings.Cut(item, "=")
+			if !found {
+				return fmt.Errorf("环境变量 SIGNING_CLIENTS 无效: %q 缺少 =", id)
+			}
+			c.Signing.Clients = append(c.Signing.Clients, SigningClient{ID: strings.TrimSpace(id), Secret: secret})
+		}
+	}
+	envString(&c.SMTP.Host, "SMTP_HOST")
+	envString(&c.SMTP.Username, "SMTP_USERNAME")
+	envString(&c.SMTP.Password, "SMTP_PASSWORD")
+	envString(&c.SMTP.From, "SMTP_FROM")
+	if v, ok := os.LookupEnv("SMTP_TO"); ok {
+		c.SMTP.To = splitList(v)
+	}
+	envString(&c.Inbox.Token, "INBOX_TOKEN")
+	envString(&c.QuickActions.Secret, "QUICK_ACTIONS_SECRET")
+	envString(&c.Paging.Format, "PAGING_FORMAT")
+	envString(&c.Paging.URL, "PAGING_URL")
+	envString(&c.Paging.Key, "PAGING_KEY")
+	envString(&c.Telegram.Token, "TELEGRAM_TOKEN")
+	envString(&c.Telegram.APIURL, "TELEGRAM_API_URL")
+	if v := strings.TrimSpace(os.Getenv("TELEGRAM_CHAT_ID")); v != "" {
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("环境变量 TELEGRAM_CHAT_ID 无效: %w", err)
+		}
+		c.Telegram.ChatID = id
+	}
+	envString(&c.WebPush.PublicKey, "WEB_PUSH_VAPID_PUBLIC_KEY")
+	envString(&c.WebPush.PrivateKey, "WEB_PUSH_VAPID_PRIVATE_KEY")
+	envString(&c.WebPush.Subject, "WEB_PUSH_SUBJECT")
+	envString(&c.Reminders.WebhookURL, "REMINDER_WEBHOOK_URL")
+	if v, ok := os.LookupEnv("REMINDER_BEFORE"); ok {
+		c.Reminders.Before = nil
+		for _, item := range splitList(v) {
+			d, err := time.ParseDuration(item)
+			if err != nil {
+				return fmt.Errorf("环境变量 REMINDER_BEFORE 无效: %w", err)
+			}
+			c.Reminders.Before = append(c.Reminders.Before, Duration(d))
+		}
+	}
+	envFlag(&c.AutoArchive.DryRun, "AUTO_ARCHIVE_DRY_RUN")
+	envFlag(&c.AgingSummary.Enabled, "AGING_SUMMARY")
+	envFlag(&c.APIKeys.Required, "API_KEYS_REQUIRED")
+	envString(&c.Audit.Path, "AUDIT_PATH")
+	envString(&c.Audit.Format, "AUDIT_FORMAT")
+	envString(&c.Login.BaseURL, "LOGIN_BASE_URL")
+	envFlag(&c.Login.Required, "LOGIN_REQUIRED")
+	envString(&c.Login.GitHub.ClientID, "GITHUB_CLIENT_ID")
+	envString(&c.Login.GitHub.ClientSecret, "GITHUB_CLIENT_SECRET")
+	envString(&c.Login.Google.ClientID, "GOOGLE_CLIENT_ID")
+	envString(&c.Login.Google.ClientSecret, "GOOGLE_CLIENT_SECRET")
+	envString(&c.Login.OIDC.Issuer, "OIDC_ISSUER")
+	envString(&c.Login.OIDC.ClientID, "OIDC_CLIENT_ID")
+	envString(&c.Login.OIDC.ClientSecret, "OIDC_CLIENT_SECRET")
+	envString(&c.Login.OIDC.Name, "OIDC_NAME")
+	envFlag(&c.Guest.ReadOnly, "GUEST_READ_ONLY")
+	envFlag(&c.QuickActions.Enabled, "QUICK_ACTIONS")
+	envFlag(&c.Onboarding.Enabled, "ONBOARDING")
+	envString(&c.Onboarding.Locale, "ONBOARDING_LOCALE")
+	envFlag(&c.Throttle.Enabled, "THROTTLE")
+	envFlag(&c.Public.Enabled, "PUBLIC_API")
+	envFlag(&c.Public.Descriptions, "PUBLIC_API_DESCRIPTIONS")
+	envFlag(&c.Tags.CaseFold, "TAG_CASE_FOLD")
+	envFlag(&c.Search.Pinyin, "SEARCH_PINYIN")
+	envFlag(&c.Search.Fuzzy, "SEARCH_FUZZY")
+	envFlag(&c.JSON.Pretty, "JSON_PRETTY")
+	envFlag(&c.Dev.Enabled, "DEV_MODE")
+	return nil
+}
+
+// envString 在环境变量非空时覆盖字符串配置
+func envString(dst *string, key string) {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		*dst = v
+	}
+}
+
+// envInt 在环境变量非空时覆盖整数配置
+func envInt(dst *int, key string) error {
+	v := strings.TrimSpace(os.Getenv(key))
+	if v == "" {
+		return nil
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return fmt.Errorf("环境变量 %s 无效: %w", key, err)
+	}
+	*dst = n
+	return nil
+}
+
+// envFlag 在环境变量已设置时覆盖布尔配置
+func envFlag(dst *bool, key string) {
+	if _, ok := os.LookupEnv(key); ok {
+		*dst = envBool(key)
+	}
+}
+
+// splitList 将逗号分隔的字符串拆分为去空白的非空列表
+func splitList(s string) []string {
+	var out []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			out = append(out, item)
+		}
+	}
+	return out
+}
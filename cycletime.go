@@ -0,0 +1,248 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// durationStats 为一组时长的汇总（单位：小时）
+type durationStats struct {
+	Count    int     `json:"count"`
+	AvgHours float64 `json:"avg_hours"`
+	P50Hours float64 `json:"p50_hours"`
+	P85Hours float64 `json:"p85_hours"`
+	P95Hours float64 `json:"p95_hours"`
+}
+
+// statusDuration 为任务在某状态列停留时长的汇总
+type statusDuration struct {
+	Status string `json:"status"`
+	durationStats
+}
+
+// cycleTimeStats 为一组已完成任务的周期分析；Tag 为空表示全部任务
+type cycleTimeStats struct {
+	Tag          string           `json:"tag,omitempty"`
+	Completed    int              `json:"completed"`
+	LeadTime     durationStats    `json:"lead_time"`
+	CycleTime    durationStats    `json:"cycle_time"`
+	TimeInStatus []statusDuration `json:"time_in_status"`
+}
+
+// cycleTimeResponse 为周期时间统计的响应结构
+type cycleTimeResponse struct {
+	WindowDays int              `json:"window_days"`
+	Overall    cycleTimeStats   `json:"overall"`
+	ByTag      []cycleTimeStats `json:"by_tag"`
+}
+
+// taskFlow 为由事件历史还原出的单个已完成任务的流转情况
+type taskFlow struct {
+	createdAt   time.Time
+	startedAt   time.Time // 首次进入"进行中"的时间，未经过该列时为零值
+	completedAt time.Time // 最后一次进入"已完成"的时间
+	inStatus    map[string]time.Duration
+	tags        []string
+}
+
+// handleCycleTime 统计窗口内完成的任务的前置时间（创建→完成）、周期时间（首次进行中→完成）
+// 及在各列的停留时长，按标签分组给出均值与分位数，用于发现瓶颈列
+func (a *App) handleCycleTime(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	days := 90
+	if v := strings.TrimSpace(r.URL.Query().Get("days")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxStatsWindowDays {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "days must be between 1 and 365"})
+			return
+		}
+		days = n
+	}
+	now := time.Now()
+	if etag, err := a.tasksETag(ctx, "cycle-time|"+strconv.Itoa(days)+"|"+now.Format("2006-01-02")); err == nil && checkNotModified(w, r, etag, "no-cache") {
+		return
+	}
+	flows, err := a.loadCompletedFlows(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	since := now.AddDate(0, 0, -days)
+	var inWindow []taskFlow
+	byTag := map[string][]taskFlow{}
+	for _, f := range flows {
+		if f.completedAt.Before(since) {
+			continue
+		}
+		inWindow = append(inWindow, f)
+		for _, tag := range f.tags {
+			byTag[tag] = append(byTag[tag], f)
+		}
+	}
+	resp := cycleTimeResponse{WindowDays: days, Overall: summarizeFlows(inWindow), ByTag: []cycleTimeStats{}}
+	for tag, fs := range byTag {
+		s := summarizeFlows(fs)
+		s.Tag = tag
+		resp.ByTag = append(resp.ByTag, s)
+	}
+	sort.Slice(resp.ByTag, func(i, j int) bool {
+		if resp.ByTag[i].Completed != resp.ByTag[j].Completed {
+			return resp.ByTag[i].Completed > resp.ByTag[j].Completed
+		}
+		return resp.ByTag[i].Tag < resp.ByTag[j].Tag
+	})
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// loadCompletedFlows 读取当前处于"已完成"的任务（含归档）的事件历史并还原流转过程
+func (a *App) loadCompletedFlows(ctx context.Context) ([]taskFlow, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT e.task_id, e.kind, e.to_status, e.created_at
+		FROM task_events e JOIN tasks t ON t.id = e.task_id
+		WHERE t.status = '已完成'
+		ORDER BY e.task_id, e.id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	flows := map[int64]*taskFlow{}
+	var order []int64
+	current := map[int64]string{}
+	since := map[int64]time.Time{}
+	for rows.Next() {
+		var taskID int64
+		var kind, to, at string
+		if err := rows.Scan(&taskID, &kind, &to, &at); err != nil {
+			return nil, err
+		}
+		ts, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			continue
+		}
+		f := flows[taskID]
+		if f == nil {
+			f = &taskFlow{inStatus: map[string]time.Duration{}}
+			flows[taskID] = f
+			order = append(order, taskID)
+		}
+		switch kind {
+		case taskEventCreated:
+			f.createdAt = ts
+		case taskEventStatus:
+			if cur, ok := current[taskID]; ok && !ts.Before(since[taskID]) {
+				f.inStatus[cur] += ts.Sub(since[taskID])
+			}
+		case taskEventArchived, taskEventRestored:
+			continue // 归档与恢复不改变所在列
+		default:
+			continue
+		}
+		current[taskID] = to
+		since[taskID] = ts
+		if to == "进行中" && f.startedAt.IsZero() {
+			f.startedAt = ts
+		}
+		if to == "已完成" {
+			f.completedAt = ts
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	tagRows, err := a.db.QueryContext(ctx, `
+		SELECT tt.task_id, tt.tag FROM task_tags tt JOIN tasks t ON t.id = tt.task_id
+		WHERE t.status = '已完成'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer tagRows.Close()
+	for tagRows.Next() {
+		var taskID int64
+		var tag string
+		if err := tagRows.Scan(&taskID, &tag); err != nil {
+			return nil, err
+		}
+		if f := flows[taskID]; f != nil {
+			f.tags = append(f.tags, tag)
+		}
+	}
+	if err := tagRows.Err(); err != nil {
+		return nil, err
+	}
+	out := make([]taskFlow, 0, len(order))
+	for _, id := range order {
+		if f := flows[id]; !f.completedAt.IsZero() && !f.createdAt.IsZero() {
+			out = append(out, *f)
+		}
+	}
+	return out, nil
+}
+
+// summarizeFlows 汇总一组任务的前置时间、周期时间与各列停留时长
+func summarizeFlows(flows []taskFlow) cycleTimeStats {
+	s := cycleTimeStats{Completed: len(flows)}
+	var lead, cycle []time.Duration
+	perStatus := map[string][]time.Duration{}
+	for _, f := range flows {
+		lead = append(lead, f.completedAt.Sub(f.createdAt))
+		if !f.startedAt.IsZero() && !f.startedAt.After(f.completedAt) {
+			cycle = append(cycle, f.completedAt.Sub(f.startedAt))
+		}
+		for status, d := range f.inStatus {
+			perStatus[status] = append(perStatus[status], d)
+		}
+	}
+	s.LeadTime = summarizeDurations(lead)
+	s.CycleTime = summarizeDurations(cycle)
+	for _, status := range taskStatuses {
+		if status == "已完成" {
+			continue
+		}
+		s.TimeInStatus = append(s.TimeInStatus, statusDuration{Status: status, durationStats: summarizeDurations(perStatus[status])})
+	}
+	return s
+}
+
+// summarizeDurations 计算均值与最近秩分位数
+func summarizeDurations(ds []time.Duration) durationStats {
+	if len(ds) == 0 {
+		return durationStats{}
+	}
+	sorted := append([]time.Duration(nil), ds...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	pct := func(p float64) float64 {
+		idx := int(math.Ceil(p*float64(len(sorted)))) - 1
+		if idx < 0 {
+			idx = 0
+		}
+		return roundHours(sorted[idx])
+	}
+	return durationStats{
+		Count:    len(sorted),
+		AvgHours: roundHours(total / time.Duration(len(sorted))),
+		P50Hours: pct(0.50),
+		P85Hours: pct(0.85),
+		P95Hours: pct(0.95),
+	}
+}
+
+// roundHours 将时长转换为保留一位小数的小时数
+func roundHours(d time.Duration) float64 {
+	return math.Round(d.Hours()*10) / 10
+}
@@ -0,0 +1,10 @@
+//go:build !linux && !darwin
+
+package main
+
+import "errors"
+
+// diskFree 在不支持的平台上不检查磁盘剩余空间
+func diskFree(dir string) (uint64, error) {
+	return 0, errors.ErrUnsupported
+}
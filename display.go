@@ -0,0 +1,123 @@
+package main
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 大屏密钥：display 范围的 API 密钥只能 GET 看板快照（任务列表、列与标签）与统计接口，不能读取单个任务详情、
+// 评论、管理接口或做任何修改，即使泄露也只暴露墙上本来就能看到的内容。缺省有效期为一年，建议同时绑定大屏所在的地址。
+// 电视浏览器无法设置请求头，因此打开 /display?token=<密钥> 后密钥保存在 HttpOnly Cookie 中，随后跳转到看板页面
+
+// displayKeyTokenPrefix 为 display 密钥原文的前缀，与普通密钥区分
+const displayKeyTokenPrefix = "tbd_"
+
+// displayCookie 为保存大屏密钥的 Cookie
+const displayCookie = "tb_display"
+
+// displayKeyTTL 为未指定有效期时 display 密钥的有效期
+const displayKeyTTL = 365 * 24 * time.Hour
+
+// displayReadPaths 为 display 密钥可读取的接口，以 / 结尾的项按前缀匹配
+var displayReadPaths = []string{"/api/health", "/api/tasks", "/api/columns", "/api/tags", "/api/stats", "/api/stats/", "/api/roadmap"}
+
+// isDisplay 判断是否为大屏密钥
+func (k APIKey) isDisplay() bool {
+	return slices.Contains(k.Scopes, scopeDisplay)
+}
+
+// boundTo 判断请求来源是否在密钥绑定的网段内，未绑定时总是放行
+func (k APIKey) boundTo(r *http.Request, p *accessPolicy) bool {
+	if len(k.AllowedIPs) == 0 {
+		return true
+	}
+	prefixes, err := parsePrefixes(k.AllowedIPs)
+	if err != nil {
+		return false
+	}
+	ip, ok := p.clientIP(r)
+	return ok && prefixesContain(prefixes, ip)
+}
+
+// normalizeAllowedIPs 校验并去重密钥绑定的地址或网段
+func normalizeAllowedIPs(items []string) ([]string, error) {
+	prefixes, err := parsePrefixes(items)
+	if err != nil {
+		return nil, err
+	}
+	var out []string
+	for _, p := range prefixes {
+		if s := p.String(); !slices.Contains(out, s) {
+			out = append(out, s)
+		}
+	}
+	return out, nil
+}
+
+// defaultKeyExpiry 为未指定有效期的 display 密钥补上缺省有效期
+func defaultKeyExpiry(scopes []string, expires *time.Time, now time.Time) *time.Time {
+	if expires != nil || !slices.Contains(scopes, scopeDisplay) {
+		return expires
+	}
+	t := now.Add(displayKeyTTL).Truncate(time.Second)
+	return &t
+}
+
+// hasDisplayCookie 判断请求是否携带大屏密钥 Cookie 且为大屏可访问的路径，密钥本身由 withAPIKeys 校验；
+// 其他路径上的 Cookie 不予理会，同一浏览器里打开过 /display 的登录用户访问其余接口时不受影响
+func hasDisplayCookie(r *http.Request) bool {
+	c, err := r.Cookie(displayCookie)
+	return err == nil && c.Value != "" && displayAllows(r)
+}
+
+// displayAllows 判断 display 密钥能否发起该请求：看板页面与静态资源，以及 displayReadPaths 中接口的读取
+func displayAllows(r *http.Request) bool {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		return false
+	}
+	path := r.URL.Path
+	if !strings.HasPrefix(path, "/api/") {
+		return true
+	}
+	for _, p := range displayReadPaths {
+		if path == p || (strings.HasSuffix(p, "/") && strings.HasPrefix(path, p)) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleDisplay 处理 GET /display?token=：校验大屏密钥后写入 Cookie 并跳转到看板页面；
+// 令牌无效、不是 display 密钥或来源地址不符时返回 403
+func (a *App) handleDisplay(w http.ResponseWriter, r *http.Request, p *accessPolicy) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	now := time.Now()
+	token := strings.TrimSpace(r.URL.Query().Get("token"))
+	k, err := a.lookupAPIKey(r.Context(), token, now)
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && (!k.isDisplay() || !k.boundTo(r, p))) {
+		http.Error(w, "大屏密钥无效、已过期或不允许从此地址使用", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		a.reqLogger(r).Error("校验大屏密钥失败", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	addLogAttrs(r, "api_key", k.Prefix)
+	c := &http.Cookie{Name: displayCookie, Value: token, Path: "/", HttpOnly: true, Secure: r.TLS != nil, SameSite: http.SameSiteLaxMode}
+	if k.ExpiresAt != nil {
+		c.Expires = *k.ExpiresAt
+	}
+	http.SetCookie(w, c)
+	a.reqLogger(r).Info("大屏已登记", "name", k.Name)
+	http.Redirect(w, r, "/", http.StatusFound)
+}
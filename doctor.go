@@ -0,0 +1,320 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+// doctor 命令：执行启动自检，并做一次导出—导入往返校验：把当前数据库导出为 JSON，导入到临时目录中的新库，
+// 再逐表比较两边的行数与内容校验和，确认导出文件中的数据能够原样恢复。
+// 导入会重新分配任务 ID，因此校验和按任务在原库中的顺序计算，不含 ID 与版本号；
+// 可比较的只有导出格式包含的数据（任务、标签、验收标准、外部链接、外部引用与列策略），
+// 库中其余的表（事件、历史版本、评论、用户、团队、密钥等）逐一列为「未覆盖」并给出行数，有数据时作为警告提示：
+// 这些数据无法通过导出文件恢复，需另行备份数据库文件。已迁移到按年归档库中的任务同样不在导出范围内
+
+// roundTripTable 为往返校验中一张表的比较方式：Query 的第一列为任务 ID，其余列为比较内容
+type roundTripTable struct {
+	Name  string
+	Query string
+}
+
+// roundTripTables 为参与往返校验的数据
+var roundTripTables = []roundTripTable{
+	{"tasks", `SELECT id, title, description, status, archived, created_at, updated_at, start_at, due_at, status_changed_at FROM tasks ORDER BY id`},
+	{"task_tags", `SELECT task_id, tag FROM task_tags ORDER BY task_id, tag`},
+	{"task_acceptance_criteria", `SELECT task_id, position, text FROM task_acceptance_criteria ORDER BY task_id, position`},
+	{"task_links", `SELECT task_id, position, title, url FROM task_links ORDER BY task_id, position`},
+	{"task_refs", `SELECT task_id, kind, url, title, created_at FROM task_refs ORDER BY task_id, kind, url`},
+}
+
+// tableDigest 为一张表的行数与内容校验和
+type tableDigest struct {
+	Table string `json:"table"`
+	Rows  int    `json:"rows"`
+	Hash  string `json:"sha256"`
+}
+
+// 往返校验中一张表的结果
+const (
+	roundTripOK         = "ok"
+	roundTripMismatch   = "mismatch"
+	roundTripNotCovered = "not_covered"
+)
+
+// roundTripResult 为一张表的往返校验结果；未覆盖的表只有行数，没有校验和
+type roundTripResult struct {
+	Table    string      `json:"table"`
+	Status   string      `json:"status"`
+	Source   tableDigest `json:"source"`
+	Restored tableDigest `json:"restored"`
+	Match    bool        `json:"match"`
+}
+
+// boardDigests 计算看板数据的逐表校验和；任务 ID 替换为其在 ID 顺序中的序号，使重新导入后的库可以比较
+func (a *App) boardDigests(ctx context.Context) ([]tableDigest, error) {
+	ordinals := map[string]string{}
+	ids, err := a.queryIDs(ctx, `SELECT id FROM tasks ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	for i, id := range ids {
+		ordinals[strconv.FormatInt(id, 10)] = strconv.Itoa(i)
+	}
+	var out []tableDigest
+	for _, t := range roundTripTables {
+		d, err := a.digestQuery(ctx, t.Name, t.Query, ordinals)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Name, err)
+		}
+		out = append(out, d)
+	}
+	// 列策略以读取后的结果比较：未配置的列在原库中没有记录，导入时会按缺省值写入
+	cols, err := a.fetchColumns(ctx)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	for _, c := range cols {
+		line, _ := json.Marshal([]any{c.Status, c.Policy, c.RequireConfirmation, c.AgingAfterDays, c.StaleAfterDays})
+		h.Write(append(line, '\n'))
+	}
+	out = append(out, tableDigest{Table: "column_policies", Rows: len(cols), Hash: hex.EncodeToString(h.Sum(nil))})
+	return out, nil
+}
+
+// digestQuery 执行查询并计算行数与校验和，第一列按 ordinals 替换为任务序号；
+// 任务 ID 与序号同序，查询按任务 ID 排序即可保证两边的行顺序一致
+func (a *App) digestQuery(ctx context.Context, table, query string, ordinals map[string]string) (tableDigest, error) {
+	rows, err := a.db.QueryContext(ctx, query)
+	if err != nil {
+		return tableDigest{}, err
+	}
+	defer rows.Close()
+	cols, err := rows.Columns()
+	if err != nil {
+		return tableDigest{}, err
+	}
+	h := sha256.New()
+	n := 0
+	for rows.Next() {
+		vals := make([]sql.NullString, len(cols))
+		ptrs := make([]any, len(cols))
+		for i := range vals {
+			ptrs[i] = &vals[i]
+		}
+		if err := rows.Scan(ptrs...); err != nil {
+			return tableDigest{}, err
+		}
+		fields := make([]any, len(vals))
+		for i, v := range vals {
+			if v.Valid {
+				fields[i] = v.String
+			}
+		}
+		if ord, ok := ordinals[vals[0].String]; ok {
+			fields[0] = ord
+		}
+		line, _ := json.Marshal(fields)
+		h.Write(append(line, '\n'))
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return tableDigest{}, err
+	}
+	return tableDigest{Table: table, Rows: n, Hash: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+// verifyRoundTrip 导出当前库并导入到 dir 下的新库，返回逐表比较结果
+func (a *App) verifyRoundTrip(ctx context.Context, dir string) ([]roundTripResult, error) {
+	data, err := a.exportBoard(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("导出失败: %w", err)
+	}
+	// 经过一次完整的 JSON 编解码，与实际备份文件的恢复路径一致
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	var decoded boardExport
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return nil, fmt.Errorf("解析导出数据失败: %w", err)
+	}
+	cfg := a.cfg
+	cfg.Data.DSN, cfg.Data.Path, cfg.Data.Dir = "", filepath.Join(dir, "roundtrip.db"), dir
+	restored := NewApp(cfg)
+	defer restored.db.Close()
+	if _, err := restored.importBoard(ctx, decoded); err != nil {
+		return nil, fmt.Errorf("导入临时库失败: %w", err)
+	}
+	src, err := a.boardDigests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dst, err := restored.boardDigests(ctx)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]roundTripResult, len(src))
+	covered := map[string]bool{}
+	for i := range src {
+		out[i] = roundTripResult{Table: src[i].Table, Status: roundTripOK, Source: src[i], Restored: dst[i], Match: src[i] == dst[i]}
+		if !out[i].Match {
+			out[i].Status = roundTripMismatch
+		}
+		covered[src[i].Table] = true
+	}
+	// 导出格式不包含的表逐一列出，避免只比较部分数据就报告通过
+	tables, err := a.userTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	restoredTables, err := restored.userTables(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range tables {
+		if covered[table] {
+			continue
+		}
+		r := roundTripResult{Table: table, Status: roundTripNotCovered, Source: tableDigest{Table: table}, Restored: tableDigest{Table: table}}
+		if err := a.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM "`+table+`"`).Scan(&r.Source.Rows); err != nil {
+			return nil, fmt.Errorf("%s: %w", table, err)
+		}
+		// 旧版本遗留的表在新建的库中不存在
+		if slices.Contains(restoredTables, table) {
+			if err := restored.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM "`+table+`"`).Scan(&r.Restored.Rows); err != nil {
+				return nil, fmt.Errorf("%s: %w", table, err)
+			}
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// userTables 返回库中除 SQLite 内部表以外的全部表，按名称排列
+func (a *App) userTables(ctx context.Context) ([]string, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		out = append(out, name)
+	}
+	return out, rows.Err()
+}
+
+// runDoctor 执行自检与导出—导入往返校验，任一项失败时返回错误
+func runDoctor(cfg Config, args []string) error {
+	flags := flag.NewFlagSet("doctor", flag.ContinueOnError)
+	roundTrip := flags.Bool("roundtrip", true, "执行导出—导入往返校验")
+	keep := flags.Bool("keep", false, "保留往返校验使用的临时库，便于排查差异")
+	asJSON := flags.Bool("json", false, "以 JSON 输出结果")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+	report := struct {
+		Checks     []checkResult     `json:"checks"`
+		RoundTrip  []roundTripResult `json:"roundtrip,omitempty"`
+		NotCovered []string          `json:"not_covered,omitempty"` // 有数据但导出不包含的表
+		TempDir    string            `json:"temp_dir,omitempty"`
+	}{}
+	report.Checks = []checkResult{checkDataDir(cfg.Data)}
+	if report.Checks[0].Status == checkFailed {
+		return fmt.Errorf("%s: %s", report.Checks[0].Name, report.Checks[0].Message)
+	}
+	// 只输出警告以上的日志，避免淹没报告
+	cfg.Log.Level = "warn"
+	app := NewApp(cfg)
+	defer app.db.Close()
+	ctx := context.Background()
+	report.Checks = append(report.Checks, app.selfCheck(ctx)...)
+	var failed []string
+	for _, c := range report.Checks {
+		if c.Status == checkFailed {
+			failed = append(failed, c.Name)
+		}
+	}
+	if *roundTrip {
+		dir, err := os.MkdirTemp("", "task-board-doctor-")
+		if err != nil {
+			return err
+		}
+		if *keep {
+			report.TempDir = dir
+		} else {
+			defer os.RemoveAll(dir)
+		}
+		if report.RoundTrip, err = app.verifyRoundTrip(ctx, dir); err != nil {
+			return err
+		}
+		for _, r := range report.RoundTrip {
+			switch {
+			case r.Status == roundTripMismatch:
+				failed = append(failed, "roundtrip:"+r.Table)
+			case r.Status == roundTripNotCovered && r.Source.Rows > 0:
+				report.NotCovered = append(report.NotCovered, r.Table)
+			}
+		}
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(report); err != nil {
+			return err
+		}
+	} else {
+		tw := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(tw, "检查\t状态\t说明")
+		for _, c := range report.Checks {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Name, c.Status, c.Message)
+		}
+		if *roundTrip {
+			fmt.Fprintln(tw, "\n往返校验\t原库行数\t导入行数\t原库校验和\t导入校验和\t结果")
+			for _, r := range report.RoundTrip {
+				result := "ok"
+				switch r.Status {
+				case roundTripMismatch:
+					result = "不一致"
+				case roundTripNotCovered:
+					result = "未覆盖"
+					if r.Source.Rows > 0 {
+						result = "未覆盖（警告）"
+					}
+				}
+				fmt.Fprintf(tw, "%s\t%d\t%d\t%.12s\t%.12s\t%s\n", r.Table, r.Source.Rows, r.Restored.Rows, r.Source.Hash, r.Restored.Hash, result)
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		if len(report.NotCovered) > 0 {
+			fmt.Printf("\n警告: 导出文件不包含以下 %d 张表中的数据，仅凭导出无法恢复，请同时备份数据库文件: %s\n",
+				len(report.NotCovered), strings.Join(report.NotCovered, ", "))
+		}
+		if report.TempDir != "" {
+			fmt.Println("临时库已保留:", filepath.Join(report.TempDir, "roundtrip.db"))
+		}
+	}
+	if len(failed) > 0 {
+		return errors.New("doctor 发现问题: " + strings.Join(failed, ", "))
+	}
+	return nil
+}
@@ -0,0 +1,182 @@
+package main
+
+import (
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 重复任务检测：将未完成任务的标题与描述切分为字符二元组，按 Jaccard 相似度两两比较，
+// 超过阈值的任务对通过并查集聚成簇。每个簇以最早创建的任务为建议保留目标，其余为合并候选。
+
+// defaultDuplicateThreshold 为默认的相似度阈值
+const defaultDuplicateThreshold = 0.6
+
+// duplicateTitleWeight 为两边都有描述时标题相似度所占的权重
+const duplicateTitleWeight = 0.7
+
+// duplicateTask 为重复簇中的任务；Similarity 为与建议保留目标的相似度，目标本身为 1
+type duplicateTask struct {
+	ID         int64     `json:"id"`
+	Title      string    `json:"title"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	Similarity float64   `json:"similarity"`
+}
+
+// duplicateCluster 为一组疑似重复的任务；TargetID 为建议保留的任务，Tasks 中首项即为目标
+type duplicateCluster struct {
+	TargetID   int64           `json:"target_id"`
+	Similarity float64         `json:"similarity"` // 簇内最高的两两相似度
+	Tasks      []duplicateTask `json:"tasks"`
+}
+
+// duplicateResponse 为重复检测的响应结构，按相似度从高到低排列
+type duplicateResponse struct {
+	Threshold float64            `json:"threshold"`
+	Scanned   int                `json:"scanned"`
+	Clusters  []duplicateCluster `json:"clusters"`
+}
+
+// textBigrams 将文本规范化（小写、去除标点）后切分为字符二元组；单字符的词保留自身
+func textBigrams(s string) map[string]struct{} {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	out := map[string]struct{}{}
+	for _, w := range words {
+		rs := []rune(w)
+		if len(rs) == 1 {
+			out[w] = struct{}{}
+			continue
+		}
+		for i := 0; i+1 < len(rs); i++ {
+			out[string(rs[i:i+2])] = struct{}{}
+		}
+	}
+	return out
+}
+
+// jaccard 返回两个集合的 Jaccard 相似度；任一为空时返回 0
+func jaccard(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	inter := 0
+	for g := range a {
+		if _, ok := b[g]; ok {
+			inter++
+		}
+	}
+	return float64(inter) / float64(len(a)+len(b)-inter)
+}
+
+// duplicateSimilarity 计算两个任务的相似度：两边都有描述时按权重组合标题与描述，否则只比较标题
+func duplicateSimilarity(ta, tb, da, db map[string]struct{}) float64 {
+	title := jaccard(ta, tb)
+	if len(da) == 0 || len(db) == 0 {
+		return title
+	}
+	return duplicateTitleWeight*title + (1-duplicateTitleWeight)*jaccard(da, db)
+}
+
+// roundScore 将相似度保留三位小数
+func roundScore(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
+
+// handleDuplicates 处理 GET /api/maintenance/duplicates?threshold=，返回未完成任务中疑似重复的簇
+func (a *App) handleDuplicates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	threshold := defaultDuplicateThreshold
+	if v := r.URL.Query().Get("threshold"); v != "" {
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil || f <= 0 || f > 1 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "threshold must be in (0, 1]"})
+			return
+		}
+		threshold = f
+	}
+	tasks, err := a.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks WHERE archived = 0 AND status <> '已完成' ORDER BY created_at, id`)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	titles := make([]map[string]struct{}, len(tasks))
+	descs := make([]map[string]struct{}, len(tasks))
+	for i, t := range tasks {
+		titles[i] = textBigrams(t.Title)
+		descs[i] = textBigrams(t.Description)
+	}
+
+	parent := make([]int, len(tasks))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	best := map[int]float64{} // 簇根 -> 簇内最高相似度，合并后在新根上汇总
+	for i := range tasks {
+		for j := i + 1; j < len(tasks); j++ {
+			s := duplicateSimilarity(titles[i], titles[j], descs[i], descs[j])
+			if s < threshold {
+				continue
+			}
+			ri, rj := find(i), find(j)
+			top := max(s, best[ri], best[rj])
+			if ri != rj {
+				// 任务按创建时间排序，保留较小下标作为根，使根始终是簇内最早的任务
+				if rj < ri {
+					ri, rj = rj, ri
+				}
+				parent[rj] = ri
+				delete(best, rj)
+			}
+			best[ri] = top
+		}
+	}
+
+	members := map[int][]int{}
+	for i := range tasks {
+		if root := find(i); root != i || best[i] > 0 {
+			members[root] = append(members[root], i)
+		}
+	}
+	resp := duplicateResponse{Threshold: threshold, Scanned: len(tasks), Clusters: []duplicateCluster{}}
+	for root, idx := range members {
+		target := tasks[root]
+		c := duplicateCluster{TargetID: target.ID, Similarity: roundScore(best[root])}
+		for _, i := range idx {
+			t := tasks[i]
+			sim := 1.0
+			if i != root {
+				sim = roundScore(duplicateSimilarity(titles[root], titles[i], descs[root], descs[i]))
+			}
+			c.Tasks = append(c.Tasks, duplicateTask{ID: t.ID, Title: t.Title, Status: t.Status, CreatedAt: t.CreatedAt, Similarity: sim})
+		}
+		resp.Clusters = append(resp.Clusters, c)
+	}
+	sort.Slice(resp.Clusters, func(i, j int) bool {
+		if resp.Clusters[i].Similarity != resp.Clusters[j].Similarity {
+			return resp.Clusters[i].Similarity > resp.Clusters[j].Similarity
+		}
+		return resp.Clusters[i].TargetID < resp.Clusters[j].TargetID
+	})
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// tasksETag 根据任务表的版本信息与查询参数计算弱 ETag。
+// 每次更新或归档都会使任务的 version 加一，因此 SUM(version) 随任意一次修改变化，
+// 即使同一秒内修改了多个任务或修改的不是最新的任务；创建与删除由 COUNT 与 MAX(id) 反映。
+// 列策略随看板一并返回，其更新时间也计入版本。
+func (a *App) tasksETag(ctx context.Context, rawQuery string) (string, error) {
+	var count, maxID, versions int64
+	var maxUpdated, policyUpdated string
+	err := a.db.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(MAX(updated_at), ''), COALESCE(MAX(id), 0), COALESCE(SUM(version), 0),
+			(SELECT COALESCE(MAX(updated_at), '') FROM column_policies)
+		FROM tasks
+	`).Scan(&count, &maxUpdated, &maxID, &versions, &policyUpdated)
+	if err != nil {
+		return "", err
+	}
+	return weakETag(itoa64(count), maxUpdated, itoa64(maxID), itoa64(versions), policyUpdated, rawQuery), nil
+}
+
+// weakETag 将若干版本片段哈希为弱 ETag
+func weakETag(parts ...string) string {
+	sum := sha1.Sum([]byte(strings.Join(parts, "|")))
+	return `W/"` + hex.EncodeToString(sum[:10]) + `"`
+}
+
+// etagMatches 判断 If-None-Match 头是否命中当前 ETag（按弱比较）
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	want := strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == want {
+			return true
+		}
+	}
+	return false
+}
+
+// checkNotModified 写入 ETag 与 Cache-Control 头；若客户端缓存仍有效则返回 304 并报告 true
+func checkNotModified(w http.ResponseWriter, r *http.Request, etag, cacheControl string) bool {
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", cacheControl)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// itoa64 将 int64 转换为十进制字符串
+func itoa64(n int64) string {
+	return strconv.FormatInt(n, 10)
+}
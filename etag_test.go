@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTasksETagChangesOnEditsInSameSecond(t *testing.T) {
+	app := newTestApp(t, nil)
+	ctx := context.Background()
+	const at = "2026-01-01T00:00:00Z"
+	for _, title := range []string{"first", "second"} {
+		if _, err := app.db.Exec(`INSERT INTO tasks (title, description, status, created_at, updated_at) VALUES (?, '', '规划中', ?, ?)`, title, at, at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	before, err := app.tasksETag(ctx, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 同一秒内先后修改两个任务，其中第一个不是最新的任务：COUNT、MAX(updated_at) 与 MAX(id) 均不变
+	seen := map[string]bool{before: true}
+	for _, id := range []int64{1, 2} {
+		if _, err := app.db.Exec(`UPDATE tasks SET title = title || '!', updated_at = ?, version = version + 1 WHERE id = ?`, at, id); err != nil {
+			t.Fatal(err)
+		}
+		etag, err := app.tasksETag(ctx, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if seen[etag] {
+			t.Fatalf("ETag %s unchanged after editing task %d in the same second", etag, id)
+		}
+		seen[etag] = true
+	}
+}
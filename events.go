@@ -0,0 +1,52 @@
+package main
+
+// 任务事件历史（创建、状态变更、归档、恢复）由数据库触发器记录，
+// 覆盖所有写入路径（HTTP、代码推送集成、导入），供周期时间等分析使用。
+
+// 事件类型
+const (
+	taskEventCreated  = "created"
+	taskEventStatus   = "status"
+	taskEventArchived = "archived"
+	taskEventRestored = "restored"
+)
+
+// taskEventTriggers 为记录任务事件的触发器
+const taskEventTriggers = `
+	CREATE TRIGGER IF NOT EXISTS trg_task_events_created AFTER INSERT ON tasks
+	BEGIN
+		INSERT INTO task_events (task_id, kind, from_status, to_status, created_at)
+		VALUES (NEW.id, 'created', '', NEW.status, NEW.created_at);
+	END;
+	CREATE TRIGGER IF NOT EXISTS trg_task_events_status AFTER UPDATE OF status ON tasks
+	WHEN OLD.status <> NEW.status
+	BEGIN
+		INSERT INTO task_events (task_id, kind, from_status, to_status, created_at)
+		VALUES (NEW.id, 'status', OLD.status, NEW.status, NEW.updated_at);
+	END;
+	CREATE TRIGGER IF NOT EXISTS trg_task_events_archived AFTER UPDATE OF archived ON tasks
+	WHEN OLD.archived <> NEW.archived
+	BEGIN
+		INSERT INTO task_events (task_id, kind, from_status, to_status, created_at)
+		VALUES (NEW.id, CASE WHEN NEW.archived <> 0 THEN 'archived' ELSE 'restored' END, NEW.status, NEW.status, NEW.updated_at);
+	END;
+`
+
+// migrateTaskEvents 创建事件触发器，并为尚无事件的旧任务补写近似历史：
+// 创建时进入规划中，若当前不在规划中则在 status_changed_at 时移入当前状态
+func (a *App) migrateTaskEvents() error {
+	if _, err := a.db.Exec(taskEventTriggers); err != nil {
+		return err
+	}
+	_, err := a.db.Exec(`
+		INSERT INTO task_events (task_id, kind, from_status, to_status, created_at)
+		SELECT id, 'status', '规划中', status, COALESCE(status_changed_at, updated_at)
+		FROM tasks
+		WHERE status <> '规划中' AND id NOT IN (SELECT task_id FROM task_events);
+		INSERT INTO task_events (task_id, kind, from_status, to_status, created_at)
+		SELECT id, 'created', '', '规划中', created_at
+		FROM tasks
+		WHERE id NOT IN (SELECT task_id FROM task_events WHERE kind = 'created');
+	`)
+	return err
+}
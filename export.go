@@ -0,0 +1,187 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// exportColumn 为静态站点中一个状态列及其任务
+type exportColumn struct {
+	Column
+	Tasks []Task
+}
+
+// exportPage 为静态站点模板的数据
+type exportPage struct {
+	Title       string
+	GeneratedAt time.Time
+	Columns     []exportColumn
+	Archived    []Task
+}
+
+// runExportSite 将看板与归档历史渲染为独立的静态 HTML 目录，用于项目结束后的长期存档
+func runExportSite(cfg Config, args []string) error {
+	flags := flag.NewFlagSet("export-site", flag.ContinueOnError)
+	out := flags.String("out", "site", "输出目录")
+	title := flags.String("title", "任务看板", "站点标题")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+	app := NewApp(cfg)
+	defer app.db.Close()
+
+	page, err := app.buildExportPage(context.Background(), *title, time.Now())
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		return err
+	}
+	for name, tmpl := range map[string]*template.Template{
+		"index.html":   exportBoardTmpl,
+		"archive.html": exportArchiveTmpl,
+	} {
+		if err := writeTemplateFile(filepath.Join(*out, name), tmpl, page); err != nil {
+			return err
+		}
+	}
+	fmt.Printf("已导出 %d 个进行中任务、%d 个归档任务到 %s\n", countExportTasks(page.Columns), len(page.Archived), *out)
+	return nil
+}
+
+// buildExportPage 读取全部状态列、在板任务与归档任务
+func (a *App) buildExportPage(ctx context.Context, title string, now time.Time) (exportPage, error) {
+	page := exportPage{Title: title, GeneratedAt: now}
+	cols, err := a.fetchColumns(ctx)
+	if err != nil {
+		return page, err
+	}
+	active, err := a.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks WHERE archived = 0 ORDER BY id DESC`)
+	if err != nil {
+		return page, err
+	}
+	annotateTaskAges(active, cols, now)
+	for _, c := range cols {
+		ec := exportColumn{Column: c}
+		for _, t := range active {
+			if t.Status == c.Status {
+				ec.Tasks = append(ec.Tasks, t)
+			}
+		}
+		page.Columns = append(page.Columns, ec)
+	}
+	page.Archived, err = a.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks WHERE archived = 1 ORDER BY id DESC`)
+	if err != nil {
+		return page, err
+	}
+	return page, nil
+}
+
+// countExportTasks 统计各列任务总数
+func countExportTasks(cols []exportColumn) int {
+	n := 0
+	for _, c := range cols {
+		n += len(c.Tasks)
+	}
+	return n
+}
+
+// writeTemplateFile 渲染模板并写入文件
+func writeTemplateFile(path string, tmpl *template.Template, data any) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := tmpl.Execute(f, data); err != nil {
+		f.Close()
+		return fmt.Errorf("渲染 %s 失败: %w", path, err)
+	}
+	return f.Close()
+}
+
+var exportFuncs = template.FuncMap{
+	"date": func(t time.Time) string { return t.Local().Format("2006-01-02") },
+	"optDate": func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Local().Format("2006-01-02")
+	},
+}
+
+const exportLayout = `{{define "head"}}<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body{font-family:system-ui,-apple-system,"PingFang SC","Microsoft YaHei",sans-serif;margin:0;background:#f5f6f8;color:#222}
+header{padding:16px 24px;background:#fff;border-bottom:1px solid #e3e5e8}
+header h1{margin:0 0 4px;font-size:20px}
+header nav a{margin-right:12px;color:#2563eb;text-decoration:none}
+.meta{color:#888;font-size:12px}
+.board{display:flex;gap:16px;padding:16px 24px;align-items:flex-start;overflow-x:auto}
+.column{flex:1;min-width:240px;background:#eceef1;border-radius:8px;padding:12px}
+.column h2{font-size:15px;margin:0 0 8px}
+.policy{font-size:12px;color:#666;margin:0 0 8px;white-space:pre-wrap}
+.card{background:#fff;border-radius:6px;padding:10px;margin-bottom:8px;box-shadow:0 1px 2px rgba(0,0,0,.06)}
+.card h3{font-size:14px;margin:0 0 6px}
+.card p{margin:0 0 6px;font-size:13px;white-space:pre-wrap}
+.card ul{margin:0 0 6px;padding-left:18px;font-size:13px}
+.tag{display:inline-block;background:#e0e7ff;color:#3730a3;border-radius:4px;padding:0 6px;margin:0 4px 4px 0;font-size:12px}
+.stale{border-left:3px solid #dc2626}.aging{border-left:3px solid #f59e0b}
+table{border-collapse:collapse;margin:16px 24px;background:#fff;width:calc(100% - 48px)}
+th,td{border:1px solid #e3e5e8;padding:6px 8px;font-size:13px;text-align:left;vertical-align:top}
+</style>
+</head>
+<body>
+<header>
+<h1>{{.Title}}</h1>
+<nav><a href="index.html">看板</a><a href="archive.html">归档</a></nav>
+<div class="meta">导出时间 {{.GeneratedAt.Format "2006-01-02 15:04"}}</div>
+</header>
+{{end}}
+{{define "tags"}}{{range .}}<span class="tag">{{.}}</span>{{end}}{{end}}
+{{define "foot"}}</body>
+</html>
+{{end}}`
+
+var exportBoardTmpl = template.Must(template.New("board").Funcs(exportFuncs).Parse(exportLayout + `{{template "head" .}}
+<main class="board">
+{{range .Columns}}<section class="column">
+<h2>{{.Status}} ({{len .Tasks}})</h2>
+{{if .Policy}}<p class="policy">{{.Policy}}</p>{{end}}
+{{range .Tasks}}<article class="card{{if .Age}} {{.Age.Bucket}}{{end}}" id="task-{{.ID}}">
+<h3>TB-{{.ID}} {{.Title}}</h3>
+{{if .Description}}<p>{{.Description}}</p>{{end}}
+{{if .AcceptanceCriteria}}<ul>{{range .AcceptanceCriteria}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{if .ExternalLinks}}<ul>{{range .ExternalLinks}}<li><a href="{{.URL}}">{{if .Title}}{{.Title}}{{else}}{{.URL}}{{end}}</a></li>{{end}}</ul>{{end}}
+<div>{{template "tags" .Tags}}</div>
+<div class="meta">{{with optDate .StartAt}}开始 {{.}} {{end}}{{with optDate .DueAt}}截止 {{.}} {{end}}{{if .Age}}本列 {{.Age.DaysInStatus}} 天{{end}}</div>
+</article>
+{{end}}</section>
+{{end}}</main>
+{{template "foot"}}`))
+
+var exportArchiveTmpl = template.Must(template.New("archive").Funcs(exportFuncs).Parse(exportLayout + `{{template "head" .}}
+<table>
+<thead><tr><th>编号</th><th>标题</th><th>状态</th><th>标签</th><th>创建</th><th>归档</th></tr></thead>
+<tbody>
+{{range .Archived}}<tr id="task-{{.ID}}">
+<td>TB-{{.ID}}</td>
+<td><strong>{{.Title}}</strong>{{if .Description}}<br>{{.Description}}{{end}}</td>
+<td>{{.Status}}</td>
+<td>{{template "tags" .Tags}}</td>
+<td>{{date .CreatedAt}}</td>
+<td>{{date .UpdatedAt}}</td>
+</tr>
+{{else}}<tr><td colspan="6">暂无归档任务</td></tr>
+{{end}}</tbody>
+</table>
+{{template "foot"}}`))
@@ -0,0 +1,234 @@
+package main
+
+import (
+	"context"
+	"math/rand/v2"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 完成日期预测：以窗口内每天离开各列（对整体而言为进入「已完成」）的任务数作为历史吞吐样本，
+// 蒙特卡洛模拟中每个模拟日随机抽取一天的吞吐量，累计到剩余任务数所需的天数即为一次试验结果；
+// 多次试验的 50/85/95 分位给出「有多大把握在哪天前完成」。随机数种子固定，同样的数据得到同样的结果。
+// 没有吞吐记录的日子同样计入样本，因此节假日与停滞期会如实拉长预测
+
+// 模拟参数：试验次数与单次试验的最长天数（超过时视为无法预测）
+const (
+	forecastTrials  = 10000
+	forecastMaxDays = 3650
+)
+
+// forecastPercents 为返回的分位
+var forecastPercents = []int{50, 85, 95}
+
+// forecastPercentile 为一个分位的预测：有 Percent% 的把握在 Date（Days 天后）前完成
+type forecastPercentile struct {
+	Percent int    `json:"percent"`
+	Days    int    `json:"days"`
+	Date    string `json:"date"`
+}
+
+// forecastResult 为一组剩余任务的预测；Status 为空表示整个看板。
+// 窗口内没有吞吐记录或预测超过十年时 Percentiles 为空并附带说明
+type forecastResult struct {
+	Status           string               `json:"status,omitempty"`
+	Remaining        int                  `json:"remaining"`
+	ThroughputPerDay float64              `json:"throughput_per_day"`
+	Percentiles      []forecastPercentile `json:"percentiles"`
+	Message          string               `json:"message,omitempty"`
+}
+
+// forecastResponse 为完成日期预测的响应结构
+type forecastResponse struct {
+	WindowDays int              `json:"window_days"`
+	Trials     int              `json:"trials"`
+	Tag        string           `json:"tag,omitempty"`
+	Overall    forecastResult   `json:"overall"`
+	Columns    []forecastResult `json:"columns"`
+}
+
+// dailyThroughput 统计窗口内每天离开各列的任务数；键为状态列，整体吞吐（进入「已完成」）的键为空串
+func (a *App) dailyThroughput(ctx context.Context, since time.Time, days int, tag string) (map[string][]int, error) {
+	query := `SELECT e.from_status, e.to_status, e.created_at FROM task_events e WHERE e.kind = ? AND e.created_at >= ?`
+	args := []any{taskEventStatus, since.Format(time.RFC3339)}
+	if tag != "" {
+		query += ` AND EXISTS (SELECT 1 FROM task_tags tt WHERE tt.task_id = e.task_id AND tt.tag = ?)`
+		args = append(args, tag)
+	}
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	counts := map[string][]int{"": make([]int, days)}
+	for _, s := range taskStatuses {
+		counts[s] = make([]int, days)
+	}
+	for rows.Next() {
+		var from, to, at string
+		if err := rows.Scan(&from, &to, &at); err != nil {
+			return nil, err
+		}
+		ts, err := time.Parse(time.RFC3339, at)
+		if err != nil || from == to {
+			continue
+		}
+		day := int(ts.In(since.Location()).Sub(since).Hours() / 24)
+		if day < 0 || day >= days {
+			continue
+		}
+		if c, ok := counts[from]; ok {
+			c[day]++
+		}
+		if to == "已完成" {
+			counts[""][day]++
+		}
+	}
+	return counts, rows.Err()
+}
+
+// simulateCompletion 对 remaining 个任务按每日吞吐样本做蒙特卡洛模拟，返回各分位的天数；
+// 样本全为零或某分位超过 forecastMaxDays 时返回 false
+func simulateCompletion(samples []int, remaining int, rng *rand.Rand) ([]int, bool) {
+	total := 0
+	for _, n := range samples {
+		total += n
+	}
+	if remaining == 0 {
+		return make([]int, len(forecastPercents)), true
+	}
+	if total == 0 {
+		return nil, false
+	}
+	results := make([]int, forecastTrials)
+	for i := range results {
+		done, day := 0, 0
+		for done < remaining && day < forecastMaxDays {
+			done += samples[rng.IntN(len(samples))]
+			day++
+		}
+		if done < remaining {
+			day = forecastMaxDays + 1
+		}
+		results[i] = day
+	}
+	sort.Ints(results)
+	out := make([]int, len(forecastPercents))
+	for i, p := range forecastPercents {
+		out[i] = results[percentileIndex(len(results), float64(p)/100)]
+		if out[i] > forecastMaxDays {
+			return nil, false
+		}
+	}
+	return out, true
+}
+
+// buildForecast 根据吞吐样本生成一组剩余任务的预测
+func buildForecast(status string, samples []int, remaining int, today time.Time, rng *rand.Rand) forecastResult {
+	f := forecastResult{Status: status, Remaining: remaining, Percentiles: []forecastPercentile{}}
+	total := 0
+	for _, n := range samples {
+		total += n
+	}
+	f.ThroughputPerDay = roundTo(float64(total)/float64(len(samples)), 2)
+	days, ok := simulateCompletion(samples, remaining, rng)
+	if !ok {
+		if total == 0 {
+			f.Message = "窗口内没有吞吐记录，无法预测"
+		} else {
+			f.Message = "按历史吞吐需要十年以上，无法预测"
+		}
+		return f
+	}
+	for i, p := range forecastPercents {
+		f.Percentiles = append(f.Percentiles, forecastPercentile{Percent: p, Days: days[i], Date: today.AddDate(0, 0, days[i]).Format("2006-01-02")})
+	}
+	return f
+}
+
+// handleForecast 处理 GET /api/stats/forecast：按窗口内的历史吞吐预测整个看板与各列剩余任务的完成日期。
+// items 可指定整体剩余任务数（如「再做 20 个要多久」），tag 只统计带该标签的任务
+func (a *App) handleForecast(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	q := r.URL.Query()
+	days := 90
+	if v := strings.TrimSpace(q.Get("days")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxStatsWindowDays {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "days must be between 1 and 365"})
+			return
+		}
+		days = n
+	}
+	items := -1
+	if v := strings.TrimSpace(q.Get("items")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 || n > 100000 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "items must be between 0 and 100000"})
+			return
+		}
+		items = n
+	}
+	tag := strings.TrimSpace(q.Get("tag"))
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	key := "forecast|" + strconv.Itoa(days) + "|" + strconv.Itoa(items) + "|" + tag + "|" + today.Format("2006-01-02")
+	if etag, err := a.tasksETag(ctx, key); err == nil && checkNotModified(w, r, etag, "no-cache") {
+		return
+	}
+	// 窗口为今天之前的 days 个完整自然日，今天尚未结束，不计入样本
+	since := today.AddDate(0, 0, -days)
+	samples, err := a.dailyThroughput(ctx, since, days, tag)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	query := `SELECT status, COUNT(*) FROM tasks t WHERE archived = 0`
+	var args []any
+	if tag != "" {
+		query += ` AND EXISTS (SELECT 1 FROM task_tags tt WHERE tt.task_id = t.id AND tt.tag = ?)`
+		args = append(args, tag)
+	}
+	rows, err := a.db.QueryContext(ctx, query+` GROUP BY status`, args...)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	active := map[string]int{}
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			rows.Close()
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		active[status] = n
+	}
+	rows.Close()
+	if items < 0 {
+		items = 0
+		for status, n := range active {
+			if status != "已完成" {
+				items += n
+			}
+		}
+	}
+	rng := rand.New(rand.NewPCG(1, 2))
+	resp := forecastResponse{WindowDays: days, Trials: forecastTrials, Tag: tag, Columns: []forecastResult{}}
+	resp.Overall = buildForecast("", samples[""], items, today, rng)
+	for _, s := range taskStatuses {
+		if s == "已完成" {
+			continue
+		}
+		resp.Columns = append(resp.Columns, buildForecast(s, samples[s], active[s], today, rng))
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// gitPushPayload 兼容 GitHub、GitLab 与通用格式的推送事件，三者的 commits 结构一致
+type gitPushPayload struct {
+	Ref        string `json:"ref"`
+	Repository struct {
+		HTMLURL string `json:"html_url"` // GitHub
+		WebURL  string `json:"web_url"`  // GitHub/GitLab 旧格式
+	} `json:"repository"`
+	Project struct {
+		WebURL string `json:"web_url"` // GitLab
+	} `json:"project"`
+	Commits []gitCommit `json:"commits"`
+}
+
+// gitCommit 为推送中的单个提交
+type gitCommit struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+	URL     string `json:"url"`
+}
+
+// gitPushLink 描述一次提交与任务的关联结果
+type gitPushLink struct {
+	TaskID int64  `json:"task_id"`
+	Commit string `json:"commit"`
+}
+
+// fixesPattern 匹配 "fixes #42"、"closes TB-42" 等关闭关键字
+var fixesPattern = regexp.MustCompile(`(?i)\b(?:fix|fixes|fixed|close|closes|closed|resolve|resolves|resolved)\s+(?:TB-|#)(\d+)\b`)
+
+// repoBaseURL 返回推送所属仓库的网页地址，用于为缺少 url 的提交拼接链接
+func (p gitPushPayload) repoBaseURL() string {
+	for _, u := range []string{p.Repository.HTMLURL, p.Project.WebURL, p.Repository.WebURL} {
+		if u != "" {
+			return strings.TrimSuffix(u, "/")
+		}
+	}
+	return ""
+}
+
+// handleGitPush 接收代码推送事件：扫描提交信息中的任务编号并挂载 commit 引用；
+// 查询参数 move_fixed=1 时，将 "fixes #42" 引用的任务移到“已完成”；该列需要审批时改为提交审批
+func (a *App) handleGitPush(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var body gitPushPayload
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	moveParam := r.URL.Query().Get("move_fixed")
+	moveFixed := moveParam == "1" || strings.ToLower(moveParam) == "true"
+	base := body.repoBaseURL()
+	linked := []gitPushLink{}
+	completed := []int64{}
+	approvals := []int64{}
+	done := map[int64]bool{}
+	var needApproval bool
+	if moveFixed {
+		var err error
+		if needApproval, _, err = a.columnApproval(ctx, "已完成"); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	for _, c := range body.Commits {
+		commitURL := strings.TrimSpace(c.URL)
+		if commitURL == "" && base != "" && c.ID != "" {
+			commitURL = base + "/commit/" + c.ID
+		}
+		if validateRef("commit", commitURL, "") != "" {
+			continue
+		}
+		title := strings.TrimSpace(strings.SplitN(c.Message, "\n", 2)[0])
+		if len([]rune(title)) > maxStructuredTextLen {
+			title = string([]rune(title)[:maxStructuredTextLen])
+		}
+		for _, id := range extractTaskIDs(c.Message) {
+			ok, err := a.taskExists(ctx, id)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			if !ok {
+				continue
+			}
+			if _, _, err := a.attachTaskRef(ctx, id, "commit", commitURL, title); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			linked = append(linked, gitPushLink{TaskID: id, Commit: c.ID})
+		}
+		if !moveFixed {
+			continue
+		}
+		for _, m := range fixesPattern.FindAllStringSubmatch(c.Message, -1) {
+			id, err := parseInt64(m[1])
+			if err != nil || done[id] {
+				continue
+			}
+			if needApproval {
+				var from string
+				err := a.db.QueryRowContext(ctx, `SELECT status FROM tasks WHERE id = ? AND archived = 0 AND status <> ?`, id, "已完成").Scan(&from)
+				if err == sql.ErrNoRows {
+					continue
+				}
+				if err != nil {
+					writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+					return
+				}
+				approvalID, err := a.createApproval(ctx, id, 0, from, "已完成", "git")
+				if err != nil && err != errApprovalPending {
+					writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+					return
+				}
+				done[id] = true
+				approvals = append(approvals, approvalID)
+				continue
+			}
+			now := time.Now().Format(time.RFC3339)
+			res, err := a.db.ExecContext(ctx, `
+				UPDATE tasks SET status = ?, updated_at = ?, version = version + 1, status_changed_at = ?
+				WHERE id = ? AND archived = 0 AND status <> ?
+			`, "已完成", now, now, id, "已完成")
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				done[id] = true
+				completed = append(completed, id)
+				a.onTaskCompleted(ctx, id)
+			}
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"linked": linked, "completed": completed, "approvals": approvals})
+}
@@ -0,0 +1,340 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// 全局搜索：一次请求同时搜索任务（标题、描述）、标签（名称、说明）与评论，按类型分组返回，供前端命令面板使用。
+// 任务沿用任务列表 q 的查询语法与拼音、容错匹配，含归档任务（排在后面）；标签与评论只按其中的普通词匹配，
+// 多个词同时出现才算命中，前缀 - 的词排除。评论分组包括任务评论与审批记录的意见
+
+// 全局搜索的结果类型
+const (
+	searchTypeTask    = "task"
+	searchTypeTag     = "tag"
+	searchTypeComment = "comment"
+)
+
+// 全局搜索每组返回条数的默认值与上限
+const (
+	defaultGlobalSearchLimit = 5
+	maxGlobalSearchLimit     = 50
+)
+
+// snippetRunes 为摘要片段的最大长度（按字符计），snippetLead 为命中词前保留的字符数
+const (
+	snippetRunes = 80
+	snippetLead  = 20
+)
+
+// searchHit 为一条搜索结果；TaskID 对任务与评论有效，Tag 对标签有效，评论按来源填写 CommentID 或 ApprovalID。
+// Snippet 为命中位置附近的文本片段，Field 为片段所在的字段
+type searchHit struct {
+	Type       string `json:"type"`
+	TaskID     int64  `json:"task_id,omitempty"`
+	Tag        string `json:"tag,omitempty"`
+	CommentID  int64  `json:"comment_id,omitempty"`
+	ApprovalID int64  `json:"approval_id,omitempty"`
+	Title      string `json:"title"`
+	Status     string `json:"status,omitempty"`
+	Archived   bool   `json:"archived,omitempty"`
+	Count      int64  `json:"count,omitempty"`
+	Field      string `json:"field,omitempty"`
+	Snippet    string `json:"snippet,omitempty"`
+}
+
+// searchGroup 为一类结果；Total 为该类命中的总数，Items 最多 limit 条
+type searchGroup struct {
+	Type  string      `json:"type"`
+	Total int64       `json:"total"`
+	Items []searchHit `json:"items"`
+}
+
+// globalSearchResponse 为全局搜索的响应结构，分组固定按任务、标签、评论的顺序
+type globalSearchResponse struct {
+	Query  string        `json:"query"`
+	Groups []searchGroup `json:"groups"`
+}
+
+// searchWords 为查询中的普通词，include 须全部出现，exclude 均不能出现
+type searchWords struct {
+	include []string
+	exclude []string
+}
+
+// plainWords 取出查询中的普通词与短语
+func (sq searchQuery) plainWords() searchWords {
+	var w searchWords
+	for _, t := range sq.terms {
+		if t.field != "" {
+			continue
+		}
+		if t.negate {
+			w.exclude = append(w.exclude, t.value)
+		} else {
+			w.include = append(w.include, t.value)
+		}
+	}
+	return w
+}
+
+// match 判断文本组是否命中：每个 include 词出现在任一文本中，且所有文本都不含 exclude 词（忽略大小写与重音）
+func (w searchWords) match(texts ...string) bool {
+	keys := make([]string, len(texts))
+	for i, t := range texts {
+		keys[i] = tagSearchKey(t)
+	}
+	contains := func(word string) bool {
+		word = tagSearchKey(word)
+		for _, k := range keys {
+			if strings.Contains(k, word) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, word := range w.include {
+		if !contains(word) {
+			return false
+		}
+	}
+	for _, word := range w.exclude {
+		if contains(word) {
+			return false
+		}
+	}
+	return true
+}
+
+// likeCond 返回 col 满足各词的 SQL 条件（以 " AND " 开头），词中的通配符按字面匹配
+func (w searchWords) likeCond(col string) (string, []any) {
+	var cond string
+	var args []any
+	for _, word := range w.include {
+		cond += " AND " + col + ` LIKE ? ESCAPE '\'`
+		args = append(args, "%"+likeEscaper.Replace(word)+"%")
+	}
+	for _, word := range w.exclude {
+		cond += " AND " + col + ` NOT LIKE ? ESCAPE '\'`
+		args = append(args, "%"+likeEscaper.Replace(word)+"%")
+	}
+	return cond, args
+}
+
+// searchSnippet 截取 text 中首个命中词附近的片段，空白合并为单个空格，截断处以 … 标示；没有命中时返回空串
+func searchSnippet(text string, words []string) string {
+	runes := []rune(strings.Join(strings.Fields(text), " "))
+	at := -1
+	for _, word := range words {
+		if i := indexFoldRunes(runes, []rune(word)); i >= 0 && (at < 0 || i < at) {
+			at = i
+		}
+	}
+	if at < 0 {
+		return ""
+	}
+	start := max(at-snippetLead, 0)
+	end := min(start+snippetRunes, len(runes))
+	if end-start < snippetRunes {
+		start = max(end-snippetRunes, 0)
+	}
+	s := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(runes) {
+		s += "…"
+	}
+	return s
+}
+
+// indexFoldRunes 返回 sub 在 s 中首次出现的位置（忽略大小写），不存在时返回 -1
+func indexFoldRunes(s, sub []rune) int {
+	if len(sub) == 0 {
+		return -1
+	}
+	for i := 0; i+len(sub) <= len(s); i++ {
+		j := 0
+		for j < len(sub) && unicode.ToLower(s[i+j]) == unicode.ToLower(sub[j]) {
+			j++
+		}
+		if j == len(sub) {
+			return i
+		}
+	}
+	return -1
+}
+
+// handleGlobalSearch 处理 GET /api/search?q=&limit=
+func (a *App) handleGlobalSearch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	if q == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "q required"})
+		return
+	}
+	limit := int64(defaultGlobalSearchLimit)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := parseInt64(v)
+		if err != nil || n < 1 || n > maxGlobalSearchLimit {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = n
+	}
+	sq, err := parseSearchQuery(q, a.cfg.Tags)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid query: " + err.Error()})
+		return
+	}
+	ctx := r.Context()
+	words := sq.plainWords()
+	tasks, err := a.searchTasks(ctx, sq, words, limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	tags, err := a.searchTags(ctx, words, limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	comments, err := a.searchComments(ctx, words, limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, globalSearchResponse{Query: q, Groups: []searchGroup{tasks, tags, comments}})
+}
+
+// searchTasks 按任务列表的查询语法搜索任务，未归档的在前，各自按最近更新排序
+func (a *App) searchTasks(ctx context.Context, sq searchQuery, words searchWords, limit int64) (searchGroup, error) {
+	g := searchGroup{Type: searchTypeTask, Items: []searchHit{}}
+	cond, args := sq.where(a.cfg.Search)
+	if cond == "" {
+		return g, nil
+	}
+	if err := a.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks WHERE `+cond, args...).Scan(&g.Total); err != nil {
+		return g, err
+	}
+	rows, err := a.db.QueryContext(ctx, `SELECT id, title, COALESCE(description, ''), status, archived FROM tasks WHERE `+cond+`
+		ORDER BY archived, updated_at DESC, id DESC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return g, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		h := searchHit{Type: searchTypeTask}
+		var desc string
+		var archived int
+		if err := rows.Scan(&h.TaskID, &h.Title, &desc, &h.Status, &archived); err != nil {
+			return g, err
+		}
+		h.Archived = archived == 1
+		// 片段优先取标题以外的描述，便于看出命中的上下文
+		if h.Snippet = searchSnippet(desc, words.include); h.Snippet != "" {
+			h.Field = "description"
+		} else if searchSnippet(h.Title, words.include) != "" {
+			h.Field = "title"
+		}
+		g.Items = append(g.Items, h)
+	}
+	return g, rows.Err()
+}
+
+// searchTags 按名称与说明搜索标签（含只有元数据、尚未使用的标签），按使用次数从多到少排序
+func (a *App) searchTags(ctx context.Context, words searchWords, limit int64) (searchGroup, error) {
+	g := searchGroup{Type: searchTypeTag, Items: []searchHit{}}
+	if len(words.include) == 0 {
+		return g, nil
+	}
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT n.name, COALESCE(m.description, ''), (SELECT COUNT(DISTINCT task_id) FROM task_tags WHERE tag = n.name)
+		FROM (SELECT tag AS name FROM task_tags UNION SELECT name FROM tags) n
+		LEFT JOIN tags m ON m.name = n.name`)
+	if err != nil {
+		return g, err
+	}
+	defer rows.Close()
+	var hits []searchHit
+	for rows.Next() {
+		h := searchHit{Type: searchTypeTag}
+		var desc string
+		if err := rows.Scan(&h.Tag, &desc, &h.Count); err != nil {
+			return g, err
+		}
+		if !words.match(h.Tag, desc) {
+			continue
+		}
+		h.Title = h.Tag
+		if words.match(h.Tag) {
+			h.Field = "name"
+		} else {
+			h.Field, h.Snippet = "description", searchSnippet(desc, words.include)
+		}
+		hits = append(hits, h)
+	}
+	if err := rows.Err(); err != nil {
+		return g, err
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Count != hits[j].Count {
+			return hits[i].Count > hits[j].Count
+		}
+		return hits[i].Tag < hits[j].Tag
+	})
+	g.Total = int64(len(hits))
+	if int64(len(hits)) > limit {
+		hits = hits[:limit]
+	}
+	g.Items = append(g.Items, hits...)
+	return g, nil
+}
+
+// searchComments 搜索任务评论与审批意见，从新到旧排序
+func (a *App) searchComments(ctx context.Context, words searchWords, limit int64) (searchGroup, error) {
+	g := searchGroup{Type: searchTypeComment, Items: []searchHit{}}
+	if len(words.include) == 0 {
+		return g, nil
+	}
+	cond, args := words.likeCond("c.body")
+	from := ` FROM (
+		SELECT 'comment' AS src, id, task_id, body, created_at FROM task_comments
+		UNION ALL
+		SELECT 'approval', id, task_id, comment, COALESCE(decided_at, created_at) FROM task_approvals WHERE comment != ''
+	) c JOIN tasks t ON t.id = c.task_id WHERE 1 = 1` + cond
+	if err := a.db.QueryRowContext(ctx, `SELECT COUNT(*)`+from, args...).Scan(&g.Total); err != nil {
+		return g, err
+	}
+	rows, err := a.db.QueryContext(ctx, `SELECT c.src, c.id, c.task_id, t.title, t.status, t.archived, c.body`+from+`
+		ORDER BY julianday(c.created_at) DESC, c.id DESC LIMIT ?`, append(args, limit)...)
+	if err != nil {
+		return g, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		h := searchHit{Type: searchTypeComment, Field: "comment"}
+		var src, comment string
+		var id int64
+		var archived int
+		if err := rows.Scan(&src, &id, &h.TaskID, &h.Title, &h.Status, &archived, &comment); err != nil {
+			return g, err
+		}
+		if src == "approval" {
+			h.ApprovalID = id
+		} else {
+			h.CommentID = id
+		}
+		h.Archived = archived == 1
+		h.Snippet = searchSnippet(comment, words.include)
+		g.Items = append(g.Items, h)
+	}
+	return g, rows.Err()
+}
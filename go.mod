@@ -2,4 +2,11 @@ module task-board
 
 go 1.22
 
-require github.com/mattn/go-sqlite3 v1.14.22
+require (
+	github.com/mattn/go-sqlite3 v1.14.22
+	golang.org/x/crypto v0.31.0
+	golang.org/x/text v0.21.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang.org/x/net v0.21.0 // indirect
@@ -0,0 +1,888 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// 本文件实现一个精简的 GraphQL 查询端点，仅支持 query 操作：
+// 字段别名、参数、变量与嵌套选择集，不支持片段与 mutation。
+//
+// 可查询的类型：
+//
+//	Query          { tasks(archived, q, status, tag, page, pageSize): TaskConnection, task(id): Task, tags(q): [Tag] }
+//	TaskConnection { items: [Task], total, page, pageSize, hasMore }
+//	Task           { id, title, description, status, archived, createdAt, updatedAt, version, startAt, dueAt, tags: [Tag], acceptanceCriteria: [String], externalLinks: [Link] }
+//	Link           { title, url }
+//	Tag            { name, taskCount, tasks(archived, first, after): [Task] }
+//
+// Tag.tasks 按 id 倒序分页：first 为条数（缺省 20，最多 200），after 为上一页最后一个任务的 id。
+// 为防止 Task.tags → Tag.tasks 反复嵌套造成的查询放大，选择集最多嵌套 gqlMaxDepth 层，
+// 一次查询最多解析 gqlMaxFields 个字段，超出的部分返回 null 并附带错误
+
+// GraphQL 查询的限制：嵌套层数、单次查询解析的字段数与 POST 请求体大小
+const (
+	gqlMaxDepth     = 10
+	gqlMaxFields    = 10000
+	gqlMaxBodyBytes = 1 << 20
+)
+
+// gqlRequest 为 GraphQL 请求体
+type gqlRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+// gqlError 为 GraphQL 错误项
+type gqlError struct {
+	Message string `json:"message"`
+}
+
+// gqlField 表示选择集中的一个字段
+type gqlField struct {
+	Alias     string
+	Name      string
+	Args      map[string]any
+	Selection []gqlField
+}
+
+// gqlVar 表示对查询变量的引用，执行时再解析为实际值
+type gqlVar string
+
+// gqlObject 为保持字段顺序的 JSON 对象
+type gqlObject struct {
+	keys []string
+	vals map[string]any
+}
+
+// set 写入字段，重复字段保留首次出现的位置
+func (o *gqlObject) set(k string, v any) {
+	if o.vals == nil {
+		o.vals = map[string]any{}
+	}
+	if _, ok := o.vals[k]; !ok {
+		o.keys = append(o.keys, k)
+	}
+	o.vals[k] = v
+}
+
+// MarshalJSON 按字段出现顺序编码
+func (o *gqlObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, k := range o.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(o.vals[k])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// handleGraphQL 处理 GraphQL 查询，支持 GET（query 参数）与 POST（JSON 请求体）
+func (a *App) handleGraphQL(w http.ResponseWriter, r *http.Request) {
+	var req gqlRequest
+	switch r.Method {
+	case http.MethodGet:
+		req.Query = r.URL.Query().Get("query")
+		req.OperationName = r.URL.Query().Get("operationName")
+		if v := r.URL.Query().Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]any{"errors": []gqlError{{Message: "invalid variables"}}})
+				return
+			}
+		}
+	case http.MethodPost:
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, gqlMaxBodyBytes)).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]any{"errors": []gqlError{{Message: "invalid json"}}})
+			return
+		}
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	fields, defaults, err := parseGraphQL(req.Query, req.OperationName)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]any{"errors": []gqlError{{Message: err.Error()}}})
+		return
+	}
+	vars := map[string]any{}
+	for k, v := range defaults {
+		vars[k] = v
+	}
+	for k, v := range req.Variables {
+		vars[k] = v
+	}
+	ex := &gqlExecutor{app: a, ctx: r.Context(), vars: vars}
+	data := ex.resolveQuery(fields)
+	resp := map[string]any{"data": data}
+	if len(ex.errors) > 0 {
+		resp["errors"] = ex.errors
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// gqlExecutor 负责按选择集解析数据并收集字段错误；fields 为已解析的字段数
+type gqlExecutor struct {
+	app    *App
+	ctx    context.Context
+	vars   map[string]any
+	errors []gqlError
+	fields int
+}
+
+// fail 记录一条字段错误
+func (ex *gqlExecutor) fail(format string, args ...any) {
+	ex.errors = append(ex.errors, gqlError{Message: fmt.Sprintf(format, args...)})
+}
+
+// charge 计入即将解析的 n 个字段，超出 gqlMaxFields 时记录一次错误并返回 false
+func (ex *gqlExecutor) charge(n int) bool {
+	if ex.fields > gqlMaxFields {
+		return false
+	}
+	ex.fields += n
+	if ex.fields > gqlMaxFields {
+		ex.fail("query exceeds the limit of %d fields", gqlMaxFields)
+		return false
+	}
+	return true
+}
+
+// resolveQuery 解析根类型 Query 的字段
+func (ex *gqlExecutor) resolveQuery(fields []gqlField) *gqlObject {
+	out := &gqlObject{}
+	if !ex.charge(len(fields)) {
+		return out
+	}
+	for _, f := range fields {
+		key := f.key()
+		switch f.Name {
+		case "__typename":
+			out.set(key, "Query")
+		case "tasks":
+			out.set(key, ex.resolveTaskConnection(f))
+		case "task":
+			id, ok := ex.argInt(f, "id")
+			if !ok {
+				ex.fail("task: argument id is required")
+				out.set(key, nil)
+				continue
+			}
+			t, err := ex.app.fetchTaskDetail(ex.ctx, int64(id))
+			if err != nil {
+				out.set(key, nil)
+				continue
+			}
+			out.set(key, ex.resolveTask(t, f.Selection))
+		case "tags":
+			q, _ := ex.argString(f, "q")
+			names, err := ex.app.queryTagNames(ex.ctx, q)
+			if err != nil {
+				ex.fail("tags: %v", err)
+				out.set(key, nil)
+				continue
+			}
+			list := make([]any, 0, len(names))
+			for _, n := range names {
+				list = append(list, ex.resolveTag(n, f.Selection))
+			}
+			out.set(key, list)
+		default:
+			ex.fail("Cannot query field %q on type Query", f.Name)
+		}
+	}
+	return out
+}
+
+// resolveTaskConnection 按过滤与分页参数查询任务
+func (ex *gqlExecutor) resolveTaskConnection(f gqlField) any {
+	if !ex.charge(len(f.Selection)) {
+		return nil
+	}
+	archived, _ := ex.argBool(f, "archived")
+	cond := "WHERE archived = ?"
+	args := []any{boolToInt(archived)}
+	if q, ok := ex.argString(f, "q"); ok {
+		qc, qargs, err := ex.app.taskSearchCond(q)
+		if err != nil {
+			ex.fail("tasks: invalid query: %v", err)
+			return nil
+		}
+		if qc != "" {
+			cond += " AND " + qc
+			args = append(args, qargs...)
+		}
+	}
+	if s, ok := ex.argString(f, "status"); ok && s != "" {
+		cond += " AND status = ?"
+		args = append(args, s)
+	}
+	if tag, ok := ex.argString(f, "tag"); ok && tag != "" {
+		cond += " AND id IN (SELECT task_id FROM task_tags WHERE tag = ?)"
+		args = append(args, ex.app.cfg.Tags.normalize(tag))
+	}
+	page, size := 1, 20
+	if v, ok := ex.argInt(f, "page"); ok && v > 0 {
+		page = v
+	}
+	if v, ok := ex.argInt(f, "pageSize"); ok && v > 0 && v <= 200 {
+		size = v
+	}
+	offset := (page - 1) * size
+	var total int64
+	if err := ex.app.db.QueryRowContext(ex.ctx, "SELECT COUNT(*) FROM tasks "+cond, args...).Scan(&total); err != nil {
+		ex.fail("tasks: %v", err)
+		return nil
+	}
+	var items []Task
+	// 仅在选择了 items 时才查询任务明细
+	if f.selects("items") {
+		var err error
+		items, err = ex.app.queryTasks(ex.ctx, `
+			SELECT `+taskColumns+`
+			FROM tasks
+			`+cond+`
+			ORDER BY id DESC
+			LIMIT ? OFFSET ?
+		`, append(args, size, offset)...)
+		if err != nil {
+			ex.fail("tasks: %v", err)
+			return nil
+		}
+	}
+	out := &gqlObject{}
+	for _, sf := range f.Selection {
+		key := sf.key()
+		switch sf.Name {
+		case "__typename":
+			out.set(key, "TaskConnection")
+		case "items":
+			list := make([]any, 0, len(items))
+			for _, t := range items {
+				list = append(list, ex.resolveTask(t, sf.Selection))
+			}
+			out.set(key, list)
+		case "total":
+			out.set(key, total)
+		case "page":
+			out.set(key, page)
+		case "pageSize":
+			out.set(key, size)
+		case "hasMore":
+			out.set(key, int64(offset+size) < total)
+		default:
+			ex.fail("Cannot query field %q on type TaskConnection", sf.Name)
+		}
+	}
+	return out
+}
+
+// resolveTask 解析 Task 类型的字段
+func (ex *gqlExecutor) resolveTask(t Task, sel []gqlField) any {
+	if !ex.charge(len(sel)) {
+		return nil
+	}
+	out := &gqlObject{}
+	for _, f := range sel {
+		key := f.key()
+		switch f.Name {
+		case "__typename":
+			out.set(key, "Task")
+		case "id":
+			out.set(key, t.ID)
+		case "title":
+			out.set(key, t.Title)
+		case "description":
+			out.set(key, t.Description)
+		case "status":
+			out.set(key, t.Status)
+		case "archived":
+			out.set(key, t.Archived)
+		case "createdAt":
+			out.set(key, t.CreatedAt.Format(time.RFC3339))
+		case "updatedAt":
+			out.set(key, t.UpdatedAt.Format(time.RFC3339))
+		case "version":
+			out.set(key, t.Version)
+		case "startAt":
+			out.set(key, timeArg(t.StartAt))
+		case "dueAt":
+			out.set(key, timeArg(t.DueAt))
+		case "tags":
+			list := make([]any, 0, len(t.Tags))
+			for _, tag := range t.Tags {
+				list = append(list, ex.resolveTag(tag, f.Selection))
+			}
+			out.set(key, list)
+		case "acceptanceCriteria":
+			out.set(key, t.AcceptanceCriteria)
+		case "externalLinks":
+			list := make([]any, 0, len(t.ExternalLinks))
+			for _, l := range t.ExternalLinks {
+				obj := &gqlObject{}
+				for _, lf := range f.Selection {
+					switch lf.Name {
+					case "__typename":
+						obj.set(lf.key(), "Link")
+					case "title":
+						obj.set(lf.key(), l.Title)
+					case "url":
+						obj.set(lf.key(), l.URL)
+					default:
+						ex.fail("Cannot query field %q on type Link", lf.Name)
+					}
+				}
+				list = append(list, obj)
+			}
+			out.set(key, list)
+		default:
+			ex.fail("Cannot query field %q on type Task", f.Name)
+		}
+	}
+	return out
+}
+
+// resolveTag 解析 Tag 类型的字段
+func (ex *gqlExecutor) resolveTag(name string, sel []gqlField) any {
+	if !ex.charge(len(sel)) {
+		return nil
+	}
+	out := &gqlObject{}
+	for _, f := range sel {
+		key := f.key()
+		switch f.Name {
+		case "__typename":
+			out.set(key, "Tag")
+		case "name":
+			out.set(key, name)
+		case "taskCount":
+			var n int64
+			if err := ex.app.db.QueryRowContext(ex.ctx, `SELECT COUNT(DISTINCT task_id) FROM task_tags WHERE tag = ?`, name).Scan(&n); err != nil {
+				ex.fail("taskCount: %v", err)
+			}
+			out.set(key, n)
+		case "tasks":
+			archived, _ := ex.argBool(f, "archived")
+			cond := "WHERE archived = ? AND id IN (SELECT task_id FROM task_tags WHERE tag = ?)"
+			args := []any{boolToInt(archived), name}
+			if after, ok := ex.argInt(f, "after"); ok {
+				cond += " AND id < ?"
+				args = append(args, after)
+			}
+			first := 20
+			if v, ok := ex.argInt(f, "first"); ok && v > 0 && v <= 200 {
+				first = v
+			}
+			tasks, err := ex.app.queryTasks(ex.ctx, `
+				SELECT `+taskColumns+`
+				FROM tasks
+				`+cond+`
+				ORDER BY id DESC
+				LIMIT ?
+			`, append(args, first)...)
+			if err != nil {
+				ex.fail("tasks: %v", err)
+				out.set(key, nil)
+				continue
+			}
+			list := make([]any, 0, len(tasks))
+			for _, t := range tasks {
+				list = append(list, ex.resolveTask(t, f.Selection))
+			}
+			out.set(key, list)
+		default:
+			ex.fail("Cannot query field %q on type Tag", f.Name)
+		}
+	}
+	return out
+}
+
+// arg 返回字段参数的实际值（变量引用会被替换）
+func (ex *gqlExecutor) arg(f gqlField, name string) (any, bool) {
+	v, ok := f.Args[name]
+	if !ok {
+		return nil, false
+	}
+	if ref, isVar := v.(gqlVar); isVar {
+		v, ok = ex.vars[string(ref)]
+	}
+	if v == nil {
+		return nil, false
+	}
+	return v, ok
+}
+
+// argString 读取字符串参数
+func (ex *gqlExecutor) argString(f gqlField, name string) (string, bool) {
+	v, ok := ex.arg(f, name)
+	if !ok {
+		return "", false
+	}
+	s, ok := v.(string)
+	return s, ok
+}
+
+// argInt 读取整数参数（JSON 变量中的数字为 float64）
+func (ex *gqlExecutor) argInt(f gqlField, name string) (int, bool) {
+	v, ok := ex.arg(f, name)
+	if !ok {
+		return 0, false
+	}
+	switch n := v.(type) {
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	default:
+		return 0, false
+	}
+}
+
+// argBool 读取布尔参数
+func (ex *gqlExecutor) argBool(f gqlField, name string) (bool, bool) {
+	v, ok := ex.arg(f, name)
+	if !ok {
+		return false, false
+	}
+	b, ok := v.(bool)
+	return b, ok
+}
+
+// key 返回字段在结果中的键名（优先使用别名）
+func (f gqlField) key() string {
+	if f.Alias != "" {
+		return f.Alias
+	}
+	return f.Name
+}
+
+// selects 判断选择集中是否包含指定字段
+func (f gqlField) selects(name string) bool {
+	for _, sf := range f.Selection {
+		if sf.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// gqlParser 为 GraphQL 查询文档的递归下降解析器；depth 为当前的嵌套层数
+type gqlParser struct {
+	src   string
+	pos   int
+	depth int
+}
+
+// enter 进入一层选择集、列表或对象，超过 gqlMaxDepth 时返回错误；成功时须以 leave 配对
+func (p *gqlParser) enter() error {
+	if p.depth >= gqlMaxDepth {
+		return fmt.Errorf("query exceeds the maximum depth of %d", gqlMaxDepth)
+	}
+	p.depth++
+	return nil
+}
+
+// leave 退出一层嵌套
+func (p *gqlParser) leave() {
+	p.depth--
+}
+
+// parseGraphQL 解析查询文档，返回选中操作的根选择集与变量默认值
+func parseGraphQL(src, operationName string) ([]gqlField, map[string]any, error) {
+	p := &gqlParser{src: src}
+	var chosen []gqlField
+	var chosenDefaults map[string]any
+	found := false
+	for {
+		p.skipIgnored()
+		if p.pos >= len(p.src) {
+			break
+		}
+		name := ""
+		defaults := map[string]any{}
+		if p.peek() != '{' {
+			kw := p.name()
+			switch kw {
+			case "query":
+			case "mutation", "subscription":
+				return nil, nil, fmt.Errorf("%s operations are not supported", kw)
+			case "fragment":
+				return nil, nil, fmt.Errorf("fragments are not supported")
+			default:
+				return nil, nil, p.errorf("unexpected %q", kw)
+			}
+			p.skipIgnored()
+			if isNameStart(p.peek()) {
+				name = p.name()
+				p.skipIgnored()
+			}
+			if p.peek() == '(' {
+				if err := p.variableDefinitions(defaults); err != nil {
+					return nil, nil, err
+				}
+			}
+		}
+		sel, err := p.selectionSet()
+		if err != nil {
+			return nil, nil, err
+		}
+		if !found && (operationName == "" || operationName == name) {
+			chosen, chosenDefaults, found = sel, defaults, true
+		}
+	}
+	if !found {
+		if operationName != "" {
+			return nil, nil, fmt.Errorf("unknown operation %q", operationName)
+		}
+		return nil, nil, fmt.Errorf("empty query")
+	}
+	return chosen, chosenDefaults, nil
+}
+
+// errorf 生成带位置信息的语法错误
+func (p *gqlParser) errorf(format string, args ...any) error {
+	return fmt.Errorf("syntax error at %d: %s", p.pos, fmt.Sprintf(format, args...))
+}
+
+// peek 返回当前字符，越界时返回 0
+func (p *gqlParser) peek() byte {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+// skipIgnored 跳过空白、逗号与注释
+func (p *gqlParser) skipIgnored() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == ',':
+			p.pos++
+		case c == '#':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+// expect 消费指定字符
+func (p *gqlParser) expect(c byte) error {
+	p.skipIgnored()
+	if p.peek() != c {
+		return p.errorf("expected %q", c)
+	}
+	p.pos++
+	return nil
+}
+
+// isNameStart 判断字符能否作为名称开头
+func isNameStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// name 读取一个名称，失败时返回空串
+func (p *gqlParser) name() string {
+	p.skipIgnored()
+	start := p.pos
+	if !isNameStart(p.peek()) {
+		return ""
+	}
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		if isNameStart(c) || (c >= '0' && c <= '9') {
+			p.pos++
+			continue
+		}
+		break
+	}
+	return p.src[start:p.pos]
+}
+
+// variableDefinitions 解析变量定义并收集默认值
+func (p *gqlParser) variableDefinitions(defaults map[string]any) error {
+	if err := p.expect('('); err != nil {
+		return err
+	}
+	for {
+		p.skipIgnored()
+		if p.peek() == ')' {
+			p.pos++
+			return nil
+		}
+		if err := p.expect('$'); err != nil {
+			return err
+		}
+		name := p.name()
+		if name == "" {
+			return p.errorf("expected variable name")
+		}
+		if err := p.expect(':'); err != nil {
+			return err
+		}
+		if err := p.typeRef(); err != nil {
+			return err
+		}
+		p.skipIgnored()
+		if p.peek() == '=' {
+			p.pos++
+			v, err := p.value()
+			if err != nil {
+				return err
+			}
+			defaults[name] = v
+		}
+	}
+}
+
+// typeRef 解析（并忽略）变量类型声明
+func (p *gqlParser) typeRef() error {
+	p.skipIgnored()
+	if p.peek() == '[' {
+		p.pos++
+		if err := p.enter(); err != nil {
+			return err
+		}
+		defer p.leave()
+		if err := p.typeRef(); err != nil {
+			return err
+		}
+		if err := p.expect(']'); err != nil {
+			return err
+		}
+	} else if p.name() == "" {
+		return p.errorf("expected type")
+	}
+	p.skipIgnored()
+	if p.peek() == '!' {
+		p.pos++
+	}
+	return nil
+}
+
+// selectionSet 解析 { ... } 选择集
+func (p *gqlParser) selectionSet() ([]gqlField, error) {
+	if err := p.expect('{'); err != nil {
+		return nil, err
+	}
+	if err := p.enter(); err != nil {
+		return nil, err
+	}
+	defer p.leave()
+	var fields []gqlField
+	for {
+		p.skipIgnored()
+		switch p.peek() {
+		case '}':
+			p.pos++
+			if len(fields) == 0 {
+				return nil, p.errorf("empty selection set")
+			}
+			return fields, nil
+		case 0:
+			return nil, p.errorf("unexpected end of query")
+		case '.':
+			return nil, fmt.Errorf("fragments are not supported")
+		}
+		f, err := p.field()
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, f)
+	}
+}
+
+// field 解析单个字段（含别名、参数与子选择集）
+func (p *gqlParser) field() (gqlField, error) {
+	var f gqlField
+	n := p.name()
+	if n == "" {
+		return f, p.errorf("expected field name")
+	}
+	p.skipIgnored()
+	if p.peek() == ':' {
+		p.pos++
+		f.Alias = n
+		if n = p.name(); n == "" {
+			return f, p.errorf("expected field name")
+		}
+		p.skipIgnored()
+	}
+	f.Name = n
+	if p.peek() == '(' {
+		p.pos++
+		f.Args = map[string]any{}
+		for {
+			p.skipIgnored()
+			if p.peek() == ')' {
+				p.pos++
+				break
+			}
+			an := p.name()
+			if an == "" {
+				return f, p.errorf("expected argument name")
+			}
+			if err := p.expect(':'); err != nil {
+				return f, err
+			}
+			v, err := p.value()
+			if err != nil {
+				return f, err
+			}
+			f.Args[an] = v
+		}
+		p.skipIgnored()
+	}
+	if p.peek() == '{' {
+		sel, err := p.selectionSet()
+		if err != nil {
+			return f, err
+		}
+		f.Selection = sel
+	}
+	return f, nil
+}
+
+// value 解析参数值：变量、数字、字符串、布尔、null、枚举、列表与对象
+func (p *gqlParser) value() (any, error) {
+	p.skipIgnored()
+	c := p.peek()
+	switch {
+	case c == '$':
+		p.pos++
+		n := p.name()
+		if n == "" {
+			return nil, p.errorf("expected variable name")
+		}
+		return gqlVar(n), nil
+	case c == '"':
+		return p.stringValue()
+	case c == '-' || (c >= '0' && c <= '9'):
+		start := p.pos
+		p.pos++
+		isFloat := false
+		for p.pos < len(p.src) {
+			d := p.src[p.pos]
+			if d >= '0' && d <= '9' {
+				p.pos++
+			} else if d == '.' || d == 'e' || d == 'E' || d == '+' || (d == '-' && isFloat) {
+				isFloat = true
+				p.pos++
+			} else {
+				break
+			}
+		}
+		lit := p.src[start:p.pos]
+		if isFloat {
+			var f float64
+			if _, err := fmt.Sscan(lit, &f); err != nil {
+				return nil, p.errorf("invalid number %q", lit)
+			}
+			return f, nil
+		}
+		var n int64
+		if _, err := fmt.Sscan(lit, &n); err != nil {
+			return nil, p.errorf("invalid number %q", lit)
+		}
+		return n, nil
+	case c == '[':
+		p.pos++
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		var list []any
+		for {
+			p.skipIgnored()
+			if p.peek() == ']' {
+				p.pos++
+				return list, nil
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+	case c == '{':
+		p.pos++
+		if err := p.enter(); err != nil {
+			return nil, err
+		}
+		defer p.leave()
+		obj := map[string]any{}
+		for {
+			p.skipIgnored()
+			if p.peek() == '}' {
+				p.pos++
+				return obj, nil
+			}
+			k := p.name()
+			if k == "" {
+				return nil, p.errorf("expected field name")
+			}
+			if err := p.expect(':'); err != nil {
+				return nil, err
+			}
+			v, err := p.value()
+			if err != nil {
+				return nil, err
+			}
+			obj[k] = v
+		}
+	case isNameStart(c):
+		switch n := p.name(); n {
+		case "true":
+			return true, nil
+		case "false":
+			return false, nil
+		case "null":
+			return nil, nil
+		default:
+			return n, nil
+		}
+	default:
+		return nil, p.errorf("unexpected character %q", c)
+	}
+}
+
+// stringValue 解析双引号字符串字面量
+func (p *gqlParser) stringValue() (string, error) {
+	start := p.pos
+	p.pos++
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '\\':
+			p.pos += 2
+		case '"':
+			p.pos++
+			var s string
+			if err := json.Unmarshal([]byte(p.src[start:p.pos]), &s); err != nil {
+				return "", p.errorf("invalid string")
+			}
+			return s, nil
+		case '\n':
+			return "", p.errorf("unterminated string")
+		default:
+			p.pos++
+		}
+	}
+	return "", p.errorf("unterminated string")
+}
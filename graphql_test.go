@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseGraphQLRejectsDeepNesting(t *testing.T) {
+	deep := strings.Repeat("{ tags { tasks ", 6) + "{ id }" + strings.Repeat("} }", 6)
+	if _, _, err := parseGraphQL("{ tasks { items { tags { name } } } }", ""); err != nil {
+		t.Fatalf("shallow query: %v", err)
+	}
+	if _, _, err := parseGraphQL(deep, ""); err == nil || !strings.Contains(err.Error(), "maximum depth") {
+		t.Errorf("deep selection: err = %v, want maximum depth error", err)
+	}
+	list := "{ tasks(q: " + strings.Repeat("[", 100) + strings.Repeat("]", 100) + ") { total } }"
+	if _, _, err := parseGraphQL(list, ""); err == nil || !strings.Contains(err.Error(), "maximum depth") {
+		t.Errorf("deep list value: err = %v, want maximum depth error", err)
+	}
+}
+
+func TestGraphQLFieldBudgetStopsFanOut(t *testing.T) {
+	app := newTestApp(t, nil)
+	for i := 0; i < 30; i++ {
+		res, err := app.db.Exec(`INSERT INTO tasks (title, description, status, created_at, updated_at) VALUES ('t', '', 'todo', '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := res.LastInsertId()
+		if _, err := app.db.Exec(`INSERT INTO task_tags (task_id, tag) VALUES (?, 'a')`, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	query := `{ tags { tasks(first: 200) { tags { tasks(first: 200) { tags { tasks(first: 200) { id title } } } } } } }`
+	body, _ := json.Marshal(gqlRequest{Query: query})
+	rec := httptest.NewRecorder()
+	app.handleGraphQL(rec, httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(string(body))))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Errors []gqlError `json:"errors"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "limit") {
+		t.Errorf("errors = %+v, want a single field limit error", resp.Errors)
+	}
+}
+
+func TestGraphQLTagTasksPagination(t *testing.T) {
+	app := newTestApp(t, nil)
+	for i := 0; i < 3; i++ {
+		res, err := app.db.Exec(`INSERT INTO tasks (title, description, status, created_at, updated_at) VALUES ('t', '', 'todo', '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')`)
+		if err != nil {
+			t.Fatal(err)
+		}
+		id, _ := res.LastInsertId()
+		if _, err := app.db.Exec(`INSERT INTO task_tags (task_id, tag) VALUES (?, 'a')`, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	body, _ := json.Marshal(gqlRequest{Query: `{ tags { first: tasks(first: 2) { id } next: tasks(first: 2, after: 2) { id } } }`})
+	rec := httptest.NewRecorder()
+	app.handleGraphQL(rec, httptest.NewRequest(http.MethodPost, "/api/graphql", strings.NewReader(string(body))))
+	want := `{"data":{"tags":[{"first":[{"id":3},{"id":2}],"next":[{"id":1}]}]}}`
+	if got := strings.TrimSpace(rec.Body.String()); got != want {
+		t.Errorf("body = %s, want %s", got, want)
+	}
+}
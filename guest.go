@@ -0,0 +1,61 @@
+package main
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// 访客只读模式：开启后未登录、未携带密钥或签名的访客可以打开看板页面并 GET 任务列表、任务详情与标签列表，
+// 即使开启了 login.required、api_keys.required 或看板归属团队；其余接口（包括任务下的快捷操作二维码与分享链接等
+// 会签发令牌的子路径）仍按原有规则校验。
+// 同时所有修改请求都必须携带会话、API 密钥或签名，适合挂在办公室墙上的只读大屏
+
+// GuestConfig 为访客只读模式配置
+type GuestConfig struct {
+	ReadOnly bool `yaml:"read_only"`
+}
+
+// guestReadPaths 为访客可读取的接口，按完整路径匹配；任务详情 /api/tasks/{id} 另行判断
+var guestReadPaths = []string{"/api/tasks", "/api/tags"}
+
+// guestReadable 判断访客只读模式下该请求是否无需身份即可访问：看板页面与静态资源，以及任务列表、任务详情与标签列表的读取
+func (a *App) guestReadable(r *http.Request) bool {
+	if !a.cfg.Guest.ReadOnly || (r.Method != http.MethodGet && r.Method != http.MethodHead) {
+		return false
+	}
+	path := r.URL.Path
+	if !strings.HasPrefix(path, "/api/") || slices.Contains(guestReadPaths, path) {
+		return true
+	}
+	if rest, ok := strings.CutPrefix(path, "/api/tasks/"); ok {
+		_, err := parseInt64(rest)
+		return rest != "" && err == nil
+	}
+	return false
+}
+
+// authenticated 判断请求是否携带了会话、API 密钥或签名
+func authenticated(r *http.Request) bool {
+	_, byKey := r.Context().Value(apiKeyCtxKey{}).(APIKey)
+	return byKey || signedClient(r) != "" || currentUserID(r) != 0
+}
+
+// withGuestMode 在访客只读模式下拒绝未认证的修改请求；自带令牌校验的接口不受影响
+func (a *App) withGuestMode(next http.Handler) http.Handler {
+	if !a.cfg.Guest.ReadOnly {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			if apiKeyRequired(r.URL.Path) && !authenticated(r) {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="task-board"`)
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "authentication required"})
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+)
+
+func TestGuestReadOnlyLimitsTaskSubpaths(t *testing.T) {
+	app := newTestApp(t, func(c *Config) {
+		c.Guest.ReadOnly = true
+		c.APIKeys.Required = true
+		c.QuickActions.Enabled = true
+		c.QuickActions.Secret = "test-secret"
+	})
+	access, err := newAccessPolicy(app.cfg.Access)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := app.withAPIKeys(access, app.withGuestMode(app.routes()))
+	res, err := app.db.Exec(`INSERT INTO tasks (title, description, status, created_at, updated_at) VALUES ('t', '', 'todo', '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	task := "/api/tasks/" + strconv.FormatInt(id, 10)
+
+	for _, tc := range []struct {
+		path string
+		want int
+	}{
+		{"/api/tasks", http.StatusOK},
+		{task, http.StatusOK},
+		{"/api/tags", http.StatusOK},
+		{task + "/actions", http.StatusUnauthorized},
+		{task + "/actions/" + quickActionDone + "/qr.svg", http.StatusUnauthorized},
+		{task + "/share", http.StatusUnauthorized},
+		{task + "/history", http.StatusUnauthorized},
+	} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+		if rec.Code != tc.want {
+			t.Errorf("GET %s = %d, want %d: %s", tc.path, rec.Code, tc.want, rec.Body.String())
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+)
+
+// 任务标题与描述的历史版本由触发器在内容变化时保存（覆盖所有写入路径），
+// 每条记录为被替换前的内容及其版本号，可通过 revert 恢复；恢复本身也会产生一条历史。
+
+// taskVersionTrigger 在标题或描述变化时保存旧内容
+const taskVersionTrigger = `
+	CREATE TRIGGER IF NOT EXISTS trg_task_versions AFTER UPDATE OF title, description ON tasks
+	WHEN OLD.title <> NEW.title OR OLD.description <> NEW.description
+	BEGIN
+		INSERT OR REPLACE INTO task_versions (task_id, version, title, description, saved_at, replaced_at)
+		VALUES (OLD.id, OLD.version, OLD.title, OLD.description, OLD.updated_at, NEW.updated_at);
+	END;
+`
+
+// TaskVersion 为任务标题与描述的一个版本；SavedAt 为该版本写入的时间，ReplacedAt 为被替换的时间（当前版本为空）
+type TaskVersion struct {
+	Version     int64      `json:"version"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	SavedAt     time.Time  `json:"saved_at"`
+	ReplacedAt  *time.Time `json:"replaced_at,omitempty"`
+	Current     bool       `json:"current,omitempty"`
+}
+
+// taskHistoryResponse 为任务历史的响应结构，按版本从新到旧排列，首项为当前内容
+type taskHistoryResponse struct {
+	Items []TaskVersion `json:"items"`
+}
+
+// taskRevertRequest 为恢复历史版本的请求体；也可通过 If-Match 提供期望版本
+type taskRevertRequest struct {
+	ExpectedVersion *int64 `json:"expected_version"`
+}
+
+// migrateTaskVersions 创建保存历史版本的触发器
+func (a *App) migrateTaskVersions() error {
+	_, err := a.db.Exec(taskVersionTrigger)
+	return err
+}
+
+// handleTaskHistory 处理 GET /api/tasks/{id}/history
+func (a *App) handleTaskHistory(w http.ResponseWriter, r *http.Request, taskID int64) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	t, err := a.fetchTaskDetail(ctx, taskID)
+	if err == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	items := []TaskVersion{{Version: t.Version, Title: t.Title, Description: t.Description, SavedAt: t.UpdatedAt, Current: true}}
+	older, err := a.fetchTaskVersions(ctx, taskID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, taskHistoryResponse{Items: append(items, older...)})
+}
+
+// fetchTaskVersions 按版本从新到旧返回任务的历史版本
+func (a *App) fetchTaskVersions(ctx context.Context, taskID int64) ([]TaskVersion, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT version, title, description, saved_at, replaced_at FROM task_versions WHERE task_id = ? ORDER BY version DESC`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []TaskVersion
+	for rows.Next() {
+		var v TaskVersion
+		var saved, replaced string
+		if err := rows.Scan(&v.Version, &v.Title, &v.Description, &saved, &replaced); err != nil {
+			return nil, err
+		}
+		v.SavedAt, _ = time.Parse(time.RFC3339, saved)
+		if t, err := time.Parse(time.RFC3339, replaced); err == nil {
+			v.ReplacedAt = &t
+		}
+		out = append(out, v)
+	}
+	return out, rows.Err()
+}
+
+// handleTaskRevert 处理 POST /api/tasks/{id}/revert/{version}：将标题与描述恢复为指定历史版本。
+// 与普通更新一样需要提供期望版本，冲突时返回 409；恢复后任务版本号递增
+func (a *App) handleTaskRevert(w http.ResponseWriter, r *http.Request, taskID int64, rest []string) {
+	ctx := r.Context()
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	if len(rest) != 1 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	target, err := parseInt64(rest[0])
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid version"})
+		return
+	}
+	var body taskRevertRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	expected, ok := requestedVersion(r, body.ExpectedVersion)
+	if !ok {
+		writeJSON(w, http.StatusPreconditionRequired, map[string]string{"error": "expected_version required"})
+		return
+	}
+	var title, description string
+	err = a.db.QueryRowContext(ctx, `SELECT title, description FROM task_versions WHERE task_id = ? AND version = ?`, taskID, target).Scan(&title, &description)
+	if err == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "version not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	res, err := a.db.ExecContext(ctx, `UPDATE tasks SET title = ?, description = ?, updated_at = ?, version = version + 1 WHERE id = ? AND version = ?`,
+		title, description, time.Now().Format(time.RFC3339), taskID, expected)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		a.writeVersionConflict(ctx, w, taskID)
+		return
+	}
+	a.syncDescriptionMentions(r, taskID, description)
+	a.reqLogger(r).Info("任务已恢复到历史版本", "reverted_to", target)
+	w.Header().Set("ETag", versionETag(expected+1))
+	writeJSON(w, http.StatusOK, map[string]any{"id": taskID, "reverted_to": target, "version": expected + 1})
+}
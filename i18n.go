@@ -0,0 +1,204 @@
+package main
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// 消息翻译：i18n/<语言>.json 为编译进二进制的消息目录，键为代码中的原文（接口错误为英文，
+// 生成的文本如通知、邮件与流转提示为中文），值为该语言的译文；以 * 结尾的键按前缀匹配，
+// 译文中的 * 替换为原文的剩余部分（如附带的具体错误）。目录中没有的消息保持原文。
+// 接口响应按请求的 ?lang=、语言偏好 Cookie 与 Accept-Language 协商语言，都未提供时保持原文，
+// 不影响按原文判断错误的旧客户端；邮件、Telegram 等后台生成的文本使用配置的 locale
+
+// messageCatalogFS 为各语言的消息目录
+//
+//go:embed i18n
+var messageCatalogFS embed.FS
+
+// messageCatalog 为一种语言的消息目录
+type messageCatalog struct {
+	exact    map[string]string
+	prefixes map[string]string
+}
+
+// messageCatalogs 为按语言加载的消息目录，启动时从内嵌文件读取
+var messageCatalogs = loadMessageCatalogs()
+
+// loadMessageCatalogs 读取全部支持语言的消息目录；目录文件随二进制发布，格式错误视为编译缺陷
+func loadMessageCatalogs() map[string]messageCatalog {
+	out := map[string]messageCatalog{}
+	for _, l := range supportedLocales {
+		raw, err := messageCatalogFS.ReadFile("i18n/" + l + ".json")
+		if err != nil {
+			panic(err)
+		}
+		var entries map[string]string
+		if err := json.Unmarshal(raw, &entries); err != nil {
+			panic(fmt.Sprintf("消息目录 %s: %v", l, err))
+		}
+		c := messageCatalog{exact: map[string]string{}, prefixes: map[string]string{}}
+		for k, v := range entries {
+			if p, ok := strings.CutSuffix(k, "*"); ok {
+				c.prefixes[p] = v
+			} else {
+				c.exact[k] = v
+			}
+		}
+		out[l] = c
+	}
+	return out
+}
+
+// translate 返回消息在指定语言中的译文，语言为空或没有译文时返回原文
+func translate(locale, msg string) string {
+	c, ok := messageCatalogs[locale]
+	if !ok || msg == "" {
+		return msg
+	}
+	if v, ok := c.exact[msg]; ok {
+		return v
+	}
+	// 多个前缀匹配时取最长的
+	best := ""
+	for p := range c.prefixes {
+		if len(p) > len(best) && strings.HasPrefix(msg, p) {
+			best = p
+		}
+	}
+	if best != "" {
+		return strings.Replace(c.prefixes[best], "*", translate(locale, msg[len(best):]), 1)
+	}
+	return msg
+}
+
+// translatef 翻译格式串后再格式化，参数本身不翻译
+func translatef(locale, format string, args ...any) string {
+	return fmt.Sprintf(translate(locale, format), args...)
+}
+
+// apiLocale 返回请求显式偏好的语言：依次为 ?lang= 参数、语言偏好 Cookie 与 Accept-Language；
+// 都未提供或均不受支持时返回空串，接口消息保持原文
+func apiLocale(r *http.Request) string {
+	if l, ok := matchLocale(r.URL.Query().Get("lang")); ok {
+		return l
+	}
+	if c, err := r.Cookie(localeCookie); err == nil {
+		if l, ok := matchLocale(c.Value); ok {
+			return l
+		}
+	}
+	if h := r.Header.Get("Accept-Language"); h != "" {
+		if l := negotiateLocale(h); l != defaultLocale || acceptsLocale(h, defaultLocale) {
+			return l
+		}
+	}
+	return ""
+}
+
+// acceptsLocale 判断 Accept-Language 中是否有匹配 locale 的语言
+func acceptsLocale(header, locale string) bool {
+	for _, part := range strings.Split(header, ",") {
+		tag, _, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if l, ok := matchLocale(tag); ok && l == locale {
+			return true
+		}
+	}
+	return false
+}
+
+// localizedResponse 为包含可翻译消息的响应结构
+type localizedResponse interface {
+	localize(locale string) any
+}
+
+// localizeResponse 翻译响应中的错误消息：{"error": ...} 形式的对象与实现了 localizedResponse 的结构
+func localizeResponse(v any, locale string) any {
+	if locale == "" {
+		return v
+	}
+	switch x := v.(type) {
+	case map[string]string:
+		if msg, ok := x["error"]; ok {
+			out := make(map[string]string, len(x))
+			for k, v := range x {
+				out[k] = v
+			}
+			out["error"] = translate(locale, msg)
+			return out
+		}
+	case map[string]any:
+		if msg, ok := x["error"].(string); ok {
+			out := make(map[string]any, len(x))
+			for k, v := range x {
+				out[k] = v
+			}
+			out["error"] = translate(locale, msg)
+			return out
+		}
+	case localizedResponse:
+		return x.localize(locale)
+	}
+	return v
+}
+
+// localize 翻译校验失败的错误与各字段消息
+func (e validationErrorResponse) localize(locale string) any {
+	out := validationErrorResponse{Error: translate(locale, e.Error), Fields: make([]fieldError, len(e.Fields))}
+	for i, f := range e.Fields {
+		f.Message = translate(locale, f.Message)
+		out.Fields[i] = f
+	}
+	return out
+}
+
+// localize 翻译流转被拒绝的错误；规则自定义的提示保持原文
+func (e transitionErrorResponse) localize(locale string) any {
+	e.Error = translate(locale, e.Error)
+	if e.messageFormat != "" {
+		e.Message = translatef(locale, e.messageFormat, e.messageArgs...)
+	}
+	return e
+}
+
+// localMessage 为按语言生成的消息：Format 为原文格式串，Args 为参数；参数为字符串，便于存储后重新生成
+type localMessage struct {
+	Format string
+	Args   []string
+}
+
+// args 返回参数列表，没有参数时为空列表而非 nil
+func (m localMessage) args() []string {
+	if m.Args == nil {
+		return []string{}
+	}
+	return m.Args
+}
+
+// text 生成消息在指定语言中的文本
+func (m localMessage) text(locale string) string {
+	args := make([]any, len(m.Args))
+	for i, a := range m.Args {
+		args[i] = a
+	}
+	return translatef(locale, m.Format, args...)
+}
+
+// outboundLocale 返回邮件、Telegram 等后台生成文本的语言，即配置的 locale
+func (a *App) outboundLocale() string {
+	if l, ok := matchLocale(a.cfg.Locale); ok {
+		return l
+	}
+	return defaultLocale
+}
+
+// messageLocale 返回生成给该请求的文本所用的语言：请求未表明偏好时使用配置的 locale
+func (a *App) messageLocale(r *http.Request) string {
+	if l := apiLocale(r); l != "" {
+		return l
+	}
+	return a.outboundLocale()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"time"
+)
+
+// 幂等键的最大长度与保留时长
+const (
+	maxIdempotencyKeyLen = 255
+	idempotencyKeyTTL    = 24 * time.Hour
+)
+
+// idempotencyFingerprint 计算请求体指纹，用于识别同一幂等键下的不同请求
+func idempotencyFingerprint(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
+
+// lookupIdempotencyKey 查询幂等键对应的已创建任务；过期记录视为不存在
+func (a *App) lookupIdempotencyKey(ctx context.Context, key string) (taskID int64, fingerprint string, found bool, err error) {
+	cutoff := time.Now().Add(-idempotencyKeyTTL).Format(time.RFC3339)
+	err = a.db.QueryRowContext(ctx, `
+		SELECT task_id, fingerprint FROM idempotency_keys
+		WHERE key = ? AND created_at >= ?
+	`, key, cutoff).Scan(&taskID, &fingerprint)
+	if err == sql.ErrNoRows {
+		return 0, "", false, nil
+	}
+	if err != nil {
+		return 0, "", false, err
+	}
+	return taskID, fingerprint, true, nil
+}
+
+// saveIdempotencyKey 记录幂等键与创建结果，并顺带清理过期记录
+func (a *App) saveIdempotencyKey(ctx context.Context, key, fingerprint string, taskID int64) error {
+	now := time.Now()
+	if _, err := a.db.ExecContext(ctx, `DELETE FROM idempotency_keys WHERE created_at < ?`, now.Add(-idempotencyKeyTTL).Format(time.RFC3339)); err != nil {
+		return err
+	}
+	_, err := a.db.ExecContext(ctx, `
+		INSERT OR REPLACE INTO idempotency_keys (key, fingerprint, task_id, created_at)
+		VALUES (?, ?, ?, ?)
+	`, key, fingerprint, taskID, now.Format(time.RFC3339))
+	return err
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"html"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// InboxConfig 为邮件收件箱配置：邮件服务商（或自建 MTA 脚本）将收到的邮件 POST 到
+// /api/integrations/email/inbound?token=<token>，每封邮件生成一个任务。token 为空表示关闭
+type InboxConfig struct {
+	Token string `yaml:"token"`
+	// DefaultTags 为邮件生成的任务附加的标签
+	DefaultTags []string `yaml:"default_tags"`
+	// AllowedSenders 限制发件人，可写完整地址或 "@example.com" 形式的域名；为空表示不限制
+	AllowedSenders []string `yaml:"allowed_senders"`
+}
+
+// maxInboundMailBytes 为入站邮件请求体的大小上限
+const maxInboundMailBytes = 10 << 20
+
+// inboundMail 为从各种入站格式中解析出的邮件
+type inboundMail struct {
+	MessageID string `json:"message_id"`
+	From      string `json:"from"`
+	Subject   string `json:"subject"`
+	Text      string `json:"text"`
+	HTML      string `json:"html"`
+}
+
+// inboundMailResponse 为入站邮件的处理结果
+type inboundMailResponse struct {
+	ID int64 `json:"id"`
+}
+
+// allowsSender 判断发件人是否在允许列表中
+func (c InboxConfig) allowsSender(from string) bool {
+	if len(c.AllowedSenders) == 0 {
+		return true
+	}
+	from = strings.ToLower(from)
+	for _, s := range c.AllowedSenders {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == from || (strings.HasPrefix(s, "@") && strings.HasSuffix(from, s)) {
+			return true
+		}
+	}
+	return false
+}
+
+// handleInboundMail 将一封入站邮件转为任务：主题为标题，正文为描述，并附加默认标签。
+// 支持原始 MIME 邮件（message/rfc822）、JSON 与常见服务商的表单格式；以 Message-ID 作为幂等键，服务商重试不会重复建任务
+func (a *App) handleInboundMail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	c := a.cfg.Inbox
+	if c.Token == "" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	if !a.secrets.match(secretInbox, "", r.URL.Query().Get("token"), time.Now()) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, maxInboundMailBytes)
+	m, err := parseInboundMail(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if addr, err := mail.ParseAddress(m.From); err == nil {
+		m.From = addr.Address
+	}
+	addLogAttrs(r, "from", m.From, "message_id", m.MessageID)
+	if !c.allowsSender(m.From) {
+		a.reqLogger(r).Warn("拒绝未授权发件人的邮件")
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "sender not allowed"})
+		return
+	}
+	body := taskCreateRequest{
+		Title:       strings.TrimSpace(m.Subject),
+		Description: mailDescription(m),
+		Tags:        append([]string{}, c.DefaultTags...),
+	}
+	if body.Title == "" {
+		body.Title = "（无主题）"
+	}
+	if len([]rune(body.Title)) > maxStructuredTextLen {
+		body.Title = string([]rune(body.Title)[:maxStructuredTextLen])
+	}
+	idemKey := ""
+	if m.MessageID != "" {
+		sum := sha256.Sum256([]byte(m.MessageID))
+		idemKey = "email-" + hex.EncodeToString(sum[:16])
+	}
+	status, raw := callAPI(ctx, a.routes(), http.MethodPost, "/api/tasks", body, idemKey)
+	var res struct {
+		ID    int64  `json:"id"`
+		Error string `json:"error"`
+	}
+	_ = json.Unmarshal(raw, &res)
+	if status != http.StatusCreated {
+		writeJSON(w, status, map[string]string{"error": res.Error})
+		return
+	}
+	a.reqLogger(r).Info("邮件已转为任务", "task_id", res.ID)
+	writeJSON(w, http.StatusCreated, inboundMailResponse{ID: res.ID})
+}
+
+// parseInboundMail 按 Content-Type 解析入站邮件
+func parseInboundMail(r *http.Request) (inboundMail, error) {
+	var m inboundMail
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "application/json":
+		if err := json.NewDecoder(r.Body).Decode(&m); err != nil {
+			return m, errors.New("invalid json")
+		}
+		return m, nil
+	case "application/x-www-form-urlencoded", "multipart/form-data":
+		// Mailgun 使用 body-plain/body-html/sender，SendGrid 使用 text/html/from
+		if err := r.ParseMultipartForm(maxInboundMailBytes); err != nil && err != http.ErrNotMultipart {
+			return m, errors.New("invalid form")
+		}
+		m.Subject = r.FormValue("subject")
+		m.From = firstNonEmpty(r.FormValue("from"), r.FormValue("sender"))
+		m.Text = firstNonEmpty(r.FormValue("body-plain"), r.FormValue("text"))
+		m.HTML = firstNonEmpty(r.FormValue("body-html"), r.FormValue("html"))
+		m.MessageID = firstNonEmpty(r.FormValue("Message-Id"), r.FormValue("message-id"))
+		return m, nil
+	default:
+		// 其余按原始 MIME 邮件处理（message/rfc822、text/plain 等）
+		msg, err := mail.ReadMessage(r.Body)
+		if err != nil {
+			return m, errors.New("invalid message")
+		}
+		dec := new(mime.WordDecoder)
+		if m.Subject, err = dec.DecodeHeader(msg.Header.Get("Subject")); err != nil {
+			m.Subject = msg.Header.Get("Subject")
+		}
+		m.From = msg.Header.Get("From")
+		m.MessageID = strings.TrimSpace(msg.Header.Get("Message-Id"))
+		m.Text, m.HTML = extractMailText(msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+		return m, nil
+	}
+}
+
+// extractMailText 从邮件正文中取出第一个纯文本与 HTML 部分，跳过附件；仅支持 UTF-8 与 ASCII 字符集
+func extractMailText(contentType, encoding string, body io.Reader) (text, htmlBody string) {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := mr.NextPart()
+			if err != nil {
+				break
+			}
+			if disp, _, _ := mime.ParseMediaType(part.Header.Get("Content-Disposition")); disp == "attachment" {
+				continue
+			}
+			t, h := extractMailText(part.Header.Get("Content-Type"), part.Header.Get("Content-Transfer-Encoding"), part)
+			if text == "" {
+				text = t
+			}
+			if htmlBody == "" {
+				htmlBody = h
+			}
+		}
+		return text, htmlBody
+	}
+	switch strings.ToLower(strings.TrimSpace(encoding)) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, body)
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+	raw, _ := io.ReadAll(io.LimitReader(body, maxInboundMailBytes))
+	switch mediaType {
+	case "text/plain":
+		return string(raw), ""
+	case "text/html":
+		return "", string(raw)
+	}
+	return "", ""
+}
+
+// htmlTagPattern 匹配 HTML 标签，用于只有 HTML 正文时粗略提取文本
+var htmlTagPattern = regexp.MustCompile(`(?s)<(script|style)[^>]*>.*?</(script|style)>|<[^>]+>`)
+
+// mailDescription 生成任务描述：优先使用纯文本正文，末尾注明发件人
+func mailDescription(m inboundMail) string {
+	text := m.Text
+	if strings.TrimSpace(text) == "" && m.HTML != "" {
+		text = html.UnescapeString(htmlTagPattern.ReplaceAllString(m.HTML, ""))
+	}
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if m.From != "" {
+		if text != "" {
+			text += "\n\n"
+		}
+		text += "—— 来自邮件：" + m.From
+	}
+	return text
+}
+
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,509 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// 事故是一类特殊任务：未解决时固定显示在看板顶部的事故泳道，创建时立即通过寻呼渠道告警，
+// 并记录确认与解决时间，用于复盘统计平均确认时长（MTTA）与平均解决时长（MTTR）。
+
+// 寻呼格式
+const (
+	pagingPagerDuty = "pagerduty" // PagerDuty Events API v2
+	pagingOpsgenie  = "opsgenie"  // Opsgenie Alert API
+	pagingWebhook   = "webhook"   // 通用 JSON webhook
+)
+
+// 事故严重程度，与 PagerDuty 的 severity 取值一致
+var incidentSeverities = []string{"critical", "error", "warning", "info"}
+
+// opsgeniePriorities 为严重程度到 Opsgenie 优先级的映射
+var opsgeniePriorities = map[string]string{"critical": "P1", "error": "P2", "warning": "P3", "info": "P5"}
+
+// PagingConfig 为事故寻呼配置：format 为空表示不寻呼；url 缺省时按 format 使用官方地址（webhook 必填），
+// key 为 PagerDuty 的 routing key 或 Opsgenie 的 API key
+type PagingConfig struct {
+	Format string `yaml:"format"`
+	URL    string `yaml:"url"`
+	Key    string `yaml:"key"`
+	// Source 为告警中标明的来源
+	Source string `yaml:"source"`
+}
+
+// enabled 判断是否配置了寻呼
+func (c PagingConfig) enabled() bool { return c.Format != "" }
+
+// endpoint 返回寻呼地址
+func (c PagingConfig) endpoint() string {
+	if c.URL != "" {
+		return strings.TrimRight(c.URL, "/")
+	}
+	switch c.Format {
+	case pagingPagerDuty:
+		return "https://events.pagerduty.com/v2/enqueue"
+	case pagingOpsgenie:
+		return "https://api.opsgenie.com/v2/alerts"
+	}
+	return ""
+}
+
+// validate 校验寻呼配置
+func (c PagingConfig) validate() error {
+	switch c.Format {
+	case "":
+		return nil
+	case pagingPagerDuty, pagingOpsgenie:
+		if c.Key == "" {
+			return fmt.Errorf("paging.key 不能为空")
+		}
+	case pagingWebhook:
+		if c.URL == "" {
+			return fmt.Errorf("paging.url 不能为空")
+		}
+	default:
+		return fmt.Errorf("paging.format 必须为 pagerduty、opsgenie 或 webhook: %q", c.Format)
+	}
+	u, err := url.Parse(c.endpoint())
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("paging.url 无效: %q", c.URL)
+	}
+	return nil
+}
+
+// TaskIncident 为任务的事故信息
+type TaskIncident struct {
+	Severity       string     `json:"severity"`
+	TriggeredAt    time.Time  `json:"triggered_at"`
+	AcknowledgedAt *time.Time `json:"acknowledged_at,omitempty"`
+	ResolvedAt     *time.Time `json:"resolved_at,omitempty"`
+	PagedAt        *time.Time `json:"paged_at,omitempty"`
+	PageError      string     `json:"page_error,omitempty"`
+}
+
+// incidentCreateRequest 为创建事故的请求体：指定 task_id 时将已有任务升级为事故，否则按标题等字段新建任务
+type incidentCreateRequest struct {
+	TaskID      *int64   `json:"task_id"`
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Tags        []string `json:"tags"`
+	Severity    string   `json:"severity"`
+}
+
+// incidentListResponse 为事故列表的响应结构
+type incidentListResponse struct {
+	Items []Task `json:"items"`
+}
+
+// incidentStats 为一组事故的复盘统计，时长单位为秒；没有样本时为空
+type incidentStats struct {
+	Count        int      `json:"count"`
+	Open         int      `json:"open"`
+	Acknowledged int      `json:"acknowledged"`
+	Resolved     int      `json:"resolved"`
+	MTTASeconds  *float64 `json:"mtta_seconds"`
+	MTTRSeconds  *float64 `json:"mttr_seconds"`
+}
+
+// incidentStatsResponse 为事故统计的响应结构
+type incidentStatsResponse struct {
+	Since      *time.Time               `json:"since,omitempty"`
+	Total      incidentStats            `json:"total"`
+	BySeverity map[string]incidentStats `json:"by_severity"`
+}
+
+// fetchIncident 返回任务的事故信息；非事故任务返回 nil
+func (a *App) fetchIncident(ctx context.Context, taskID int64) (*TaskIncident, error) {
+	var inc TaskIncident
+	var triggered string
+	var acked, resolved, paged sql.NullString
+	err := a.db.QueryRowContext(ctx, `SELECT severity, triggered_at, acknowledged_at, resolved_at, paged_at, page_error FROM task_incidents WHERE task_id = ?`, taskID).
+		Scan(&inc.Severity, &triggered, &acked, &resolved, &paged, &inc.PageError)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	inc.TriggeredAt, _ = time.Parse(time.RFC3339, triggered)
+	inc.AcknowledgedAt = parseOptionalTime(acked)
+	inc.ResolvedAt = parseOptionalTime(resolved)
+	inc.PagedAt = parseOptionalTime(paged)
+	return &inc, nil
+}
+
+// parseOptionalTime 解析可为空的 RFC3339 时间
+func parseOptionalTime(s sql.NullString) *time.Time {
+	if !s.Valid {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, s.String)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// handleIncidents 处理 /api/incidents：GET 列出事故任务（state=open|resolved|all，默认 open），POST 创建事故
+func (a *App) handleIncidents(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodGet:
+		var cond string
+		switch r.URL.Query().Get("state") {
+		case "", "open":
+			cond = `WHERE resolved_at IS NULL`
+		case "resolved":
+			cond = `WHERE resolved_at IS NOT NULL`
+		case "all":
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid state"})
+			return
+		}
+		tasks, err := a.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id IN (SELECT task_id FROM task_incidents `+cond+`) ORDER BY id DESC`)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if tasks == nil {
+			tasks = []Task{}
+		}
+		writeJSON(w, http.StatusOK, incidentListResponse{Items: tasks})
+	case http.MethodPost:
+		a.createIncident(w, r)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// createIncident 新建事故任务或将已有任务升级为事故，随后立即寻呼；寻呼失败不影响创建，错误记录在 page_error 中
+func (a *App) createIncident(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var body incidentCreateRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	if body.Severity == "" {
+		body.Severity = "critical"
+	}
+	if !validIncidentSeverity(body.Severity) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "severity must be one of " + strings.Join(incidentSeverities, ", ")})
+		return
+	}
+	var taskID int64
+	if body.TaskID != nil {
+		taskID = *body.TaskID
+		ok, err := a.taskExists(ctx, taskID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+			return
+		}
+	} else {
+		// 通过任务接口创建，沿用标题校验、数量上限与幂等键
+		status, raw := callAPI(ctx, a.routes(), http.MethodPost, "/api/tasks",
+			taskCreateRequest{Title: body.Title, Description: body.Description, Tags: body.Tags}, r.Header.Get("Idempotency-Key"))
+		var res struct {
+			ID    int64  `json:"id"`
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(raw, &res)
+		if status != http.StatusCreated {
+			writeJSON(w, status, map[string]string{"error": res.Error})
+			return
+		}
+		taskID = res.ID
+	}
+	addLogAttrs(r, "task_id", taskID, "severity", body.Severity)
+	res, err := a.db.ExecContext(ctx, `INSERT OR IGNORE INTO task_incidents (task_id, severity, triggered_at) VALUES (?, ?, ?)`,
+		taskID, body.Severity, time.Now().Format(time.RFC3339))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		if body.TaskID != nil {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "task is already an incident"})
+			return
+		}
+		// 幂等重试命中已创建的事故，不再重复寻呼
+		a.writeIncidentTask(w, r, taskID, http.StatusCreated)
+		return
+	}
+	// 使列表 ETag 失效，使看板刷新后显示事故泳道
+	_, _ = a.db.ExecContext(ctx, `UPDATE tasks SET version = version + 1, updated_at = ? WHERE id = ?`, time.Now().Format(time.RFC3339), taskID)
+	a.reqLogger(r).Warn("事故已触发")
+	a.pageIncident(ctx, taskID, "trigger")
+	a.writeIncidentTask(w, r, taskID, http.StatusCreated)
+}
+
+// handleIncidentItem 处理 POST /api/incidents/{id}/acknowledge 与 /resolve，id 为任务编号；
+// 解决事故会同时将任务移到「已完成」。重复确认或解决保持首次记录的时间
+func (a *App) handleIncidentItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	rest := strings.TrimPrefix(r.URL.Path, "/api/incidents/")
+	if rest == "stats" {
+		a.handleIncidentStats(w, r)
+		return
+	}
+	idStr, action, _ := strings.Cut(rest, "/")
+	taskID, err := parseInt64(idStr)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	addLogAttrs(r, "task_id", taskID)
+	if action != "acknowledge" && action != "resolve" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	inc, err := a.fetchIncident(ctx, taskID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if inc == nil {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "incident not found"})
+		return
+	}
+	now := time.Now().Format(time.RFC3339)
+	if action == "acknowledge" {
+		if inc.ResolvedAt != nil {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "incident already resolved"})
+			return
+		}
+		if inc.AcknowledgedAt == nil {
+			if _, err := a.db.ExecContext(ctx, `UPDATE task_incidents SET acknowledged_at = ? WHERE task_id = ? AND acknowledged_at IS NULL`, now, taskID); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			if _, err := a.db.ExecContext(ctx, `UPDATE tasks SET version = version + 1, updated_at = ? WHERE id = ?`, now, taskID); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			a.reqLogger(r).Info("事故已确认")
+			a.pageIncident(ctx, taskID, "acknowledge")
+		}
+		a.writeIncidentTask(w, r, taskID, http.StatusOK)
+		return
+	}
+	if inc.ResolvedAt == nil {
+		if _, err := a.db.ExecContext(ctx, `UPDATE task_incidents SET resolved_at = ? WHERE task_id = ? AND resolved_at IS NULL`, now, taskID); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		res, err := a.db.ExecContext(ctx, `
+			UPDATE tasks SET status = ?, updated_at = ?, version = version + 1,
+				status_changed_at = CASE WHEN status <> ? THEN ? ELSE status_changed_at END
+			WHERE id = ?
+		`, "已完成", now, "已完成", now, taskID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if n, _ := res.RowsAffected(); n > 0 {
+			a.onTaskCompleted(ctx, taskID)
+		}
+		a.reqLogger(r).Info("事故已解决")
+		a.pageIncident(ctx, taskID, "resolve")
+	}
+	a.writeIncidentTask(w, r, taskID, http.StatusOK)
+}
+
+// writeIncidentTask 返回带事故信息的任务详情
+func (a *App) writeIncidentTask(w http.ResponseWriter, r *http.Request, taskID int64, status int) {
+	t, err := a.fetchTaskDetail(r.Context(), taskID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	w.Header().Set("ETag", versionETag(t.Version))
+	writeJSON(w, status, t)
+}
+
+// validIncidentSeverity 判断严重程度是否有效
+func validIncidentSeverity(s string) bool {
+	for _, v := range incidentSeverities {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// pageIncident 向寻呼渠道发送事故的触发、确认或解决；结果记录在事故的 paged_at 与 page_error 中
+func (a *App) pageIncident(ctx context.Context, taskID int64, action string) {
+	c := a.cfg.Paging
+	if !c.enabled() {
+		return
+	}
+	t, err := a.fetchTaskDetail(ctx, taskID)
+	if err != nil || t.Incident == nil {
+		a.logger.Error("读取事故失败", "task_id", taskID, "err", err)
+		return
+	}
+	client := &http.Client{Timeout: reminderHTTPTimeout}
+	err = sendPage(ctx, client, c, action, t)
+	a.deliveries.record("paging_"+c.Format, err)
+	now := time.Now().Format(time.RFC3339)
+	if err != nil {
+		a.logger.Error("事故寻呼失败", "task_id", taskID, "action", action, "format", c.Format, "err", err)
+		_, _ = a.db.ExecContext(ctx, `UPDATE task_incidents SET page_error = ? WHERE task_id = ?`, err.Error(), taskID)
+		return
+	}
+	a.logger.Info("已发送事故寻呼", "task_id", taskID, "action", action, "format", c.Format)
+	if action == "trigger" {
+		_, _ = a.db.ExecContext(ctx, `UPDATE task_incidents SET paged_at = ?, page_error = '' WHERE task_id = ?`, now, taskID)
+	}
+}
+
+// sendPage 按配置的格式发送一次寻呼请求；同一任务的告警使用固定的去重键，确认与解决据此关联到原告警
+func sendPage(ctx context.Context, client *http.Client, c PagingConfig, action string, t Task) error {
+	source := c.Source
+	if source == "" {
+		source = "task-board"
+	}
+	dedupKey := fmt.Sprintf("%s-TB-%d", source, t.ID)
+	summary := fmt.Sprintf("TB-%d %s", t.ID, t.Title)
+	switch c.Format {
+	case pagingPagerDuty:
+		event := map[string]any{"routing_key": c.Key, "event_action": action, "dedup_key": dedupKey}
+		if action == "trigger" {
+			event["payload"] = map[string]any{
+				"summary":   summary,
+				"source":    source,
+				"severity":  t.Incident.Severity,
+				"timestamp": t.Incident.TriggeredAt.Format(time.RFC3339),
+				"custom_details": map[string]any{
+					"task_id":     t.ID,
+					"description": t.Description,
+					"tags":        t.Tags,
+				},
+			}
+		}
+		return postJSON(ctx, client, c.endpoint(), nil, event)
+	case pagingOpsgenie:
+		header := http.Header{"Authorization": {"GenieKey " + c.Key}}
+		switch action {
+		case "trigger":
+			message := []rune(summary)
+			if len(message) > 130 {
+				message = message[:130]
+			}
+			return postJSON(ctx, client, c.endpoint(), header, map[string]any{
+				"message":     string(message),
+				"alias":       dedupKey,
+				"description": t.Description,
+				"priority":    opsgeniePriorities[t.Incident.Severity],
+				"source":      source,
+				"tags":        t.Tags,
+			})
+		case "acknowledge":
+			return postJSON(ctx, client, c.endpoint()+"/"+url.PathEscape(dedupKey)+"/acknowledge?identifierType=alias", header, map[string]any{"source": source})
+		default:
+			return postJSON(ctx, client, c.endpoint()+"/"+url.PathEscape(dedupKey)+"/close?identifierType=alias", header, map[string]any{"source": source})
+		}
+	default:
+		events := map[string]string{"trigger": "incident.triggered", "acknowledge": "incident.acknowledged", "resolve": "incident.resolved"}
+		return postJSON(ctx, client, c.endpoint(), nil, map[string]any{"event": events[action], "dedup_key": dedupKey, "task": t})
+	}
+}
+
+// handleIncidentStats 处理 GET /api/incidents/stats：按严重程度统计事故数量、MTTA 与 MTTR，可用 since=YYYY-MM-DD 限定触发时间
+func (a *App) handleIncidentStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	resp := incidentStatsResponse{BySeverity: map[string]incidentStats{}}
+	query := `SELECT severity, triggered_at, acknowledged_at, resolved_at FROM task_incidents`
+	args := []any{}
+	if v := r.URL.Query().Get("since"); v != "" {
+		since, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "since must be YYYY-MM-DD"})
+			return
+		}
+		resp.Since = &since
+		query += ` WHERE triggered_at >= ?`
+		args = append(args, since.Format(time.RFC3339))
+	}
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+	// 键为空串的一组为全部事故
+	type bucket struct {
+		stats           incidentStats
+		ack, resolution []float64
+	}
+	buckets := map[string]*bucket{"": {}}
+	for rows.Next() {
+		var severity, triggered string
+		var acked, resolved sql.NullString
+		if err := rows.Scan(&severity, &triggered, &acked, &resolved); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		start, _ := time.Parse(time.RFC3339, triggered)
+		if buckets[severity] == nil {
+			buckets[severity] = &bucket{}
+		}
+		for _, b := range []*bucket{buckets[""], buckets[severity]} {
+			b.stats.Count++
+			if t := parseOptionalTime(acked); t != nil {
+				b.stats.Acknowledged++
+				b.ack = append(b.ack, t.Sub(start).Seconds())
+			}
+			if t := parseOptionalTime(resolved); t != nil {
+				b.stats.Resolved++
+				b.resolution = append(b.resolution, t.Sub(start).Seconds())
+			} else {
+				b.stats.Open++
+			}
+		}
+	}
+	if err := rows.Err(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	for key, b := range buckets {
+		b.stats.MTTASeconds, b.stats.MTTRSeconds = meanSeconds(b.ack), meanSeconds(b.resolution)
+		if key == "" {
+			resp.Total = b.stats
+		} else {
+			resp.BySeverity[key] = b.stats
+		}
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// meanSeconds 返回平均值（保留整数秒）；没有样本时返回 nil
+func meanSeconds(values []float64) *float64 {
+	if len(values) == 0 {
+		return nil
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	mean := float64(int64(sum / float64(len(values))))
+	return &mean
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// fieldNamingHeader 为按请求选择字段命名风格的请求头，取值 snake 或 camel
+const fieldNamingHeader = "X-Taskboard-Field-Naming"
+
+// 字段命名风格
+const (
+	namingSnake = "snake"
+	namingCamel = "camel"
+)
+
+// JSONConfig 为 JSON 响应的编码配置：Pretty 为 true 时缩进输出；FieldNaming 为 camel 时字段名改为 camelCase，
+// 供约定不同的第三方客户端直接对接。单个请求可用 ?pretty=1 与 X-Taskboard-Field-Naming 请求头覆盖
+type JSONConfig struct {
+	Pretty      bool   `yaml:"pretty"`
+	FieldNaming string `yaml:"field_naming"`
+}
+
+// validate 校验字段命名风格
+func (c JSONConfig) validate() error {
+	switch c.FieldNaming {
+	case "", namingSnake, namingCamel:
+		return nil
+	}
+	return fmt.Errorf("json.field_naming 必须为 snake 或 camel: %q", c.FieldNaming)
+}
+
+// jsonOptions 为单个响应的编码选项
+type jsonOptions struct {
+	pretty bool
+	camel  bool
+	// locale 为错误消息的语言，空串表示保持原文
+	locale string
+}
+
+// jsonOptionsWriter 携带当前请求的编码选项，由 writeJSON 沿 Unwrap 链查找
+type jsonOptionsWriter struct {
+	http.ResponseWriter
+	opts jsonOptions
+}
+
+// Unwrap 返回底层 ResponseWriter
+func (w *jsonOptionsWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// withJSONOptions 根据配置与请求参数确定 JSON 响应的编码选项与错误消息的语言；未经过该中间件的调用（如进程内 callAPI）使用默认编码
+func (a *App) withJSONOptions(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts := jsonOptions{pretty: a.cfg.JSON.Pretty, camel: a.cfg.JSON.FieldNaming == namingCamel}
+		if v := r.URL.Query().Get("pretty"); v != "" {
+			opts.pretty = v == "1" || strings.ToLower(v) == "true"
+		}
+		switch strings.ToLower(strings.TrimSpace(r.Header.Get(fieldNamingHeader))) {
+		case namingSnake:
+			opts.camel = false
+		case namingCamel:
+			opts.camel = true
+		}
+		opts.locale = apiLocale(r)
+		// 同一资源的 ETag 不随命名风格与消息语言变化，共享缓存需要按这些请求头区分
+		w.Header().Add("Vary", fieldNamingHeader+", Accept-Language")
+		next.ServeHTTP(&jsonOptionsWriter{ResponseWriter: w, opts: opts}, r)
+	})
+}
+
+// responseJSONOptions 沿 Unwrap 链查找当前响应的编码选项
+func responseJSONOptions(w http.ResponseWriter) jsonOptions {
+	for {
+		switch x := w.(type) {
+		case *jsonOptionsWriter:
+			return x.opts
+		case interface{ Unwrap() http.ResponseWriter }:
+			w = x.Unwrap()
+		default:
+			return jsonOptions{}
+		}
+	}
+}
+
+// encodeJSON 按选项编码响应体
+func encodeJSON(v any, opts jsonOptions) ([]byte, error) {
+	if opts.camel {
+		cv, err := camelJSON(reflect.ValueOf(v))
+		if err != nil {
+			return nil, err
+		}
+		v = cv
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	if opts.pretty {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// snakeToCamel 将 snake_case 转为 camelCase，如 task_id → taskId
+func snakeToCamel(s string) string {
+	if !strings.Contains(s, "_") {
+		return s
+	}
+	parts := strings.Split(s, "_")
+	for i := 1; i < len(parts); i++ {
+		if p := parts[i]; p != "" {
+			parts[i] = strings.ToUpper(p[:1]) + p[1:]
+		}
+	}
+	return strings.Join(parts, "")
+}
+
+// orderedJSON 为保持字段顺序的 JSON 对象
+type orderedJSON []orderedField
+
+// orderedField 为 orderedJSON 的一个字段
+type orderedField struct {
+	key   string
+	value any
+}
+
+// MarshalJSON 按字段顺序编码
+func (o orderedJSON) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, f := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		kb, _ := json.Marshal(f.key)
+		buf.Write(kb)
+		buf.WriteByte(':')
+		vb, err := json.Marshal(f.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(vb)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// camelJSON 将 v 转为字段名为 camelCase 的等价结构，规则与 encoding/json 一致（json 标签、omitempty、嵌入结构体）。
+// map[string]any 为代码中拼出的响应对象，其键同样改名；其他 map 的键是数据（状态、标签等），保持不变。
+// 实现了 json.Marshaler 的类型（time.Time、GraphQL 结果等）按其自身编码输出
+func camelJSON(v reflect.Value) (any, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	if (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface || v.Kind() == reflect.Map || v.Kind() == reflect.Slice) && v.IsNil() {
+		return nil, nil
+	}
+	t := v.Type()
+	if t.Implements(jsonMarshalerType) || t.Implements(textMarshalerType) {
+		b, err := json.Marshal(v.Interface())
+		return json.RawMessage(b), err
+	}
+	if v.CanAddr() && reflect.PointerTo(t).Implements(jsonMarshalerType) {
+		b, err := json.Marshal(v.Addr().Interface())
+		return json.RawMessage(b), err
+	}
+	switch v.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		return camelJSON(v.Elem())
+	case reflect.Struct:
+		var out orderedJSON
+		if err := appendCamelFields(&out, v); err != nil {
+			return nil, err
+		}
+		if out == nil {
+			out = orderedJSON{}
+		}
+		return out, nil
+	case reflect.Map:
+		rename := t.Elem().Kind() == reflect.Interface
+		out := make(orderedJSON, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			key, err := mapKeyString(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			val, err := camelJSON(iter.Value())
+			if err != nil {
+				return nil, err
+			}
+			if rename {
+				key = snakeToCamel(key)
+			}
+			out = append(out, orderedField{key: key, value: val})
+		}
+		sort.Slice(out, func(i, j int) bool { return out[i].key < out[j].key })
+		return out, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			b, err := json.Marshal(v.Interface())
+			return json.RawMessage(b), err
+		}
+		out := make([]any, v.Len())
+		for i := range out {
+			val, err := camelJSON(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			out[i] = val
+		}
+		return out, nil
+	}
+	return v.Interface(), nil
+}
+
+// appendCamelFields 追加结构体的导出字段，匿名嵌入的结构体字段提升到外层
+func appendCamelFields(out *orderedJSON, v reflect.Value) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+		fv := v.Field(i)
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				ft, fv = ft.Elem(), fv.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := appendCamelFields(out, fv); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if strings.Contains(","+opts+",", ",omitempty,") && isEmptyJSONValue(fv) {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+		val, err := camelJSON(fv)
+		if err != nil {
+			return err
+		}
+		*out = append(*out, orderedField{key: snakeToCamel(name), value: val})
+	}
+	return nil
+}
+
+// mapKeyString 返回 map 键的 JSON 形式
+func mapKeyString(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if tm, ok := k.Interface().(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported map key type %s", k.Type())
+}
+
+// isEmptyJSONValue 与 encoding/json 的 omitempty 判断一致
+func isEmptyJSONValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// startBackground 启动一个受应用生命周期管理的后台任务；关闭时 ctx 会被取消，Close 会等待其返回。
+// 任务的运行状态记入 a.workers，由 /api/admin/runtime 返回
+func (a *App) startBackground(name string, fn func(ctx context.Context)) {
+	a.bgWG.Add(1)
+	a.workers.started(name)
+	go func() {
+		defer a.bgWG.Done()
+		defer func() {
+			rec := recover()
+			if rec != nil {
+				a.logger.Error("后台任务异常退出", "job", name, "panic", rec)
+			}
+			a.workers.exited(name, rec)
+		}()
+		fn(a.bgCtx)
+	}()
+}
+
+// Close 取消并等待后台任务（最长等待 ctx 截止），随后关闭数据库连接
+func (a *App) Close(ctx context.Context) error {
+	a.bgCancel()
+	done := make(chan struct{})
+	go func() {
+		a.bgWG.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		a.logger.Warn("等待后台任务超时，强制关闭")
+	}
+	return a.db.Close()
+}
+
+// withRequestDeadline 为请求上下文设置截止时间（取服务写超时），超时或客户端断开时取消进行中的数据库查询
+func withRequestDeadline(timeout time.Duration, next http.Handler) http.Handler {
+	if timeout <= 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// warningHeader 为接近软限制时附加在响应上的提示头
+const warningHeader = "X-Taskboard-Warning"
+
+// LimitsConfig 为配额类限制：Warn 为软阈值（超过后响应附带警告头），Max 为硬上限（超过则拒绝写入），0 表示不限制
+type LimitsConfig struct {
+	TasksWarn       int `yaml:"tasks_warn"`
+	TasksMax        int `yaml:"tasks_max"`
+	TagsPerTaskWarn int `yaml:"tags_per_task_warn"`
+	TagsPerTaskMax  int `yaml:"tags_per_task_max"`
+}
+
+// checkTaskLimit 检查看板上再增加 adding 个在板任务是否超限；超过硬上限时写入 422 并返回 false
+func (a *App) checkTaskLimit(ctx context.Context, w http.ResponseWriter, adding int) bool {
+	l := a.cfg.Limits
+	if l.TasksWarn <= 0 && l.TasksMax <= 0 {
+		return true
+	}
+	var active int
+	if err := a.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks WHERE archived = 0`).Scan(&active); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return false
+	}
+	total := active + adding
+	if l.TasksMax > 0 && total > l.TasksMax {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": fmt.Sprintf("task limit reached (%d active, max %d); archive finished tasks first", active, l.TasksMax)})
+		return false
+	}
+	if l.TasksWarn > 0 && total > l.TasksWarn {
+		w.Header().Add(warningHeader, limitWarning("active tasks", total, l.TasksWarn, l.TasksMax))
+	}
+	return true
+}
+
+// checkTagLimit 检查单个任务的标签数量；超过硬上限时写入 400 并返回 false
+func (a *App) checkTagLimit(w http.ResponseWriter, tags []string) bool {
+	l := a.cfg.Limits
+	n := countTags(tags)
+	if l.TagsPerTaskMax > 0 && n > l.TagsPerTaskMax {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("too many tags (%d, max %d)", n, l.TagsPerTaskMax)})
+		return false
+	}
+	if l.TagsPerTaskWarn > 0 && n > l.TagsPerTaskWarn {
+		w.Header().Add(warningHeader, limitWarning("tags on task", n, l.TagsPerTaskWarn, l.TagsPerTaskMax))
+	}
+	return true
+}
+
+// limitWarning 生成警告头内容，如 "active tasks 85 exceeds soft limit 80 (hard limit 100)"
+func limitWarning(what string, n, warn, max int) string {
+	msg := fmt.Sprintf("%s %d exceeds soft limit %d", what, n, warn)
+	if max > 0 {
+		msg += fmt.Sprintf(" (hard limit %d)", max)
+	}
+	return msg
+}
+
+// countTags 统计去重后的非空标签数量
+func countTags(tags []string) int {
+	seen := map[string]bool{}
+	for _, t := range tags {
+		if t = strings.TrimSpace(t); t != "" {
+			seen[t] = true
+		}
+	}
+	return len(seen)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// newLogger 根据级别（debug/info/warn/error）与格式（text/json）创建结构化日志器
+func newLogger(w io.Writer, level, format string) *slog.Logger {
+	var lv slog.Level
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		lv = slog.LevelDebug
+	case "warn", "warning":
+		lv = slog.LevelWarn
+	case "error":
+		lv = slog.LevelError
+	default:
+		lv = slog.LevelInfo
+	}
+	opts := &slog.HandlerOptions{Level: lv}
+	var h slog.Handler
+	if strings.ToLower(strings.TrimSpace(format)) == "json" {
+		h = slog.NewJSONHandler(w, opts)
+	} else {
+		h = slog.NewTextHandler(w, opts)
+	}
+	return slog.New(h).With("app", "task-board")
+}
+
+// requestLog 保存请求级日志器；处理器追加的属性也会出现在访问日志中
+type requestLog struct {
+	l *slog.Logger
+}
+
+// requestLogKey 为请求上下文中 requestLog 的键
+type requestLogKey struct{}
+
+// reqLogger 返回携带请求属性（request_id、task_id 等）的日志器，缺省时退回应用日志器
+func (a *App) reqLogger(r *http.Request) *slog.Logger {
+	if rl, ok := r.Context().Value(requestLogKey{}).(*requestLog); ok {
+		return rl.l
+	}
+	return a.logger
+}
+
+// addLogAttrs 为当前请求后续的所有日志附加属性
+func addLogAttrs(r *http.Request, args ...any) {
+	if rl, ok := r.Context().Value(requestLogKey{}).(*requestLog); ok {
+		rl.l = rl.l.With(args...)
+	}
+}
+
+// newRequestID 生成随机请求 ID
+func newRequestID() string {
+	var b [8]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// statusRecorder 记录响应状态码
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+// WriteHeader 记录状态码后写出
+func (s *statusRecorder) WriteHeader(code int) {
+	s.status = code
+	s.ResponseWriter.WriteHeader(code)
+}
+
+// Unwrap 返回底层 ResponseWriter，使 http.ResponseController 可以使用 Flush 等能力
+func (s *statusRecorder) Unwrap() http.ResponseWriter { return s.ResponseWriter }
+
+// withRequestLogging 为每个请求分配请求 ID（沿用传入的 X-Request-ID），并在结束时记录访问日志
+func (a *App) withRequestLogging(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		reqID := strings.TrimSpace(r.Header.Get("X-Request-ID"))
+		if reqID == "" || len(reqID) > 64 {
+			reqID = newRequestID()
+		}
+		w.Header().Set("X-Request-ID", reqID)
+		rl := &requestLog{l: a.logger.With("request_id", reqID)}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestLogKey{}, rl)))
+		a.metrics.observe(r.Method, r.URL.Path, rec.status, time.Since(start), time.Now())
+		level := slog.LevelDebug
+		if strings.HasPrefix(r.URL.Path, "/api/") || strings.HasPrefix(r.URL.Path, "/public/") {
+			level = slog.LevelInfo
+		}
+		if rec.status >= http.StatusInternalServerError {
+			level = slog.LevelError
+		}
+		rl.l.Log(r.Context(), level, "request",
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", rec.status,
+			"duration_ms", time.Since(start).Milliseconds(),
+		)
+	})
+}
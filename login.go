@@ -0,0 +1,723 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 第三方登录：通过 GitHub、Google 或任意 OIDC 提供方（授权码流程）登录，无需另设密码。
+// 首次登录时按「提供方 + 账号 ID」创建本地用户；提供方确认过的邮箱与已有用户相同时关联到该用户，
+// 因此同一个人用 GitHub 与 Google 登录会得到同一个本地用户。登录后以 HttpOnly Cookie 保持会话，
+// 会话令牌在库中只保存 SHA-256。login.required 开启后网页需先登录，/api/ 下的请求需携带会话、API 密钥或签名
+
+// 登录提供方 ID
+const (
+	providerGitHub = "github"
+	providerGoogle = "google"
+	providerOIDC   = "oidc"
+)
+
+// 会话与登录状态 Cookie
+const (
+	sessionCookie = "tb_session"
+	oauthCookie   = "tb_oauth"
+)
+
+// oauthStateTTL 为从跳转到提供方到回调之间允许的最长时间
+const oauthStateTTL = 10 * time.Minute
+
+// loginPublicPaths 为 login.required 开启后仍无需登录的路径前缀：登录流程本身、分享页、快捷操作、公开接口与大屏登记
+var loginPublicPaths = []string{"/login", "/auth/", "/share/", "/a/", "/public/", "/readyz", "/display"}
+
+// LoginConfig 为第三方登录配置；BaseURL 为对外访问地址（回调地址为 <base_url>/auth/<提供方>/callback），
+// 为空时按请求的协议与主机推断；AllowedDomains 非空时只允许这些邮箱域名的用户登录
+type LoginConfig struct {
+	BaseURL        string      `yaml:"base_url"`
+	Required       bool        `yaml:"required"`
+	SessionTTL     Duration    `yaml:"session_ttl"`
+	AllowedDomains []string    `yaml:"allowed_domains"`
+	GitHub         OAuthClient `yaml:"github"`
+	Google         OAuthClient `yaml:"google"`
+	OIDC           OAuthClient `yaml:"oidc"`
+}
+
+// OAuthClient 为在提供方登记的应用；Issuer 与 Name 仅用于通用 OIDC（Name 为登录按钮上的名称）
+type OAuthClient struct {
+	ClientID     string `yaml:"client_id"`
+	ClientSecret string `yaml:"client_secret"`
+	Issuer       string `yaml:"issuer"`
+	Name         string `yaml:"name"`
+}
+
+// validate 校验登录配置
+func (c LoginConfig) validate() error {
+	for _, item := range []struct {
+		key string
+		cl  OAuthClient
+	}{{providerGitHub, c.GitHub}, {providerGoogle, c.Google}, {providerOIDC, c.OIDC}} {
+		if (item.cl.ClientID == "") != (item.cl.ClientSecret == "") {
+			return fmt.Errorf("login.%s: client_id 与 client_secret 须同时配置", item.key)
+		}
+	}
+	if c.OIDC.ClientID != "" {
+		u, err := url.Parse(c.OIDC.Issuer)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("login.oidc.issuer 无效: %q", c.OIDC.Issuer)
+		}
+	}
+	if c.BaseURL != "" {
+		u, err := url.Parse(c.BaseURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("login.base_url 无效: %q", c.BaseURL)
+		}
+	}
+	if c.Required && len(newOAuthProviders(c)) == 0 {
+		return fmt.Errorf("login.required 已开启但没有配置任何登录提供方")
+	}
+	return nil
+}
+
+// oauthProvider 为一个登录提供方；OIDC 提供方的端点在首次登录时通过发现文档获取
+type oauthProvider struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	clientID     string
+	clientSecret string
+	scopes       string
+	issuer       string
+
+	mu       sync.Mutex
+	authURL  string
+	tokenURL string
+	userURL  string
+}
+
+// newOAuthProviders 返回已配置的登录提供方
+func newOAuthProviders(c LoginConfig) []*oauthProvider {
+	var out []*oauthProvider
+	if c.GitHub.ClientID != "" {
+		out = append(out, &oauthProvider{
+			ID: providerGitHub, Name: "GitHub", clientID: c.GitHub.ClientID, clientSecret: c.GitHub.ClientSecret,
+			scopes:   "read:user user:email",
+			authURL:  "https://github.com/login/oauth/authorize",
+			tokenURL: "https://github.com/login/oauth/access_token",
+			userURL:  "https://api.github.com/user",
+		})
+	}
+	if c.Google.ClientID != "" {
+		out = append(out, &oauthProvider{
+			ID: providerGoogle, Name: "Google", clientID: c.Google.ClientID, clientSecret: c.Google.ClientSecret,
+			scopes: "openid email profile", issuer: "https://accounts.google.com",
+		})
+	}
+	if c.OIDC.ClientID != "" {
+		name := c.OIDC.Name
+		if name == "" {
+			name = "SSO"
+		}
+		out = append(out, &oauthProvider{
+			ID: providerOIDC, Name: name, clientID: c.OIDC.ClientID, clientSecret: c.OIDC.ClientSecret,
+			scopes: "openid email profile", issuer: strings.TrimRight(c.OIDC.Issuer, "/"),
+		})
+	}
+	return out
+}
+
+// oauthHTTPTimeout 为访问提供方接口的超时
+const oauthHTTPTimeout = 10 * time.Second
+
+// getJSON 以 GET 请求 JSON 接口，bearer 非空时附带访问令牌
+func getJSON(ctx context.Context, client *http.Client, endpoint, bearer string, v any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if bearer != "" {
+		req.Header.Set("Authorization", "Bearer "+bearer)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s 返回 %s", endpoint, resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(v)
+}
+
+// endpoints 返回授权、令牌与用户信息端点；OIDC 提供方首次调用时读取发现文档并缓存
+func (p *oauthProvider) endpoints(ctx context.Context, client *http.Client) (auth, token, user string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.authURL == "" {
+		var doc struct {
+			AuthorizationEndpoint string `json:"authorization_endpoint"`
+			TokenEndpoint         string `json:"token_endpoint"`
+			UserinfoEndpoint      string `json:"userinfo_endpoint"`
+		}
+		if err := getJSON(ctx, client, p.issuer+"/.well-known/openid-configuration", "", &doc); err != nil {
+			return "", "", "", fmt.Errorf("读取 OIDC 发现文档失败: %w", err)
+		}
+		if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.UserinfoEndpoint == "" {
+			return "", "", "", fmt.Errorf("OIDC 发现文档缺少端点")
+		}
+		p.authURL, p.tokenURL, p.userURL = doc.AuthorizationEndpoint, doc.TokenEndpoint, doc.UserinfoEndpoint
+	}
+	return p.authURL, p.tokenURL, p.userURL, nil
+}
+
+// exchangeCode 用授权码换取访问令牌
+func (p *oauthProvider) exchangeCode(ctx context.Context, client *http.Client, tokenURL, code, redirectURI string) (string, error) {
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {redirectURI},
+		"client_id":     {p.clientID},
+		"client_secret": {p.clientSecret},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, tokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	var body struct {
+		AccessToken      string `json:"access_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&body); err != nil {
+		return "", fmt.Errorf("令牌端点返回 %s", resp.Status)
+	}
+	if body.AccessToken == "" {
+		return "", fmt.Errorf("换取令牌失败: %s %s", body.Error, body.ErrorDescription)
+	}
+	return body.AccessToken, nil
+}
+
+// externalIdentity 为提供方返回的账号信息；EmailVerified 为提供方确认过邮箱归属
+type externalIdentity struct {
+	Provider      string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	AvatarURL     string
+}
+
+// fetchIdentity 用访问令牌读取账号信息
+func (p *oauthProvider) fetchIdentity(ctx context.Context, client *http.Client, userURL, accessToken string) (externalIdentity, error) {
+	id := externalIdentity{Provider: p.ID}
+	if p.ID == providerGitHub {
+		var u struct {
+			ID        int64  `json:"id"`
+			Login     string `json:"login"`
+			Name      string `json:"name"`
+			Email     string `json:"email"`
+			AvatarURL string `json:"avatar_url"`
+		}
+		if err := getJSON(ctx, client, userURL, accessToken, &u); err != nil {
+			return id, err
+		}
+		id.Subject, id.Name, id.AvatarURL = strconv.FormatInt(u.ID, 10), u.Name, u.AvatarURL
+		if id.Name == "" {
+			id.Name = u.Login
+		}
+		// 公开邮箱可能为空或未设置，改取已验证的主邮箱
+		var emails []struct {
+			Email    string `json:"email"`
+			Primary  bool   `json:"primary"`
+			Verified bool   `json:"verified"`
+		}
+		if err := getJSON(ctx, client, strings.TrimSuffix(userURL, "/user")+"/user/emails", accessToken, &emails); err == nil {
+			for _, e := range emails {
+				if e.Primary && e.Verified {
+					id.Email, id.EmailVerified = e.Email, true
+				}
+			}
+		}
+		return id, nil
+	}
+	var u struct {
+		Sub               string `json:"sub"`
+		Email             string `json:"email"`
+		EmailVerified     any    `json:"email_verified"`
+		Name              string `json:"name"`
+		PreferredUsername string `json:"preferred_username"`
+		Picture           string `json:"picture"`
+	}
+	if err := getJSON(ctx, client, userURL, accessToken, &u); err != nil {
+		return id, err
+	}
+	if u.Sub == "" {
+		return id, fmt.Errorf("用户信息缺少 sub")
+	}
+	id.Subject, id.Email, id.AvatarURL = u.Sub, u.Email, u.Picture
+	// 部分提供方以字符串 "true" 返回 email_verified
+	id.EmailVerified = u.EmailVerified == true || u.EmailVerified == "true"
+	id.Name = u.Name
+	if id.Name == "" {
+		id.Name = u.PreferredUsername
+	}
+	return id, nil
+}
+
+// User 为本地用户
+type User struct {
+	ID          int64      `json:"id"`
+	Name        string     `json:"name"`
+	Email       string     `json:"email"`
+	AvatarURL   string     `json:"avatar_url"`
+	Providers   []string   `json:"providers"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
+
+// fetchUser 读取用户及其关联的提供方
+func (a *App) fetchUser(ctx context.Context, id int64) (User, error) {
+	var u User
+	var created string
+	var last sql.NullString
+	err := a.db.QueryRowContext(ctx, `SELECT id, name, email, avatar_url, created_at, last_login_at FROM users WHERE id = ?`, id).
+		Scan(&u.ID, &u.Name, &u.Email, &u.AvatarURL, &created, &last)
+	if err != nil {
+		return u, err
+	}
+	u.CreatedAt, _ = time.Parse(time.RFC3339, created)
+	u.LastLoginAt = parseOptionalTime(last)
+	rows, err := a.db.QueryContext(ctx, `SELECT provider FROM user_identities WHERE user_id = ? ORDER BY provider`, id)
+	if err != nil {
+		return u, err
+	}
+	defer rows.Close()
+	u.Providers = []string{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return u, err
+		}
+		u.Providers = append(u.Providers, p)
+	}
+	return u, rows.Err()
+}
+
+// linkUser 返回账号对应的本地用户：已关联时直接返回，否则按已验证邮箱关联已有用户或新建用户
+func (a *App) linkUser(ctx context.Context, ident externalIdentity, now time.Time) (int64, error) {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	ts := now.Format(time.RFC3339)
+	var userID int64
+	err = tx.QueryRowContext(ctx, `SELECT user_id FROM user_identities WHERE provider = ? AND subject = ?`, ident.Provider, ident.Subject).Scan(&userID)
+	switch {
+	case err == nil:
+		if _, err := tx.ExecContext(ctx, `UPDATE user_identities SET email = ? WHERE provider = ? AND subject = ?`, ident.Email, ident.Provider, ident.Subject); err != nil {
+			return 0, err
+		}
+	case err == sql.ErrNoRows:
+		if ident.Email != "" && ident.EmailVerified {
+			err = tx.QueryRowContext(ctx, `SELECT id FROM users WHERE email = ? COLLATE NOCASE ORDER BY id LIMIT 1`, ident.Email).Scan(&userID)
+			if err != nil && err != sql.ErrNoRows {
+				return 0, err
+			}
+		}
+		if userID == 0 {
+			email := ""
+			if ident.EmailVerified {
+				email = ident.Email
+			}
+			res, err := tx.ExecContext(ctx, `INSERT INTO users (name, email, avatar_url, created_at) VALUES (?, ?, ?, ?)`, ident.Name, email, ident.AvatarURL, ts)
+			if err != nil {
+				return 0, err
+			}
+			userID, _ = res.LastInsertId()
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO user_identities (provider, subject, user_id, email, created_at) VALUES (?, ?, ?, ?, ?)`,
+			ident.Provider, ident.Subject, userID, ident.Email, ts); err != nil {
+			return 0, err
+		}
+	default:
+		return 0, err
+	}
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET last_login_at = ?, name = CASE WHEN name = '' THEN ? ELSE name END,
+		avatar_url = CASE WHEN avatar_url = '' THEN ? ELSE avatar_url END WHERE id = ?`, ts, ident.Name, ident.AvatarURL, userID); err != nil {
+		return 0, err
+	}
+	return userID, tx.Commit()
+}
+
+// hashSessionToken 返回会话令牌的 SHA-256（十六进制）
+func hashSessionToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// createSession 为用户创建会话并清理已过期的会话，返回令牌原文
+func (a *App) createSession(ctx context.Context, userID int64, now time.Time, ttl time.Duration) (string, error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", err
+	}
+	token := hex.EncodeToString(raw)
+	if _, err := a.db.ExecContext(ctx, `DELETE FROM user_sessions WHERE expires_at <= ?`, now.Format(time.RFC3339)); err != nil {
+		return "", err
+	}
+	_, err := a.db.ExecContext(ctx, `INSERT INTO user_sessions (token_hash, user_id, created_at, expires_at) VALUES (?, ?, ?, ?)`,
+		hashSessionToken(token), userID, now.Format(time.RFC3339), now.Add(ttl).Format(time.RFC3339))
+	return token, err
+}
+
+// sessionTTL 返回会话有效期，缺省 30 天
+func (c LoginConfig) sessionTTL() time.Duration {
+	if d := c.SessionTTL.Std(); d > 0 {
+		return d
+	}
+	return 30 * 24 * time.Hour
+}
+
+// userCtxKey 为请求上下文中保存已登录用户 ID 的键
+type userCtxKey struct{}
+
+// currentUserID 返回已登录用户的 ID，未登录时返回 0
+func currentUserID(r *http.Request) int64 {
+	id, _ := r.Context().Value(userCtxKey{}).(int64)
+	return id
+}
+
+// sessionUserID 根据会话 Cookie 返回用户 ID，没有有效会话时返回 0
+func (a *App) sessionUserID(r *http.Request, now time.Time) (int64, error) {
+	c, err := r.Cookie(sessionCookie)
+	if err != nil || c.Value == "" {
+		return 0, nil
+	}
+	var userID int64
+	err = a.db.QueryRowContext(r.Context(), `SELECT user_id FROM user_sessions WHERE token_hash = ? AND expires_at > ?`,
+		hashSessionToken(c.Value), now.Format(time.RFC3339)).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return userID, err
+}
+
+// loginRequiredFor 判断开启 login.required 后该路径是否需要登录
+func loginRequiredFor(path string) bool {
+	for _, p := range loginPublicPaths {
+		if path == p || strings.HasPrefix(path, p) {
+			return false
+		}
+	}
+	return !slices.Contains(apiKeyPublicPaths, path)
+}
+
+// withSessions 识别会话 Cookie 中的登录用户；login.required 开启时，未登录且未携带 API 密钥或签名的请求
+// 对接口返回 401，对网页跳转到登录页；带 Authorization 头的请求交给 withAPIKeys，它只放行校验通过的 Bearer 密钥
+func (a *App) withSessions(next http.Handler) http.Handler {
+	if len(a.oauth) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		userID, err := a.sessionUserID(r, time.Now())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if userID != 0 {
+			addLogAttrs(r, "user_id", userID)
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userCtxKey{}, userID)))
+			return
+		}
+		if a.cfg.Login.Required && loginRequiredFor(r.URL.Path) && signedClient(r) == "" && r.Header.Get("Authorization") == "" && !hasDisplayCookie(r) && !a.guestReadable(r) {
+			if strings.HasPrefix(r.URL.Path, "/api/") {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "login required"})
+				return
+			}
+			http.Redirect(w, r, "/login?return="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// safeReturnPath 只接受站内相对路径作为登录后的跳转目标
+func safeReturnPath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.Contains(p, `\`) {
+		return "/"
+	}
+	return p
+}
+
+// loginBaseURL 返回回调地址使用的对外地址
+func (a *App) loginBaseURL(r *http.Request) string {
+	if a.cfg.Login.BaseURL != "" {
+		return strings.TrimRight(a.cfg.Login.BaseURL, "/")
+	}
+	return requestOrigin(r)
+}
+
+// secureCookies 判断是否应为 Cookie 设置 Secure
+func (a *App) secureCookies(r *http.Request) bool {
+	return r.TLS != nil || strings.HasPrefix(a.cfg.Login.BaseURL, "https://")
+}
+
+// oauthProviderByID 返回已配置的提供方
+func (a *App) oauthProviderByID(id string) *oauthProvider {
+	for _, p := range a.oauth {
+		if p.ID == id {
+			return p
+		}
+	}
+	return nil
+}
+
+// handleAuth 处理 /auth/ 下的登录流程：GET /auth/providers 列出提供方，GET /auth/{提供方}/login 跳转到提供方，
+// GET /auth/{提供方}/callback 完成登录，POST /auth/logout 退出登录
+func (a *App) handleAuth(w http.ResponseWriter, r *http.Request) {
+	if len(a.oauth) == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	rest := strings.TrimPrefix(r.URL.Path, "/auth/")
+	switch rest {
+	case "providers":
+		writeJSON(w, http.StatusOK, map[string]any{"items": a.oauth})
+		return
+	case "logout":
+		a.handleLogout(w, r)
+		return
+	}
+	id, action, _ := strings.Cut(rest, "/")
+	p := a.oauthProviderByID(id)
+	if p == nil || (action != "login" && action != "callback") {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	if action == "login" {
+		a.handleLoginRedirect(w, r, p)
+		return
+	}
+	a.handleLoginCallback(w, r, p)
+}
+
+// handleLoginRedirect 生成 state 并跳转到提供方的授权页
+func (a *App) handleLoginRedirect(w http.ResponseWriter, r *http.Request, p *oauthProvider) {
+	client := &http.Client{Timeout: oauthHTTPTimeout}
+	authURL, _, _, err := p.endpoints(r.Context(), client)
+	if err != nil {
+		a.reqLogger(r).Error("登录跳转失败", "provider", p.ID, "err", err)
+		a.renderLoginPage(w, r, http.StatusBadGateway, "暂时无法连接 "+p.Name+"，请稍后再试")
+		return
+	}
+	raw := make([]byte, 16)
+	_, _ = rand.Read(raw)
+	state := hex.EncodeToString(raw)
+	http.SetCookie(w, &http.Cookie{
+		Name: oauthCookie, Value: state + "|" + safeReturnPath(r.URL.Query().Get("return")), Path: "/auth/",
+		MaxAge: int(oauthStateTTL.Seconds()), HttpOnly: true, Secure: a.secureCookies(r), SameSite: http.SameSiteLaxMode,
+	})
+	q := url.Values{
+		"response_type": {"code"},
+		"client_id":     {p.clientID},
+		"redirect_uri":  {a.loginBaseURL(r) + "/auth/" + p.ID + "/callback"},
+		"scope":         {p.scopes},
+		"state":         {state},
+	}
+	sep := "?"
+	if strings.Contains(authURL, "?") {
+		sep = "&"
+	}
+	http.Redirect(w, r, authURL+sep+q.Encode(), http.StatusFound)
+}
+
+// handleLoginCallback 校验 state、换取令牌、读取账号并关联本地用户，成功后写入会话 Cookie 并跳回原页面
+func (a *App) handleLoginCallback(w http.ResponseWriter, r *http.Request, p *oauthProvider) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	if e := q.Get("error"); e != "" {
+		a.renderLoginPage(w, r, http.StatusUnauthorized, "登录已取消（"+e+"）")
+		return
+	}
+	c, err := r.Cookie(oauthCookie)
+	state, returnPath, _ := strings.Cut(valueOr(c, err), "|")
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(q.Get("state"))) != 1 || q.Get("code") == "" {
+		a.renderLoginPage(w, r, http.StatusBadRequest, "登录请求已失效，请重新登录")
+		return
+	}
+	http.SetCookie(w, &http.Cookie{Name: oauthCookie, Path: "/auth/", MaxAge: -1, HttpOnly: true, Secure: a.secureCookies(r)})
+	client := &http.Client{Timeout: oauthHTTPTimeout}
+	_, tokenURL, userURL, err := p.endpoints(ctx, client)
+	var ident externalIdentity
+	if err == nil {
+		var accessToken string
+		accessToken, err = p.exchangeCode(ctx, client, tokenURL, q.Get("code"), a.loginBaseURL(r)+"/auth/"+p.ID+"/callback")
+		if err == nil {
+			ident, err = p.fetchIdentity(ctx, client, userURL, accessToken)
+		}
+	}
+	if err != nil {
+		a.reqLogger(r).Warn("第三方登录失败", "provider", p.ID, "err", err)
+		a.renderLoginPage(w, r, http.StatusBadGateway, p.Name+" 登录失败，请稍后再试")
+		return
+	}
+	if domains := a.cfg.Login.AllowedDomains; len(domains) > 0 {
+		_, domain, _ := strings.Cut(strings.ToLower(ident.Email), "@")
+		if !ident.EmailVerified || !slices.Contains(domains, domain) {
+			a.reqLogger(r).Warn("邮箱域名不允许登录", "provider", p.ID, "email", ident.Email)
+			a.renderLoginPage(w, r, http.StatusForbidden, "该账号的邮箱不在允许登录的域名内")
+			return
+		}
+	}
+	now := time.Now().Truncate(time.Second)
+	userID, err := a.linkUser(ctx, ident, now)
+	if err == nil {
+		var token string
+		if token, err = a.createSession(ctx, userID, now, a.cfg.Login.sessionTTL()); err == nil {
+			http.SetCookie(w, &http.Cookie{
+				Name: sessionCookie, Value: token, Path: "/", MaxAge: int(a.cfg.Login.sessionTTL().Seconds()),
+				HttpOnly: true, Secure: a.secureCookies(r), SameSite: http.SameSiteLaxMode,
+			})
+		}
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	a.reqLogger(r).Info("用户已登录", "provider", p.ID, "user_id", userID)
+	http.Redirect(w, r, safeReturnPath(returnPath), http.StatusFound)
+}
+
+// valueOr 返回 Cookie 的值，读取失败时返回空串
+func valueOr(c *http.Cookie, err error) string {
+	if err != nil {
+		return ""
+	}
+	return c.Value
+}
+
+// handleLogout 删除当前会话并清除 Cookie，之后跳转到登录页
+func (a *App) handleLogout(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	if c, err := r.Cookie(sessionCookie); err == nil && c.Value != "" {
+		if _, err := a.db.ExecContext(r.Context(), `DELETE FROM user_sessions WHERE token_hash = ?`, hashSessionToken(c.Value)); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	http.SetCookie(w, &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1, HttpOnly: true, Secure: a.secureCookies(r)})
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+// handleMe 处理 GET /api/me，返回当前登录的用户；未登录时返回 401
+func (a *App) handleMe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	id := currentUserID(r)
+	if id == 0 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "not logged in"})
+		return
+	}
+	u, err := a.fetchUser(r.Context(), id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, u)
+}
+
+// loginPage 为登录页展示的内容
+type loginPage struct {
+	Providers []*oauthProvider
+	Return    string
+	User      *User
+	Message   string
+}
+
+// handleLoginPage 处理 GET /login：未登录时列出提供方，已登录时显示当前用户与退出按钮
+func (a *App) handleLoginPage(w http.ResponseWriter, r *http.Request) {
+	if len(a.oauth) == 0 {
+		http.NotFound(w, r)
+		return
+	}
+	a.renderLoginPage(w, r, http.StatusOK, "")
+}
+
+// renderLoginPage 渲染登录页，message 非空时显示为提示
+func (a *App) renderLoginPage(w http.ResponseWriter, r *http.Request, code int, message string) {
+	page := loginPage{Providers: a.oauth, Return: safeReturnPath(r.URL.Query().Get("return")), Message: message}
+	if id := currentUserID(r); id != 0 {
+		if u, err := a.fetchUser(r.Context(), id); err == nil {
+			page.User = &u
+		}
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	if err := loginPageTmpl.Execute(w, page); err != nil {
+		a.reqLogger(r).Warn("渲染登录页失败", "err", err)
+	}
+}
+
+var loginPageTmpl = template.Must(template.New("login").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>登录 - 任务看板</title>
+<style>
+body{font-family:system-ui,-apple-system,"PingFang SC","Microsoft YaHei",sans-serif;margin:0;background:#f5f6f8;color:#222}
+main{max-width:360px;margin:80px auto;padding:24px;background:#fff;border-radius:8px;box-shadow:0 1px 2px rgba(0,0,0,.06)}
+h1{font-size:20px;margin:0 0 20px}
+a.provider,button{display:block;width:100%;box-sizing:border-box;padding:12px;margin-bottom:12px;font-size:16px;text-align:center;text-decoration:none;border:0;border-radius:6px;background:#4f46e5;color:#fff}
+.message{background:#fef2f2;border:1px solid #fecaca;border-radius:4px;padding:8px 12px;font-size:14px;margin-bottom:16px}
+.user{margin-bottom:16px}
+</style>
+</head>
+<body>
+<main>
+<h1>任务看板</h1>
+{{with .Message}}<div class="message">{{.}}</div>{{end}}
+{{if .User}}
+<div class="user">已登录：{{.User.Name}}{{with .User.Email}}（{{.}}）{{end}}</div>
+<a class="provider" href="/">进入看板</a>
+<form method="post" action="/auth/logout"><button type="submit">退出登录</button></form>
+{{else}}
+{{range .Providers}}<a class="provider" href="/auth/{{.ID}}/login?return={{$.Return}}">使用 {{.Name}} 登录</a>
+{{end}}
+{{end}}
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLoginRequiredRejectsNonBearerAuthorization(t *testing.T) {
+	app := newTestApp(t, func(c *Config) {
+		c.Login.Required = true
+		c.Login.GitHub.ClientID = "id"
+		c.Login.GitHub.ClientSecret = "secret"
+	})
+	app.oauth = newOAuthProviders(app.cfg.Login)
+	access, err := newAccessPolicy(app.cfg.Access)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := app.withSessions(app.withAPIKeys(access, app.routes()))
+
+	for _, tc := range []struct {
+		method, auth string
+	}{
+		{http.MethodGet, ""},
+		{http.MethodGet, "Basic eDp5"},
+		{http.MethodGet, "Bearer "},
+		{http.MethodGet, "Bearer"},
+		{http.MethodPost, "Basic eDp5"},
+		{http.MethodPost, "Bearer "},
+	} {
+		req := httptest.NewRequest(tc.method, "/api/tasks", strings.NewReader(`{"title":"x"}`))
+		if tc.auth != "" {
+			req.Header.Set("Authorization", tc.auth)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s /api/tasks with Authorization %q = %d, want 401: %s", tc.method, tc.auth, rec.Code, rec.Body.String())
+		}
+	}
+}
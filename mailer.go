@@ -0,0 +1,336 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/quotedprintable"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// SMTPConfig 为邮件通知配置；Host 为空表示关闭。端口 465 使用隐式 TLS，其他端口在服务器支持时升级 STARTTLS
+type SMTPConfig struct {
+	Host     string   `yaml:"host"`
+	Port     int      `yaml:"port"`
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	From     string   `yaml:"from"`
+	To       []string `yaml:"to"`
+	// DigestHour 为每日摘要的发送时刻（本地时间 0-23），-1 表示不发送摘要
+	DigestHour int `yaml:"digest_hour"`
+}
+
+// smtpDialTimeout 为连接邮件服务器的超时
+const smtpDialTimeout = 10 * time.Second
+
+// enabled 判断是否配置了邮件通知
+func (c SMTPConfig) enabled() bool { return c.Host != "" }
+
+// addr 返回邮件服务器地址
+func (c SMTPConfig) addr() string { return net.JoinHostPort(c.Host, strconv.Itoa(c.Port)) }
+
+// validate 校验邮件配置
+func (c SMTPConfig) validate() error {
+	if !c.enabled() {
+		return nil
+	}
+	if c.From == "" || len(c.To) == 0 {
+		return fmt.Errorf("smtp.from 与 smtp.to 不能为空")
+	}
+	if c.DigestHour < -1 || c.DigestHour > 23 {
+		return fmt.Errorf("smtp.digest_hour 应为 0-23，-1 表示关闭")
+	}
+	return nil
+}
+
+// mailMessage 为一封同时包含纯文本与 HTML 正文的邮件；To 为空时发送给配置中的收件人
+type mailMessage struct {
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// recipients 返回邮件的实际收件人
+func (c SMTPConfig) recipients(m mailMessage) []string {
+	if len(m.To) > 0 {
+		return m.To
+	}
+	return c.To
+}
+
+// sendMail 将邮件发送给 m.To 或配置中的全部收件人
+func (c SMTPConfig) sendMail(ctx context.Context, m mailMessage) error {
+	raw, err := c.buildMessage(m, time.Now())
+	if err != nil {
+		return err
+	}
+	dialer := &net.Dialer{Timeout: smtpDialTimeout}
+	var conn net.Conn
+	if c.Port == 465 {
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: c.Host}}).DialContext(ctx, "tcp", c.addr())
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", c.addr())
+	}
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	} else {
+		conn.SetDeadline(time.Now().Add(time.Minute))
+	}
+	client, err := smtp.NewClient(conn, c.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+	if ok, _ := client.Extension("STARTTLS"); ok && c.Port != 465 {
+		if err := client.StartTLS(&tls.Config{ServerName: c.Host}); err != nil {
+			return err
+		}
+	}
+	if c.Username != "" {
+		if err := client.Auth(smtp.PlainAuth("", c.Username, c.Password, c.Host)); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(c.From); err != nil {
+		return err
+	}
+	for _, to := range c.recipients(m) {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMessage 组装 multipart/alternative 邮件，正文使用 quoted-printable 编码
+func (c SMTPConfig) buildMessage(m mailMessage, now time.Time) ([]byte, error) {
+	var b [12]byte
+	_, _ = rand.Read(b[:])
+	boundary := "tb-" + hex.EncodeToString(b[:])
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", c.From)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(c.recipients(m), ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", now.Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", boundary)
+	for _, part := range []struct{ typ, body string }{{"text/plain", m.Text}, {"text/html", m.HTML}} {
+		fmt.Fprintf(&buf, "--%s\r\nContent-Type: %s; charset=utf-8\r\nContent-Transfer-Encoding: quoted-printable\r\n\r\n", boundary, part.typ)
+		qp := quotedprintable.NewWriter(&buf)
+		if _, err := qp.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+		buf.WriteString("\r\n")
+	}
+	fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	return buf.Bytes(), nil
+}
+
+// renderMail 以同一份数据渲染纯文本与 HTML 模板，模板中的 t 与 tf 按 locale 翻译
+func renderMail(locale, subject string, text *texttemplate.Template, html *htmltemplate.Template, data any) (mailMessage, error) {
+	m := mailMessage{Subject: subject}
+	funcs := map[string]any{
+		"t":  func(s string) string { return translate(locale, s) },
+		"tf": func(format string, args ...any) string { return translatef(locale, format, args...) },
+	}
+	tt, err := text.Clone()
+	if err != nil {
+		return m, err
+	}
+	ht, err := html.Clone()
+	if err != nil {
+		return m, err
+	}
+	var tb, hb bytes.Buffer
+	if err := tt.Funcs(funcs).Execute(&tb, data); err != nil {
+		return m, err
+	}
+	if err := ht.Funcs(funcs).Execute(&hb, data); err != nil {
+		return m, err
+	}
+	m.Text, m.HTML = tb.String(), hb.String()
+	return m, nil
+}
+
+// mailFuncs 为邮件模板函数；t 与 tf 在渲染时替换为对应语言的版本
+var mailFuncs = map[string]any{
+	"datetime": func(t *time.Time) string {
+		if t == nil {
+			return ""
+		}
+		return t.Local().Format("2006-01-02 15:04")
+	},
+	"t":  func(s string) string { return s },
+	"tf": fmt.Sprintf,
+}
+
+var reminderTextTmpl = texttemplate.Must(texttemplate.New("reminder").Funcs(mailFuncs).Parse(
+	`{{tf "任务 TB-%d「%s」将于 %s 截止（约 %.1f 小时后）。" .Task.ID .Task.Title (datetime .Task.DueAt) .DueInHours}}
+{{tf "当前状态：%s" .Task.Status}}
+{{with .Task.Description}}
+{{.}}
+{{end}}`))
+
+var reminderHTMLTmpl = htmltemplate.Must(htmltemplate.New("reminder").Funcs(mailFuncs).Parse(
+	`<p><strong>TB-{{.Task.ID}} {{.Task.Title}}</strong></p>
+<p>{{tf "将于 %s 截止（约 %.1f 小时后）。" (datetime .Task.DueAt) .DueInHours}}</p>
+<p>{{tf "当前状态：%s" .Task.Status}}</p>
+{{with .Task.Description}}<pre style="white-space:pre-wrap;font-family:inherit">{{.}}</pre>{{end}}`))
+
+// reminderMail 渲染截止提醒邮件
+func reminderMail(locale string, p reminderPayload) (mailMessage, error) {
+	return renderMail(locale, translatef(locale, "[看板] TB-%d 即将截止：%s", p.Task.ID, p.Task.Title), reminderTextTmpl, reminderHTMLTmpl, p)
+}
+
+// digestData 为每日摘要的内容
+type digestData struct {
+	Date     string
+	ByStatus []statusCount
+	Overdue  []Task
+	DueSoon  []Task
+}
+
+var digestTextTmpl = texttemplate.Must(texttemplate.New("digest").Funcs(mailFuncs).Parse(
+	`{{tf "看板摘要 %s" .Date}}
+
+{{range .ByStatus}}{{tf "%s：%d" .Status .Count}}
+{{end}}
+{{tf "已逾期（%d）" (len .Overdue)}}
+{{range .Overdue}}- TB-{{.ID}} {{.Title}}{{tf "（截止 %s）" (datetime .DueAt)}}
+{{else}}- {{t "无"}}
+{{end}}
+{{tf "24 小时内截止（%d）" (len .DueSoon)}}
+{{range .DueSoon}}- TB-{{.ID}} {{.Title}}{{tf "（截止 %s）" (datetime .DueAt)}}
+{{else}}- {{t "无"}}
+{{end}}`))
+
+var digestHTMLTmpl = htmltemplate.Must(htmltemplate.New("digest").Funcs(mailFuncs).Parse(
+	`<h2>{{tf "看板摘要 %s" .Date}}</h2>
+<p>{{range .ByStatus}}{{tf "%s：" .Status}}<strong>{{.Count}}</strong>　{{end}}</p>
+<h3>{{tf "已逾期（%d）" (len .Overdue)}}</h3>
+<ul>{{range .Overdue}}<li>TB-{{.ID}} {{.Title}}{{tf "（截止 %s）" (datetime .DueAt)}}</li>{{else}}<li>{{t "无"}}</li>{{end}}</ul>
+<h3>{{tf "24 小时内截止（%d）" (len .DueSoon)}}</h3>
+<ul>{{range .DueSoon}}<li>TB-{{.ID}} {{.Title}}{{tf "（截止 %s）" (datetime .DueAt)}}</li>{{else}}<li>{{t "无"}}</li>{{end}}</ul>`))
+
+// buildDigest 汇总各列任务数、已逾期与 24 小时内截止的未完成任务
+func (a *App) buildDigest(ctx context.Context, now time.Time) (digestData, error) {
+	d := digestData{Date: now.Local().Format("2006-01-02")}
+	counts := map[string]int{}
+	rows, err := a.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM tasks WHERE archived = 0 GROUP BY status`)
+	if err != nil {
+		return d, err
+	}
+	for rows.Next() {
+		var s string
+		var n int
+		if err := rows.Scan(&s, &n); err != nil {
+			rows.Close()
+			return d, err
+		}
+		counts[s] = n
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return d, err
+	}
+	for _, s := range taskStatuses {
+		d.ByStatus = append(d.ByStatus, statusCount{Status: s, Count: counts[s]})
+	}
+	nowStr, soonStr := now.UTC().Format(time.RFC3339), now.Add(24*time.Hour).UTC().Format(time.RFC3339)
+	const open = `SELECT ` + taskColumns + ` FROM tasks WHERE archived = 0 AND status <> '已完成' AND due_at IS NOT NULL`
+	if d.Overdue, err = a.queryTasks(ctx, open+` AND due_at <= ? ORDER BY due_at`, nowStr); err != nil {
+		return d, err
+	}
+	if d.DueSoon, err = a.queryTasks(ctx, open+` AND due_at > ? AND due_at <= ? ORDER BY due_at`, nowStr, soonStr); err != nil {
+		return d, err
+	}
+	return d, nil
+}
+
+// startDigest 按配置每日在 DigestHour 发送一次摘要邮件
+func (a *App) startDigest() {
+	c := a.cfg.SMTP
+	if !c.enabled() || c.DigestHour < 0 {
+		return
+	}
+	a.startBackground("digest", func(ctx context.Context) {
+		for {
+			now := time.Now()
+			next := time.Date(now.Year(), now.Month(), now.Day(), c.DigestHour, 0, 0, 0, time.Local)
+			if !next.After(now) {
+				next = next.AddDate(0, 0, 1)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(time.Until(next)):
+			}
+			if a.waitForHeadroom(ctx, "digest") != nil {
+				return
+			}
+			if err := a.sendDigest(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				a.logger.Error("发送每日摘要失败", "err", err)
+			}
+		}
+	})
+}
+
+// sendDigest 生成并发送每日摘要
+func (a *App) sendDigest(ctx context.Context, now time.Time) error {
+	d, err := a.buildDigest(ctx, now)
+	if err != nil {
+		return err
+	}
+	locale := a.outboundLocale()
+	m, err := renderMail(locale, translatef(locale, "[看板] 每日摘要 %s", d.Date), digestTextTmpl, digestHTMLTmpl, d)
+	if err != nil {
+		return err
+	}
+	if err := a.cfg.SMTP.sendMail(ctx, m); err != nil {
+		return err
+	}
+	a.logger.Info("已发送每日摘要", "overdue", len(d.Overdue), "due_soon", len(d.DueSoon))
+	return nil
+}
+
+// checkSMTP 确认邮件服务器可以连接；不可达时邮件通知降级
+func (a *App) checkSMTP(ctx context.Context) checkResult {
+	res := checkResult{Name: "smtp", Status: checkOK, Message: a.cfg.SMTP.addr()}
+	ctx, cancel := context.WithTimeout(ctx, 3*time.Second)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(ctx, "tcp", a.cfg.SMTP.addr())
+	if err != nil {
+		res.Status, res.Message = checkDegraded, fmt.Sprintf("无法连接邮件服务器 %s: %v；邮件通知将失败，请检查 smtp.host/port 与出站防火墙", a.cfg.SMTP.addr(), err)
+		return res
+	}
+	conn.Close()
+	return res
+}
@@ -1,37 +1,79 @@
 package main
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"os/signal"
 	"path/filepath"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
-	_ "github.com/mattn/go-sqlite3"
+	"github.com/mattn/go-sqlite3"
 )
 
-// App 表示应用的核心结构，负责管理日志、静态资源目录、数据库连接与路由配置
+// App 表示应用的核心结构，负责管理结构化日志、静态资源目录、数据库连接与路由配置
 type App struct {
-	logger    *log.Logger
+	cfg       Config
+	logger    *slog.Logger
 	staticDir string
 	db        *sql.DB
+	// idemMu 串行化携带幂等键的任务创建
+	idemMu sync.Mutex
+	// tagCache 缓存标签查询结果，写请求后失效
+	tagCache tagCache
+	// publicAPI 控制是否开放 /public/api 只读接口，publicDescriptions 控制是否公开任务描述
+	publicAPI          bool
+	publicDescriptions bool
+	// 后台任务的生命周期：bgCtx 在关闭时取消，bgWG 用于等待任务退出
+	bgCtx    context.Context
+	bgCancel context.CancelFunc
+	bgWG     sync.WaitGroup
+	// checks 为启动自检结果，由 /readyz 返回
+	checks []checkResult
+	// chaos 为开发模式下的故障注入设置
+	chaos chaosInjector
+	// queryStats 为数据库语句耗时统计
+	queryStats *queryStats
+	// secrets 为签名客户端密钥与入站邮件令牌的密钥环，支持轮换
+	secrets *secretRing
+	// 运行概况：启动时间、请求统计、外发通知统计、事件驱动后台任务的游标与各后台任务的状态
+	started      time.Time
+	metrics      requestMetrics
+	deliveries   deliveryStats
+	eventCursors eventCursors
+	workers      workerRegistry
+	// audit 为审计日志，未开启时为 nil
+	audit *auditSink
+	// oauth 为已配置的第三方登录提供方
+	oauth []*oauthProvider
 }
 
-// NewApp 创建并返回一个新的应用实例，初始化日志器与静态资源目录
-func NewApp() *App {
-	logger := log.New(os.Stdout, "[task-board] ", log.LstdFlags|log.Lshortfile)
-	staticDir := "web"
+// NewApp 根据配置创建并返回一个新的应用实例，初始化日志器、静态资源目录与数据库
+func NewApp(cfg Config) *App {
+	logger := newLogger(os.Stdout, cfg.Log.Level, cfg.Log.Format)
+	bgCtx, bgCancel := context.WithCancel(context.Background())
 	app := &App{
-		logger:    logger,
-		staticDir: staticDir,
+		cfg:                cfg,
+		started:            time.Now(),
+		bgCtx:              bgCtx,
+		bgCancel:           bgCancel,
+		logger:             logger,
+		staticDir:          cfg.Server.StaticDir,
+		publicAPI:          cfg.Public.Enabled,
+		publicDescriptions: cfg.Public.Descriptions,
 	}
 	// 初始化 SQLite 数据库
 	if err := app.initDB(); err != nil {
-		logger.Fatalf("数据库初始化失败: %v", err)
+		logger.Error("数据库初始化失败", "err", err)
+		os.Exit(1)
 	}
 	return app
 }
@@ -42,15 +84,96 @@ func (a *App) routes() *http.ServeMux {
 
 	// 基础 API
 	mux.HandleFunc("/api/health", a.handleHealth)
+	mux.HandleFunc("/readyz", a.handleReadyz)
 	// 看板任务 API
 	mux.HandleFunc("/api/tasks", a.handleTasks)
+	mux.HandleFunc("/api/tasks/random", a.handleRandomTask)
 	mux.HandleFunc("/api/tasks/", a.handleTaskItem)
+	// 从 Markdown 清单批量导入任务
+	mux.HandleFunc("/api/import/markdown", a.handleMarkdownImport)
 	// 标签查询 API
 	mux.HandleFunc("/api/tags", a.handleTags)
+	mux.HandleFunc("/api/tags/history", a.handleTagHistory)
+	mux.HandleFunc("/api/tags/rename", a.handleTagRename)
+	mux.HandleFunc("/api/tags/stats", a.handleTagStats)
+	mux.HandleFunc("/api/tags/", a.handleTagItem)
+	// 全局搜索（任务、标签、评论）
+	mux.HandleFunc("/api/search", a.handleGlobalSearch)
+	// 状态列策略
+	mux.HandleFunc("/api/columns", a.handleColumns)
+	mux.HandleFunc("/api/columns/", a.handleColumnItem)
+	// 保存的视图（过滤条件与排序组合）
+	mux.HandleFunc("/api/views", a.handleViews)
+	mux.HandleFunc("/api/views/", a.handleViewItem)
+	// 状态变更审批
+	mux.HandleFunc("/api/approvals", a.handleApprovals)
+	mux.HandleFunc("/api/approvals/", a.handleApprovalItem)
+	// 事故：事故泳道、寻呼与复盘统计
+	mux.HandleFunc("/api/incidents", a.handleIncidents)
+	mux.HandleFunc("/api/incidents/", a.handleIncidentItem)
+	// 按年拆分的归档库（只读）
+	mux.HandleFunc("/api/archive/partitions", a.handleArchivePartitions)
+	mux.HandleFunc("/api/archive/partitions/", a.handleArchivePartitions)
+	// 看板统计
+	mux.HandleFunc("/api/stats", a.handleStats)
+	mux.HandleFunc("/api/stats/cycle-time", a.handleCycleTime)
+	mux.HandleFunc("/api/stats/forecast", a.handleForecast)
+	// 路线图
+	mux.HandleFunc("/api/roadmap", a.handleRoadmap)
+
+	mux.HandleFunc("/api/maintenance/duplicates", a.handleDuplicates)
+	// 外部引用回调（CI 构建等）
+	mux.HandleFunc("/api/refs/inbound", a.handleRefsInbound)
+	// 代码推送集成：提交信息自动关联任务
+	mux.HandleFunc("/api/integrations/git/push", a.handleGitPush)
+	// 入站邮件：每封邮件生成一个任务（需配置 inbox.token）
+	mux.HandleFunc("/api/integrations/email/inbound", a.handleInboundMail)
+	// GraphQL 查询
+	mux.HandleFunc("/api/graphql", a.handleGraphQL)
+	// 公开只读 API（需开启 PUBLIC_API）
+	mux.HandleFunc("/public/api/", a.handlePublicAPI)
+	// 单个任务的分享页（凭链接中的令牌访问）
+	mux.HandleFunc("/share/", a.handleSharePage)
+	mux.HandleFunc("/share/board/", a.handleBoardSharePage)
+	mux.HandleFunc("/a/", a.handleQuickActionPage)
+	// API 文档
+	mux.HandleFunc("/api/openapi.json", a.handleOpenAPI)
+	mux.HandleFunc("/api/docs", a.handleAPIDocs)
+	// 故障注入（需开启开发模式）
+	mux.HandleFunc("/api/dev/chaos", a.handleChaos)
+	// 数据库语句与慢查询统计（受 access.admin_allow 限制）
+	mux.HandleFunc("/api/me", a.handleMe)
+	mux.HandleFunc("/api/me/tasks", a.handleMyTasks)
+	mux.HandleFunc("/api/users/suggest", a.handleUserSuggest)
+	mux.HandleFunc("/api/notifications", a.handleNotifications)
+	mux.HandleFunc("/api/notifications/", a.handleNotificationItem)
+	// 浏览器推送订阅
+	mux.HandleFunc("/api/push/key", a.handlePushKey)
+	mux.HandleFunc("/api/push/subscriptions", a.handlePushSubscriptions)
+	mux.HandleFunc("/auth/", a.handleAuth)
+	mux.HandleFunc("/login", a.handleLoginPage)
+
+	mux.HandleFunc("/api/keys", a.handleAPIKeys)
+	mux.HandleFunc("/api/keys/", a.handleAPIKeyItem)
+	mux.HandleFunc("/api/teams", a.handleTeams)
+	mux.HandleFunc("/api/teams/", a.handleTeamItem)
+	mux.HandleFunc("/api/admin/board", a.handleBoardSettings)
+	mux.HandleFunc("/api/boards/", a.handleBoards)
+
+	mux.HandleFunc("/api/admin/db/stats", a.handleDBStats)
+	mux.HandleFunc("/api/admin/observability", a.handleObservability)
+	mux.HandleFunc("/api/admin/runtime", a.handleRuntime)
+	// 凭据密钥轮换（受 access.admin_allow 限制）
+	mux.HandleFunc("/api/admin/secrets", a.handleSecrets)
+	mux.HandleFunc("/api/admin/secrets/", a.handleSecretItem)
+	// 自定义校验规则
+	mux.HandleFunc("/api/admin/validation-rules", a.handleValidationRules)
+	mux.HandleFunc("/api/admin/validation-rules/", a.handleValidationRuleItem)
+	mux.HandleFunc("/api/admin/transitions", a.handleTransitionRules)
+	mux.HandleFunc("/api/admin/transitions/", a.handleTransitionRuleItem)
 
 	// 静态资源与首页
-	fs := http.FileServer(http.Dir(a.staticDir))
-	mux.Handle("/", fs)
+	mux.Handle("/", a.localizedFileServer())
 	return mux
 }
 
@@ -60,34 +183,30 @@ func (a *App) handleHealth(w http.ResponseWriter, r *http.Request) {
 		"status": "ok",
 		"time":   time.Now().Format(time.RFC3339),
 	}
-	w.Header().Set("Content-Type", "application/json; charset=utf-8")
-	json.NewEncoder(w).Encode(resp)
+	writeJSON(w, http.StatusOK, resp)
 }
 
 // 已移除 hello 与 notes 相关 demo 代码，保留健康检查与看板任务功能
 
 // initDB 初始化并迁移 SQLite 数据库
 func (a *App) initDB() error {
-	// 创建数据目录
-	dataDir := "data"
-	if err := os.MkdirAll(dataDir, 0o755); err != nil {
-		return err
-	}
-	dbPath := filepath.Join(dataDir, "app.db")
-	// 打开数据库（mattn/go-sqlite3 驱动名称为 "sqlite3"）
-	db, err := sql.Open("sqlite3", dbPath)
-	if err != nil {
-		return err
+	// 创建数据目录；使用完整 DSN 时由调用方自行保证路径可用
+	if a.cfg.Data.DSN == "" {
+		if err := os.MkdirAll(filepath.Dir(a.cfg.Data.dbPath()), 0o755); err != nil {
+			return err
+		}
 	}
+	// 打开数据库：连接经 timedConnector 包装以记录慢查询
+	a.queryStats = &queryStats{threshold: a.cfg.Data.SlowQuery.Std(), logger: a.logger, ops: map[string]*slowOpStat{}}
+	db := sql.OpenDB(&timedConnector{dsn: a.cfg.Data.dataSource(), drv: &sqlite3.SQLiteDriver{ConnectHook: registerSearchFuncs}, stats: a.queryStats})
 	// 简单的连接检查
 	if err := db.Ping(); err != nil {
 		return err
 	}
+	// 外键约束、WAL 等 pragma 已通过连接参数对每个连接生效；限制连接数避免写锁争用过多
+	db.SetMaxOpenConns(a.cfg.Data.MaxOpenConns)
+	db.SetMaxIdleConns(a.cfg.Data.MaxIdleConns)
 	a.db = db
-	// 开启外键约束，确保删除任务时级联删除 task_tags
-	if _, err := a.db.Exec(`PRAGMA foreign_keys = ON`); err != nil {
-		return fmt.Errorf("启用外键约束失败: %w", err)
-	}
 	// 迁移表
 	schema := `
 	CREATE TABLE IF NOT EXISTS tasks (
@@ -106,18 +225,419 @@ func (a *App) initDB() error {
 		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
 	);
 	CREATE INDEX IF NOT EXISTS idx_task_tags_task ON task_tags(task_id);
+	CREATE TABLE IF NOT EXISTS task_acceptance_criteria (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		text TEXT NOT NULL,
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_task_acceptance_criteria_task ON task_acceptance_criteria(task_id);
+	CREATE TABLE IF NOT EXISTS task_links (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL,
+		position INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		url TEXT NOT NULL,
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_task_links_task ON task_links(task_id);
+	CREATE TABLE IF NOT EXISTS task_refs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		url TEXT NOT NULL,
+		title TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_task_refs_task ON task_refs(task_id);
+	CREATE TABLE IF NOT EXISTS column_policies (
+		status TEXT PRIMARY KEY,
+		policy TEXT NOT NULL DEFAULT '',
+		require_confirmation INTEGER NOT NULL DEFAULT 0,
+		updated_at TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+		key TEXT PRIMARY KEY,
+		fingerprint TEXT NOT NULL,
+		task_id INTEGER NOT NULL,
+		created_at TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS task_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		from_status TEXT NOT NULL DEFAULT '',
+		to_status TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_task_events_task ON task_events(task_id, id);
+	CREATE TABLE IF NOT EXISTS task_recurrences (
+		task_id INTEGER PRIMARY KEY,
+		rule TEXT NOT NULL,
+		mode TEXT NOT NULL,
+		next_at TEXT,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE TABLE IF NOT EXISTS task_occurrences (
+		task_id INTEGER PRIMARY KEY,
+		template_id INTEGER NOT NULL,
+		created_at TEXT NOT NULL,
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_task_occurrences_template ON task_occurrences(template_id);
+	CREATE TABLE IF NOT EXISTS task_reminders (
+		task_id INTEGER NOT NULL,
+		due_at TEXT NOT NULL,
+		lead_seconds INTEGER NOT NULL,
+		sent_at TEXT NOT NULL,
+		PRIMARY KEY(task_id, due_at, lead_seconds),
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE TABLE IF NOT EXISTS task_versions (
+		task_id INTEGER NOT NULL,
+		version INTEGER NOT NULL,
+		title TEXT NOT NULL,
+		description TEXT NOT NULL,
+		saved_at TEXT NOT NULL,
+		replaced_at TEXT NOT NULL,
+		PRIMARY KEY(task_id, version),
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE TABLE IF NOT EXISTS column_subscriptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		status TEXT NOT NULL,
+		channel TEXT NOT NULL,
+		target TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		UNIQUE(status, channel, target)
+	);
+	CREATE TABLE IF NOT EXISTS tag_events (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		tag TEXT NOT NULL,
+		kind TEXT NOT NULL,
+		task_id INTEGER,
+		detail TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_tag_events_tag ON tag_events(tag, id);
+	CREATE TABLE IF NOT EXISTS tags (
+		name TEXT PRIMARY KEY,
+		color TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS task_approvals (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL,
+		from_status TEXT NOT NULL,
+		to_status TEXT NOT NULL,
+		state TEXT NOT NULL DEFAULT 'pending',
+		requested_by TEXT NOT NULL DEFAULT '',
+		decided_by TEXT NOT NULL DEFAULT '',
+		comment TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		decided_at TEXT,
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE UNIQUE INDEX IF NOT EXISTS idx_task_approvals_pending ON task_approvals(task_id) WHERE state = 'pending';
+	CREATE TABLE IF NOT EXISTS task_incidents (
+		task_id INTEGER PRIMARY KEY,
+		severity TEXT NOT NULL,
+		triggered_at TEXT NOT NULL,
+		acknowledged_at TEXT,
+		resolved_at TEXT,
+		paged_at TEXT,
+		page_error TEXT NOT NULL DEFAULT '',
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE TABLE IF NOT EXISTS task_share_links (
+		task_id INTEGER PRIMARY KEY,
+		token TEXT NOT NULL UNIQUE,
+		created_at TEXT NOT NULL,
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE TABLE IF NOT EXISTS board_share_links (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		board_id INTEGER NOT NULL,
+		token TEXT NOT NULL UNIQUE,
+		label TEXT NOT NULL DEFAULT '',
+		expires_at TEXT,
+		created_at TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS secret_versions (
+		kind TEXT NOT NULL,
+		name TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		secret TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		expires_at TEXT,
+		PRIMARY KEY (kind, name, fingerprint)
+	);
+	CREATE TABLE IF NOT EXISTS validation_rules (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		expression TEXT NOT NULL,
+		field TEXT NOT NULL DEFAULT '',
+		message TEXT NOT NULL,
+		enabled INTEGER NOT NULL DEFAULT 1,
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS task_comments (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL,
+		author TEXT NOT NULL,
+		kind TEXT NOT NULL DEFAULT '',
+		body TEXT NOT NULL,
+		created_at TEXT NOT NULL,
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_task_comments_task ON task_comments(task_id, id);
+	CREATE TABLE IF NOT EXISTS status_transitions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		from_status TEXT NOT NULL,
+		to_status TEXT NOT NULL,
+		allowed INTEGER NOT NULL DEFAULT 1,
+		require_reason INTEGER NOT NULL DEFAULT 0,
+		message TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL,
+		UNIQUE(from_status, to_status)
+	);
+	CREATE TABLE IF NOT EXISTS action_token_uses (
+		nonce TEXT PRIMARY KEY,
+		task_id INTEGER NOT NULL,
+		used_at TEXT NOT NULL,
+		expires_at TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS views (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		filters TEXT NOT NULL DEFAULT '{}',
+		sort_field TEXT NOT NULL DEFAULT 'id',
+		sort_order TEXT NOT NULL DEFAULT 'desc',
+		created_at TEXT NOT NULL,
+		updated_at TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL DEFAULT '',
+		email TEXT NOT NULL DEFAULT '',
+		avatar_url TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		last_login_at TEXT
+	);
+	CREATE TABLE IF NOT EXISTS user_identities (
+		provider TEXT NOT NULL,
+		subject TEXT NOT NULL,
+		user_id INTEGER NOT NULL,
+		email TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (provider, subject)
+	);
+	CREATE TABLE IF NOT EXISTS user_sessions (
+		token_hash TEXT PRIMARY KEY,
+		user_id INTEGER NOT NULL,
+		created_at TEXT NOT NULL,
+		expires_at TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS api_keys (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL,
+		prefix TEXT NOT NULL,
+		key_hash TEXT NOT NULL UNIQUE,
+		scopes TEXT NOT NULL,
+		expires_at TEXT,
+		last_used_at TEXT,
+		created_at TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS task_relations (
+		task_id INTEGER NOT NULL,
+		related_id INTEGER NOT NULL,
+		origin TEXT NOT NULL,
+		origin_id INTEGER NOT NULL DEFAULT 0,
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (task_id, related_id, origin, origin_id),
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_task_relations_related ON task_relations(related_id);
+	CREATE TABLE IF NOT EXISTS user_mentions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		task_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		origin TEXT NOT NULL,
+		origin_id INTEGER NOT NULL DEFAULT 0,
+		created_at TEXT NOT NULL,
+		UNIQUE (task_id, origin, origin_id, user_id),
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE,
+		FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_user_mentions_user ON user_mentions(user_id);
+	CREATE TABLE IF NOT EXISTS task_watchers (
+		task_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (task_id, user_id),
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_task_watchers_user ON task_watchers(user_id);
+	CREATE TABLE IF NOT EXISTS notifications (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		kind TEXT NOT NULL,
+		task_id INTEGER,
+		body TEXT NOT NULL,
+		dedup_key TEXT NOT NULL,
+		read_at TEXT,
+		created_at TEXT NOT NULL,
+		UNIQUE (user_id, dedup_key),
+		FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications(user_id, id);
+	CREATE TABLE IF NOT EXISTS push_subscriptions (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		user_id INTEGER NOT NULL,
+		endpoint TEXT NOT NULL UNIQUE,
+		p256dh TEXT NOT NULL,
+		auth TEXT NOT NULL,
+		user_agent TEXT NOT NULL DEFAULT '',
+		created_at TEXT NOT NULL,
+		FOREIGN KEY(user_id) REFERENCES users(id) ON DELETE CASCADE
+	);
+	CREATE INDEX IF NOT EXISTS idx_push_subscriptions_user ON push_subscriptions(user_id);
+	CREATE TABLE IF NOT EXISTS teams (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT NOT NULL UNIQUE,
+		created_at TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS team_members (
+		team_id INTEGER NOT NULL,
+		user_id INTEGER NOT NULL,
+		role TEXT NOT NULL DEFAULT 'member',
+		created_at TEXT NOT NULL,
+		PRIMARY KEY (team_id, user_id)
+	);
+	CREATE TABLE IF NOT EXISTS boards (
+		id INTEGER PRIMARY KEY,
+		team_id INTEGER,
+		updated_at TEXT NOT NULL
+	);
 	`
 	if _, err := a.db.Exec(schema); err != nil {
 		return err
 	}
+	// 为旧库补齐后续新增的列
+	if err := a.ensureColumn("tasks", "version", "INTEGER NOT NULL DEFAULT 1"); err != nil {
+		return err
+	}
+	if err := a.ensureColumn("tasks", "start_at", "TEXT"); err != nil {
+		return err
+	}
+	if err := a.ensureColumn("tasks", "due_at", "TEXT"); err != nil {
+		return err
+	}
+	if err := a.ensureColumn("tasks", "status_changed_at", "TEXT"); err != nil {
+		return err
+	}
+	// 旧数据没有状态变更时间，以最后更新时间近似
+	if _, err := a.db.Exec(`UPDATE tasks SET status_changed_at = updated_at WHERE status_changed_at IS NULL`); err != nil {
+		return err
+	}
+	if err := a.ensureColumn("column_policies", "aging_after_days", "INTEGER"); err != nil {
+		return err
+	}
+	if err := a.ensureColumn("column_policies", "stale_after_days", "INTEGER"); err != nil {
+		return err
+	}
+	if err := a.ensureColumn("column_policies", "require_approval", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := a.ensureColumn("column_policies", "approvers", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := a.ensureColumn("column_policies", "wip_limit", "INTEGER NOT NULL DEFAULT 0"); err != nil {
+		return err
+	}
+	if err := a.ensureColumn("column_policies", "wip_mode", "TEXT NOT NULL DEFAULT 'reject'"); err != nil {
+		return err
+	}
+	if err := a.ensureColumn("api_keys", "allowed_ips", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := a.ensureColumn("notifications", "args", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := a.ensureColumn("tag_events", "actor", "TEXT NOT NULL DEFAULT ''"); err != nil {
+		return err
+	}
+	if err := a.ensureColumn("api_keys", "created_by", "INTEGER REFERENCES users(id) ON DELETE CASCADE"); err != nil {
+		return err
+	}
+	if err := a.migrateTaskEvents(); err != nil {
+		return err
+	}
+	if err := a.migrateTaskVersions(); err != nil {
+		return err
+	}
+	if err := a.migrateTaskRelations(); err != nil {
+		return err
+	}
+	if err := a.migrateTagEvents(); err != nil {
+		return err
+	}
+	if err := a.migrateTagRegistry(); err != nil {
+		return err
+	}
+	return a.migrateTagNormalization()
+}
+
+// ensureColumn 在列不存在时通过 ALTER TABLE 添加，用于兼容旧版本数据库
+func (a *App) ensureColumn(table, column, decl string) error {
+	rows, err := a.db.Query(`PRAGMA table_info(` + table + `)`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var cid, notNull, pk int
+		var name, typ string
+		var dflt sql.NullString
+		if err := rows.Scan(&cid, &name, &typ, &notNull, &dflt, &pk); err != nil {
+			return err
+		}
+		if name == column {
+			return nil
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	rows.Close()
+	if _, err := a.db.Exec(`ALTER TABLE ` + table + ` ADD COLUMN ` + column + ` ` + decl); err != nil {
+		return fmt.Errorf("添加列 %s.%s 失败: %w", table, column, err)
+	}
 	return nil
 }
 
-// writeJSON 将对象编码为 JSON 并写入响应
+// writeJSON 将对象编码为 JSON 并写入响应，缩进与字段命名按 withJSONOptions 确定的选项
 func writeJSON(w http.ResponseWriter, status int, v any) {
+	opts := responseJSONOptions(w)
+	if opts.locale != "" {
+		v = localizeResponse(v, opts.locale)
+		w.Header().Set("Content-Language", opts.locale)
+	}
+	body, err := encodeJSON(v, opts)
+	if err != nil {
+		status, body = http.StatusInternalServerError, []byte(`{"error":"encode response failed"}`+"\n")
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(status)
-	_ = json.NewEncoder(w).Encode(v)
+	_, _ = w.Write(body)
 }
 
 // 已移除与 notes 相关的接口与数据结构
@@ -132,16 +652,67 @@ type Task struct {
 	Archived    bool      `json:"archived"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
+	// Version 在每次修改后递增，用于乐观并发控制
+	Version int64 `json:"version"`
+	// 计划开始与截止时间（可选）
+	StartAt *time.Time `json:"start_at"`
+	DueAt   *time.Time `json:"due_at"`
+	// StatusChangedAt 为进入当前状态列的时间，Age 仅在看板列表中计算
+	StatusChangedAt time.Time `json:"status_changed_at"`
+	Age             *TaskAge  `json:"age,omitempty"`
+	// 结构化描述：验收标准与外部链接
+	AcceptanceCriteria []string   `json:"acceptance_criteria"`
+	ExternalLinks      []TaskLink `json:"external_links"`
+	// RecurrenceOf 为生成该任务的周期模板编号
+	RecurrenceOf *int64 `json:"recurrence_of,omitempty"`
+	// Incident 为事故任务的严重程度与确认、解决时间
+	Incident *TaskIncident `json:"incident,omitempty"`
+}
+
+// taskCreateRequest 为创建任务的请求体
+type taskCreateRequest struct {
+	Title              string     `json:"title"`
+	Description        string     `json:"description"`
+	Tags               []string   `json:"tags"`
+	AcceptanceCriteria []string   `json:"acceptance_criteria"`
+	ExternalLinks      []TaskLink `json:"external_links"`
+	// StartAt 与 DueAt 支持 RFC3339 或 YYYY-MM-DD
+	StartAt string `json:"start_at"`
+	DueAt   string `json:"due_at"`
+}
+
+// taskUpdateRequest 为更新任务的请求体，字段均为可选
+type taskUpdateRequest struct {
+	Title              *string    `json:"title"`
+	Description        *string    `json:"description"`
+	Tags               []string   `json:"tags"`
+	AcceptanceCriteria []string   `json:"acceptance_criteria"`
+	ExternalLinks      []TaskLink `json:"external_links"`
+	// StartAt 与 DueAt 传空串表示清除
+	StartAt *string `json:"start_at"`
+	DueAt   *string `json:"due_at"`
+	// ExpectedVersion 为客户端读取时的版本，也可通过 If-Match 头传递
+	ExpectedVersion *int64 `json:"expected_version"`
+}
+
+// taskStatusRequest 为变更任务状态的请求体
+type taskStatusRequest struct {
+	Status          string `json:"status"`
+	ExpectedVersion *int64 `json:"expected_version"`
+	// Confirm 表示已确认目标列的策略（列要求确认时必填）
+	Confirm bool `json:"confirm"`
+	// Reason 为变更原因，流转规则要求时必填；填写后记录为任务评论
+	Reason string `json:"reason"`
 }
 
 // validStatus 检查任务状态是否有效
 func validStatus(s string) bool {
-	switch s {
-	case "规划中", "进行中", "搁置中", "已完成":
-		return true
-	default:
-		return false
+	for _, status := range taskStatuses {
+		if s == status {
+			return true
+		}
 	}
+	return false
 }
 
 // handleTasks 处理任务的创建与列表
@@ -156,11 +727,21 @@ func (a *App) handleTasks(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// handleTasksList 返回任务列表，支持 archived 查询参数
+// handleTasksList 返回任务列表，支持 archived、starting、q 查询参数与 If-None-Match 条件请求
 func (a *App) handleTasksList(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	// 看板未变化时直接返回 304，避免轮询客户端重复下载；老化天数按日变化，日期也计入版本
+	if etag, err := a.tasksETag(ctx, r.URL.RawQuery+"|"+time.Now().Format("2006-01-02")); err == nil && checkNotModified(w, r, etag, "no-cache") {
+		return
+	}
 	archParam := r.URL.Query().Get("archived")
 	archived := archParam == "1" || strings.ToLower(archParam) == "true"
-	q := strings.TrimSpace(r.URL.Query().Get("q"))
+	// q 支持查询语法，如 status:进行中 tag:bug created:>2024-01-01
+	qc, qargs, err := a.taskSearchCond(r.URL.Query().Get("q"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid query: " + err.Error()})
+		return
+	}
 	if archived {
 		page := int64(1)
 		size := int64(20)
@@ -178,19 +759,32 @@ func (a *App) handleTasksList(w http.ResponseWriter, r *http.Request) {
 		cond := "WHERE archived = ?"
 		var args []any
 		args = append(args, 1)
-		if q != "" {
-			cond += " AND (title LIKE ? OR description LIKE ? OR id IN (SELECT task_id FROM task_tags WHERE tag LIKE ?))"
-			pat := "%" + q + "%"
-			args = append(args, pat, pat, pat)
+		if qc != "" {
+			cond += " AND " + qc
+			args = append(args, qargs...)
+		}
+		// status、tag、month 为可多选的分面过滤，响应中附带各分面的计数
+		ff, err := parseArchiveFacetFilter(r.URL.Query(), a.cfg.Tags)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
 		}
+		facets, err := a.queryArchiveFacets(ctx, cond, args, ff)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		fc, fargs := ff.cond("")
+		cond += fc
+		args = append(args, fargs...)
 		var total int64
-		if err := a.db.QueryRow("SELECT COUNT(*) FROM tasks "+cond, args...).Scan(&total); err != nil {
+		if err := a.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks "+cond, args...).Scan(&total); err != nil {
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
 		}
 		argsList := append(args, size, offset)
-		rows, err := a.db.Query(`
-			SELECT id, title, description, status, archived, created_at, updated_at
+		out, err := a.queryTasks(ctx, `
+			SELECT `+taskColumns+`
 			FROM tasks
 			`+cond+`
 			ORDER BY id DESC
@@ -200,23 +794,6 @@ func (a *App) handleTasksList(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
 		}
-		defer rows.Close()
-		var out []Task
-		for rows.Next() {
-			var t Task
-			var created, updated string
-			var archInt int
-			if err := rows.Scan(&t.ID, &t.Title, &t.Description, &t.Status, &archInt, &created, &updated); err != nil {
-				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
-				return
-			}
-			t.Archived = archInt != 0
-			tags, _ := a.fetchTags(t.ID)
-			t.Tags = tags
-			t.CreatedAt, _ = time.Parse(time.RFC3339, created)
-			t.UpdatedAt, _ = time.Parse(time.RFC3339, updated)
-			out = append(out, t)
-		}
 		hasMore := offset+int64(len(out)) < total
 		writeJSON(w, http.StatusOK, map[string]any{
 			"items":     out,
@@ -224,42 +801,60 @@ func (a *App) handleTasksList(w http.ResponseWriter, r *http.Request) {
 			"page":      page,
 			"page_size": size,
 			"has_more":  hasMore,
+			"facets":    facets,
 		})
 		return
 	}
-	rows, err := a.db.Query(`
-		SELECT id, title, description, status, archived, created_at, updated_at
+	// group_by 按维度返回分组后的任务（泳道）
+	groupBy := strings.TrimSpace(r.URL.Query().Get("group_by"))
+	if groupBy != "" {
+		if err := validGroupBy(groupBy); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+	}
+	cond := "WHERE archived = 0"
+	var args []any
+	// starting 过滤计划开始时间落在指定区间内的任务，如 starting=this_week
+	if starting := strings.TrimSpace(r.URL.Query().Get("starting")); starting != "" {
+		from, to, err := startingWindow(starting, time.Now())
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		cond += " AND start_at >= ? AND start_at < ?"
+		args = append(args, from, to)
+	}
+	if qc != "" {
+		cond += " AND " + qc
+		args = append(args, qargs...)
+	}
+	out, err := a.queryTasks(ctx, `
+		SELECT `+taskColumns+`
 		FROM tasks
-		WHERE archived = 0
+		`+cond+`
 		ORDER BY id DESC
-	`)
+	`, args...)
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
-	defer rows.Close()
-	var out []Task
-	for rows.Next() {
-		var t Task
-		var created, updated string
-		var archInt int
-		if err := rows.Scan(&t.ID, &t.Title, &t.Description, &t.Status, &archInt, &created, &updated); err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
-			return
-		}
-		t.Archived = archInt != 0
-		tags, _ := a.fetchTags(t.ID)
-		t.Tags = tags
-		t.CreatedAt, _ = time.Parse(time.RFC3339, created)
-		t.UpdatedAt, _ = time.Parse(time.RFC3339, updated)
-		out = append(out, t)
+	cols, err := a.fetchColumns(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	annotateTaskAges(out, cols, time.Now())
+	if groupBy != "" {
+		writeJSON(w, http.StatusOK, groupedTaskListResponse{GroupBy: groupBy, Total: len(out), Groups: groupTasks(out, groupBy), Columns: cols})
+		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"items": out})
+	writeJSON(w, http.StatusOK, map[string]any{"items": out, "columns": cols})
 }
 
 // fetchTags 查询任务的标签
-func (a *App) fetchTags(taskID int64) ([]string, error) {
-	rows, err := a.db.Query(`SELECT tag FROM task_tags WHERE task_id = ?`, taskID)
+func (a *App) fetchTags(ctx context.Context, taskID int64) ([]string, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT tag FROM task_tags WHERE task_id = ?`, taskID)
 	if err != nil {
 		return nil, err
 	}
@@ -275,74 +870,179 @@ func (a *App) fetchTags(taskID int64) ([]string, error) {
 	return tags, nil
 }
 
-// handleTags 返回系统中已有的标签列表，支持 q 模糊查询
+// handleTags 返回系统中已有的标签，支持 q 模糊查询；默认按使用次数与最近使用排序，sort=name 时按名称排序。
+// items 为标签名列表，suggestions 附带使用次数与最近使用时间
 func (a *App) handleTags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	if r.Method != http.MethodGet {
 		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
 		return
 	}
+	// 自动补全每次按键都会请求，结果按关键字缓存并支持 ETag 协商
 	q := strings.TrimSpace(r.URL.Query().Get("q"))
-	var rows *sql.Rows
-	var err error
-	if q != "" {
-		rows, err = a.db.Query(`SELECT DISTINCT tag FROM task_tags WHERE tag LIKE ? ORDER BY tag`, "%"+q+"%")
-	} else {
-		rows, err = a.db.Query(`SELECT DISTINCT tag FROM task_tags ORDER BY tag`)
+	sortBy := "usage"
+	if r.URL.Query().Get("sort") == "name" {
+		sortBy = "name"
 	}
-	if err != nil {
-		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+	key := sortBy + "|" + q
+	now := time.Now()
+	entry, gen, ok := a.tagCache.get(key, now)
+	if !ok {
+		tags, err := a.queryTagSuggestions(ctx, q, sortBy, now)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		entry = a.tagCache.put(key, gen, tags, now)
+	}
+	if checkNotModified(w, r, entry.etag, "no-cache") {
 		return
 	}
+	names := make([]string, len(entry.items))
+	for i, s := range entry.items {
+		names[i] = s.Tag
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": names, "suggestions": entry.items})
+}
+
+// queryTagNames 返回去重后的标签名，q 非空时按模糊匹配过滤（忽略大小写与重音）
+func (a *App) queryTagNames(ctx context.Context, q string) ([]string, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT DISTINCT tag FROM task_tags ORDER BY tag`)
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
+	key := tagSearchKey(q)
 	var tags []string
 	for rows.Next() {
 		var tag string
 		if err := rows.Scan(&tag); err != nil {
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
-			return
+			return nil, err
+		}
+		if strings.Contains(tagSearchKey(tag), key) {
+			tags = append(tags, tag)
 		}
-		tags = append(tags, tag)
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"items": tags})
+	return tags, rows.Err()
 }
 
-// handleTasksCreate 创建任务，默认状态为“规划中”
+// handleTasksCreate 创建任务，默认状态为“规划中”；
+// 携带 Idempotency-Key 头的重试请求会返回首次创建的任务 ID 而不会重复创建
 func (a *App) handleTasksCreate(w http.ResponseWriter, r *http.Request) {
-	var body struct {
-		Title       string   `json:"title"`
-		Description string   `json:"description"`
-		Tags        []string `json:"tags"`
+	ctx := r.Context()
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid body"})
+		return
 	}
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+	var body taskCreateRequest
+	if err := json.Unmarshal(raw, &body); err != nil {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
 		return
 	}
+	idemKey := strings.TrimSpace(r.Header.Get("Idempotency-Key"))
+	fingerprint := ""
+	if idemKey != "" {
+		if len(idemKey) > maxIdempotencyKeyLen {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "idempotency key too long"})
+			return
+		}
+		// 串行化带幂等键的创建，避免并发重试同时通过查重
+		a.idemMu.Lock()
+		defer a.idemMu.Unlock()
+		fingerprint = idempotencyFingerprint(raw)
+		prevID, prevFP, found, err := a.lookupIdempotencyKey(ctx, idemKey)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if found {
+			if prevFP != fingerprint {
+				writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "idempotency key reused with different payload"})
+				return
+			}
+			w.Header().Set("Idempotent-Replayed", "true")
+			writeJSON(w, http.StatusCreated, map[string]any{"id": prevID})
+			return
+		}
+	}
 	if strings.TrimSpace(body.Title) == "" {
 		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "title required"})
 		return
 	}
+	body.Tags = a.cfg.Tags.normalizeAll(body.Tags)
+	if !a.checkTagLimit(w, body.Tags) {
+		return
+	}
+	criteria, err := normalizeAcceptanceCriteria(body.AcceptanceCriteria)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	links, err := normalizeExternalLinks(body.ExternalLinks)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	startAt, err := optionalTime(body.StartAt)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid start_at"})
+		return
+	}
+	dueAt, err := optionalTime(body.DueAt)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid due_at"})
+		return
+	}
+	if err := checkScheduleOrder(startAt, dueAt); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	if !a.checkValidationRules(w, r, "create", func() (Task, error) {
+		return Task{
+			Title: body.Title, Description: body.Description, Status: "规划中", Tags: body.Tags,
+			StartAt: nullTimeValue(startAt), DueAt: nullTimeValue(dueAt), CreatedAt: time.Now(),
+			AcceptanceCriteria: criteria, ExternalLinks: links,
+		}, nil
+	}) {
+		return
+	}
+	if !a.checkTaskLimit(ctx, w, 1) {
+		return
+	}
 	now := time.Now().Format(time.RFC3339)
-	res, err := a.db.Exec(`
-		INSERT INTO tasks (title, description, status, archived, created_at, updated_at)
-		VALUES (?, ?, ?, 0, ?, ?)
-	`, body.Title, body.Description, "规划中", now, now)
+	res, err := a.db.ExecContext(ctx, `
+		INSERT INTO tasks (title, description, status, archived, created_at, updated_at, start_at, due_at, status_changed_at)
+		VALUES (?, ?, ?, 0, ?, ?, ?, ?, ?)
+	`, body.Title, body.Description, "规划中", now, now, startAt, dueAt, now)
 	if err != nil {
 		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 		return
 	}
 	taskID, _ := res.LastInsertId()
 	for _, tag := range body.Tags {
-		tag = strings.TrimSpace(tag)
-		if tag == "" {
-			continue
+		_, _ = a.db.ExecContext(ctx, `INSERT INTO task_tags (task_id, tag) VALUES (?, ?)`, taskID, tag)
+	}
+	if err := a.replaceAcceptanceCriteria(ctx, taskID, criteria); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if err := a.replaceExternalLinks(ctx, taskID, links); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	a.syncDescriptionMentions(r, taskID, body.Description)
+	if idemKey != "" {
+		if err := a.saveIdempotencyKey(ctx, idemKey, fingerprint, taskID); err != nil {
+			a.reqLogger(r).Warn("保存幂等键失败", "task_id", taskID, "err", err)
 		}
-		_, _ = a.db.Exec(`INSERT INTO task_tags (task_id, tag) VALUES (?, ?)`, taskID, tag)
 	}
 	writeJSON(w, http.StatusCreated, map[string]any{"id": taskID})
 }
 
 // handleTaskItem 处理单个任务的子路径操作，如 status、archive
 func (a *App) handleTaskItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
 	rest := strings.TrimPrefix(r.URL.Path, "/api/tasks/")
 	parts := strings.Split(rest, "/")
 	if len(parts) == 0 || parts[0] == "" {
@@ -363,15 +1063,36 @@ func (a *App) handleTaskItem(w http.ResponseWriter, r *http.Request) {
 	if len(parts) > 1 {
 		action = parts[1]
 	}
+	addLogAttrs(r, "task_id", id)
 	switch action {
+	case "refs":
+		a.handleTaskRefs(w, r, id, parts[2:])
+	case "recurrence":
+		a.handleTaskRecurrence(w, r, id)
+	case "history":
+		a.handleTaskHistory(w, r, id)
+	case "comments":
+		a.handleTaskComments(w, r, id)
+	case "mentions":
+		a.handleTaskMentions(w, r, id)
+	case "rendered":
+		a.handleTaskRendered(w, r, id)
+	case "watch":
+		a.handleTaskWatch(w, r, id)
+	case "card.svg":
+		a.handleTaskCard(w, r, id)
+	case "revert":
+		a.handleTaskRevert(w, r, id, parts[2:])
+	case "share":
+		a.handleTaskShare(w, r, id)
+	case "actions":
+		a.handleTaskActions(w, r, id, parts[2:])
 	case "status":
 		if r.Method != http.MethodPatch {
 			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
 			return
 		}
-		var body struct {
-			Status string `json:"status"`
-		}
+		var body taskStatusRequest
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
 			return
@@ -380,19 +1101,84 @@ func (a *App) handleTaskItem(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid status"})
 			return
 		}
+		expected, ok := requestedVersion(r, body.ExpectedVersion)
+		if !ok {
+			writeJSON(w, http.StatusPreconditionRequired, map[string]string{"error": "expected_version required"})
+			return
+		}
+		if len([]rune(body.Reason)) > maxTransitionReasonLen {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "reason too long"})
+			return
+		}
+		var prevStatus string
+		_ = a.db.QueryRowContext(ctx, `SELECT status FROM tasks WHERE id = ?`, id).Scan(&prevStatus)
+		if !a.checkTransitionRules(ctx, w, prevStatus, body.Status, body.Reason) {
+			return
+		}
+		// 目标列配置了需确认的策略时，要求客户端显式确认
+		if !body.Confirm {
+			policy, err := a.columnPolicy(ctx, body.Status)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			if policy != "" {
+				writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "confirmation required", "status": body.Status, "policy": policy})
+				return
+			}
+		}
+		if !a.checkValidationRules(w, r, "status", func() (Task, error) {
+			t, err := a.fetchTaskDetail(ctx, id)
+			t.Status = body.Status
+			return t, err
+		}) {
+			return
+		}
+		if prevStatus != "" && prevStatus != body.Status && !a.checkWIPLimit(ctx, w, body.Status, id) {
+			return
+		}
+		// 进入需审批的列时只创建待审批记录，审批通过后才真正变更状态
+		if prevStatus != "" && prevStatus != body.Status {
+			required, _, err := a.columnApproval(ctx, body.Status)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			if required {
+				a.requestStatusApproval(w, r, id, expected, prevStatus, body.Status, body.Reason)
+				return
+			}
+		}
 		now := time.Now().Format(time.RFC3339)
-		if _, err := a.db.Exec(`UPDATE tasks SET status = ?, updated_at = ? WHERE id = ?`, body.Status, now, id); err != nil {
+		res, err := a.db.ExecContext(ctx, `
+			UPDATE tasks
+			SET status = ?, updated_at = ?, version = version + 1,
+				status_changed_at = CASE WHEN status <> ? THEN ? ELSE status_changed_at END
+			WHERE id = ? AND version = ?
+		`, body.Status, now, body.Status, now, id, expected)
+		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
 		}
-		writeJSON(w, http.StatusOK, map[string]any{"id": id, "status": body.Status})
+		if n, _ := res.RowsAffected(); n == 0 {
+			a.writeVersionConflict(ctx, w, id)
+			return
+		}
+		if prevStatus != body.Status {
+			a.recordTransitionReason(ctx, r, id, prevStatus, body.Status, body.Reason)
+		}
+		if body.Status == "已完成" && prevStatus != body.Status {
+			a.onTaskCompleted(ctx, id)
+		}
+		w.Header().Set("ETag", versionETag(expected+1))
+		writeJSON(w, http.StatusOK, map[string]any{"id": id, "status": body.Status, "version": expected + 1})
 	case "archive":
 		if r.Method != http.MethodPost {
 			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
 			return
 		}
 		now := time.Now().Format(time.RFC3339)
-		if _, err := a.db.Exec(`UPDATE tasks SET archived = 1, updated_at = ? WHERE id = ?`, now, id); err != nil {
+		if _, err := a.db.ExecContext(ctx, `UPDATE tasks SET archived = 1, updated_at = ?, version = version + 1 WHERE id = ?`, now, id); err != nil {
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
 		}
@@ -403,11 +1189,7 @@ func (a *App) handleTaskItem(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		// 解析可选字段
-		var body struct {
-			Title       *string  `json:"title"`
-			Description *string  `json:"description"`
-			Tags        []string `json:"tags"`
-		}
+		var body taskUpdateRequest
 		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
 			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
 			return
@@ -427,60 +1209,194 @@ func (a *App) handleTaskItem(w http.ResponseWriter, r *http.Request) {
 			setParts = append(setParts, "description = ?")
 			args = append(args, *body.Description)
 		}
-		now := time.Now().Format(time.RFC3339)
-		setParts = append(setParts, "updated_at = ?")
-		args = append(args, now, id)
-		if len(setParts) > 0 {
-			q := `UPDATE tasks SET ` + strings.Join(setParts, ", ") + ` WHERE id = ?`
-			if _, err := a.db.Exec(q, args...); err != nil {
-				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		if body.StartAt != nil || body.DueAt != nil {
+			// 未提供的一端沿用当前值，以校验开始不晚于截止
+			var startAt, dueAt sql.NullString
+			if err := a.db.QueryRowContext(ctx, `SELECT start_at, due_at FROM tasks WHERE id = ?`, id).Scan(&startAt, &dueAt); err != nil {
+				writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
 				return
 			}
+			if body.StartAt != nil {
+				v, err := optionalTime(*body.StartAt)
+				if err != nil {
+					writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid start_at"})
+					return
+				}
+				startAt = v
+				setParts = append(setParts, "start_at = ?")
+				args = append(args, v)
+			}
+			if body.DueAt != nil {
+				v, err := optionalTime(*body.DueAt)
+				if err != nil {
+					writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid due_at"})
+					return
+				}
+				dueAt = v
+				setParts = append(setParts, "due_at = ?")
+				args = append(args, v)
+			}
+			if err := checkScheduleOrder(startAt, dueAt); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		if body.Tags != nil {
+			body.Tags = a.cfg.Tags.normalizeAll(body.Tags)
+			if !a.checkTagLimit(w, body.Tags) {
+				return
+			}
+		}
+		var criteria []string
+		if body.AcceptanceCriteria != nil {
+			var err error
+			if criteria, err = normalizeAcceptanceCriteria(body.AcceptanceCriteria); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		var links []TaskLink
+		if body.ExternalLinks != nil {
+			var err error
+			if links, err = normalizeExternalLinks(body.ExternalLinks); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		expected, ok := requestedVersion(r, body.ExpectedVersion)
+		if !ok {
+			writeJSON(w, http.StatusPreconditionRequired, map[string]string{"error": "expected_version required"})
+			return
+		}
+		if !a.checkValidationRules(w, r, "update", func() (Task, error) {
+			t, err := a.fetchTaskDetail(ctx, id)
+			if err != nil {
+				return t, err
+			}
+			if body.Title != nil {
+				t.Title = *body.Title
+			}
+			if body.Description != nil {
+				t.Description = *body.Description
+			}
+			if body.StartAt != nil {
+				v, _ := optionalTime(*body.StartAt)
+				t.StartAt = nullTimeValue(v)
+			}
+			if body.DueAt != nil {
+				v, _ := optionalTime(*body.DueAt)
+				t.DueAt = nullTimeValue(v)
+			}
+			if body.Tags != nil {
+				t.Tags = body.Tags
+			}
+			if body.AcceptanceCriteria != nil {
+				t.AcceptanceCriteria = criteria
+			}
+			if body.ExternalLinks != nil {
+				t.ExternalLinks = links
+			}
+			return t, nil
+		}) {
+			return
+		}
+		now := time.Now().Format(time.RFC3339)
+		setParts = append(setParts, "updated_at = ?", "version = version + 1")
+		args = append(args, now, id, expected)
+		q := `UPDATE tasks SET ` + strings.Join(setParts, ", ") + ` WHERE id = ? AND version = ?`
+		res, err := a.db.ExecContext(ctx, q, args...)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			a.writeVersionConflict(ctx, w, id)
+			return
 		}
 		// 更新标签（如果提供）
 		if body.Tags != nil {
-			if err := a.replaceTaskTags(id, body.Tags); err != nil {
+			if err := a.replaceTaskTags(ctx, id, body.Tags, auditActor(r)); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		// 更新结构化描述字段（如果提供）
+		if body.AcceptanceCriteria != nil {
+			if err := a.replaceAcceptanceCriteria(ctx, id, criteria); err != nil {
 				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 				return
 			}
 		}
-		writeJSON(w, http.StatusOK, map[string]any{"id": id, "updated": true})
+		if body.ExternalLinks != nil {
+			if err := a.replaceExternalLinks(ctx, id, links); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		if body.Description != nil {
+			a.syncDescriptionMentions(r, id, *body.Description)
+		}
+		w.Header().Set("ETag", versionETag(expected+1))
+		writeJSON(w, http.StatusOK, map[string]any{"id": id, "updated": true, "version": expected + 1})
 	case "copy":
 		if r.Method != http.MethodPost {
 			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
 			return
 		}
 		// 读取原任务
-		src, err := a.fetchTaskDetail(id)
+		src, err := a.fetchTaskDetail(ctx, id)
 		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
 		}
+		if !a.checkTaskLimit(ctx, w, 1) {
+			return
+		}
 		// 创建副本（保持原状态，归档强制为 0）
 		now := time.Now().Format(time.RFC3339)
-		res, err := a.db.Exec(`
-			INSERT INTO tasks (title, description, status, archived, created_at, updated_at)
-			VALUES (?, ?, ?, 0, ?, ?)
-		`, src.Title, src.Description, src.Status, now, now)
+		res, err := a.db.ExecContext(ctx, `
+			INSERT INTO tasks (title, description, status, archived, created_at, updated_at, start_at, due_at, status_changed_at)
+			VALUES (?, ?, ?, 0, ?, ?, ?, ?, ?)
+		`, src.Title, src.Description, src.Status, now, now, timeArg(src.StartAt), timeArg(src.DueAt), now)
 		if err != nil {
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
 		}
 		newID, _ := res.LastInsertId()
-		// 复制标签
-		if err := a.replaceTaskTags(newID, src.Tags); err != nil {
+		// 复制标签与结构化描述字段
+		if err := a.replaceTaskTags(ctx, newID, src.Tags, auditActor(r)); err != nil {
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
 		}
+		if err := a.replaceAcceptanceCriteria(ctx, newID, src.AcceptanceCriteria); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if err := a.replaceExternalLinks(ctx, newID, src.ExternalLinks); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		a.syncDescriptionMentions(r, newID, src.Description)
 		writeJSON(w, http.StatusCreated, map[string]any{"id": newID})
 	case "":
+		if r.Method == http.MethodGet {
+			a.handleTaskDetail(w, r, id)
+			return
+		}
 		// 支持 RESTful 删除：DELETE /api/tasks/{id}
 		if r.Method != http.MethodDelete {
 			writeJSON(w, http.StatusNotFound, map[string]string{"error": "unknown action"})
 			return
 		}
-		// 彻底删除任务（已启用外键，task_tags 将级联删除）
-		if _, err := a.db.Exec(`DELETE FROM tasks WHERE id = ?`, id); err != nil {
+		// 彻底删除任务（已启用外键，task_tags 将级联删除；其他任务指向它的提及关系没有外键，单独删除）
+		if err := a.withTagActor(ctx, auditActor(r), func(tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, `DELETE FROM tasks WHERE id = ?`, id)
+			return err
+		}); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if _, err := a.db.ExecContext(ctx, `DELETE FROM task_relations WHERE related_id = ?`, id); err != nil {
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
 		}
@@ -490,8 +1406,16 @@ func (a *App) handleTaskItem(w http.ResponseWriter, r *http.Request) {
 			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
 			return
 		}
+		if !a.checkTaskLimit(ctx, w, 1) || !a.checkWIPLimit(ctx, w, "规划中", id) {
+			return
+		}
 		now := time.Now().Format(time.RFC3339)
-		if _, err := a.db.Exec(`UPDATE tasks SET archived = 0, status = ?, updated_at = ? WHERE id = ?`, "规划中", now, id); err != nil {
+		if _, err := a.db.ExecContext(ctx, `
+			UPDATE tasks
+			SET archived = 0, status = ?, updated_at = ?, version = version + 1,
+				status_changed_at = CASE WHEN status <> ? THEN ? ELSE status_changed_at END
+			WHERE id = ?
+		`, "规划中", now, "规划中", now, id); err != nil {
 			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
 			return
 		}
@@ -501,42 +1425,105 @@ func (a *App) handleTaskItem(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
-// fetchTaskDetail 查询并返回单个任务的详细信息（含标签）
-func (a *App) fetchTaskDetail(id int64) (Task, error) {
+// taskColumns 为查询任务时的列顺序，需与 scanTask 保持一致
+const taskColumns = `id, title, description, status, archived, created_at, updated_at, version, start_at, due_at, status_changed_at`
+
+// rowScanner 抽象 *sql.Row 与 *sql.Rows 的 Scan 方法
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+// scanTask 按 taskColumns 的列顺序扫描一行任务数据（不含标签等关联数据）
+func scanTask(sc rowScanner) (Task, error) {
 	var t Task
 	var created, updated string
 	var archInt int
-	err := a.db.QueryRow(`
-		SELECT id, title, description, status, archived, created_at, updated_at
-		FROM tasks
-		WHERE id = ?
-	`, id).Scan(&t.ID, &t.Title, &t.Description, &t.Status, &archInt, &created, &updated)
-	if err != nil {
+	var startAt, dueAt, statusChanged sql.NullString
+	if err := sc.Scan(&t.ID, &t.Title, &t.Description, &t.Status, &archInt, &created, &updated, &t.Version, &startAt, &dueAt, &statusChanged); err != nil {
 		return t, err
 	}
+	t.StatusChangedAt, _ = time.Parse(time.RFC3339, statusChanged.String)
+	t.StartAt = nullTimeValue(startAt)
+	t.DueAt = nullTimeValue(dueAt)
 	t.Archived = archInt != 0
 	t.CreatedAt, _ = time.Parse(time.RFC3339, created)
 	t.UpdatedAt, _ = time.Parse(time.RFC3339, updated)
-	tags, _ := a.fetchTags(id)
-	t.Tags = tags
 	return t, nil
 }
 
-// replaceTaskTags 将指定任务的标签替换为给定集合（先清空后插入）
-func (a *App) replaceTaskTags(taskID int64, tags []string) error {
-	if _, err := a.db.Exec(`DELETE FROM task_tags WHERE task_id = ?`, taskID); err != nil {
+// fetchTaskDetail 查询并返回单个任务的详细信息（含标签与结构化字段）
+func (a *App) fetchTaskDetail(ctx context.Context, id int64) (Task, error) {
+	t, err := scanTask(a.db.QueryRowContext(ctx, `SELECT `+taskColumns+` FROM tasks WHERE id = ?`, id))
+	if err != nil {
+		return t, err
+	}
+	a.loadTaskRelations(ctx, &t)
+	return t, nil
+}
+
+// queryTasks 执行以 taskColumns 为列的任务查询，并附带标签与结构化字段
+func (a *App) queryTasks(ctx context.Context, query string, args ...any) ([]Task, error) {
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Task
+	for rows.Next() {
+		t, err := scanTask(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// 在关闭结果集后再查询关联数据，避免单连接时相互阻塞
+	for i := range out {
+		a.loadTaskRelations(ctx, &out[i])
+	}
+	return out, nil
+}
+
+// replaceTaskTags 将指定任务的标签替换为给定集合：只删除不再需要的标签、插入新增的标签，
+// 未变化的标签保持不动，标签历史中不会出现多余的移除与添加记录；actor 记入由此产生的标签事件
+func (a *App) replaceTaskTags(ctx context.Context, taskID int64, tags []string, actor string) error {
+	current, err := a.fetchTags(ctx, taskID)
+	if err != nil {
 		return err
 	}
+	tags = a.cfg.Tags.normalizeAll(tags)
+	want := map[string]bool{}
 	for _, tag := range tags {
-		tag = strings.TrimSpace(tag)
-		if tag == "" {
-			continue
+		want[tag] = true
+	}
+	return a.withTagActor(ctx, actor, func(tx *sql.Tx) error {
+		have := map[string]bool{}
+		for _, tag := range current {
+			if !want[tag] || have[tag] {
+				if _, err := tx.ExecContext(ctx, `DELETE FROM task_tags WHERE task_id = ? AND tag = ?`, taskID, tag); err != nil {
+					return err
+				}
+				if want[tag] {
+					// 重复的标签整体删除后重新插入一条
+					delete(have, tag)
+				}
+				continue
+			}
+			have[tag] = true
 		}
-		if _, err := a.db.Exec(`INSERT INTO task_tags (task_id, tag) VALUES (?, ?)`, taskID, tag); err != nil {
-			return err
+		for _, tag := range tags {
+			if have[tag] {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT INTO task_tags (task_id, tag) VALUES (?, ?)`, taskID, tag); err != nil {
+				return err
+			}
+			have[tag] = true
 		}
-	}
-	return nil
+		return nil
+	})
 }
 
 // parseInt64 将字符串解析为 int64
@@ -581,21 +1568,169 @@ func getEnv(key, def string) string {
 	return def
 }
 
+// envBool 读取布尔型环境变量，"1"/"true" 视为开启
+func envBool(key string) bool {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv(key)))
+	return v == "1" || v == "true"
+}
+
 // main 是应用入口，负责启动 HTTP 服务器并绑定路由
 func main() {
-	app := NewApp()
-	addr := ":" + getEnv("PORT", "8080")
+	cfg, err := LoadConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "配置加载失败: %v\n", err)
+		os.Exit(1)
+	}
+	if err := runCLI(cfg, os.Args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%v\n", err)
+		os.Exit(1)
+	}
+}
 
+// runServe 启动 HTTP 服务，收到退出信号后优雅关闭
+func runServe(cfg Config) error {
+	access, err := newAccessPolicy(cfg.Access)
+	if err != nil {
+		return err
+	}
+	keys := newSecretRing()
+	verifier, err := newRequestVerifier(cfg.Signing, keys)
+	if err != nil {
+		return err
+	}
+	if cfg.Inbox.Token != "" {
+		keys.seed(secretInbox, "", cfg.Inbox.Token)
+	}
+	if cfg.QuickActions.Secret != "" {
+		keys.seed(secretAction, "", cfg.QuickActions.Secret)
+	}
+	if err := cfg.Reminders.validate(); err != nil {
+		return err
+	}
+	if err := cfg.SMTP.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Telegram.validate(); err != nil {
+		return err
+	}
+	if err := cfg.WebPush.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Paging.validate(); err != nil {
+		return err
+	}
+	if _, ok := matchLocale(cfg.Locale); cfg.Locale != "" && !ok {
+		return fmt.Errorf("locale 必须为 %s 之一: %q", strings.Join(supportedLocales, "、"), cfg.Locale)
+	}
+	if err := cfg.JSON.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Audit.validate(); err != nil {
+		return err
+	}
+	if err := cfg.Login.validate(); err != nil {
+		return err
+	}
+	dataDir := checkDataDir(cfg.Data)
+	if dataDir.Status == checkFailed {
+		return fmt.Errorf("启动自检失败:\n  %s: %s", dataDir.Name, dataDir.Message)
+	}
+	audit, err := openAuditSink(cfg.Audit)
+	if err != nil {
+		return fmt.Errorf("打开审计日志失败: %w", err)
+	}
+	app := NewApp(cfg)
+	app.secrets = keys
+	app.oauth = newOAuthProviders(cfg.Login)
+	if audit != nil {
+		app.audit = audit
+		defer audit.Close()
+		app.logger.Info("审计日志已启用", "path", cfg.Audit.Path, "format", audit.format)
+	}
+	if err := app.loadSecretVersions(context.Background()); err != nil {
+		app.db.Close()
+		return fmt.Errorf("读取轮换的密钥失败: %w", err)
+	}
+	if cfg.QuickActions.Enabled {
+		if err := app.ensureSecret(context.Background(), secretKey{secretAction, ""}, time.Now().Truncate(time.Second)); err != nil {
+			app.db.Close()
+			return fmt.Errorf("生成快捷操作密钥失败: %w", err)
+		}
+	}
+	if err := app.seedOnboarding(context.Background()); err != nil {
+		app.db.Close()
+		return fmt.Errorf("写入示例看板失败: %w", err)
+	}
+	app.checks = append([]checkResult{dataDir}, app.selfCheck(context.Background())...)
+	if err := app.logCheckResults(app.checks); err != nil {
+		app.db.Close()
+		return err
+	}
+	app.startAutoArchive()
+	app.startAgingSummary()
+	app.startRecurrenceScheduler()
+	app.startReminders()
+	app.startDigest()
+	app.startTelegram()
+	app.startColumnNotifications()
+	app.startWatcherNotifications()
+	app.startNotifications()
+	addr := ":" + cfg.Server.Port
+
+	if cfg.Dev.Enabled {
+		app.logger.Warn("开发模式已开启：可通过 /api/dev/chaos 与 " + chaosHeader + " 请求头注入故障，请勿用于生产环境")
+	}
+	handler := withRequestDeadline(cfg.Server.WriteTimeout.Std(), app.withChaos(app.withCacheInvalidation(app.routes())))
 	srv := &http.Server{
 		Addr:         addr,
-		Handler:      app.routes(),
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Handler:      app.withJSONOptions(app.withRequestLogging(app.withAccessControl(access, app.withSignedRequests(verifier, app.withSessions(app.withAPIKeys(access, app.withBoardMembership(app.withGuestMode(app.withAudit(access, handler))))))))),
+		ReadTimeout:  cfg.Server.ReadTimeout.Std(),
+		WriteTimeout: cfg.Server.WriteTimeout.Std(),
+		IdleTimeout:  cfg.Server.IdleTimeout.Std(),
+	}
+
+	tlsCfg := cfg.TLS
+	if err := tlsCfg.validate(); err != nil {
+		app.db.Close()
+		return fmt.Errorf("TLS 配置无效: %w", err)
 	}
+	serve, challenge := tlsCfg.prepareServer(srv)
 
-	app.logger.Printf("HTTP 服务启动于 %s", addr)
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		app.logger.Fatalf("服务器启动失败: %v", err)
+	// 收到 SIGINT/SIGTERM 后停止接收新请求，排空进行中的请求与后台任务再退出
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errCh := make(chan error, 2)
+	go func() {
+		app.logStartupBanner(addr, tlsCfg.mode())
+		errCh <- serve()
+	}()
+	if challenge != nil {
+		go func() {
+			app.logger.Info("ACME 挑战服务启动", "addr", challenge.Addr, "domains", tlsCfg.AutocertDomains)
+			errCh <- challenge.ListenAndServe()
+		}()
+	}
+	select {
+	case err := <-errCh:
+		if err != nil && err != http.ErrServerClosed {
+			app.db.Close()
+			return fmt.Errorf("服务器启动失败: %w", err)
+		}
+	case <-ctx.Done():
+		stop()
+		app.logger.Info("收到退出信号，开始优雅关闭", "timeout", cfg.Server.ShutdownTimeout.Std().String())
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout.Std())
+	defer cancel()
+	if challenge != nil {
+		_ = challenge.Shutdown(shutdownCtx)
 	}
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		app.logger.Error("HTTP 服务关闭失败", "err", err)
+	}
+	if err := app.Close(shutdownCtx); err != nil {
+		app.logger.Error("数据库关闭失败", "err", err)
+	}
+	app.logger.Info("服务已退出")
+	return nil
 }
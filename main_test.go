@@ -0,0 +1,17 @@
+package main
+
+import "testing"
+
+// newTestApp 创建使用临时数据目录的应用实例，测试结束时关闭
+func newTestApp(t *testing.T, configure func(*Config)) *App {
+	t.Helper()
+	cfg := defaultConfig()
+	cfg.Data.Dir = t.TempDir()
+	cfg.Log.Level = "error"
+	if configure != nil {
+		configure(&cfg)
+	}
+	app := NewApp(cfg)
+	t.Cleanup(func() { app.db.Close() })
+	return app
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// maxImportTasks 单次导入可创建的任务上限
+const maxImportTasks = 500
+
+// markdownImportRequest 为 Markdown 导入的请求体
+type markdownImportRequest struct {
+	Markdown string   `json:"markdown"`
+	Status   string   `json:"status,omitempty"` // 新任务所在列，缺省为规划中
+	Tags     []string `json:"tags,omitempty"`   // 附加到全部新任务的标签
+	DryRun   bool     `json:"dry_run,omitempty"`
+}
+
+// importedTask 为从 Markdown 解析出的一个任务
+type importedTask struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Items       int    `json:"items"` // 清单项数量
+}
+
+// markdownImportResponse 为 Markdown 导入的响应结构
+type markdownImportResponse struct {
+	Created []int64        `json:"created"`
+	Tasks   []importedTask `json:"tasks"`
+}
+
+var (
+	mdHeadingPattern  = regexp.MustCompile(`^(#{1,6})\s+(.*?)\s*#*\s*$`)
+	mdListItemPattern = regexp.MustCompile(`^([ \t]*)(?:[-*+]|\d+[.)])\s+(?:\[([ xX])\]\s*)?(.*)$`)
+)
+
+// parseMarkdownTasks 将 Markdown 文档转换为任务：每个标题为一个任务，其下的列表项转为清单行
+// （"- [ ] 内容"，保留勾选状态与嵌套层级），其余正文原样保留在描述中。
+// 仅包含下级标题、自身没有正文的标题视为分组，不单独创建任务。
+func parseMarkdownTasks(doc string) []importedTask {
+	type section struct {
+		level int
+		task  importedTask
+		body  []string
+	}
+	var sections []section
+	inFence := false
+	sc := bufio.NewScanner(strings.NewReader(doc))
+	sc.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for sc.Scan() {
+		line := strings.TrimRight(sc.Text(), " \t\r")
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+		}
+		if !inFence {
+			if m := mdHeadingPattern.FindStringSubmatch(line); m != nil && strings.TrimSpace(m[2]) != "" {
+				sections = append(sections, section{level: len(m[1]), task: importedTask{Title: strings.TrimSpace(m[2])}})
+				continue
+			}
+		}
+		if len(sections) == 0 {
+			continue // 首个标题之前的内容不属于任何任务
+		}
+		cur := &sections[len(sections)-1]
+		if m := mdListItemPattern.FindStringSubmatch(line); !inFence && m != nil && strings.TrimSpace(m[3]) != "" {
+			depth := len(strings.ReplaceAll(m[1], "\t", "    ")) / 2
+			mark := " "
+			if m[2] == "x" || m[2] == "X" {
+				mark = "x"
+			}
+			line = strings.Repeat("  ", depth) + "- [" + mark + "] " + strings.TrimSpace(m[3])
+			cur.task.Items++
+		}
+		cur.body = append(cur.body, line)
+	}
+	var out []importedTask
+	for i, s := range sections {
+		desc := strings.Trim(strings.Join(s.body, "\n"), "\n")
+		if desc == "" && i+1 < len(sections) && sections[i+1].level > s.level {
+			continue
+		}
+		s.task.Description = desc
+		out = append(out, s.task)
+	}
+	return out
+}
+
+// handleMarkdownImport 从 Markdown 文档批量创建任务；dry_run 时仅返回解析结果
+func (a *App) handleMarkdownImport(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var body markdownImportRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	status := strings.TrimSpace(body.Status)
+	if status == "" {
+		status = "规划中"
+	}
+	if !validStatus(status) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid status"})
+		return
+	}
+	tasks := parseMarkdownTasks(body.Markdown)
+	if len(tasks) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "no headings found"})
+		return
+	}
+	if len(tasks) > maxImportTasks {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "too many tasks"})
+		return
+	}
+	body.Tags = a.cfg.Tags.normalizeAll(body.Tags)
+	if !a.checkTagLimit(w, body.Tags) {
+		return
+	}
+	// 逐个任务求值校验规则，字段错误以 tasks[i] 标明对应的任务；预览时同样返回
+	rules, err := a.fetchValidationRules(ctx, true)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	var violations []fieldError
+	for i, t := range tasks {
+		candidate := Task{Title: t.Title, Description: t.Description, Status: status, Tags: body.Tags, CreatedAt: time.Now()}
+		for _, e := range evalValidationRules(a.reqLogger(r), rules, "create", candidate) {
+			e.Field = strings.TrimSuffix(fmt.Sprintf("tasks[%d].%s", i, e.Field), ".")
+			violations = append(violations, e)
+		}
+	}
+	if len(violations) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, validationErrorResponse{Error: "validation failed", Fields: violations})
+		return
+	}
+	resp := markdownImportResponse{Created: []int64{}, Tasks: tasks}
+	if body.DryRun {
+		writeJSON(w, http.StatusOK, resp)
+		return
+	}
+	if !a.checkTaskLimit(ctx, w, len(tasks)) {
+		return
+	}
+	ids, err := a.insertImportedTasks(ctx, tasks, status, body.Tags)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	resp.Created = ids
+	a.reqLogger(r).Info("Markdown 导入完成", "count", len(ids))
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+// insertImportedTasks 在同一事务中创建导入的任务，任一失败则全部回滚
+func (a *App) insertImportedTasks(ctx context.Context, tasks []importedTask, status string, tags []string) ([]int64, error) {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	now := time.Now().Format(time.RFC3339)
+	ids := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO tasks (title, description, status, archived, created_at, updated_at, status_changed_at)
+			VALUES (?, ?, ?, 0, ?, ?, ?)
+		`, t.Title, t.Description, status, now, now, now)
+		if err != nil {
+			return nil, err
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return nil, err
+		}
+		for _, tag := range tags {
+			if tag = strings.TrimSpace(tag); tag == "" {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO task_tags (task_id, tag) VALUES (?, ?)`, id, tag); err != nil {
+				return nil, err
+			}
+		}
+		ids = append(ids, id)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return ids, nil
+}
@@ -0,0 +1,348 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"html"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 描述与评论中的编号引用：保存描述或添加评论时解析其中的 #42、TB-42 等引用，
+// 自动记为 task_relations 中的「提及」关系（按来源分别记录，编辑描述时只替换描述产生的关系），
+// 因此被提及的任务也能反查到谁提到了它。渲染接口把引用替换为链接并附带目标当前的状态徽标，
+// 状态在渲染时读取，始终是最新的。引用语法以 idRefSyntaxes 注册，新增一种编号只需追加一项
+
+// 提及关系的来源
+const (
+	mentionOriginDescription = "description"
+	mentionOriginComment     = "comment"
+)
+
+// idRefTarget 为一个编号引用解析出的目标；TaskID 非 0 时记为任务间的提及关系
+type idRefTarget struct {
+	TaskID int64
+	URL    string
+	Label  string
+	Status string
+}
+
+// idRefSyntax 为一种编号引用语法：Pattern 的第一个分组为编号，Resolve 返回目标，不存在时返回 false
+type idRefSyntax struct {
+	Kind    string
+	Pattern *regexp.Regexp
+	Resolve func(ctx context.Context, a *App, id int64) (idRefTarget, bool, error)
+}
+
+// idRefSyntaxes 为已注册的引用语法
+var idRefSyntaxes = []idRefSyntax{
+	{Kind: "task", Pattern: taskIDPattern, Resolve: resolveTaskRef},
+}
+
+// resolveTaskRef 解析任务编号引用
+func resolveTaskRef(ctx context.Context, a *App, id int64) (idRefTarget, bool, error) {
+	var title, status string
+	err := a.db.QueryRowContext(ctx, `SELECT title, status FROM tasks WHERE id = ?`, id).Scan(&title, &status)
+	if err == sql.ErrNoRows {
+		return idRefTarget{}, false, nil
+	}
+	if err != nil {
+		return idRefTarget{}, false, err
+	}
+	return idRefTarget{TaskID: id, URL: "/?task=" + strconv.FormatInt(id, 10), Label: title, Status: status}, true, nil
+}
+
+// idRefMatch 为文本中的一处引用，Start/End 为字节位置
+type idRefMatch struct {
+	Start, End int
+	Syntax     *idRefSyntax
+	ID         int64
+}
+
+// findIDRefs 按出现顺序返回文本中各语法的引用，重叠时保留先出现的
+func findIDRefs(text string) []idRefMatch {
+	var out []idRefMatch
+	for i := range idRefSyntaxes {
+		s := &idRefSyntaxes[i]
+		for _, m := range s.Pattern.FindAllStringSubmatchIndex(text, -1) {
+			id, err := parseInt64(text[m[2]:m[3]])
+			if err != nil || id <= 0 {
+				continue
+			}
+			out = append(out, idRefMatch{Start: m[0], End: m[1], Syntax: s, ID: id})
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Start < out[j].Start })
+	kept := out[:0]
+	end := 0
+	for _, m := range out {
+		if m.Start >= end {
+			kept = append(kept, m)
+			end = m.End
+		}
+	}
+	return kept
+}
+
+// syncTaskMentions 以文本中引用的任务替换某一来源产生的提及关系；引用自身与不存在的任务会被忽略。
+// 同一来源的 @ 提及一并更新
+func (a *App) syncTaskMentions(ctx context.Context, taskID int64, origin string, originID int64, text string) error {
+	if _, err := a.db.ExecContext(ctx, `DELETE FROM task_relations WHERE task_id = ? AND origin = ? AND origin_id = ?`, taskID, origin, originID); err != nil {
+		return err
+	}
+	now := time.Now().Format(time.RFC3339)
+	for _, m := range findIDRefs(text) {
+		target, ok, err := m.Syntax.Resolve(ctx, a, m.ID)
+		if err != nil {
+			return err
+		}
+		if !ok || target.TaskID == 0 || target.TaskID == taskID {
+			continue
+		}
+		if _, err := a.db.ExecContext(ctx, `INSERT OR IGNORE INTO task_relations (task_id, related_id, origin, origin_id, created_at) VALUES (?, ?, ?, ?, ?)`,
+			taskID, target.TaskID, origin, originID, now); err != nil {
+			return err
+		}
+	}
+	return a.syncUserMentions(ctx, taskID, origin, originID, text)
+}
+
+// syncDescriptionMentions 在保存描述后更新提及关系，失败只记录日志，不影响已保存的修改
+func (a *App) syncDescriptionMentions(r *http.Request, taskID int64, description string) {
+	if err := a.syncTaskMentions(r.Context(), taskID, mentionOriginDescription, 0, description); err != nil {
+		a.reqLogger(r).Warn("更新任务提及关系失败", "task_id", taskID, "err", err)
+	}
+}
+
+// renderIDRefs 将文本渲染为 HTML：转义内容、保留换行，并把可解析的引用替换为链接与状态徽标
+func (a *App) renderIDRefs(ctx context.Context, text string) (string, error) {
+	var b strings.Builder
+	pos := 0
+	for _, m := range findIDRefs(text) {
+		target, ok, err := m.Syntax.Resolve(ctx, a, m.ID)
+		if err != nil {
+			return "", err
+		}
+		if !ok {
+			continue
+		}
+		b.WriteString(html.EscapeString(text[pos:m.Start]))
+		b.WriteString(`<a class="id-ref" data-kind="` + html.EscapeString(m.Syntax.Kind) + `" href="` + html.EscapeString(target.URL) + `" title="` + html.EscapeString(target.Label) + `">`)
+		b.WriteString(html.EscapeString(text[m.Start:m.End]))
+		b.WriteString(`</a>`)
+		if target.Status != "" {
+			b.WriteString(` <span class="status-badge" data-status="` + html.EscapeString(target.Status) + `">` + html.EscapeString(target.Status) + `</span>`)
+		}
+		pos = m.End
+	}
+	b.WriteString(html.EscapeString(text[pos:]))
+	return strings.ReplaceAll(b.String(), "\n", "<br>\n"), nil
+}
+
+// mentionedTask 为提及关系另一端的任务；Origins 为产生该关系的来源（description 或 comment:<评论 ID>）
+type mentionedTask struct {
+	ID       int64    `json:"id"`
+	Title    string   `json:"title"`
+	Status   string   `json:"status"`
+	Archived bool     `json:"archived"`
+	Origins  []string `json:"origins"`
+}
+
+// taskMentionsResponse 为任务提及关系的响应结构：Mentions 为本任务提到的任务，MentionedBy 为提到本任务的任务
+type taskMentionsResponse struct {
+	TaskID      int64           `json:"task_id"`
+	Mentions    []mentionedTask `json:"mentions"`
+	MentionedBy []mentionedTask `json:"mentioned_by"`
+}
+
+// renderedComment 为渲染后的一条评论
+type renderedComment struct {
+	ID       int64  `json:"id"`
+	BodyHTML string `json:"body_html"`
+}
+
+// renderedTaskResponse 为任务描述与评论渲染结果的响应结构
+type renderedTaskResponse struct {
+	TaskID          int64             `json:"task_id"`
+	DescriptionHTML string            `json:"description_html"`
+	Comments        []renderedComment `json:"comments"`
+}
+
+// fetchMentions 查询提及关系；incoming 为 true 时返回提到该任务的任务
+func (a *App) fetchMentions(ctx context.Context, taskID int64, incoming bool) ([]mentionedTask, error) {
+	self, other := "r.task_id", "r.related_id"
+	if incoming {
+		self, other = other, self
+	}
+	// 被提及的任务压缩到归档库后不在主库中，仍列出编号并视为已归档
+	rows, err := a.db.QueryContext(ctx, `SELECT `+other+`, COALESCE(t.title, ''), COALESCE(t.status, ''), COALESCE(t.archived, 1), r.origin, r.origin_id
+		FROM task_relations r LEFT JOIN tasks t ON t.id = `+other+`
+		WHERE `+self+` = ? ORDER BY `+other+`, r.origin, r.origin_id`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []mentionedTask{}
+	for rows.Next() {
+		var m mentionedTask
+		var origin string
+		var originID int64
+		if err := rows.Scan(&m.ID, &m.Title, &m.Status, &m.Archived, &origin, &originID); err != nil {
+			return nil, err
+		}
+		if originID != 0 {
+			origin += ":" + strconv.FormatInt(originID, 10)
+		}
+		if n := len(out); n > 0 && out[n-1].ID == m.ID {
+			out[n-1].Origins = append(out[n-1].Origins, origin)
+			continue
+		}
+		m.Origins = []string{origin}
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// handleTaskMentions 处理 GET /api/tasks/{id}/mentions：返回双向的提及关系
+func (a *App) handleTaskMentions(w http.ResponseWriter, r *http.Request, taskID int64) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	ok, err := a.taskExists(ctx, taskID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	}
+	resp := taskMentionsResponse{TaskID: taskID}
+	if resp.Mentions, err = a.fetchMentions(ctx, taskID, false); err == nil {
+		resp.MentionedBy, err = a.fetchMentions(ctx, taskID, true)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleTaskRendered 处理 GET /api/tasks/{id}/rendered：返回描述与评论的 HTML，引用渲染为带当前状态徽标的链接
+func (a *App) handleTaskRendered(w http.ResponseWriter, r *http.Request, taskID int64) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	t, err := a.fetchTaskDetail(ctx, taskID)
+	if err == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	resp := renderedTaskResponse{TaskID: taskID, Comments: []renderedComment{}}
+	if resp.DescriptionHTML, err = a.renderIDRefs(ctx, t.Description); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	comments, err := a.fetchTaskComments(ctx, taskID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	for _, c := range comments {
+		body, err := a.renderIDRefs(ctx, c.Body)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		resp.Comments = append(resp.Comments, renderedComment{ID: c.ID, BodyHTML: body})
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// rebuildTaskMentions 按全部任务的描述与评论重建提及关系与 @ 提及，用于升级后为已有数据补齐；
+// @ 提及按来源增删，未变化的记录保留，不会重新触发通知。返回两者的数量
+func (a *App) rebuildTaskMentions(ctx context.Context) (relations, users int, err error) {
+	type source struct {
+		taskID, originID int64
+		origin, text     string
+	}
+	var sources []source
+	rows, err := a.db.QueryContext(ctx, `SELECT id, 0, 'description', description FROM tasks
+		UNION ALL SELECT task_id, id, 'comment', body FROM task_comments`)
+	if err != nil {
+		return 0, 0, err
+	}
+	for rows.Next() {
+		var s source
+		if err := rows.Scan(&s.taskID, &s.originID, &s.origin, &s.text); err != nil {
+			rows.Close()
+			return 0, 0, err
+		}
+		sources = append(sources, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, 0, err
+	}
+	if _, err := a.db.ExecContext(ctx, `DELETE FROM user_mentions WHERE origin = 'comment' AND origin_id NOT IN (SELECT id FROM task_comments)`); err != nil {
+		return 0, 0, err
+	}
+	if _, err := a.db.ExecContext(ctx, `DELETE FROM task_relations`); err != nil {
+		return 0, 0, err
+	}
+	for _, s := range sources {
+		if err := a.syncTaskMentions(ctx, s.taskID, s.origin, s.originID, s.text); err != nil {
+			return 0, 0, err
+		}
+	}
+	err = a.db.QueryRowContext(ctx, `SELECT (SELECT COUNT(*) FROM task_relations), (SELECT COUNT(*) FROM user_mentions)`).Scan(&relations, &users)
+	return relations, users, err
+}
+
+// migrateTaskRelations 去掉旧库中 related_id 的外键：被提及的任务压缩到归档库时，
+// 活动任务指向它的提及关系须保留在主库，不能随之级联删除
+func (a *App) migrateTaskRelations() error {
+	var ddl string
+	if err := a.db.QueryRow(`SELECT sql FROM sqlite_master WHERE type = 'table' AND name = 'task_relations'`).Scan(&ddl); err != nil {
+		return err
+	}
+	if !strings.Contains(ddl, "FOREIGN KEY(related_id)") {
+		return nil
+	}
+	tx, err := a.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(`
+		ALTER TABLE task_relations RENAME TO task_relations_old;
+		CREATE TABLE task_relations (
+			task_id INTEGER NOT NULL,
+			related_id INTEGER NOT NULL,
+			origin TEXT NOT NULL,
+			origin_id INTEGER NOT NULL DEFAULT 0,
+			created_at TEXT NOT NULL,
+			PRIMARY KEY (task_id, related_id, origin, origin_id),
+			FOREIGN KEY(task_id) REFERENCES tasks(id) ON DELETE CASCADE
+		);
+		INSERT INTO task_relations SELECT task_id, related_id, origin, origin_id, created_at FROM task_relations_old;
+		DROP TABLE task_relations_old;
+		CREATE INDEX IF NOT EXISTS idx_task_relations_related ON task_relations(related_id);
+	`); err != nil {
+		return fmt.Errorf("迁移 task_relations 失败: %w", err)
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+	"gopkg.in/yaml.v3"
+)
+
+// move-data 命令：将数据目录（主库、按年归档库与目录中的其他文件）迁移到新目录。
+// 步骤为：把 WAL 合并回主库（有其他连接占用时中止），复制全部文件并逐个核对 SHA-256，
+// 对新库执行 integrity_check，再确认复制期间原文件没有变化（服务仍在运行时会被发现），
+// 然后将原目录重命名为 <原目录>.moved-<时间> 作为备份，并更新配置文件中的 data.dir 与 data.path。
+// 迁移须在服务停止后进行；使用 data.dsn 时无法确定库文件位置，不支持迁移
+
+// dataFileDigest 为数据目录中一个文件的相对路径与校验和
+type dataFileDigest struct {
+	Rel  string
+	Hash string
+	Size int64
+}
+
+// runMoveData 迁移数据目录
+func runMoveData(cfg Config, args []string) error {
+	flags := flag.NewFlagSet("move-data", flag.ContinueOnError)
+	to := flags.String("to", "", "新的数据目录，须不存在或为空")
+	configPath := flags.String("config", getEnv("CONFIG_FILE", "config.yaml"), "需要更新 data.dir 的配置文件，不存在时跳过")
+	if ok, err := parseFlags(flags, args); !ok {
+		return err
+	}
+	if strings.TrimSpace(*to) == "" {
+		return errors.New("请通过 -to 指定新的数据目录")
+	}
+	if cfg.Data.DSN != "" {
+		return errors.New("使用 data.dsn 时无法确定库文件位置，请改用 data.dir 后再迁移")
+	}
+	src, err := filepath.Abs(cfg.Data.Dir)
+	if err != nil {
+		return err
+	}
+	dst, err := filepath.Abs(*to)
+	if err != nil {
+		return err
+	}
+	if src == dst {
+		return fmt.Errorf("数据目录已是 %s", dst)
+	}
+	if strings.HasPrefix(dst, src+string(filepath.Separator)) {
+		return fmt.Errorf("新目录 %s 不能位于原数据目录中", dst)
+	}
+	dbPath, err := filepath.Abs(cfg.Data.dbPath())
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(dbPath); err != nil {
+		return fmt.Errorf("找不到数据库文件 %s: %w", dbPath, err)
+	}
+	rel, err := filepath.Rel(src, dbPath)
+	if err != nil || strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("数据库文件 %s 不在数据目录 %s 中，请先将其移入数据目录", dbPath, src)
+	}
+	if entries, err := os.ReadDir(dst); err == nil && len(entries) > 0 {
+		return fmt.Errorf("新目录 %s 不为空", dst)
+	} else if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	// 1. 合并 WAL，确保主库文件包含全部数据
+	if err := checkpointForMove(cfg); err != nil {
+		return err
+	}
+	// 2. 复制并逐个核对
+	before, err := digestDataDir(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dst, 0o755); err != nil {
+		return err
+	}
+	for _, f := range before {
+		if err := copyDataFile(filepath.Join(src, f.Rel), filepath.Join(dst, f.Rel)); err != nil {
+			return fmt.Errorf("复制 %s 失败: %w", f.Rel, err)
+		}
+	}
+	copied, err := digestDataDir(dst)
+	if err != nil {
+		return err
+	}
+	if err := compareDataDigests(before, copied); err != nil {
+		return fmt.Errorf("复制结果校验失败，原目录未改动: %w", err)
+	}
+	// 3. 新库完整性检查
+	if err := integrityCheck(filepath.Join(dst, rel)); err != nil {
+		return fmt.Errorf("新库完整性检查失败，原目录未改动: %w", err)
+	}
+	// 4. 确认复制期间原文件没有被写入
+	after, err := digestDataDir(src)
+	if err != nil {
+		return err
+	}
+	if err := compareDataDigests(before, after); err != nil {
+		return fmt.Errorf("复制期间原数据发生变化，服务可能仍在运行；请停止服务、清空 %s 后重试: %w", dst, err)
+	}
+	// 5. 保留原目录作为备份，并更新配置
+	backup := src + ".moved-" + time.Now().Format("20060102T150405")
+	if err := os.Rename(src, backup); err != nil {
+		return fmt.Errorf("重命名原目录失败（新目录已就绪）: %w", err)
+	}
+	fmt.Printf("已迁移 %d 个文件到 %s，原目录已重命名为 %s，确认服务正常后可删除\n", len(before), dst, backup)
+	newPath := ""
+	if cfg.Data.Path != "" {
+		newPath = filepath.Join(dst, rel)
+	}
+	updated, err := updateDataConfig(*configPath, dst, newPath)
+	switch {
+	case err != nil:
+		fmt.Printf("更新配置文件失败，请手动将 data.dir 改为 %s: %v\n", dst, err)
+	case updated:
+		fmt.Printf("已更新 %s 中的 data.dir（原文件备份为 %s.bak）\n", *configPath, *configPath)
+	default:
+		fmt.Printf("配置文件 %s 不存在，请将 data.dir 设置为 %s\n", *configPath, dst)
+	}
+	for _, env := range []string{"DATA_DIR", "DB_PATH"} {
+		if os.Getenv(env) != "" {
+			fmt.Printf("注意：环境变量 %s 优先于配置文件，请同步修改\n", env)
+		}
+	}
+	return nil
+}
+
+// checkpointForMove 将 WAL 合并回主库并截断。连接以独占锁定模式访问库文件，
+// 服务进程即使空闲也会持有 WAL 模式下的共享锁，此时加锁失败并返回错误
+func checkpointForMove(cfg Config) error {
+	cfg.Log.Level = "warn"
+	app := NewApp(cfg)
+	defer app.db.Close()
+	ctx := context.Background()
+	conn, err := app.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	if _, err := conn.ExecContext(ctx, `PRAGMA locking_mode = EXCLUSIVE`); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, `BEGIN EXCLUSIVE; COMMIT`); err != nil {
+		return fmt.Errorf("数据库正被其他进程使用，请先停止服务: %w", err)
+	}
+	var busy, logFrames, checkpointed int
+	if err := conn.QueryRowContext(ctx, `PRAGMA wal_checkpoint(TRUNCATE)`).Scan(&busy, &logFrames, &checkpointed); err != nil {
+		return fmt.Errorf("合并 WAL 失败: %w", err)
+	}
+	if busy != 0 {
+		return errors.New("数据库正被其他进程使用，请先停止服务")
+	}
+	return nil
+}
+
+// digestDataDir 计算目录中全部文件的校验和；WAL 与共享内存文件在合并后不含数据，跳过
+func digestDataDir(dir string) ([]dataFileDigest, error) {
+	var out []dataFileDigest
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		if strings.HasSuffix(path, "-wal") || strings.HasSuffix(path, "-shm") {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return fmt.Errorf("%s 不是普通文件，请手动处理", path)
+		}
+		rel, _ := filepath.Rel(dir, path)
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha256.New()
+		n, err := io.Copy(h, f)
+		if err != nil {
+			return err
+		}
+		out = append(out, dataFileDigest{Rel: rel, Hash: hex.EncodeToString(h.Sum(nil)), Size: n})
+		return nil
+	})
+	return out, err
+}
+
+// compareDataDigests 比较两组文件校验和
+func compareDataDigests(want, got []dataFileDigest) error {
+	if len(want) != len(got) {
+		return fmt.Errorf("文件数不一致：%d / %d", len(want), len(got))
+	}
+	for i := range want {
+		if want[i] != got[i] {
+			return fmt.Errorf("%s 内容不一致", want[i].Rel)
+		}
+	}
+	return nil
+}
+
+// copyDataFile 复制文件并同步到磁盘，保留权限位
+func copyDataFile(from, to string) error {
+	in, err := os.Open(from)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(to), 0o755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(to, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// integrityCheck 以只读方式打开库文件并执行 integrity_check，immutable 避免在新目录中留下 WAL 文件；不设慢查询阈值，无需日志
+func integrityCheck(path string) error {
+	db := sql.OpenDB(&timedConnector{dsn: "file:" + path + "?mode=ro&immutable=1", drv: &sqlite3.SQLiteDriver{ConnectHook: registerSearchFuncs}, stats: &queryStats{}})
+	defer db.Close()
+	var result string
+	if err := db.QueryRowContext(context.Background(), `PRAGMA integrity_check`).Scan(&result); err != nil {
+		return err
+	}
+	if result != "ok" {
+		return errors.New(result)
+	}
+	return nil
+}
+
+// updateDataConfig 更新配置文件中的 data.dir（及 newPath 非空时的 data.path），保留其余内容与注释；
+// 配置文件不存在时返回 false
+func updateDataConfig(path, dir, dbPath string) (bool, error) {
+	raw, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(raw, &doc); err != nil {
+		return false, err
+	}
+	if len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return false, errors.New("配置文件顶层不是映射")
+	}
+	data := yamlMapValue(doc.Content[0], "data")
+	if data.Kind != yaml.MappingNode {
+		data.Kind, data.Tag, data.Value = yaml.MappingNode, "!!map", ""
+	}
+	setYAMLString(data, "dir", dir)
+	if dbPath != "" {
+		setYAMLString(data, "path", dbPath)
+	}
+	var out bytes.Buffer
+	enc := yaml.NewEncoder(&out)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return false, err
+	}
+	if err := os.WriteFile(path+".bak", raw, 0o600); err != nil {
+		return false, err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, out.Bytes(), 0o644); err != nil {
+		return false, err
+	}
+	return true, os.Rename(tmp, path)
+}
+
+// yamlMapValue 返回映射中键对应的值节点，不存在时追加一个空节点
+func yamlMapValue(m *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i+1]
+		}
+	}
+	k := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}
+	v := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+	m.Content = append(m.Content, k, v)
+	return v
+}
+
+// setYAMLString 设置映射中键的字符串值，保留该行的注释
+func setYAMLString(m *yaml.Node, key, value string) {
+	v := yamlMapValue(m, key)
+	v.Kind, v.Tag, v.Value, v.Style, v.Content = yaml.ScalarNode, "!!str", value, 0, nil
+}
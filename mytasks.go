@@ -0,0 +1,140 @@
+package main
+
+import (
+	"database/sql"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// 个人任务队列：/api/me/tasks 汇总与当前登录用户相关的任务：用户关注的任务，以及描述或评论中 @ 提到该用户的任务
+// （见 usermentions.go）。结果按截止时间排列，没有截止时间的排在最后，同截止时间按最近更新排列。
+// 任务没有负责人与优先级字段，因此不含「分配给我」的任务，也不按优先级排序
+
+// 任务与当前用户相关的原因
+const (
+	myTaskWatching  = "watching"
+	myTaskMentioned = "mentioned"
+)
+
+// myTask 为个人队列中的一个任务及其与用户相关的原因
+type myTask struct {
+	Task
+	Reasons []string `json:"reasons"`
+}
+
+// myTasksResponse 为个人任务队列的响应结构
+type myTasksResponse struct {
+	UserID int64    `json:"user_id"`
+	Items  []myTask `json:"items"`
+}
+
+// taskWatchResponse 为关注状态的响应结构
+type taskWatchResponse struct {
+	TaskID   int64 `json:"task_id"`
+	Watching bool  `json:"watching"`
+	Watchers int   `json:"watchers"`
+}
+
+// handleMyTasks 处理 GET /api/me/tasks：当前用户关注或被 @ 提到的未归档任务；
+// 缺省不含已完成的任务，include_done=1 时包含
+func (a *App) handleMyTasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	userID := currentUserID(r)
+	if userID == 0 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "not logged in"})
+		return
+	}
+	reasons := map[int64][]string{}
+	watched, err := a.queryIDs(ctx, `SELECT task_id FROM task_watchers WHERE user_id = ?`, userID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	for _, id := range watched {
+		reasons[id] = append(reasons[id], myTaskWatching)
+	}
+	mentioned, err := a.mentionedTaskIDs(ctx, userID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	for _, id := range mentioned {
+		reasons[id] = append(reasons[id], myTaskMentioned)
+	}
+	includeDone := r.URL.Query().Get("include_done") == "1"
+	resp := myTasksResponse{UserID: userID, Items: []myTask{}}
+	for id, why := range reasons {
+		t, err := a.fetchTaskDetail(ctx, id)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if t.Archived || (t.Status == "已完成" && !includeDone) {
+			continue
+		}
+		resp.Items = append(resp.Items, myTask{Task: t, Reasons: why})
+	}
+	sort.Slice(resp.Items, func(i, j int) bool {
+		a, b := resp.Items[i], resp.Items[j]
+		if (a.DueAt == nil) != (b.DueAt == nil) {
+			return a.DueAt != nil
+		}
+		if a.DueAt != nil && !a.DueAt.Equal(*b.DueAt) {
+			return a.DueAt.Before(*b.DueAt)
+		}
+		if !a.UpdatedAt.Equal(b.UpdatedAt) {
+			return a.UpdatedAt.After(b.UpdatedAt)
+		}
+		return a.ID < b.ID
+	})
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// handleTaskWatch 处理 /api/tasks/{id}/watch：GET 查看关注状态，PUT 关注，DELETE 取消关注；需登录
+func (a *App) handleTaskWatch(w http.ResponseWriter, r *http.Request, taskID int64) {
+	ctx := r.Context()
+	userID := currentUserID(r)
+	if userID == 0 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "not logged in"})
+		return
+	}
+	ok, err := a.taskExists(ctx, taskID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		_, err = a.db.ExecContext(ctx, `INSERT OR IGNORE INTO task_watchers (task_id, user_id, created_at) VALUES (?, ?, ?)`,
+			taskID, userID, time.Now().Format(time.RFC3339))
+	case http.MethodDelete:
+		_, err = a.db.ExecContext(ctx, `DELETE FROM task_watchers WHERE task_id = ? AND user_id = ?`, taskID, userID)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	resp := taskWatchResponse{TaskID: taskID}
+	if err := a.db.QueryRowContext(ctx, `SELECT COUNT(*), COALESCE(SUM(user_id = ?), 0) > 0 FROM task_watchers WHERE task_id = ?`,
+		userID, taskID).Scan(&resp.Watchers, &resp.Watching); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
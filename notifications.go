@@ -0,0 +1,416 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 站内通知：后台任务跟踪任务事件、历史版本与评论，为登录用户生成通知：
+// 描述或评论中 @ 提到该用户（mention）、关注的任务状态变化、归档或恢复、内容修改与新增评论（watched），
+// 以及关注的未完成任务将在一天内截止（due_soon）。同一来源对同一用户只生成一条通知，提及优先于关注。
+// 任务没有负责人字段，因此没有分配通知。已读超过 notificationRetention 的通知会被清理。
+// 配置了 web_push 时，提及与截止提醒还会推送到用户订阅的浏览器（见 webpush.go）
+
+// 通知类型
+const (
+	notificationMention = "mention"
+	notificationWatched = "watched"
+	notificationDueSoon = "due_soon"
+)
+
+// notificationPollInterval 为生成通知的间隔
+const notificationPollInterval = 10 * time.Second
+
+// notificationDueLead 为截止提醒的提前量
+const notificationDueLead = 24 * time.Hour
+
+// notificationRetention 为已读通知的保留时间
+const notificationRetention = 90 * 24 * time.Hour
+
+// Notification 为一条站内通知
+type Notification struct {
+	ID        int64      `json:"id"`
+	Kind      string     `json:"kind"`
+	TaskID    *int64     `json:"task_id,omitempty"`
+	TaskTitle string     `json:"task_title,omitempty"`
+	Body      string     `json:"body"`
+	Read      bool       `json:"read"`
+	ReadAt    *time.Time `json:"read_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// notificationListResponse 为通知列表的响应结构，按时间从新到旧排列
+type notificationListResponse struct {
+	Items  []Notification `json:"items"`
+	Unread int            `json:"unread"`
+}
+
+// notificationCountResponse 为未读数的响应结构
+type notificationCountResponse struct {
+	Unread int `json:"unread"`
+}
+
+// pendingNotification 为待写入的通知，Key 标识来源，用于去重
+type pendingNotification struct {
+	UserID int64
+	Kind   string
+	TaskID int64
+	Body   localMessage
+	Key    string
+	At     string
+}
+
+// startNotifications 启动站内通知后台任务，只处理启动之后发生的变更
+func (a *App) startNotifications() {
+	cur, err := a.latestWatcherCursors(context.Background(), watcherCursors{})
+	if err != nil {
+		a.logger.Error("站内通知启动失败", "err", err)
+		return
+	}
+	a.eventCursors.set("notifications", cur.Event)
+	a.startBackground("notifications", func(ctx context.Context) {
+		ticker := time.NewTicker(notificationPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			next, err := a.feedNotifications(ctx, cur, time.Now())
+			if err != nil && ctx.Err() == nil {
+				a.logger.Error("生成站内通知失败", "err", err)
+			}
+			cur = next
+			a.eventCursors.set("notifications", cur.Event)
+		}
+	})
+}
+
+// feedNotifications 为 cur 之后的变更与即将截止的任务生成通知，返回新的游标
+func (a *App) feedNotifications(ctx context.Context, cur watcherCursors, now time.Time) (watcherCursors, error) {
+	next, err := a.latestWatcherCursors(ctx, cur)
+	if err != nil {
+		return cur, err
+	}
+	var pending []pendingNotification
+	if next != cur {
+		if pending, err = a.changeNotifications(ctx, cur, next); err != nil {
+			return cur, err
+		}
+	}
+	due, err := a.dueNotifications(ctx, now)
+	if err != nil {
+		return cur, err
+	}
+	pending = append(pending, due...)
+	created := 0
+	var pushes []pendingNotification
+	for _, n := range pending {
+		args, _ := json.Marshal(n.Body.args())
+		res, err := a.db.ExecContext(ctx, `INSERT OR IGNORE INTO notifications (user_id, kind, task_id, body, args, dedup_key, created_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			n.UserID, n.Kind, n.TaskID, n.Body.Format, string(args), n.Key, n.At)
+		if err != nil {
+			return cur, err
+		}
+		if k, _ := res.RowsAffected(); k > 0 {
+			created++
+			if n.Kind == notificationMention || n.Kind == notificationDueSoon {
+				pushes = append(pushes, n)
+			}
+		}
+	}
+	if _, err := a.db.ExecContext(ctx, `DELETE FROM notifications WHERE read_at IS NOT NULL AND read_at < ?`,
+		now.Add(-notificationRetention).Format(time.RFC3339)); err != nil {
+		return next, err
+	}
+	if created > 0 {
+		a.logger.Debug("已生成站内通知", "count", created)
+	}
+	a.pushNotifications(ctx, pushes)
+	return next, nil
+}
+
+// changeNotifications 为 (cur, next] 范围内新增的 @ 提及记录生成提及通知，为事件、历史版本与评论生成关注通知；
+// 同一来源中被提到的关注者只收到提及通知
+func (a *App) changeNotifications(ctx context.Context, cur, next watcherCursors) ([]pendingNotification, error) {
+	// 各来源统一为 (来源, 编号, 任务, 类型, 内容一, 内容二, 时间)
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT 'event', id, task_id, kind, from_status, to_status, created_at FROM task_events
+		WHERE id > ? AND id <= ? AND kind IN ('created', 'status', 'archived', 'restored')
+		UNION ALL
+		SELECT 'version', rowid, task_id, 'edited', '', '', replaced_at FROM task_versions
+		WHERE rowid > ? AND rowid <= ?
+		UNION ALL
+		SELECT 'comment', id, task_id, 'comment', author, body, created_at FROM task_comments
+		WHERE id > ? AND id <= ?`,
+		cur.Event, next.Event, cur.Version, next.Version, cur.Comment, next.Comment)
+	if err != nil {
+		return nil, err
+	}
+	var watched []pendingNotification
+	watchedTasks := map[int64]bool{}
+	createdTasks := map[int64]bool{}
+	for rows.Next() {
+		var src, kind, x, y, at string
+		var id, taskID int64
+		if err := rows.Scan(&src, &id, &taskID, &kind, &x, &y, &at); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if kind == taskEventCreated {
+			createdTasks[taskID] = true
+			continue
+		}
+		watched = append(watched, pendingNotification{Kind: notificationWatched, TaskID: taskID, Body: describeWatcherChange(kind, x, y), Key: src + ":" + strconv.FormatInt(id, 10), At: at})
+		watchedTasks[taskID] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// 新增的 @ 提及记录；描述中的提及对同一任务只通知一次，评论按评论通知
+	mrows, err := a.db.QueryContext(ctx, `
+		SELECT m.user_id, m.task_id, m.origin, m.origin_id, COALESCE(c.author, ''), COALESCE(c.body, ''), m.created_at
+		FROM user_mentions m LEFT JOIN task_comments c ON m.origin = 'comment' AND c.id = m.origin_id
+		WHERE m.id > ? AND m.id <= ? ORDER BY m.id`, cur.Mention, next.Mention)
+	if err != nil {
+		return nil, err
+	}
+	var out []pendingNotification
+	// mentioned 记录本轮被提及的用户，键为评论的来源键或 task:<任务>（描述），这些用户不再收到同一变更的关注通知
+	mentioned := map[string]map[int64]bool{}
+	for mrows.Next() {
+		var n pendingNotification
+		var origin, author, body string
+		var originID int64
+		if err := mrows.Scan(&n.UserID, &n.TaskID, &origin, &originID, &author, &body, &n.At); err != nil {
+			mrows.Close()
+			return nil, err
+		}
+		n.Kind = notificationMention
+		key := "task:" + strconv.FormatInt(n.TaskID, 10)
+		switch {
+		case origin == mentionOriginComment:
+			key = "comment:" + strconv.FormatInt(originID, 10)
+			n.Key, n.Body = key, describeMention("comment", author, body)
+		case createdTasks[n.TaskID]:
+			n.Key, n.Body = "description:"+strconv.FormatInt(n.TaskID, 10), describeMention(taskEventCreated, "", "")
+		default:
+			n.Key, n.Body = "description:"+strconv.FormatInt(n.TaskID, 10), describeMention("edited", "", "")
+		}
+		out = append(out, n)
+		if mentioned[key] == nil {
+			mentioned[key] = map[int64]bool{}
+		}
+		mentioned[key][n.UserID] = true
+	}
+	mrows.Close()
+	if err := mrows.Err(); err != nil {
+		return nil, err
+	}
+	watchers := map[int64][]int64{}
+	for taskID := range watchedTasks {
+		if watchers[taskID], err = a.queryIDs(ctx, `SELECT user_id FROM task_watchers WHERE task_id = ?`, taskID); err != nil {
+			return nil, err
+		}
+	}
+	for _, n := range watched {
+		skip := mentioned[n.Key]
+		if strings.HasPrefix(n.Key, "version:") {
+			skip = mentioned["task:"+strconv.FormatInt(n.TaskID, 10)]
+		}
+		for _, userID := range watchers[n.TaskID] {
+			if skip[userID] {
+				continue
+			}
+			n.UserID = userID
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+// describeMention 描述提及发生的位置
+func describeMention(kind, author, body string) localMessage {
+	switch kind {
+	case taskEventCreated:
+		return localMessage{Format: "在新任务的描述中提到了你"}
+	case "edited":
+		return localMessage{Format: "在任务描述中提到了你"}
+	}
+	return localMessage{"%s 在评论中提到了你：%s", []string{author, commentExcerpt(body)}}
+}
+
+// dueNotifications 为关注者生成即将截止的提醒，同一截止时间只提醒一次
+func (a *App) dueNotifications(ctx context.Context, now time.Time) ([]pendingNotification, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT w.user_id, t.id, t.due_at FROM tasks t JOIN task_watchers w ON w.task_id = t.id
+		WHERE t.archived = 0 AND t.status <> '已完成' AND t.due_at > ? AND t.due_at <= ?`,
+		now.Format(time.RFC3339), now.Add(notificationDueLead).Format(time.RFC3339))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []pendingNotification
+	for rows.Next() {
+		var n pendingNotification
+		var dueAt string
+		if err := rows.Scan(&n.UserID, &n.TaskID, &dueAt); err != nil {
+			return nil, err
+		}
+		due, _ := time.Parse(time.RFC3339, dueAt)
+		n.Kind, n.Key, n.At = notificationDueSoon, fmt.Sprintf("due:%d:%s", n.TaskID, dueAt), now.Format(time.RFC3339)
+		n.Body = localMessage{"将于 %s 截止", []string{due.Local().Format("01-02 15:04")}}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+// handleNotifications 处理 GET /api/notifications：当前用户的通知（按请求协商的语言生成内容），unread=1 时只返回未读，
+// limit 为条数（缺省 50，最多 200），before 为上一页最后一条的编号
+func (a *App) handleNotifications(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	userID := currentUserID(r)
+	if userID == 0 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "not logged in"})
+		return
+	}
+	q := r.URL.Query()
+	limit := int64(50)
+	if v, err := parseInt64(q.Get("limit")); err == nil && v > 0 && v <= 200 {
+		limit = v
+	}
+	locale := a.messageLocale(r)
+	query := `SELECT n.id, n.kind, n.task_id, COALESCE(t.title, ''), n.body, n.args, n.read_at, n.created_at
+		FROM notifications n LEFT JOIN tasks t ON t.id = n.task_id WHERE n.user_id = ?`
+	args := []any{userID}
+	if q.Get("unread") == "1" {
+		query += ` AND n.read_at IS NULL`
+	}
+	if v := q.Get("before"); v != "" {
+		before, err := parseInt64(v)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid before"})
+			return
+		}
+		query += ` AND n.id < ?`
+		args = append(args, before)
+	}
+	query += ` ORDER BY n.id DESC LIMIT ?`
+	args = append(args, limit)
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	resp := notificationListResponse{Items: []Notification{}}
+	for rows.Next() {
+		var n Notification
+		var taskID sql.NullInt64
+		var readAt sql.NullString
+		var args, created string
+		if err := rows.Scan(&n.ID, &n.Kind, &taskID, &n.TaskTitle, &n.Body, &args, &readAt, &created); err != nil {
+			rows.Close()
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if taskID.Valid {
+			n.TaskID = &taskID.Int64
+		}
+		// body 为原文格式串，args 为其参数；args 为空的是加入翻译之前生成的通知，内容按原样返回
+		var m localMessage
+		if args != "" && json.Unmarshal([]byte(args), &m.Args) == nil {
+			m.Format = n.Body
+			n.Body = m.text(locale)
+		}
+		n.ReadAt = parseOptionalTime(readAt)
+		n.Read = n.ReadAt != nil
+		n.CreatedAt, _ = time.Parse(time.RFC3339, created)
+		resp.Items = append(resp.Items, n)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if resp.Unread, err = a.unreadNotifications(ctx, userID); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// unreadNotifications 返回用户的未读通知数
+func (a *App) unreadNotifications(ctx context.Context, userID int64) (int, error) {
+	var n int
+	err := a.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM notifications WHERE user_id = ? AND read_at IS NULL`, userID).Scan(&n)
+	return n, err
+}
+
+// handleNotificationItem 处理 /api/notifications/ 下的路径：GET unread-count 返回未读数，
+// POST read-all 将全部标记为已读，POST {id}/read 将单条标记为已读；响应均为最新的未读数
+func (a *App) handleNotificationItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := currentUserID(r)
+	if userID == 0 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "not logged in"})
+		return
+	}
+	rest := strings.TrimPrefix(r.URL.Path, "/api/notifications/")
+	now := time.Now().Format(time.RFC3339)
+	switch {
+	case rest == "unread-count":
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+	case rest == "read-all":
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		if _, err := a.db.ExecContext(ctx, `UPDATE notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL`, now, userID); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+	case strings.HasSuffix(rest, "/read"):
+		id, err := parseInt64(strings.TrimSuffix(rest, "/read"))
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		res, err := a.db.ExecContext(ctx, `UPDATE notifications SET read_at = COALESCE(read_at, ?) WHERE id = ? AND user_id = ?`, now, id, userID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "notification not found"})
+			return
+		}
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	unread, err := a.unreadNotifications(ctx, userID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, notificationCountResponse{Unread: unread})
+}
@@ -0,0 +1,383 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 运行概况：GET /api/admin/observability 以一份 JSON 汇总最近一段时间（window，缺省 15m）内各路由的请求数、
+// 错误率与 p50/p95 耗时，事件驱动的后台任务积压的事件数，外发通知（webhook、寻呼等）的成功与失败次数，
+// 以及数据库健康状况，供没有 Prometheus 的部署直接查看或由简单脚本告警。
+// 统计保存在内存中，重启后清零；每条路由只保留最近 metricSamples 个请求样本，流量很大时窗口会相应变短
+
+// 路由统计的容量：每条路由保留的样本数与最多记录的路由数（超出的归入 other）
+const (
+	metricSamples   = 512
+	metricMaxRoutes = 200
+)
+
+// 统计窗口的默认值与上限
+const (
+	defaultMetricWindow = 15 * time.Minute
+	maxMetricWindow     = time.Hour
+)
+
+// requestSample 为一次请求的耗时与状态码
+type requestSample struct {
+	at     time.Time
+	dur    time.Duration
+	status int
+}
+
+// routeMetrics 为一条路由的统计：环形缓冲保存最近的样本，另累计启动以来的总数
+type routeMetrics struct {
+	samples []requestSample
+	next    int
+	total   int64
+	errors  int64
+}
+
+// requestMetrics 按「方法 路由」统计请求，零值可用
+type requestMetrics struct {
+	mu     sync.Mutex
+	routes map[string]*routeMetrics
+}
+
+// observe 记录一次请求
+func (m *requestMetrics) observe(method, path string, status int, d time.Duration, now time.Time) {
+	key := method + " " + metricRoute(path)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.routes == nil {
+		m.routes = map[string]*routeMetrics{}
+	}
+	rm, ok := m.routes[key]
+	if !ok {
+		if len(m.routes) >= metricMaxRoutes {
+			key = method + " other"
+			rm = m.routes[key]
+		}
+		if rm == nil {
+			rm = &routeMetrics{}
+			m.routes[key] = rm
+		}
+	}
+	s := requestSample{at: now, dur: d, status: status}
+	if len(rm.samples) < metricSamples {
+		rm.samples = append(rm.samples, s)
+	} else {
+		rm.samples[rm.next] = s
+		rm.next = (rm.next + 1) % metricSamples
+	}
+	rm.total++
+	if status >= http.StatusInternalServerError {
+		rm.errors++
+	}
+}
+
+// routeSummary 为一条路由（或全部请求）在窗口内的统计
+type routeSummary struct {
+	Route        string  `json:"route"`
+	Requests     int     `json:"requests"`
+	ServerErrors int     `json:"server_errors"`
+	ClientErrors int     `json:"client_errors"`
+	ErrorRate    float64 `json:"error_rate"` // 5xx 占比
+	P50MS        float64 `json:"p50_ms"`
+	P95MS        float64 `json:"p95_ms"`
+	MaxMS        float64 `json:"max_ms"`
+	TotalSince   int64   `json:"total_since_start"`
+	ErrorsSince  int64   `json:"errors_since_start"`
+}
+
+// summarize 汇总 since 之后的样本，返回各路由（按请求数降序）与全部请求的统计
+func (m *requestMetrics) summarize(since time.Time) ([]routeSummary, routeSummary) {
+	all := routeSummary{Route: "*"}
+	var allDurs []time.Duration
+	out := []routeSummary{}
+	m.mu.Lock()
+	for key, rm := range m.routes {
+		s := routeSummary{Route: key, TotalSince: rm.total, ErrorsSince: rm.errors}
+		var durs []time.Duration
+		for _, sample := range rm.samples {
+			if sample.at.Before(since) {
+				continue
+			}
+			durs = append(durs, sample.dur)
+			switch {
+			case sample.status >= http.StatusInternalServerError:
+				s.ServerErrors++
+			case sample.status >= http.StatusBadRequest:
+				s.ClientErrors++
+			}
+		}
+		all.TotalSince += rm.total
+		all.ErrorsSince += rm.errors
+		if len(durs) == 0 {
+			continue
+		}
+		all.ServerErrors += s.ServerErrors
+		all.ClientErrors += s.ClientErrors
+		allDurs = append(allDurs, durs...)
+		out = append(out, s.withLatencies(durs))
+	}
+	m.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Requests != out[j].Requests {
+			return out[i].Requests > out[j].Requests
+		}
+		return out[i].Route < out[j].Route
+	})
+	return out, all.withLatencies(allDurs)
+}
+
+// withLatencies 根据耗时样本填充请求数、错误率与分位耗时
+func (s routeSummary) withLatencies(durs []time.Duration) routeSummary {
+	s.Requests = len(durs)
+	if len(durs) == 0 {
+		return s
+	}
+	sort.Slice(durs, func(i, j int) bool { return durs[i] < durs[j] })
+	s.ErrorRate = roundTo(float64(s.ServerErrors)/float64(len(durs)), 4)
+	s.P50MS = durationMS(durs[percentileIndex(len(durs), 0.50)])
+	s.P95MS = durationMS(durs[percentileIndex(len(durs), 0.95)])
+	s.MaxMS = durationMS(durs[len(durs)-1])
+	return s
+}
+
+// percentileIndex 返回 n 个有序样本中 p 分位（最近秩法）的下标
+func percentileIndex(n int, p float64) int {
+	return max(int(math.Ceil(p*float64(n)))-1, 0)
+}
+
+// durationMS 将耗时转换为保留两位小数的毫秒数
+func durationMS(d time.Duration) float64 {
+	return roundTo(float64(d)/float64(time.Millisecond), 2)
+}
+
+// roundTo 将 v 四舍五入到 digits 位小数
+func roundTo(v float64, digits int) float64 {
+	p := math.Pow10(digits)
+	return math.Round(v*p) / p
+}
+
+// metricRoute 将请求路径归并为路由：数字段替换为 {id}，标签名、令牌等自由文本段替换为占位符，
+// 接口以外的页面与静态资源统一记为 static
+func metricRoute(path string) string {
+	switch {
+	case strings.HasPrefix(path, "/a/"):
+		return "/a/{token}"
+	case strings.HasPrefix(path, "/share/board/"):
+		return "/share/board/{token}"
+	case strings.HasPrefix(path, "/share/"):
+		return "/share/{token}"
+	case !strings.HasPrefix(path, "/api/") && !strings.HasPrefix(path, "/public/api/") && path != "/readyz":
+		return "static"
+	}
+	parts := strings.Split(path, "/")
+	for i, p := range parts {
+		if p == "" {
+			continue
+		}
+		if _, err := strconv.ParseInt(p, 10, 64); err == nil {
+			parts[i] = "{id}"
+			continue
+		}
+		switch {
+		case i == 3 && parts[2] == "tags" && p != "history" && p != "rename" && p != "stats":
+			parts[i] = "{tag}"
+		case i == 5 && parts[3] == "secrets" && parts[4] == secretSigning:
+			parts[i] = "{client}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// deliveryStat 为一个外发通知渠道的发送统计
+type deliveryStat struct {
+	Channel       string     `json:"channel"`
+	Delivered     int64      `json:"delivered"`
+	Failed        int64      `json:"failed"`
+	LastError     string     `json:"last_error,omitempty"`
+	LastFailureAt *time.Time `json:"last_failure_at,omitempty"`
+}
+
+// deliveryStats 统计各渠道外发通知的成功与失败次数，零值可用
+type deliveryStats struct {
+	mu       sync.Mutex
+	channels map[string]*deliveryStat
+}
+
+// record 记录一次发送结果
+func (d *deliveryStats) record(channel string, err error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.channels == nil {
+		d.channels = map[string]*deliveryStat{}
+	}
+	st, ok := d.channels[channel]
+	if !ok {
+		st = &deliveryStat{Channel: channel}
+		d.channels[channel] = st
+	}
+	if err == nil {
+		st.Delivered++
+		return
+	}
+	now := time.Now()
+	st.Failed++
+	st.LastError = err.Error()
+	st.LastFailureAt = &now
+}
+
+// snapshot 按渠道名返回统计
+func (d *deliveryStats) snapshot() []deliveryStat {
+	d.mu.Lock()
+	out := make([]deliveryStat, 0, len(d.channels))
+	for _, st := range d.channels {
+		out = append(out, *st)
+	}
+	d.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Channel < out[j].Channel })
+	return out
+}
+
+// eventCursors 记录事件驱动的后台任务已处理到的 task_events 位置，用于计算积压，零值可用
+type eventCursors struct {
+	mu      sync.Mutex
+	cursors map[string]int64
+}
+
+// set 更新后台任务 name 的游标
+func (c *eventCursors) set(name string, cursor int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cursors == nil {
+		c.cursors = map[string]int64{}
+	}
+	c.cursors[name] = cursor
+}
+
+// snapshot 返回各后台任务的游标
+func (c *eventCursors) snapshot() map[string]int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make(map[string]int64, len(c.cursors))
+	for k, v := range c.cursors {
+		out[k] = v
+	}
+	return out
+}
+
+// queueDepth 为一个队列当前的积压数
+type queueDepth struct {
+	Name  string `json:"name"`
+	Depth int    `json:"depth"`
+}
+
+// databaseHealth 为数据库健康状况；InteractiveLatencyMS 为最近一分钟内请求语句的平均耗时
+type databaseHealth struct {
+	Status               string  `json:"status"`
+	PingMS               float64 `json:"ping_ms"`
+	Error                string  `json:"error,omitempty"`
+	Queries              int64   `json:"queries"`
+	SlowQueries          int64   `json:"slow_queries"`
+	InteractiveLatencyMS float64 `json:"interactive_latency_ms"`
+	Load                 string  `json:"load,omitempty"`
+}
+
+// observabilityResponse 为运行概况的响应结构
+type observabilityResponse struct {
+	GeneratedAt   time.Time      `json:"generated_at"`
+	UptimeSeconds int64          `json:"uptime_seconds"`
+	Window        string         `json:"window"`
+	Requests      routeSummary   `json:"requests"`
+	Routes        []routeSummary `json:"routes"`
+	Queues        []queueDepth   `json:"queues"`
+	Deliveries    []deliveryStat `json:"deliveries"`
+	Database      databaseHealth `json:"database"`
+}
+
+// queueDepths 返回各事件驱动后台任务积压的事件数与待处理的审批数
+func (a *App) queueDepths(ctx context.Context) ([]queueDepth, error) {
+	cursors := a.eventCursors.snapshot()
+	names := make([]string, 0, len(cursors))
+	for name := range cursors {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	out := []queueDepth{}
+	for _, name := range names {
+		q := queueDepth{Name: name}
+		if err := a.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM task_events WHERE id > ?`, cursors[name]).Scan(&q.Depth); err != nil {
+			return nil, err
+		}
+		out = append(out, q)
+	}
+	q := queueDepth{Name: "approvals"}
+	if err := a.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM task_approvals WHERE state = 'pending'`).Scan(&q.Depth); err != nil {
+		return nil, err
+	}
+	return append(out, q), nil
+}
+
+// databaseHealth 检查数据库连通性并附带语句统计与负载压力
+func (a *App) databaseHealth(ctx context.Context, now time.Time) databaseHealth {
+	h := databaseHealth{Status: checkOK}
+	pingCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	start := time.Now()
+	if err := a.db.PingContext(pingCtx); err != nil {
+		h.Status, h.Error = checkFailed, err.Error()
+	}
+	h.PingMS = durationMS(time.Since(start))
+	if a.queryStats != nil {
+		h.Queries = a.queryStats.total.Load()
+		h.SlowQueries = a.queryStats.slow.Load()
+		h.InteractiveLatencyMS = durationMS(a.queryStats.interactiveLatency(now, throttleWindow))
+	}
+	if h.Load = a.loadPressure(now); h.Load != "" && h.Status == checkOK {
+		h.Status = checkDegraded
+	}
+	return h
+}
+
+// handleObservability 处理 GET /api/admin/observability，window 为统计窗口（1m 至 1h）
+func (a *App) handleObservability(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	window := defaultMetricWindow
+	if v := r.URL.Query().Get("window"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < time.Minute || d > maxMetricWindow {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "window must be a duration between 1m and 1h"})
+			return
+		}
+		window = d
+	}
+	ctx := r.Context()
+	now := time.Now()
+	queues, err := a.queueDepths(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	routes, all := a.metrics.summarize(now.Add(-window))
+	writeJSON(w, http.StatusOK, observabilityResponse{
+		GeneratedAt:   now.Truncate(time.Second),
+		UptimeSeconds: int64(now.Sub(a.started).Seconds()),
+		Window:        window.String(),
+		Requests:      all,
+		Routes:        routes,
+		Queues:        queues,
+		Deliveries:    a.deliveries.snapshot(),
+		Database:      a.databaseHealth(ctx, now),
+	})
+}
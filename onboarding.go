@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"encoding/json"
+	"fmt"
+)
+
+// 新看板的示例数据：首次启动、看板从未创建过任务时，按配置的语言写入一组介绍功能的示例卡片与列策略，
+// 让新用户打开看板就能看到各功能的用法。示例数据位于 onboarding/<语言>.json，编译进二进制；
+// 删除示例卡片后不会再次写入。也可通过 seed -onboarding 手动写入
+
+// OnboardingConfig 为示例看板配置；Locale 为示例内容的语言，缺省为界面默认语言
+type OnboardingConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Locale  string `yaml:"locale"`
+}
+
+// onboardingFixtures 为各语言的示例看板
+//
+//go:embed onboarding
+var onboardingFixtures embed.FS
+
+// onboardingFixture 为示例看板文件的格式；列只需给出与默认值不同的策略
+type onboardingFixture struct {
+	Columns []struct {
+		Status              string `json:"status"`
+		Policy              string `json:"policy"`
+		RequireConfirmation bool   `json:"require_confirmation"`
+	} `json:"columns"`
+	Tasks []exportedTask `json:"tasks"`
+}
+
+// onboardingBoard 读取指定语言的示例看板，不支持的语言回退到默认语言
+func onboardingBoard(locale string) (boardExport, error) {
+	l, ok := matchLocale(locale)
+	if !ok {
+		l = defaultLocale
+	}
+	raw, err := onboardingFixtures.ReadFile("onboarding/" + l + ".json")
+	if err != nil {
+		return boardExport{}, err
+	}
+	var f onboardingFixture
+	if err := json.Unmarshal(raw, &f); err != nil {
+		return boardExport{}, fmt.Errorf("示例看板 %s: %w", l, err)
+	}
+	data := boardExport{Version: boardExportVersion, Tasks: f.Tasks}
+	for _, c := range f.Columns {
+		col := newColumn(c.Status)
+		col.Policy, col.RequireConfirmation = c.Policy, c.RequireConfirmation
+		data.Columns = append(data.Columns, col)
+	}
+	return data, nil
+}
+
+// boardIsNew 判断看板是否从未创建过任务（删除全部任务后不再视为新看板）
+func (a *App) boardIsNew(ctx context.Context) (bool, error) {
+	var seq int64
+	err := a.db.QueryRowContext(ctx, `SELECT seq FROM sqlite_sequence WHERE name = 'tasks'`).Scan(&seq)
+	if err == sql.ErrNoRows {
+		return true, nil
+	}
+	return seq == 0, err
+}
+
+// seedOnboarding 在启用示例看板且看板为新建时写入示例数据
+func (a *App) seedOnboarding(ctx context.Context) error {
+	c := a.cfg.Onboarding
+	if !c.Enabled {
+		return nil
+	}
+	fresh, err := a.boardIsNew(ctx)
+	if err != nil || !fresh {
+		return err
+	}
+	data, err := onboardingBoard(c.Locale)
+	if err != nil {
+		return err
+	}
+	n, err := a.importBoard(ctx, data)
+	if err != nil {
+		return err
+	}
+	a.logger.Info("已为新看板写入示例任务", "count", n, "locale", c.Locale)
+	return nil
+}
@@ -0,0 +1,533 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// apiParam 描述 API 操作的路径或查询参数
+type apiParam struct {
+	Name        string
+	In          string // path、query 或 header
+	Type        string // string / integer / boolean
+	Description string
+}
+
+// apiOperation 描述一个 API 操作，OpenAPI 文档由该列表生成
+type apiOperation struct {
+	Method  string
+	Path    string
+	Summary string
+	Tag     string
+	Params  []apiParam
+	Body    any // 请求体类型的零值，nil 表示无请求体
+	Status  int // 成功状态码
+	Resp    any // 成功响应类型的零值，nil 表示通用对象
+}
+
+// taskListResponse 为活动任务列表的响应结构（仅用于文档）
+type taskListResponse struct {
+	Items   []Task   `json:"items"`
+	Columns []Column `json:"columns"`
+}
+
+// columnListResponse 为状态列列表的响应结构（仅用于文档）
+type columnListResponse struct {
+	Items []Column `json:"items"`
+}
+
+// archivedPageResponse 为归档任务分页列表的响应结构（仅用于文档）
+type archivedPageResponse struct {
+	Items    []Task `json:"items"`
+	Total    int64  `json:"total"`
+	Page     int64  `json:"page"`
+	PageSize int64  `json:"page_size"`
+	HasMore  bool   `json:"has_more"`
+	// Facets 仅在任务列表的归档分页中返回
+	Facets *archiveFacets `json:"facets,omitempty"`
+}
+
+// archivePartitionListResponse 为归档库列表的响应结构（仅用于文档）
+type archivePartitionListResponse struct {
+	Items []archivePartition `json:"items"`
+}
+
+// tagListResponse 为标签列表的响应结构（仅用于文档）
+type tagListResponse struct {
+	Items []string `json:"items"`
+}
+
+// tagSuggestionListResponse 为标签建议列表的响应结构（仅用于文档）
+type tagSuggestionListResponse struct {
+	Items       []string        `json:"items"`
+	Suggestions []tagSuggestion `json:"suggestions"`
+}
+
+// taskRefListResponse 为任务外部引用列表的响应结构（仅用于文档）
+type taskRefListResponse struct {
+	Items []TaskRef `json:"items"`
+}
+
+// publicTaskListResponse 为公开任务列表的响应结构（仅用于文档）
+type publicTaskListResponse struct {
+	Items []publicTask `json:"items"`
+}
+
+// roadmapResponse 为路线图的响应结构（仅用于文档）
+type roadmapResponse struct {
+	Months []roadmapMonth `json:"months"`
+}
+
+// idResponse 为仅返回任务 ID 的响应结构（仅用于文档）
+type idResponse struct {
+	ID int64 `json:"id"`
+}
+
+// errorResponse 为统一的错误响应结构（仅用于文档）
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// taskIDParam 为任务 ID 路径参数
+var taskIDParam = apiParam{Name: "id", In: "path", Type: "integer", Description: "任务 ID"}
+
+// tagNameParam 为标签路径中的标签名
+var tagNameParam = apiParam{Name: "name", In: "path", Type: "string", Description: "标签名"}
+
+// approvalIDParam 为审批路径中的审批 ID
+var approvalIDParam = apiParam{Name: "id", In: "path", Type: "integer", Description: "审批 ID"}
+
+// viewIDParam 为视图路径中的视图 ID
+var viewIDParam = apiParam{Name: "id", In: "path", Type: "integer", Description: "视图 ID"}
+
+// transitionRuleIDParam 为状态流转规则路径中的规则 ID
+var transitionRuleIDParam = apiParam{Name: "id", In: "path", Type: "integer", Description: "流转规则 ID"}
+
+// apiKeyIDParam 为 API 密钥路径中的密钥 ID
+var apiKeyIDParam = apiParam{Name: "id", In: "path", Type: "integer", Description: "API 密钥 ID"}
+
+// teamIDParam 与 teamMemberParam 为团队路径中的团队 ID 与成员用户 ID
+var (
+	teamIDParam     = apiParam{Name: "id", In: "path", Type: "integer", Description: "团队 ID"}
+	teamMemberParam = apiParam{Name: "user_id", In: "path", Type: "integer", Description: "成员用户 ID"}
+)
+
+// boardIDParam 为看板路径中的看板 ID，当前只有 1
+var boardIDParam = apiParam{Name: "id", In: "path", Type: "integer", Description: "看板 ID（当前实例只有看板 1）"}
+
+// validationRuleIDParam 为校验规则路径中的规则 ID
+var validationRuleIDParam = apiParam{Name: "id", In: "path", Type: "integer", Description: "校验规则 ID"}
+
+// ifMatchParam 为乐观并发控制使用的 If-Match 头，可由请求体 expected_version 替代
+var ifMatchParam = apiParam{Name: "If-Match", In: "header", Type: "string", Description: "期望的任务版本，如 \"3\""}
+
+// apiOperations 列出所有对外 API，新增路由时需同步补充
+var apiOperations = []apiOperation{
+	{Method: "GET", Path: "/api/health", Summary: "健康检查", Tag: "system", Status: 200},
+	{Method: "GET", Path: "/readyz", Summary: "就绪检查：数据库可用性与启动自检中的降级项，不可用时返回 503", Tag: "system", Status: 200},
+	{Method: "GET", Path: "/api/tasks", Summary: "任务列表（archived=1 时返回归档分页）", Tag: "tasks", Params: []apiParam{
+		{Name: "archived", In: "query", Type: "boolean", Description: "是否查询归档任务"},
+		{Name: "q", In: "query", Type: "string", Description: "搜索查询：关键字或 \"短语\"，以及 status:、tag:、created:、updated:、start:、due:（支持 > >= < <=）限定词，前缀 - 表示排除；开启 search.pinyin、search.fuzzy 后关键字还可按拼音或容错匹配标题"},
+		{Name: "page", In: "query", Type: "integer", Description: "页码，从 1 开始"},
+		{Name: "page_size", In: "query", Type: "integer", Description: "每页数量，最大 200"},
+		{Name: "status", In: "query", Type: "string", Description: "归档分面：归档时的状态，可重复，满足其一即可"},
+		{Name: "tag", In: "query", Type: "string", Description: "归档分面：标签，可重复，满足其一即可"},
+		{Name: "month", In: "query", Type: "string", Description: "归档分面：归档月份 YYYY-MM，可重复，满足其一即可"},
+		{Name: "starting", In: "query", Type: "string", Description: "按计划开始时间过滤：today、this_week、next_week、this_month"},
+		{Name: "group_by", In: "query", Type: "string", Description: "按维度分组返回（泳道），目前支持 tag；设置后响应为 groups（各组含计数、各列计数与任务）而非 items，多标签任务出现在每个标签组中"},
+		{Name: "If-None-Match", In: "header", Type: "string", Description: "上次响应的 ETag，未变化时返回 304"},
+	}, Status: 200, Resp: taskListResponse{}},
+	{Method: "GET", Path: "/api/columns", Summary: "状态列及其策略", Tag: "columns", Status: 200, Resp: columnListResponse{}},
+	{Method: "PUT", Path: "/api/columns/{status}", Summary: "更新列策略（完成定义 / 进入条件 / WIP 限制）", Tag: "columns", Params: []apiParam{
+		{Name: "status", In: "path", Type: "string", Description: "状态列名称"},
+	}, Body: columnPolicyRequest{}, Status: 200},
+	{Method: "GET", Path: "/api/columns/{status}/subscriptions", Summary: "列订阅列表", Tag: "columns", Params: []apiParam{
+		{Name: "status", In: "path", Type: "string", Description: "状态列名称"},
+	}, Status: 200, Resp: columnSubscriptionListResponse{}},
+	{Method: "POST", Path: "/api/columns/{status}/subscriptions", Summary: "订阅列：任务进入该列（新建、移入或恢复）时通过 webhook、email 或 telegram 通知；重复订阅返回 409", Tag: "columns", Params: []apiParam{
+		{Name: "status", In: "path", Type: "string", Description: "状态列名称"},
+	}, Body: columnSubscriptionRequest{}, Status: 201, Resp: ColumnSubscription{}},
+	{Method: "DELETE", Path: "/api/columns/{status}/subscriptions/{id}", Summary: "取消列订阅", Tag: "columns", Params: []apiParam{
+		{Name: "status", In: "path", Type: "string", Description: "状态列名称"},
+		{Name: "id", In: "path", Type: "integer", Description: "订阅 ID"},
+	}, Status: 200},
+	{Method: "GET", Path: "/api/views", Summary: "保存的视图列表（按创建顺序）", Tag: "views", Status: 200, Resp: viewListResponse{}},
+	{Method: "POST", Path: "/api/views", Summary: "保存视图：命名的过滤条件（状态、全部标签、关键字、归档、计划开始与截止区间）与排序；名称重复返回 409", Tag: "views", Body: viewRequest{}, Status: 201, Resp: View{}},
+	{Method: "GET", Path: "/api/views/{id}", Summary: "视图详情", Tag: "views", Params: []apiParam{viewIDParam}, Status: 200, Resp: View{}},
+	{Method: "PUT", Path: "/api/views/{id}", Summary: "更新视图，未提供的字段保持不变", Tag: "views", Params: []apiParam{viewIDParam}, Body: viewRequest{}, Status: 200, Resp: View{}},
+	{Method: "DELETE", Path: "/api/views/{id}", Summary: "删除视图", Tag: "views", Params: []apiParam{viewIDParam}, Status: 200},
+	{Method: "GET", Path: "/api/views/{id}/tasks", Summary: "执行视图：按保存的条件与排序分页返回任务", Tag: "views", Params: []apiParam{viewIDParam,
+		{Name: "page", In: "query", Type: "integer", Description: "页码，从 1 开始"},
+		{Name: "page_size", In: "query", Type: "integer", Description: "每页数量（最大 200，默认 50）"},
+	}, Status: 200, Resp: viewTasksResponse{}},
+	{Method: "GET", Path: "/api/incidents", Summary: "事故任务列表", Tag: "incidents", Params: []apiParam{
+		{Name: "state", In: "query", Type: "string", Description: "open（默认）、resolved 或 all"},
+	}, Status: 200, Resp: incidentListResponse{}},
+	{Method: "POST", Path: "/api/incidents", Summary: "创建事故：新建任务或以 task_id 升级已有任务，并立即寻呼", Tag: "incidents", Params: []apiParam{
+		{Name: "Idempotency-Key", In: "header", Type: "string", Description: "幂等键，新建任务时生效"},
+	}, Body: incidentCreateRequest{}, Status: 201, Resp: Task{}},
+	{Method: "POST", Path: "/api/incidents/{id}/acknowledge", Summary: "确认事故，记录确认时间", Tag: "incidents", Params: []apiParam{taskIDParam}, Status: 200, Resp: Task{}},
+	{Method: "POST", Path: "/api/incidents/{id}/resolve", Summary: "解决事故，记录解决时间并将任务移到「已完成」", Tag: "incidents", Params: []apiParam{taskIDParam}, Status: 200, Resp: Task{}},
+	{Method: "GET", Path: "/api/incidents/stats", Summary: "事故复盘统计：数量、MTTA 与 MTTR（秒），按严重程度分组", Tag: "incidents", Params: []apiParam{
+		{Name: "since", In: "query", Type: "string", Description: "只统计该日期（YYYY-MM-DD）之后触发的事故"},
+	}, Status: 200, Resp: incidentStatsResponse{}},
+	{Method: "GET", Path: "/api/approvals", Summary: "状态变更审批列表，默认只列出待审批", Tag: "approvals", Params: []apiParam{
+		{Name: "state", In: "query", Type: "string", Description: "pending、approved、rejected、cancelled 或 all"},
+		{Name: "task_id", In: "query", Type: "integer", Description: "只返回该任务的审批"},
+	}, Status: 200, Resp: approvalListResponse{}},
+	{Method: "GET", Path: "/api/approvals/{id}", Summary: "审批详情", Tag: "approvals", Params: []apiParam{approvalIDParam}, Status: 200, Resp: TaskApproval{}},
+	{Method: "POST", Path: "/api/approvals/{id}/approve", Summary: "通过审批并变更任务状态；列配置了审批人时须以其签名请求，否则返回 403", Tag: "approvals", Params: []apiParam{approvalIDParam}, Body: approvalDecisionRequest{}, Status: 200, Resp: TaskApproval{}},
+	{Method: "POST", Path: "/api/approvals/{id}/reject", Summary: "驳回审批，任务保持原状态", Tag: "approvals", Params: []apiParam{approvalIDParam}, Body: approvalDecisionRequest{}, Status: 200, Resp: TaskApproval{}},
+	{Method: "POST", Path: "/api/tasks", Summary: "创建任务（支持 Idempotency-Key 幂等重试）", Tag: "tasks", Params: []apiParam{
+		{Name: "Idempotency-Key", In: "header", Type: "string", Description: "幂等键，24 小时内重试返回首次创建的任务"},
+	}, Body: taskCreateRequest{}, Status: 201, Resp: idResponse{}},
+	{Method: "GET", Path: "/api/tasks/random", Summary: "随机挑选一项未归档任务，默认按在当前列停留的天数加权", Tag: "tasks", Params: []apiParam{
+		{Name: "status", In: "query", Type: "string", Description: "只从该状态列中挑选"},
+		{Name: "tag", In: "query", Type: "string", Description: "只从带该标签的任务中挑选"},
+		{Name: "weight", In: "query", Type: "string", Description: "age（缺省，停留越久越容易被选中）或 uniform（等概率）"},
+	}, Status: 200, Resp: randomTaskResponse{}},
+	{Method: "GET", Path: "/api/tasks/{id}", Summary: "任务详情，含关注者列表", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200, Resp: taskDetailResponse{}},
+	{Method: "DELETE", Path: "/api/tasks/{id}", Summary: "彻底删除任务", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200},
+	{Method: "PATCH", Path: "/api/tasks/{id}/status", Summary: "变更任务状态（需提供期望版本，冲突返回 409；目标列要求确认时未确认返回 422；流转规则禁止或要求填写原因而未填写时返回 422；目标列需要审批时返回 202 与待审批记录；超出目标列 WIP 限制时按列配置返回 422 或附带警告头）", Tag: "tasks", Params: []apiParam{taskIDParam, ifMatchParam}, Body: taskStatusRequest{}, Status: 200},
+	{Method: "PATCH", Path: "/api/tasks/{id}/update", Summary: "更新任务标题、描述与标签（需提供期望版本，冲突返回 409）", Tag: "tasks", Params: []apiParam{taskIDParam, ifMatchParam}, Body: taskUpdateRequest{}, Status: 200},
+	{Method: "GET", Path: "/api/tasks/{id}/actions", Summary: "任务当前可用的快捷操作（next 移到下一列、done 标记完成）及其一次性签名链接；未启用 quick_actions 时返回 404", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200, Resp: quickActionListResponse{}},
+	{Method: "GET", Path: "/api/tasks/{id}/actions/{action}/qr.svg", Summary: "快捷操作链接的二维码（SVG），扫码后在确认页执行；任务不在可执行该操作的状态时返回 422", Tag: "tasks", Params: []apiParam{
+		taskIDParam,
+		{Name: "action", In: "path", Type: "string", Description: "next 或 done"},
+	}, Status: 200},
+	{Method: "GET", Path: "/api/tasks/{id}/share", Summary: "任务的分享链接", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200, Resp: TaskShareLink{}},
+	{Method: "POST", Path: "/api/tasks/{id}/share", Summary: "生成分享链接：持有链接者可查看该任务的标题、状态与最后更新时间；已有链接时更换令牌，旧链接失效", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 201, Resp: TaskShareLink{}},
+	{Method: "DELETE", Path: "/api/tasks/{id}/share", Summary: "撤销分享链接", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200},
+	{Method: "POST", Path: "/api/tasks/{id}/archive", Summary: "归档任务", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200},
+	{Method: "POST", Path: "/api/tasks/{id}/restore", Summary: "恢复归档任务到规划中", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200},
+	{Method: "POST", Path: "/api/tasks/{id}/copy", Summary: "复制任务", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 201, Resp: idResponse{}},
+	{Method: "GET", Path: "/api/tasks/{id}/refs", Summary: "任务外部引用列表", Tag: "refs", Params: []apiParam{taskIDParam}, Status: 200, Resp: taskRefListResponse{}},
+	{Method: "POST", Path: "/api/tasks/{id}/refs", Summary: "挂载外部引用（commit/pr/incident/doc/build）", Tag: "refs", Params: []apiParam{taskIDParam}, Body: taskRefRequest{}, Status: 201, Resp: idResponse{}},
+	{Method: "DELETE", Path: "/api/tasks/{id}/refs/{ref_id}", Summary: "解除外部引用", Tag: "refs", Params: []apiParam{taskIDParam, {Name: "ref_id", In: "path", Type: "integer", Description: "引用 ID"}}, Status: 200},
+	{Method: "GET", Path: "/api/tasks/{id}/recurrence", Summary: "任务的周期规则", Tag: "recurrence", Params: []apiParam{taskIDParam}, Status: 200, Resp: TaskRecurrence{}},
+	{Method: "PUT", Path: "/api/tasks/{id}/recurrence", Summary: "设置周期规则（daily/weekly/monthly/every N days/cron），完成时或按计划生成下一次任务", Tag: "recurrence", Params: []apiParam{taskIDParam}, Body: taskRecurrenceRequest{}, Status: 200, Resp: TaskRecurrence{}},
+	{Method: "GET", Path: "/api/tasks/{id}/card.svg", Summary: "可打印的任务卡片（SVG，100×60 mm）：编号、标题、标签与打开该任务的二维码", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200},
+	{Method: "GET", Path: "/api/tasks/{id}/comments", Summary: "任务评论（从旧到新），含停滞任务的系统自动评论", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200, Resp: taskCommentListResponse{}},
+	{Method: "GET", Path: "/api/tasks/{id}/mentions", Summary: "任务的提及关系：mentions 为描述与评论中以 #编号 或 TB-编号 提到的任务，mentioned_by 为提到本任务的任务", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200, Resp: taskMentionsResponse{}},
+	{Method: "GET", Path: "/api/tasks/{id}/rendered", Summary: "渲染后的描述与评论 HTML：编号引用替换为链接并附带目标任务的当前状态徽标", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200, Resp: renderedTaskResponse{}},
+	{Method: "GET", Path: "/api/tasks/{id}/watch", Summary: "当前用户是否关注该任务及关注人数（需登录）", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200, Resp: taskWatchResponse{}},
+	{Method: "PUT", Path: "/api/tasks/{id}/watch", Summary: "关注任务，关注的任务出现在 /api/me/tasks 中；配置 smtp 后，任务状态变化、归档或恢复、内容修改与新增评论会汇总发邮件通知（需登录）", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200, Resp: taskWatchResponse{}},
+	{Method: "DELETE", Path: "/api/tasks/{id}/watch", Summary: "取消关注任务（需登录）", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200, Resp: taskWatchResponse{}},
+	{Method: "GET", Path: "/api/tasks/{id}/history", Summary: "任务标题与描述的历史版本（从新到旧，首项为当前内容）", Tag: "tasks", Params: []apiParam{taskIDParam}, Status: 200, Resp: taskHistoryResponse{}},
+	{Method: "POST", Path: "/api/tasks/{id}/revert/{version}", Summary: "将标题与描述恢复为历史版本（需提供期望版本，冲突返回 409）", Tag: "tasks", Params: []apiParam{taskIDParam, ifMatchParam,
+		{Name: "version", In: "path", Type: "integer", Description: "要恢复的历史版本号"},
+	}, Body: taskRevertRequest{}, Status: 200},
+	{Method: "DELETE", Path: "/api/tasks/{id}/recurrence", Summary: "移除周期规则", Tag: "recurrence", Params: []apiParam{taskIDParam}, Status: 200},
+	{Method: "POST", Path: "/api/refs/inbound", Summary: "CI 回调：为文本中提到的任务挂载引用", Tag: "refs", Body: refInboundRequest{}, Status: 200},
+	{Method: "POST", Path: "/api/integrations/git/push", Summary: "代码推送事件（GitHub/GitLab/通用格式），按提交信息关联任务", Tag: "refs", Params: []apiParam{
+		{Name: "move_fixed", In: "query", Type: "boolean", Description: "将 fixes #id 引用的任务移到已完成"},
+	}, Body: gitPushPayload{}, Status: 200},
+	{Method: "POST", Path: "/api/integrations/email/inbound", Summary: "入站邮件转任务：主题为标题、正文为描述并附加默认标签；支持原始 MIME 邮件、JSON 与 Mailgun/SendGrid 表单（需配置 inbox.token）", Tag: "refs", Params: []apiParam{
+		{Name: "token", In: "query", Type: "string", Description: "inbox.token 中配置的令牌"},
+	}, Body: inboundMail{}, Status: 201, Resp: inboundMailResponse{}},
+	{Method: "POST", Path: "/api/import/markdown", Summary: "从 Markdown 文档导入任务：标题为任务，列表项为清单", Tag: "tasks", Body: markdownImportRequest{}, Status: 201, Resp: markdownImportResponse{}},
+	{Method: "GET", Path: "/api/search", Summary: "全局搜索：按任务、标签、评论（任务评论与审批意见）分组返回命中结果与摘要片段，供命令面板使用", Tag: "tasks", Params: []apiParam{
+		{Name: "q", In: "query", Type: "string", Description: "搜索查询，语法同任务列表的 q；标签与评论只按其中的关键字匹配"},
+		{Name: "limit", In: "query", Type: "integer", Description: "每组返回条数（最大 50，默认 5）"},
+	}, Status: 200, Resp: globalSearchResponse{}},
+	{Method: "GET", Path: "/api/tags", Summary: "标签列表（默认按使用次数与最近使用排序）", Tag: "tags", Params: []apiParam{
+		{Name: "q", In: "query", Type: "string", Description: "模糊匹配关键字"},
+		{Name: "sort", In: "query", Type: "string", Description: "usage（默认）或 name"},
+	}, Status: 200, Resp: tagSuggestionListResponse{}},
+	{Method: "GET", Path: "/api/tags/history", Summary: "标签历史：创建、添加到任务、从任务移除、重命名、合并与不再使用的记录（按时间倒序）", Tag: "tags", Params: []apiParam{
+		{Name: "tag", In: "query", Type: "string", Description: "标签名，为空时返回所有标签的最近事件"},
+		{Name: "limit", In: "query", Type: "integer", Description: "返回条数（最大 1000，默认 100）"},
+	}, Status: 200, Resp: tagEventListResponse{}},
+	{Method: "GET", Path: "/api/tags/stats", Summary: "标签使用统计：各标签的未完成、已完成与已归档任务数及最近使用时间（最久未用的在前）", Tag: "tags", Params: []apiParam{
+		{Name: "unused_days", In: "query", Type: "integer", Description: "只返回没有未完成任务且超过该天数未被使用的标签"},
+	}, Status: 200, Resp: tagStatsResponse{}},
+	{Method: "GET", Path: "/api/tags/{name}", Summary: "标签元数据（颜色、说明、创建时间）", Tag: "tags", Params: []apiParam{tagNameParam}, Status: 200, Resp: Tag{}},
+	{Method: "PUT", Path: "/api/tags/{name}", Summary: "设置标签颜色（#rgb 或 #rrggbb，空串清除）与说明；标签不存在时创建", Tag: "tags", Params: []apiParam{tagNameParam}, Body: tagUpdateRequest{}, Status: 200, Resp: Tag{}},
+	{Method: "POST", Path: "/api/tags/rename", Summary: "重命名标签；目标标签已存在时合并", Tag: "tags", Body: tagRenameRequest{}, Status: 200, Resp: tagRenameResponse{}},
+	{Method: "GET", Path: "/api/archive/partitions", Summary: "按年拆分的归档库列表", Tag: "tasks", Status: 200, Resp: archivePartitionListResponse{}},
+	{Method: "GET", Path: "/api/archive/partitions/{year}/tasks", Summary: "分页搜索某年归档库中的任务", Tag: "tasks", Params: []apiParam{
+		{Name: "year", In: "path", Type: "integer", Description: "归档年份"},
+		{Name: "q", In: "query", Type: "string", Description: "搜索查询，语法同任务列表的 q"},
+		{Name: "page", In: "query", Type: "integer", Description: "页码，从 1 开始"},
+		{Name: "page_size", In: "query", Type: "integer", Description: "每页数量（最大 200）"},
+	}, Status: 200, Resp: archivedPageResponse{}},
+	{Method: "GET", Path: "/api/stats", Summary: "看板统计：各状态/标签任务数、每日新建与完成数、归档汇总、超出 WIP 限制的列", Tag: "stats", Params: []apiParam{
+		{Name: "days", In: "query", Type: "integer", Description: "统计窗口天数（1-365，默认 30）"},
+	}, Status: 200, Resp: statsResponse{}},
+	{Method: "GET", Path: "/api/stats/cycle-time", Summary: "周期分析：已完成任务的前置时间、周期时间与各列停留时长（按标签分组）", Tag: "stats", Params: []apiParam{
+		{Name: "days", In: "query", Type: "integer", Description: "按完成时间统计的窗口天数（1-365，默认 90）"},
+	}, Status: 200, Resp: cycleTimeResponse{}},
+	{Method: "GET", Path: "/api/stats/forecast", Summary: "完成日期预测：按窗口内每日吞吐做蒙特卡洛模拟，给出整个看板与各列剩余任务在 50/85/95% 把握下的完成日期", Tag: "stats", Params: []apiParam{
+		{Name: "days", In: "query", Type: "integer", Description: "吞吐样本的窗口天数（1-365，默认 90）"},
+		{Name: "items", In: "query", Type: "integer", Description: "整体剩余任务数，缺省为未完成的在板任务数"},
+		{Name: "tag", In: "query", Type: "string", Description: "只统计带该标签的任务"},
+	}, Status: 200, Resp: forecastResponse{}},
+	{Method: "GET", Path: "/api/roadmap", Summary: "路线图：按月份与标签分组的计划任务", Tag: "tasks", Params: []apiParam{
+		{Name: "from", In: "query", Type: "string", Description: "起始月份 YYYY-MM"},
+		{Name: "to", In: "query", Type: "string", Description: "结束月份 YYYY-MM"},
+		{Name: "include_archived", In: "query", Type: "boolean", Description: "是否包含归档任务"},
+	}, Status: 200, Resp: roadmapResponse{}},
+	{Method: "GET", Path: "/api/maintenance/duplicates", Summary: "重复任务建议：按标题与描述相似度聚类未完成任务，每簇以最早创建的任务为建议保留目标", Tag: "tasks", Params: []apiParam{
+		{Name: "threshold", In: "query", Type: "number", Description: "相似度阈值（0-1，默认 0.6）"},
+	}, Status: 200, Resp: duplicateResponse{}},
+	{Method: "GET", Path: "/public/api/tasks", Summary: "公开只读任务列表（需开启 PUBLIC_API）", Tag: "public", Status: 200, Resp: publicTaskListResponse{}},
+	{Method: "GET", Path: "/public/api/tags", Summary: "公开只读标签列表（需开启 PUBLIC_API）", Tag: "public", Status: 200, Resp: tagListResponse{}},
+	{Method: "POST", Path: "/api/graphql", Summary: "GraphQL 查询（仅 query 操作）", Tag: "graphql", Body: gqlRequest{}, Status: 200},
+	{Method: "GET", Path: "/api/admin/db/stats", Summary: "数据库语句总数与慢查询统计（按发起查询的操作汇总）", Tag: "system", Status: 200, Resp: queryStatsResponse{}},
+	{Method: "GET", Path: "/api/admin/secrets", Summary: "签名客户端密钥与入站邮件令牌的当前有效密钥（只含指纹与失效时间）", Tag: "system", Status: 200, Resp: secretListResponse{}},
+	{Method: "POST", Path: "/api/admin/secrets/signing/{client}/rotate", Summary: "轮换签名客户端密钥：新密钥立即生效，旧密钥在宽限期内继续有效；新密钥只在响应中返回一次", Tag: "system", Params: []apiParam{
+		{Name: "client", In: "path", Type: "string", Description: "签名客户端 ID"},
+	}, Body: secretRotateRequest{}, Status: 200, Resp: secretRotateResponse{}},
+	{Method: "POST", Path: "/api/admin/secrets/action/rotate", Summary: "轮换快捷操作签名密钥：宽限期结束后，旧密钥签发的二维码失效", Tag: "system", Body: secretRotateRequest{}, Status: 200, Resp: secretRotateResponse{}},
+	{Method: "POST", Path: "/api/admin/secrets/inbox/rotate", Summary: "轮换入站邮件令牌：新令牌立即生效，旧令牌在宽限期内继续有效", Tag: "system", Body: secretRotateRequest{}, Status: 200, Resp: secretRotateResponse{}},
+	{Method: "GET", Path: "/api/admin/validation-rules", Summary: "自定义校验规则列表", Tag: "system", Status: 200, Resp: validationRuleListResponse{}},
+	{Method: "POST", Path: "/api/admin/validation-rules", Summary: "新增校验规则：表达式（CEL 子集，可用 task、action、now）在任务创建、更新与变更状态时求值，结果不为 true 时请求返回 422 与字段错误", Tag: "system", Body: validationRuleRequest{}, Status: 201, Resp: ValidationRule{}},
+	{Method: "GET", Path: "/api/admin/validation-rules/{id}", Summary: "校验规则详情", Tag: "system", Params: []apiParam{validationRuleIDParam}, Status: 200, Resp: ValidationRule{}},
+	{Method: "PUT", Path: "/api/admin/validation-rules/{id}", Summary: "更新校验规则，未提供的字段保持不变；可用 enabled 停用规则", Tag: "system", Params: []apiParam{validationRuleIDParam}, Body: validationRuleRequest{}, Status: 200, Resp: ValidationRule{}},
+	{Method: "DELETE", Path: "/api/admin/validation-rules/{id}", Summary: "删除校验规则", Tag: "system", Params: []apiParam{validationRuleIDParam}, Status: 200},
+	{Method: "GET", Path: "/api/admin/transitions", Summary: "状态流转规则列表", Tag: "system", Status: 200, Resp: transitionRuleListResponse{}},
+	{Method: "POST", Path: "/api/admin/transitions", Summary: "新增状态流转规则：来源或目标可为 *，变更状态时取最具体的规则，禁止流转或要求填写原因；同一来源与目标重复返回 409", Tag: "system", Body: transitionRuleRequest{}, Status: 201, Resp: TransitionRule{}},
+	{Method: "GET", Path: "/api/admin/transitions/{id}", Summary: "状态流转规则详情", Tag: "system", Params: []apiParam{transitionRuleIDParam}, Status: 200, Resp: TransitionRule{}},
+	{Method: "PUT", Path: "/api/admin/transitions/{id}", Summary: "更新状态流转规则，未提供的字段保持不变", Tag: "system", Params: []apiParam{transitionRuleIDParam}, Body: transitionRuleRequest{}, Status: 200, Resp: TransitionRule{}},
+	{Method: "DELETE", Path: "/api/admin/transitions/{id}", Summary: "删除状态流转规则", Tag: "system", Params: []apiParam{transitionRuleIDParam}, Status: 200},
+	{Method: "GET", Path: "/api/admin/observability", Summary: "运行概况：窗口内（window，缺省 15m，至多 1h）各路由的请求数、5xx 错误率与 p50/p95 耗时，后台任务积压，外发通知失败次数与数据库健康状况", Tag: "system", Params: []apiParam{{Name: "window", In: "query", Type: "string", Description: "统计窗口，如 5m"}}, Status: 200, Resp: observabilityResponse{}},
+	{Method: "GET", Path: "/api/admin/runtime", Summary: "运行信息：运行时长、构建版本、协程数、内存与 GC 统计、数据库连接池、各后台任务状态、配置指纹与已启用的功能", Tag: "system", Status: 200, Resp: runtimeResponse{}},
+	{Method: "GET", Path: "/api/me", Summary: "当前登录的用户（通过 /login 使用 GitHub、Google 或 OIDC 登录）；未登录时返回 401", Tag: "system", Status: 200, Resp: User{}},
+	{Method: "GET", Path: "/api/me/tasks", Summary: "个人任务队列：当前用户关注或在描述、评论中被 @ 提到的未归档任务，按截止时间排列（无截止时间的在后）；include_done=1 时包含已完成的任务", Tag: "system", Params: []apiParam{{Name: "include_done", In: "query", Type: "boolean", Description: "为 1 时包含已完成的任务"}}, Status: 200, Resp: myTasksResponse{}},
+	{Method: "GET", Path: "/api/users/suggest", Summary: "@ 提及补全：姓名包含 q 或邮箱以 q 开头的用户，handle 为 @ 之后应填写的内容", Tag: "system", Params: []apiParam{
+		{Name: "q", In: "query", Type: "string", Description: "已输入的内容，可带 @"},
+		{Name: "limit", In: "query", Type: "integer", Description: "条数，缺省 10，最多 50"},
+	}, Status: 200, Resp: userSuggestResponse{}},
+	{Method: "GET", Path: "/api/notifications", Summary: "当前用户的站内通知（从新到旧）：描述或评论中被 @ 提到（mention）、关注的任务有变更（watched）、关注的未完成任务一天内截止（due_soon），并附未读数", Tag: "system", Params: []apiParam{
+		{Name: "unread", In: "query", Type: "boolean", Description: "为 1 时只返回未读通知"},
+		{Name: "limit", In: "query", Type: "integer", Description: "条数，缺省 50，最多 200"},
+		{Name: "before", In: "query", Type: "integer", Description: "只返回编号小于该值的通知，用于翻页"},
+	}, Status: 200, Resp: notificationListResponse{}},
+	{Method: "GET", Path: "/api/notifications/unread-count", Summary: "当前用户的未读通知数", Tag: "system", Status: 200, Resp: notificationCountResponse{}},
+	{Method: "POST", Path: "/api/notifications/read-all", Summary: "将全部通知标记为已读，返回未读数", Tag: "system", Status: 200, Resp: notificationCountResponse{}},
+	{Method: "POST", Path: "/api/notifications/{id}/read", Summary: "将单条通知标记为已读，返回未读数", Tag: "system", Params: []apiParam{
+		{Name: "id", In: "path", Type: "integer", Description: "通知编号"},
+	}, Status: 200, Resp: notificationCountResponse{}},
+	{Method: "GET", Path: "/api/push/key", Summary: "浏览器推送订阅所需的 VAPID 公钥，未配置推送时返回 404", Tag: "system", Status: 200, Resp: pushKeyResponse{}},
+	{Method: "GET", Path: "/api/push/subscriptions", Summary: "当前用户的浏览器推送订阅", Tag: "system", Status: 200},
+	{Method: "POST", Path: "/api/push/subscriptions", Summary: "登记浏览器推送订阅（PushSubscription.toJSON() 的内容），之后提及与截止提醒会推送到该浏览器", Tag: "system", Body: pushSubscriptionRequest{}, Status: 201, Resp: idResponse{}},
+	{Method: "DELETE", Path: "/api/push/subscriptions", Summary: "按 endpoint 取消浏览器推送订阅", Tag: "system", Status: 200},
+	{Method: "GET", Path: "/api/keys", Summary: "API 密钥列表（不含原文，需 admin 权限；开启登录后需为看板所属团队的 owner）", Tag: "system", Status: 200, Resp: apiKeyListResponse{}},
+	{Method: "POST", Path: "/api/keys", Summary: "创建 API 密钥：scopes 为 read、write、admin 的组合，或单独的 display（大屏密钥，只能读取任务列表、列、标签与统计，缺省有效期一年，可通过 /display?token= 写入浏览器 Cookie）；allowed_ips 绑定允许使用的地址或网段；可用 expires_at 或 expires_in 设置过期时间；响应中的 token 只返回这一次，调用时放入 Authorization: Bearer；登录用户创建的密钥以创建者的身份与角色访问", Tag: "system", Body: apiKeyRequest{}, Status: 201, Resp: apiKeyCreatedResponse{}},
+	{Method: "GET", Path: "/api/keys/{id}", Summary: "API 密钥详情", Tag: "system", Params: []apiParam{apiKeyIDParam}, Status: 200, Resp: APIKey{}},
+	{Method: "DELETE", Path: "/api/keys/{id}", Summary: "吊销 API 密钥，立即失效", Tag: "system", Params: []apiParam{apiKeyIDParam}, Status: 200},
+	{Method: "GET", Path: "/api/teams", Summary: "团队列表：登录用户只看到自己所在的团队", Tag: "system", Status: 200, Resp: teamListResponse{}},
+	{Method: "POST", Path: "/api/teams", Summary: "创建团队，创建者成为 owner", Tag: "system", Body: teamRequest{}, Status: 201, Resp: Team{}},
+	{Method: "GET", Path: "/api/teams/{id}", Summary: "团队详情（含成员，需为团队成员）", Tag: "system", Params: []apiParam{teamIDParam}, Status: 200, Resp: Team{}},
+	{Method: "PATCH", Path: "/api/teams/{id}", Summary: "重命名团队（需 owner）", Tag: "system", Params: []apiParam{teamIDParam}, Body: teamRequest{}, Status: 200, Resp: Team{}},
+	{Method: "DELETE", Path: "/api/teams/{id}", Summary: "删除团队（需 owner）；看板归属该团队时恢复为不限制访问", Tag: "system", Params: []apiParam{teamIDParam}, Status: 200},
+	{Method: "GET", Path: "/api/teams/{id}/members", Summary: "团队成员列表", Tag: "system", Params: []apiParam{teamIDParam}, Status: 200},
+	{Method: "POST", Path: "/api/teams/{id}/members", Summary: "按 user_id 或 email 添加成员（需 owner），用户须至少登录过一次", Tag: "system", Params: []apiParam{teamIDParam}, Body: teamMemberRequest{}, Status: 201, Resp: Team{}},
+	{Method: "PATCH", Path: "/api/teams/{id}/members/{user_id}", Summary: "调整成员角色（需 owner），团队至少保留一名 owner", Tag: "system", Params: []apiParam{teamIDParam, teamMemberParam}, Body: teamMemberRequest{}, Status: 200, Resp: Team{}},
+	{Method: "DELETE", Path: "/api/teams/{id}/members/{user_id}", Summary: "移除成员（需 owner），不能移除最后一名 owner", Tag: "system", Params: []apiParam{teamIDParam, teamMemberParam}, Status: 200, Resp: Team{}},
+	{Method: "GET", Path: "/api/boards/{id}/share", Summary: "看板的分享链接（不含令牌，已过期的不列出）", Tag: "system", Params: []apiParam{boardIDParam}, Status: 200, Resp: boardShareListResponse{}},
+	{Method: "POST", Path: "/api/boards/{id}/share", Summary: "生成看板的只读分享链接：持有 /share/board/{token} 即可查看各列未归档任务，无需账号；可用 expires_at 或 expires_in 设置过期时间，令牌只返回这一次", Tag: "system", Params: []apiParam{boardIDParam}, Body: boardShareRequest{}, Status: 201, Resp: BoardShareLink{}},
+	{Method: "DELETE", Path: "/api/boards/{id}/share/{link_id}", Summary: "撤销看板分享链接，立即失效", Tag: "system", Params: []apiParam{boardIDParam, {Name: "link_id", In: "path", Type: "integer", Description: "分享链接 ID"}}, Status: 200},
+	{Method: "GET", Path: "/api/admin/board", Summary: "看板归属团队", Tag: "system", Status: 200, Resp: boardSettings{}},
+	{Method: "PUT", Path: "/api/admin/board", Summary: "设置看板归属团队，之后只有该团队成员与 API 密钥、签名客户端可访问看板接口；team_id 为 null 取消限制", Tag: "system", Body: boardSettingsRequest{}, Status: 200, Resp: boardSettings{}},
+	{Method: "GET", Path: "/api/dev/chaos", Summary: "当前故障注入设置（需开启 DEV_MODE）", Tag: "dev", Status: 200, Resp: chaosSettings{}},
+	{Method: "PUT", Path: "/api/dev/chaos", Summary: "设置全局故障注入：附加延迟、按概率返回 500 或中途断开连接（需开启 DEV_MODE）；单个请求也可用 X-Taskboard-Chaos 请求头指定", Tag: "dev", Body: chaosSettings{}, Status: 200, Resp: chaosSettings{}},
+	{Method: "DELETE", Path: "/api/dev/chaos", Summary: "清除故障注入设置（需开启 DEV_MODE）", Tag: "dev", Status: 200},
+}
+
+// buildOpenAPISpec 由 apiOperations 与 Go 类型定义生成 OpenAPI 3 文档
+func buildOpenAPISpec() map[string]any {
+	schemas := map[string]any{}
+	paths := map[string]map[string]any{}
+	errRef := schemaRef(reflect.TypeOf(errorResponse{}), schemas)
+	for _, op := range apiOperations {
+		item := paths[op.Path]
+		if item == nil {
+			item = map[string]any{}
+			paths[op.Path] = item
+		}
+		operation := map[string]any{
+			"summary":     op.Summary,
+			"operationId": operationID(op),
+			"tags":        []string{op.Tag},
+		}
+		if len(op.Params) > 0 {
+			var params []map[string]any
+			for _, p := range op.Params {
+				params = append(params, map[string]any{
+					"name":        p.Name,
+					"in":          p.In,
+					"required":    p.In == "path",
+					"description": p.Description,
+					"schema":      map[string]any{"type": p.Type},
+				})
+			}
+			operation["parameters"] = params
+		}
+		if op.Body != nil {
+			operation["requestBody"] = map[string]any{
+				"required": true,
+				"content": map[string]any{
+					"application/json": map[string]any{"schema": schemaRef(reflect.TypeOf(op.Body), schemas)},
+				},
+			}
+		}
+		respSchema := map[string]any{"type": "object"}
+		if op.Resp != nil {
+			respSchema = schemaRef(reflect.TypeOf(op.Resp), schemas)
+		}
+		operation["responses"] = map[string]any{
+			statusKey(op.Status): map[string]any{
+				"description": "成功",
+				"content":     map[string]any{"application/json": map[string]any{"schema": respSchema}},
+			},
+			"default": map[string]any{
+				"description": "错误",
+				"content":     map[string]any{"application/json": map[string]any{"schema": errRef}},
+			},
+		}
+		item[strings.ToLower(op.Method)] = operation
+	}
+	return map[string]any{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   "Task Board API",
+			"version": "1.0.0",
+		},
+		"paths":      paths,
+		"components": map[string]any{"schemas": schemas},
+	}
+}
+
+// operationID 根据方法与路径生成稳定的 operationId
+func operationID(op apiOperation) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(op.Method))
+	for _, seg := range strings.Split(strings.TrimPrefix(op.Path, "/api/"), "/") {
+		seg = strings.Trim(seg, "{}")
+		for _, part := range strings.FieldsFunc(seg, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+		}
+	}
+	return b.String()
+}
+
+// statusKey 将状态码转换为 OpenAPI 响应键
+func statusKey(code int) string {
+	if code == 0 {
+		code = http.StatusOK
+	}
+	return strconv.Itoa(code)
+}
+
+// schemaRef 返回类型对应的 JSON Schema；命名结构体会登记到 components 并以 $ref 引用
+func schemaRef(t reflect.Type, schemas map[string]any) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t == reflect.TypeOf(time.Time{}) {
+		return map[string]any{"type": "string", "format": "date-time"}
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schemaRef(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaRef(t.Elem(), schemas)}
+	case reflect.Struct:
+		name := t.Name()
+		if name != "" {
+			if _, ok := schemas[name]; !ok {
+				// 先占位，避免自引用类型无限递归
+				schemas[name] = map[string]any{}
+				schemas[name] = structSchema(t, schemas)
+			}
+			return map[string]any{"$ref": "#/components/schemas/" + name}
+		}
+		return structSchema(t, schemas)
+	default:
+		return map[string]any{}
+	}
+}
+
+// structSchema 依据 json 标签生成结构体的对象 Schema
+func structSchema(t reflect.Type, schemas map[string]any) map[string]any {
+	props := map[string]any{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		// 与 encoding/json 一致：未命名的内嵌结构体字段提升到外层
+		if f.Anonymous && f.Type.Kind() == reflect.Struct && f.Tag.Get("json") == "" {
+			for k, v := range structSchema(f.Type, schemas)["properties"].(map[string]any) {
+				props[k] = v
+			}
+			continue
+		}
+		if !f.IsExported() {
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if n := strings.Split(tag, ",")[0]; n != "" {
+				name = n
+			}
+		}
+		s := schemaRef(f.Type, schemas)
+		if f.Type.Kind() == reflect.Pointer {
+			s = map[string]any{"allOf": []any{s}, "nullable": true}
+		}
+		props[name] = s
+	}
+	return map[string]any{"type": "object", "properties": props}
+}
+
+// handleOpenAPI 返回由代码生成的 OpenAPI 3 文档
+func (a *App) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, buildOpenAPISpec())
+}
+
+// apiDocsHTML 为 Swagger UI 页面，静态资源通过 CDN 加载
+const apiDocsHTML = `<!doctype html>
+<html lang="zh-CN">
+  <head>
+    <meta charset="utf-8" />
+    <title>看板 API 文档</title>
+    <link rel="stylesheet" href="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5.11.0/swagger-ui.css" />
+  </head>
+  <body>
+    <div id="swagger-ui"></div>
+    <script src="https://cdn.jsdelivr.net/npm/swagger-ui-dist@5.11.0/swagger-ui-bundle.js"></script>
+    <script>
+      window.ui = SwaggerUIBundle({ url: "/api/openapi.json", dom_id: "#swagger-ui" });
+    </script>
+  </body>
+</html>
+`
+
+// handleAPIDocs 返回 Swagger UI 页面
+func (a *App) handleAPIDocs(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write([]byte(apiDocsHTML))
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+)
+
+// publicCacheControl 为公开只读接口的缓存策略，适合在网站中嵌入路线图
+const publicCacheControl = "public, max-age=300, stale-while-revalidate=3600"
+
+// publicTask 为对外公开的脱敏任务结构
+type publicTask struct {
+	ID          int64     `json:"id"`
+	Title       string    `json:"title"`
+	Description string    `json:"description,omitempty"`
+	Status      string    `json:"status"`
+	Tags        []string  `json:"tags"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// handlePublicAPI 处理 /public/api/ 下的只读接口，仅在开启公开模式时可用
+func (a *App) handlePublicAPI(w http.ResponseWriter, r *http.Request) {
+	if !a.publicAPI {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	switch strings.TrimPrefix(r.URL.Path, "/public/api/") {
+	case "tasks":
+		a.handlePublicTasks(w, r)
+	case "tags":
+		a.handlePublicTags(w, r)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+	}
+}
+
+// handlePublicTasks 返回未归档任务的脱敏列表
+func (a *App) handlePublicTasks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	etag, err := a.tasksETag(ctx, "public|"+boolString(a.publicDescriptions))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if checkNotModified(w, r, etag, publicCacheControl) {
+		return
+	}
+	tasks, err := a.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks WHERE archived = 0 ORDER BY id DESC`)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	out := make([]publicTask, 0, len(tasks))
+	for _, t := range tasks {
+		pt := publicTask{ID: t.ID, Title: t.Title, Status: t.Status, Tags: t.Tags, UpdatedAt: t.UpdatedAt}
+		if a.publicDescriptions {
+			pt.Description = t.Description
+		}
+		if pt.Tags == nil {
+			pt.Tags = []string{}
+		}
+		out = append(out, pt)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": out})
+}
+
+// handlePublicTags 返回未归档任务使用中的标签
+func (a *App) handlePublicTags(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	etag, err := a.tasksETag(ctx, "public-tags")
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if checkNotModified(w, r, etag, publicCacheControl) {
+		return
+	}
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT DISTINCT tt.tag
+		FROM task_tags tt JOIN tasks t ON t.id = tt.task_id
+		WHERE t.archived = 0
+		ORDER BY tt.tag
+	`)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	defer rows.Close()
+	tags := []string{}
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		tags = append(tags, tag)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"items": tags})
+}
+
+// boolString 将布尔值格式化为 "1"/"0"
+func boolString(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
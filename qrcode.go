@@ -0,0 +1,427 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// 最小的二维码编码器，用于任务卡片与快捷操作中的链接：字节模式、纠错等级 M、版本 1–10（最多 213 字节），
+// 按 ISO/IEC 18004 的规则放置功能图形、交织数据与纠错码，并在 8 种掩码中选取惩罚分最低的一种
+
+// errQRTooLong 表示内容超过支持的最大版本容量
+var errQRTooLong = errors.New("qr: data too long")
+
+// qrBlocks 为某个版本在纠错等级 M 下的分块：每块的纠错码字数，以及两组块的块数与数据码字数
+type qrBlocks struct {
+	ecLen          int
+	g1Count, g1Len int
+	g2Count, g2Len int
+}
+
+// qrVersionsM 为版本 1–10 在纠错等级 M 下的分块
+var qrVersionsM = []qrBlocks{
+	{10, 1, 16, 0, 0},
+	{16, 1, 28, 0, 0},
+	{26, 1, 44, 0, 0},
+	{18, 2, 32, 0, 0},
+	{24, 2, 43, 0, 0},
+	{16, 4, 27, 0, 0},
+	{18, 4, 31, 0, 0},
+	{22, 2, 38, 2, 39},
+	{22, 3, 36, 2, 37},
+	{26, 4, 43, 1, 44},
+}
+
+// qrAlignment 为各版本校正图形的中心坐标
+var qrAlignment = [][]int{
+	nil,
+	{6, 18},
+	{6, 22},
+	{6, 26},
+	{6, 30},
+	{6, 34},
+	{6, 22, 38},
+	{6, 24, 42},
+	{6, 26, 46},
+	{6, 28, 50},
+}
+
+// dataLen 返回数据码字总数
+func (b qrBlocks) dataLen() int { return b.g1Count*b.g1Len + b.g2Count*b.g2Len }
+
+// qrCode 为编码结果；modules[y][x] 为 true 表示深色模块
+type qrCode struct {
+	size     int
+	modules  [][]bool
+	function [][]bool
+}
+
+// qrEncode 以能容纳内容的最小版本编码 data
+func qrEncode(data []byte) (*qrCode, error) {
+	for i, b := range qrVersionsM {
+		version := i + 1
+		countBits := 8
+		if version >= 10 {
+			countBits = 16
+		}
+		if 4+countBits+len(data)*8 > b.dataLen()*8 {
+			continue
+		}
+		q := newQRCode(version)
+		codewords := qrInterleave(qrDataCodewords(data, countBits, b.dataLen()), b)
+		q.drawCodewords(codewords)
+		best, bestPenalty := 0, math.MaxInt
+		for mask := 0; mask < 8; mask++ {
+			q.applyMask(mask)
+			q.drawFormat(mask)
+			if p := q.penalty(); p < bestPenalty {
+				best, bestPenalty = mask, p
+			}
+			q.applyMask(mask) // 异或两次即还原
+		}
+		q.applyMask(best)
+		q.drawFormat(best)
+		return q, nil
+	}
+	return nil, errQRTooLong
+}
+
+// qrDataCodewords 按字节模式生成数据码字：模式指示、字符数、数据、终止符，再以 0xEC、0x11 交替填充到 capacity
+func qrDataCodewords(data []byte, countBits, capacity int) []byte {
+	var bits []bool
+	put := func(v, n int) {
+		for i := n - 1; i >= 0; i-- {
+			bits = append(bits, v>>i&1 == 1)
+		}
+	}
+	put(0b0100, 4)
+	put(len(data), countBits)
+	for _, b := range data {
+		put(int(b), 8)
+	}
+	put(0, min(4, capacity*8-len(bits)))
+	for len(bits)%8 != 0 {
+		bits = append(bits, false)
+	}
+	out := make([]byte, 0, capacity)
+	for i := 0; i < len(bits); i += 8 {
+		var b byte
+		for j := 0; j < 8; j++ {
+			if bits[i+j] {
+				b |= 1 << (7 - j)
+			}
+		}
+		out = append(out, b)
+	}
+	for pad := byte(0xEC); len(out) < capacity; pad ^= 0xEC ^ 0x11 {
+		out = append(out, pad)
+	}
+	return out
+}
+
+// qrInterleave 将数据分块并计算纠错码，按列交织数据码字后再交织纠错码字
+func qrInterleave(data []byte, b qrBlocks) []byte {
+	divisor := rsDivisor(b.ecLen)
+	var blocks, ecs [][]byte
+	for i := 0; i < b.g1Count+b.g2Count; i++ {
+		n := b.g1Len
+		if i >= b.g1Count {
+			n = b.g2Len
+		}
+		blocks = append(blocks, data[:n])
+		ecs = append(ecs, rsRemainder(data[:n], divisor))
+		data = data[n:]
+	}
+	var out []byte
+	for i := 0; i < max(b.g1Len, b.g2Len); i++ {
+		for _, blk := range blocks {
+			if i < len(blk) {
+				out = append(out, blk[i])
+			}
+		}
+	}
+	for i := 0; i < b.ecLen; i++ {
+		for _, ec := range ecs {
+			out = append(out, ec[i])
+		}
+	}
+	return out
+}
+
+// gfMul 为 GF(256)（本原多项式 0x11D）上的乘法
+func gfMul(x, y byte) byte {
+	z := 0
+	for i := 7; i >= 0; i-- {
+		z = z<<1 ^ (z>>7)*0x11D
+		z ^= int(y>>i&1) * int(x)
+	}
+	return byte(z)
+}
+
+// rsDivisor 返回 degree 次的 Reed-Solomon 生成多项式系数（省略最高次项）
+func rsDivisor(degree int) []byte {
+	result := make([]byte, degree)
+	result[degree-1] = 1
+	root := byte(1)
+	for i := 0; i < degree; i++ {
+		for j := range result {
+			result[j] = gfMul(result[j], root)
+			if j+1 < len(result) {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMul(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder 返回 data 除以生成多项式的余式，即纠错码字
+func rsRemainder(data, divisor []byte) []byte {
+	result := make([]byte, len(divisor))
+	for _, b := range data {
+		factor := b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i := range result {
+			result[i] ^= gfMul(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// newQRCode 创建指定版本的矩阵并绘制功能图形（定位、分隔、定时、校正图形与版本信息，格式信息区预留）
+func newQRCode(version int) *qrCode {
+	size := version*4 + 17
+	q := &qrCode{size: size, modules: make([][]bool, size), function: make([][]bool, size)}
+	for i := range q.modules {
+		q.modules[i] = make([]bool, size)
+		q.function[i] = make([]bool, size)
+	}
+	for i := 0; i < size; i++ {
+		q.set(6, i, i%2 == 0)
+		q.set(i, 6, i%2 == 0)
+	}
+	q.drawFinder(3, 3)
+	q.drawFinder(size-4, 3)
+	q.drawFinder(3, size-4)
+	align := qrAlignment[version-1]
+	for i, cx := range align {
+		for j, cy := range align {
+			// 与定位图形重叠的三个角不放校正图形
+			if i == 0 && j == 0 || i == 0 && j == len(align)-1 || i == len(align)-1 && j == 0 {
+				continue
+			}
+			for dy := -2; dy <= 2; dy++ {
+				for dx := -2; dx <= 2; dx++ {
+					q.set(cx+dx, cy+dy, max(abs(dx), abs(dy)) != 1)
+				}
+			}
+		}
+	}
+	q.drawFormat(0)
+	if version >= 7 {
+		rem := version
+		for i := 0; i < 12; i++ {
+			rem = rem<<1 ^ (rem>>11)*0x1F25
+		}
+		bits := version<<12 | rem
+		for i := 0; i < 18; i++ {
+			a, b := size-11+i%3, i/3
+			q.set(a, b, bits>>i&1 == 1)
+			q.set(b, a, bits>>i&1 == 1)
+		}
+	}
+	return q
+}
+
+// abs 返回整数的绝对值
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// set 设置功能模块；x 为列，y 为行
+func (q *qrCode) set(x, y int, dark bool) {
+	q.modules[y][x] = dark
+	q.function[y][x] = true
+}
+
+// drawFinder 以 (cx, cy) 为中心绘制定位图形及其分隔带
+func (q *qrCode) drawFinder(cx, cy int) {
+	for dy := -4; dy <= 4; dy++ {
+		for dx := -4; dx <= 4; dx++ {
+			x, y := cx+dx, cy+dy
+			if x < 0 || x >= q.size || y < 0 || y >= q.size {
+				continue
+			}
+			d := max(abs(dx), abs(dy))
+			q.set(x, y, d != 2 && d != 4)
+		}
+	}
+}
+
+// drawFormat 绘制纠错等级 M 与掩码对应的两份格式信息及固定的深色模块
+func (q *qrCode) drawFormat(mask int) {
+	data := 0<<3 | mask // 纠错等级 M 的指示位为 00
+	rem := data
+	for i := 0; i < 10; i++ {
+		rem = rem<<1 ^ (rem>>9)*0x537
+	}
+	bits := (data<<10 | rem) ^ 0x5412
+	bit := func(i int) bool { return bits>>i&1 == 1 }
+	for i := 0; i <= 5; i++ {
+		q.set(8, i, bit(i))
+	}
+	q.set(8, 7, bit(6))
+	q.set(8, 8, bit(7))
+	q.set(7, 8, bit(8))
+	for i := 9; i < 15; i++ {
+		q.set(14-i, 8, bit(i))
+	}
+	for i := 0; i < 8; i++ {
+		q.set(q.size-1-i, 8, bit(i))
+	}
+	for i := 8; i < 15; i++ {
+		q.set(8, q.size-15+i, bit(i))
+	}
+	q.set(8, q.size-8, true)
+}
+
+// drawCodewords 按之字形从右下角起将码字逐位填入非功能模块，剩余位保持浅色
+func (q *qrCode) drawCodewords(data []byte) {
+	i := 0
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x := right - j
+				y := vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert
+				}
+				if !q.function[y][x] && i < len(data)*8 {
+					q.modules[y][x] = data[i>>3]>>(7-i&7)&1 == 1
+					i++
+				}
+			}
+		}
+	}
+}
+
+// applyMask 对非功能模块异或指定掩码
+func (q *qrCode) applyMask(mask int) {
+	for y := 0; y < q.size; y++ {
+		for x := 0; x < q.size; x++ {
+			var invert bool
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert && !q.function[y][x] {
+				q.modules[y][x] = !q.modules[y][x]
+			}
+		}
+	}
+}
+
+// penalty 按标准的四条规则计算惩罚分：连续同色、2×2 同色块、类定位图形序列与深色比例
+func (q *qrCode) penalty() int {
+	n := q.size
+	score := 0
+	at := func(x, y int, transpose bool) bool {
+		if transpose {
+			return q.modules[x][y]
+		}
+		return q.modules[y][x]
+	}
+	finderLike := []bool{true, false, true, true, true, false, true}
+	for _, transpose := range []bool{false, true} {
+		for y := 0; y < n; y++ {
+			run := 1
+			for x := 1; x <= n; x++ {
+				if x < n && at(x, y, transpose) == at(x-1, y, transpose) {
+					run++
+					continue
+				}
+				if run >= 5 {
+					score += run - 2
+				}
+				run = 1
+			}
+			// 1:1:3:1:1 的深浅序列且一侧有 4 个浅色模块（矩阵外视为浅色）
+			for x := 0; x+7 <= n; x++ {
+				match := true
+				for k, dark := range finderLike {
+					if at(x+k, y, transpose) != dark {
+						match = false
+						break
+					}
+				}
+				if !match {
+					continue
+				}
+				light := func(from, to int) bool {
+					for i := from; i < to; i++ {
+						if i >= 0 && i < n && at(i, y, transpose) {
+							return false
+						}
+					}
+					return true
+				}
+				if light(x-4, x) || light(x+7, x+11) {
+					score += 40
+				}
+			}
+		}
+	}
+	dark := 0
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			if q.modules[y][x] {
+				dark++
+			}
+			if x+1 < n && y+1 < n {
+				c := q.modules[y][x]
+				if q.modules[y][x+1] == c && q.modules[y+1][x] == c && q.modules[y+1][x+1] == c {
+					score += 3
+				}
+			}
+		}
+	}
+	// 深色比例每偏离 50% 五个百分点加 10 分
+	score += abs(dark*20-n*n*10) / (n * n) * 10
+	return score
+}
+
+// svgPath 返回以模块为单位绘制全部深色模块的 SVG 路径数据
+func (q *qrCode) svgPath() string {
+	var b strings.Builder
+	for y, row := range q.modules {
+		for x, dark := range row {
+			if dark {
+				fmt.Fprintf(&b, "M%d %dh1v1h-1z", x, y)
+			}
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 快捷操作：为任务生成带签名的一次性操作链接（移到下一列、标记完成），以二维码形式提供，
+// 用手机扫码后在确认页点击即执行状态变更，适合在实体看板前顺手更新。
+// 令牌中包含任务、来源与目标状态、过期时间和随机数，以快捷操作密钥（见 secrets.go）做 HMAC 签名，
+// 生成令牌不写库；执行时记录随机数，同一令牌只能成功使用一次。任务已不在来源状态时令牌失效。
+// 状态变更通过进程内调用 PATCH /api/tasks/{id}/status 完成，流转规则、WIP 限制、校验规则与审批照常生效
+
+// QuickActionConfig 为快捷操作的配置；Secret 为空时由服务生成并保存签名密钥，TTL 为令牌有效期
+type QuickActionConfig struct {
+	Enabled bool     `yaml:"enabled"`
+	Secret  string   `yaml:"secret"`
+	TTL     Duration `yaml:"ttl"`
+}
+
+// 快捷操作类型
+const (
+	quickActionNext = "next" // 移到下一列
+	quickActionDone = "done" // 标记完成
+)
+
+// quickActions 为支持的快捷操作，按展示顺序排列
+var quickActions = []string{quickActionNext, quickActionDone}
+
+// actionTokenVersion 为令牌格式版本
+const actionTokenVersion = "v1"
+
+// QuickAction 为任务当前可用的一个快捷操作
+type QuickAction struct {
+	Action     string    `json:"action"`
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	URL        string    `json:"url"`
+	ExpiresAt  time.Time `json:"expires_at"`
+}
+
+// quickActionListResponse 为快捷操作列表的响应结构
+type quickActionListResponse struct {
+	Items []QuickAction `json:"items"`
+}
+
+// actionToken 为快捷操作令牌的内容
+type actionToken struct {
+	TaskID    int64
+	From, To  string
+	ExpiresAt time.Time
+	Nonce     string
+}
+
+// errActionTokenInvalid 表示令牌格式错误、签名不符或已过期
+var errActionTokenInvalid = errors.New("invalid action token")
+
+// statusIndex 返回状态在看板列中的下标，不存在时返回 -1
+func statusIndex(status string) int {
+	for i, s := range taskStatuses {
+		if s == status {
+			return i
+		}
+	}
+	return -1
+}
+
+// quickActionTarget 返回任务在 status 时执行 action 的目标状态；操作不适用时返回 false
+func quickActionTarget(action, status string) (string, bool) {
+	i := statusIndex(status)
+	switch {
+	case i < 0:
+		return "", false
+	case action == quickActionNext && i+1 < len(taskStatuses):
+		return taskStatuses[i+1], true
+	case action == quickActionDone && status != "已完成":
+		return "已完成", true
+	}
+	return "", false
+}
+
+// payload 返回参与签名的令牌内容；状态以列下标表示，使令牌只含 URL 安全字符
+func (t actionToken) payload() string {
+	return strings.Join([]string{actionTokenVersion, strconv.FormatInt(t.TaskID, 10),
+		strconv.Itoa(statusIndex(t.From)), strconv.Itoa(statusIndex(t.To)),
+		strconv.FormatInt(t.ExpiresAt.Unix(), 10), t.Nonce}, ".")
+}
+
+// signActionToken 返回令牌签名（HMAC-SHA256 的前 16 字节）
+func signActionToken(secret []byte, payload string) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil)[:16])
+}
+
+// issueActionToken 生成快捷操作令牌，以最新的快捷操作密钥签名
+func (a *App) issueActionToken(taskID int64, from, to string, now time.Time) (string, actionToken, error) {
+	secrets := a.secrets.valid(secretAction, "", now)
+	if len(secrets) == 0 {
+		return "", actionToken{}, errors.New("quick action secret not configured")
+	}
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", actionToken{}, err
+	}
+	t := actionToken{TaskID: taskID, From: from, To: to, ExpiresAt: now.Add(a.quickActionTTL()).Truncate(time.Second), Nonce: hex.EncodeToString(nonce)}
+	payload := t.payload()
+	return payload + "." + signActionToken(secrets[0], payload), t, nil
+}
+
+// parseActionToken 校验令牌签名与有效期并解析内容；轮换宽限期内旧密钥签名的令牌仍然有效
+func (a *App) parseActionToken(token string, now time.Time) (actionToken, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 7 || parts[0] != actionTokenVersion {
+		return actionToken{}, errActionTokenInvalid
+	}
+	payload := strings.Join(parts[:6], ".")
+	signed := false
+	for _, secret := range a.secrets.valid(secretAction, "", now) {
+		if hmac.Equal([]byte(parts[6]), []byte(signActionToken(secret, payload))) {
+			signed = true
+			break
+		}
+	}
+	if !signed {
+		return actionToken{}, errActionTokenInvalid
+	}
+	taskID, err1 := strconv.ParseInt(parts[1], 10, 64)
+	from, err2 := strconv.Atoi(parts[2])
+	to, err3 := strconv.Atoi(parts[3])
+	exp, err4 := strconv.ParseInt(parts[4], 10, 64)
+	if err := errors.Join(err1, err2, err3, err4); err != nil || from < 0 || to < 0 || from >= len(taskStatuses) || to >= len(taskStatuses) {
+		return actionToken{}, errActionTokenInvalid
+	}
+	t := actionToken{TaskID: taskID, From: taskStatuses[from], To: taskStatuses[to], ExpiresAt: time.Unix(exp, 0), Nonce: parts[5]}
+	if !now.Before(t.ExpiresAt) {
+		return actionToken{}, errActionTokenInvalid
+	}
+	return t, nil
+}
+
+// quickActionTTL 返回令牌有效期，未配置时为 30 天
+func (a *App) quickActionTTL() time.Duration {
+	if ttl := a.cfg.QuickActions.TTL.Std(); ttl > 0 {
+		return ttl
+	}
+	return 30 * 24 * time.Hour
+}
+
+// quickActionURL 返回执行令牌的页面地址
+func quickActionURL(r *http.Request, token string) string {
+	return requestOrigin(r) + "/a/" + token
+}
+
+// handleTaskActions 处理 GET /api/tasks/{id}/actions（列出可用的快捷操作及其链接）
+// 与 GET /api/tasks/{id}/actions/{action}/qr.svg（操作链接的二维码）；每次请求都生成新的令牌
+func (a *App) handleTaskActions(w http.ResponseWriter, r *http.Request, taskID int64, rest []string) {
+	ctx := r.Context()
+	if !a.cfg.QuickActions.Enabled {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "quick actions disabled"})
+		return
+	}
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	t, err := a.fetchTaskDetail(ctx, taskID)
+	if err == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	now := time.Now()
+	// 链接本身即凭据
+	w.Header().Set("Cache-Control", "no-store")
+	if len(rest) == 0 {
+		items := []QuickAction{}
+		for _, action := range quickActions {
+			to, ok := quickActionTarget(action, t.Status)
+			if !ok || t.Archived {
+				continue
+			}
+			token, tok, err := a.issueActionToken(t.ID, t.Status, to, now)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			items = append(items, QuickAction{Action: action, FromStatus: t.Status, ToStatus: to, URL: quickActionURL(r, token), ExpiresAt: tok.ExpiresAt})
+		}
+		writeJSON(w, http.StatusOK, quickActionListResponse{Items: items})
+		return
+	}
+	if len(rest) != 2 || rest[1] != "qr.svg" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	to, ok := quickActionTarget(rest[0], t.Status)
+	if !ok || t.Archived {
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]string{"error": "action not available", "status": t.Status})
+		return
+	}
+	token, _, err := a.issueActionToken(t.ID, t.Status, to, now)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	qr, err := qrEncode([]byte(quickActionURL(r, token)))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	// 四周保留 4 个模块宽的空白区
+	w.Header().Set("Content-Type", "image/svg+xml; charset=utf-8")
+	fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" viewBox="-4 -4 %d %d" width="%d" height="%d">`+"\n", qr.size+8, qr.size+8, (qr.size+8)*4, (qr.size+8)*4)
+	fmt.Fprintf(w, `<rect x="-4" y="-4" width="%d" height="%d" fill="#fff"/>`+"\n", qr.size+8, qr.size+8)
+	fmt.Fprintf(w, `<path fill="#000" shape-rendering="crispEdges" d="%s"/>`+"\n</svg>\n", qr.svgPath())
+}
+
+// quickActionPage 为快捷操作页面展示的内容
+type quickActionPage struct {
+	TaskID  int64
+	Title   string
+	From    string
+	To      string
+	Policy  string
+	Confirm bool   // 为 true 时展示确认表单
+	Message string // 操作结果或无法执行的原因
+}
+
+// handleQuickActionPage 处理 /a/{token}：GET 展示确认页，POST 执行状态变更；
+// 令牌无效或已过期时返回 404，已使用返回 410，任务已不在来源状态返回 409
+func (a *App) handleQuickActionPage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !a.cfg.QuickActions.Enabled {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	// 链接本身即凭据：禁止缓存、收录与通过 Referer 外泄
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	now := time.Now()
+	tok, err := a.parseActionToken(strings.TrimPrefix(r.URL.Path, "/a/"), now)
+	if err != nil {
+		http.Error(w, "链接无效或已过期", http.StatusNotFound)
+		return
+	}
+	var used int
+	if err := a.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM action_token_uses WHERE nonce = ?`, tok.Nonce).Scan(&used); err != nil {
+		a.reqLogger(r).Error("读取快捷操作令牌失败", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	page := quickActionPage{TaskID: tok.TaskID, From: tok.From, To: tok.To}
+	if used > 0 {
+		page.Message = "该二维码已使用过，请重新生成"
+		a.renderQuickActionPage(w, r, http.StatusGone, page)
+		return
+	}
+	var status string
+	var archived int
+	var version int64
+	err = a.db.QueryRowContext(ctx, `SELECT title, status, archived, version FROM tasks WHERE id = ?`, tok.TaskID).Scan(&page.Title, &status, &archived, &version)
+	if err == sql.ErrNoRows {
+		http.Error(w, "链接无效或已过期", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		a.reqLogger(r).Error("读取快捷操作任务失败", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	if status != tok.From || archived != 0 {
+		page.Message = fmt.Sprintf("任务当前在「%s」%s，该二维码已失效", status, map[bool]string{true: "（已归档）"}[archived != 0])
+		a.renderQuickActionPage(w, r, http.StatusConflict, page)
+		return
+	}
+	if r.Method != http.MethodPost {
+		if page.Policy, err = a.columnPolicy(ctx, tok.To); err != nil {
+			a.reqLogger(r).Error("读取列策略失败", "err", err)
+		}
+		page.Confirm = true
+		a.renderQuickActionPage(w, r, http.StatusOK, page)
+		return
+	}
+	code, msg := a.runQuickAction(ctx, tok, version, strings.TrimSpace(r.FormValue("reason")), now)
+	page.Message = msg
+	// 进程内的状态变更请求已在日志属性中记录 task_id
+	a.reqLogger(r).Info("执行快捷操作", "from", tok.From, "to", tok.To, "status", code)
+	a.renderQuickActionPage(w, r, code, page)
+}
+
+// runQuickAction 记录令牌已使用并执行状态变更，返回页面状态码与结果说明；变更未成功时撤销使用记录，令牌可以重试
+func (a *App) runQuickAction(ctx context.Context, tok actionToken, version int64, reason string, now time.Time) (int, string) {
+	_, _ = a.db.ExecContext(ctx, `DELETE FROM action_token_uses WHERE expires_at < ?`, now.Format(time.RFC3339))
+	res, err := a.db.ExecContext(ctx, `INSERT OR IGNORE INTO action_token_uses (nonce, task_id, used_at, expires_at) VALUES (?, ?, ?, ?)`,
+		tok.Nonce, tok.TaskID, now.Format(time.RFC3339), tok.ExpiresAt.Format(time.RFC3339))
+	if err != nil {
+		return http.StatusInternalServerError, "操作失败：" + err.Error()
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return http.StatusGone, "该二维码已使用过，请重新生成"
+	}
+	status, raw := callAPI(ctx, a.withCacheInvalidation(a.routes()), http.MethodPatch, fmt.Sprintf("/api/tasks/%d/status", tok.TaskID),
+		taskStatusRequest{Status: tok.To, ExpectedVersion: &version, Confirm: true, Reason: reason}, "")
+	switch status {
+	case http.StatusOK:
+		return http.StatusOK, fmt.Sprintf("已移到「%s」", tok.To)
+	case http.StatusAccepted:
+		return http.StatusAccepted, fmt.Sprintf("「%s」列需要审批，已提交审批申请", tok.To)
+	}
+	_, _ = a.db.ExecContext(ctx, `DELETE FROM action_token_uses WHERE nonce = ?`, tok.Nonce)
+	var body struct {
+		Error   string `json:"error"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(raw, &body)
+	if body.Message == "" {
+		body.Message = body.Error
+	}
+	return status, "未能变更状态：" + body.Message
+}
+
+// renderQuickActionPage 渲染快捷操作页面
+func (a *App) renderQuickActionPage(w http.ResponseWriter, r *http.Request, code int, page quickActionPage) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(code)
+	if err := quickActionPageTmpl.Execute(w, page); err != nil {
+		a.reqLogger(r).Warn("渲染快捷操作页失败", "err", err)
+	}
+}
+
+var quickActionPageTmpl = template.Must(template.New("action").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>TB-{{.TaskID}} {{.From}} → {{.To}}</title>
+<style>
+body{font-family:system-ui,-apple-system,"PingFang SC","Microsoft YaHei",sans-serif;margin:0;background:#f5f6f8;color:#222}
+main{max-width:560px;margin:48px auto;padding:24px;background:#fff;border-radius:8px;box-shadow:0 1px 2px rgba(0,0,0,.06)}
+h1{font-size:20px;margin:0 0 16px}
+.move{font-size:16px;margin-bottom:16px}
+.status{display:inline-block;background:#e0e7ff;color:#3730a3;border-radius:4px;padding:2px 10px;font-size:14px}
+.policy{white-space:pre-wrap;background:#fffbeb;border:1px solid #fde68a;border-radius:4px;padding:8px 12px;font-size:14px;margin-bottom:16px}
+textarea{width:100%;box-sizing:border-box;min-height:64px;font:inherit;margin-bottom:16px}
+button{width:100%;padding:12px;font-size:16px;border:0;border-radius:6px;background:#4f46e5;color:#fff}
+.message{font-size:16px}
+</style>
+</head>
+<body>
+<main>
+<h1>TB-{{.TaskID}} {{.Title}}</h1>
+<div class="move"><span class="status">{{.From}}</span> → <span class="status">{{.To}}</span></div>
+{{if .Confirm}}
+{{if .Policy}}<div class="policy">「{{.To}}」列策略：
+{{.Policy}}</div>{{end}}
+<form method="post">
+<textarea name="reason" placeholder="原因（可选）"></textarea>
+<button type="submit">确认移到「{{.To}}」</button>
+</form>
+{{else}}
+<div class="message">{{.Message}}</div>
+{{end}}
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+)
+
+// 随机挑选任务：GET /api/tasks/random 从未归档任务中随机返回一项，可按 status 与 tag 过滤，
+// 供“下一个做什么”机器人或结对分配使用。默认按在当前列停留的天数加权，停留越久越容易被选中；
+// weight=uniform 时等概率挑选
+
+// randomTaskWeights 为支持的加权方式
+var randomTaskWeights = []string{"age", "uniform"}
+
+// randomTaskResponse 为随机挑选的结果；Candidates 为符合条件的任务数，Chance 为该任务被选中的概率
+type randomTaskResponse struct {
+	Task       Task    `json:"task"`
+	Weight     string  `json:"weight"`
+	Candidates int     `json:"candidates"`
+	Chance     float64 `json:"chance"`
+}
+
+// randomTaskWeight 返回任务的挑选权重：age 为 1 + 在当前列停留的天数，uniform 恒为 1
+func randomTaskWeight(t Task, mode string, now time.Time) float64 {
+	if mode == "uniform" {
+		return 1
+	}
+	return 1 + float64(computeTaskAge(t, agingThresholds{}, now).DaysInStatus)
+}
+
+// pickWeighted 按权重随机返回下标，r 为 [0, 1) 的随机数
+func pickWeighted(weights []float64, r float64) int {
+	total := 0.0
+	for _, w := range weights {
+		total += w
+	}
+	x := r * total
+	for i, w := range weights {
+		if x < w {
+			return i
+		}
+		x -= w
+	}
+	return len(weights) - 1
+}
+
+// handleRandomTask 处理 GET /api/tasks/random?status=&tag=&weight=
+func (a *App) handleRandomTask(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	ctx := r.Context()
+	q := r.URL.Query()
+	mode := strings.TrimSpace(q.Get("weight"))
+	if mode == "" {
+		mode = "age"
+	}
+	if !slices.Contains(randomTaskWeights, mode) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "weight must be age or uniform"})
+		return
+	}
+	cond := `WHERE archived = 0`
+	var args []any
+	if status := strings.TrimSpace(q.Get("status")); status != "" {
+		if !validStatus(status) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid status"})
+			return
+		}
+		cond += ` AND status = ?`
+		args = append(args, status)
+	}
+	if tag := a.cfg.Tags.normalize(q.Get("tag")); tag != "" {
+		cond += ` AND id IN (SELECT task_id FROM task_tags WHERE tag = ?)`
+		args = append(args, tag)
+	}
+	tasks, err := a.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks `+cond+` ORDER BY id`, args...)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if len(tasks) == 0 {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "no matching task"})
+		return
+	}
+	now := time.Now()
+	weights := make([]float64, len(tasks))
+	total := 0.0
+	for i, t := range tasks {
+		weights[i] = randomTaskWeight(t, mode, now)
+		total += weights[i]
+	}
+	i := pickWeighted(weights, rand.Float64())
+	writeJSON(w, http.StatusOK, randomTaskResponse{Task: tasks[i], Weight: mode, Candidates: len(tasks), Chance: weights[i] / total})
+}
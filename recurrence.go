@@ -0,0 +1,495 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/bits"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// 周期任务的生成方式
+const (
+	recurrenceOnComplete = "on_complete" // 完成一次后按规则生成下一次
+	recurrenceSchedule   = "schedule"    // 到达计划时间时生成，与完成与否无关
+)
+
+// recurrenceScanInterval 为按计划生成周期任务的扫描间隔
+const recurrenceScanInterval = time.Minute
+
+// TaskRecurrence 为任务上的周期规则；按规则生成的任务通过 recurrence_of 指回该任务（模板）
+type TaskRecurrence struct {
+	TaskID    int64      `json:"task_id"`
+	Rule      string     `json:"rule"`
+	Mode      string     `json:"mode"`
+	NextAt    *time.Time `json:"next_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+}
+
+// taskRecurrenceRequest 为设置周期规则的请求体；mode 缺省为 on_complete
+type taskRecurrenceRequest struct {
+	Rule string `json:"rule"`
+	Mode string `json:"mode"`
+}
+
+// recurrenceRule 为解析后的周期规则：固定间隔（天/月）或五段式 cron；
+// day 为按月规则的锚定日，0 表示沿用 after 的日期
+type recurrenceRule struct {
+	days, months int
+	day          int
+	cron         *cronSpec
+}
+
+var recurrenceEveryPattern = regexp.MustCompile(`^every\s+(\d+)\s*(d|days?|w|weeks?|m|months?)$`)
+
+// parseRecurrenceRule 解析周期规则，支持 daily、weekly、monthly、"every 3 days" / "every 2w"
+// 以及五段式 cron（分 时 日 月 周，如 "0 9 * * 1-5"）
+func parseRecurrenceRule(s string) (recurrenceRule, error) {
+	s = strings.ToLower(strings.Join(strings.Fields(s), " "))
+	switch s {
+	case "daily":
+		return recurrenceRule{days: 1}, nil
+	case "weekly":
+		return recurrenceRule{days: 7}, nil
+	case "monthly":
+		return recurrenceRule{months: 1}, nil
+	}
+	if m := recurrenceEveryPattern.FindStringSubmatch(s); m != nil {
+		n, err := strconv.Atoi(m[1])
+		if err != nil || n <= 0 || n > 366 {
+			return recurrenceRule{}, errors.New("invalid interval")
+		}
+		switch m[2][0] {
+		case 'd':
+			return recurrenceRule{days: n}, nil
+		case 'w':
+			return recurrenceRule{days: 7 * n}, nil
+		default:
+			return recurrenceRule{months: n}, nil
+		}
+	}
+	spec, err := parseCron(s)
+	if err != nil {
+		return recurrenceRule{}, err
+	}
+	return recurrenceRule{cron: spec}, nil
+}
+
+// next 返回 after 之后的下一次发生时间；cron 规则在五年内无匹配时返回零值
+func (r recurrenceRule) next(after time.Time) time.Time {
+	if r.cron != nil {
+		return r.cron.next(after)
+	}
+	if r.months > 0 {
+		return addMonths(after, r.months, r.day)
+	}
+	return after.AddDate(0, 0, r.days)
+}
+
+// addMonths 返回 t 之后第 n 个月的第 day 日（day 为 0 时取 t 的日），该月没有这一天时取月末，
+// 避免 AddDate 把 1 月 31 日加一个月规范化为 3 月初
+func addMonths(t time.Time, n, day int) time.Time {
+	if day == 0 {
+		day = t.Day()
+	}
+	first := time.Date(t.Year(), t.Month()+time.Month(n), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	last := first.AddDate(0, 1, -1).Day()
+	return first.AddDate(0, 0, min(day, last)-1)
+}
+
+// cronSpec 为五段式 cron 表达式各字段允许的取值位图
+type cronSpec struct {
+	minute, hour, dom, month, dow uint64
+	domAny, dowAny                bool
+}
+
+// cronFields 为各字段的取值范围
+var cronFields = []struct{ min, max int }{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 7}}
+
+// parseCron 解析五段式 cron，字段支持 *、数字、a-b 区间、/n 步长与逗号列表；周字段 0 与 7 均表示周日。
+// 与标准 cron 一致，以 * 开头的日、周字段（含 */n）视为不受限，不参与两者任一满足的判断
+func parseCron(s string) (*cronSpec, error) {
+	parts := strings.Fields(s)
+	if len(parts) != 5 {
+		return nil, errors.New("unsupported rule: use daily, weekly, monthly, every N days/weeks/months or a 5-field cron expression")
+	}
+	var masks [5]uint64
+	for i, p := range parts {
+		m, err := parseCronField(p, cronFields[i].min, cronFields[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron field %q: %w", p, err)
+		}
+		masks[i] = m
+	}
+	if masks[4]&(1<<7) != 0 {
+		masks[4] |= 1
+	}
+	return &cronSpec{
+		minute: masks[0], hour: masks[1], dom: masks[2], month: masks[3], dow: masks[4],
+		domAny: strings.HasPrefix(parts[2], "*"), dowAny: strings.HasPrefix(parts[4], "*"),
+	}, nil
+}
+
+// parseCronField 将单个 cron 字段解析为取值位图
+func parseCronField(field string, lo, hi int) (uint64, error) {
+	var mask uint64
+	for _, item := range strings.Split(field, ",") {
+		rangePart, step := item, 1
+		if i := strings.IndexByte(item, '/'); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, errors.New("invalid step")
+			}
+			rangePart, step = item[:i], n
+		}
+		start, end := lo, hi
+		if rangePart != "*" {
+			a, b, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = strconv.Atoi(a); err != nil {
+				return 0, errors.New("invalid value")
+			}
+			end = start
+			if isRange {
+				if end, err = strconv.Atoi(b); err != nil {
+					return 0, errors.New("invalid range")
+				}
+			} else if step > 1 {
+				end = hi
+			}
+		}
+		if start < lo || end > hi || start > end {
+			return 0, errors.New("value out of range")
+		}
+		for v := start; v <= end; v += step {
+			mask |= 1 << uint(v)
+		}
+	}
+	return mask, nil
+}
+
+// matchDay 判断日期是否满足日与周字段；两者都受限时任一满足即可（与标准 cron 一致）
+func (c *cronSpec) matchDay(t time.Time) bool {
+	domOK := c.dom&(1<<uint(t.Day())) != 0
+	dowOK := c.dow&(1<<uint(t.Weekday())) != 0
+	switch {
+	case c.domAny && c.dowAny:
+		return true
+	case c.domAny:
+		return dowOK
+	case c.dowAny:
+		return domOK
+	default:
+		return domOK || dowOK
+	}
+}
+
+// next 逐级跳过不匹配的月、日、时、分，返回 after 之后的首个匹配时间（按 after 所在时区计算）
+func (c *cronSpec) next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case c.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.matchDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case c.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case c.minute&(1<<uint(t.Minute())) == 0:
+			// 直接跳到本小时内下一个允许的分钟，没有则进入下一小时
+			rest := c.minute >> uint(t.Minute())
+			if rest == 0 {
+				t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			} else {
+				t = t.Add(time.Duration(bits.TrailingZeros64(rest)) * time.Minute)
+			}
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// fetchRecurrence 查询任务上的周期规则，不存在时返回 sql.ErrNoRows
+func (a *App) fetchRecurrence(ctx context.Context, taskID int64) (TaskRecurrence, error) {
+	rec := TaskRecurrence{TaskID: taskID}
+	var nextAt sql.NullString
+	var updated string
+	err := a.db.QueryRowContext(ctx, `SELECT rule, mode, next_at, updated_at FROM task_recurrences WHERE task_id = ?`, taskID).
+		Scan(&rec.Rule, &rec.Mode, &nextAt, &updated)
+	if err != nil {
+		return rec, err
+	}
+	rec.NextAt = nullTimeValue(nextAt)
+	rec.UpdatedAt, _ = time.Parse(time.RFC3339, updated)
+	return rec, nil
+}
+
+// fetchRecurrenceOf 返回生成该任务的周期模板编号；非周期生成的任务返回 nil
+func (a *App) fetchRecurrenceOf(ctx context.Context, taskID int64) (*int64, error) {
+	var templateID int64
+	err := a.db.QueryRowContext(ctx, `SELECT template_id FROM task_occurrences WHERE task_id = ?`, taskID).Scan(&templateID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &templateID, nil
+}
+
+// handleTaskRecurrence 处理 /api/tasks/{id}/recurrence：查询、设置与移除周期规则
+func (a *App) handleTaskRecurrence(w http.ResponseWriter, r *http.Request, taskID int64) {
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodGet:
+		rec, err := a.fetchRecurrence(ctx, taskID)
+		if err == sql.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "recurrence not found"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, rec)
+	case http.MethodPut:
+		var body taskRecurrenceRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		body.Rule = strings.TrimSpace(body.Rule)
+		rule, err := parseRecurrenceRule(body.Rule)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		switch body.Mode = strings.TrimSpace(body.Mode); body.Mode {
+		case "":
+			body.Mode = recurrenceOnComplete
+		case recurrenceOnComplete, recurrenceSchedule:
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid mode"})
+			return
+		}
+		ok, err := a.taskExists(ctx, taskID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+			return
+		}
+		now := time.Now()
+		var nextAt *time.Time
+		if body.Mode == recurrenceSchedule {
+			next := rule.next(now)
+			if next.IsZero() {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "rule never matches"})
+				return
+			}
+			nextAt = &next
+		}
+		if _, err := a.db.ExecContext(ctx, `
+			INSERT INTO task_recurrences (task_id, rule, mode, next_at, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(task_id) DO UPDATE SET
+				rule = excluded.rule,
+				mode = excluded.mode,
+				next_at = excluded.next_at,
+				updated_at = excluded.updated_at
+		`, taskID, body.Rule, body.Mode, timeArg(nextAt), now.Format(time.RFC3339), now.Format(time.RFC3339)); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		rec, err := a.fetchRecurrence(ctx, taskID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, rec)
+	case http.MethodDelete:
+		res, err := a.db.ExecContext(ctx, `DELETE FROM task_recurrences WHERE task_id = ?`, taskID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "recurrence not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"task_id": taskID, "deleted": true})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// onTaskCompleted 在任务进入「已完成」后调用：若其模板（或自身）配置了 on_complete 规则，则生成下一次任务
+func (a *App) onTaskCompleted(ctx context.Context, taskID int64) {
+	templateID := taskID
+	if of, err := a.fetchRecurrenceOf(ctx, taskID); err == nil && of != nil {
+		templateID = *of
+	}
+	rec, err := a.fetchRecurrence(ctx, templateID)
+	if err != nil || rec.Mode != recurrenceOnComplete {
+		if err != nil && err != sql.ErrNoRows {
+			a.logger.Error("读取周期规则失败", "task_id", templateID, "err", err)
+		}
+		return
+	}
+	rule, err := parseRecurrenceRule(rec.Rule)
+	if err != nil {
+		return
+	}
+	due := rule.next(time.Now())
+	if due.IsZero() {
+		return
+	}
+	newID, err := a.createOccurrence(ctx, templateID, due)
+	if err != nil {
+		a.logger.Error("生成周期任务失败", "template_id", templateID, "err", err)
+		return
+	}
+	a.logger.Info("已生成周期任务", "template_id", templateID, "task_id", newID, "due_at", due.Format(time.RFC3339))
+}
+
+// createOccurrence 按模板复制标题、描述、标签与结构化字段生成一次新任务，截止时间为本次发生时间
+func (a *App) createOccurrence(ctx context.Context, templateID int64, due time.Time) (int64, error) {
+	src, err := a.fetchTaskDetail(ctx, templateID)
+	if err != nil {
+		return 0, err
+	}
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	now := time.Now().Format(time.RFC3339)
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO tasks (title, description, status, archived, created_at, updated_at, due_at, status_changed_at)
+		VALUES (?, ?, ?, 0, ?, ?, ?, ?)
+	`, src.Title, src.Description, "规划中", now, now, timeArg(&due), now)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	for _, tag := range src.Tags {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO task_tags (task_id, tag) VALUES (?, ?)`, id, tag); err != nil {
+			return 0, err
+		}
+	}
+	for i, text := range src.AcceptanceCriteria {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO task_acceptance_criteria (task_id, position, text) VALUES (?, ?, ?)`, id, i, text); err != nil {
+			return 0, err
+		}
+	}
+	for i, l := range src.ExternalLinks {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO task_links (task_id, position, title, url) VALUES (?, ?, ?, ?)`, id, i, l.Title, l.URL); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO task_occurrences (task_id, template_id, created_at) VALUES (?, ?, ?)`, id, templateID, now); err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// startRecurrenceScheduler 启动按计划生成周期任务的后台扫描
+func (a *App) startRecurrenceScheduler() {
+	a.startBackground("recurrence", func(ctx context.Context) {
+		ticker := time.NewTicker(recurrenceScanInterval)
+		defer ticker.Stop()
+		for {
+			if a.waitForHeadroom(ctx, "recurrence") != nil {
+				return
+			}
+			if err := a.runScheduledRecurrences(ctx, time.Now()); err != nil && ctx.Err() == nil {
+				a.logger.Error("生成周期任务失败", "err", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// runScheduledRecurrences 为到期的 schedule 规则生成任务并推进下次时间；停机期间错过的多次只补生成一次
+func (a *App) runScheduledRecurrences(ctx context.Context, now time.Time) error {
+	rows, err := a.db.QueryContext(ctx, `SELECT task_id, rule, next_at, updated_at FROM task_recurrences WHERE mode = ? AND next_at IS NOT NULL`, recurrenceSchedule)
+	if err != nil {
+		return err
+	}
+	type dueRule struct {
+		templateID int64
+		rule       string
+		at         time.Time
+		setAt      time.Time
+	}
+	var due []dueRule
+	for rows.Next() {
+		var d dueRule
+		var nextAt, setAt string
+		if err := rows.Scan(&d.templateID, &d.rule, &nextAt, &setAt); err != nil {
+			rows.Close()
+			return err
+		}
+		d.setAt, _ = time.Parse(time.RFC3339, setAt)
+		if d.at, err = time.Parse(time.RFC3339, nextAt); err == nil && !d.at.After(now) {
+			due = append(due, d)
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, d := range due {
+		rule, err := parseRecurrenceRule(d.rule)
+		if err != nil {
+			continue
+		}
+		// 按月规则锚定在设置规则当天的日期，月末被截短后下个月仍回到原来的日期
+		if !d.setAt.IsZero() {
+			rule.day = d.setAt.Day()
+		}
+		next := d.at
+		for !next.IsZero() && !next.After(now) {
+			next = rule.next(next)
+		}
+		// 先推进下次时间，避免生成失败后每次扫描重复尝试产生多份任务
+		if _, err := a.db.ExecContext(ctx, `UPDATE task_recurrences SET next_at = ? WHERE task_id = ?`, timeArg(nonZeroTime(next)), d.templateID); err != nil {
+			return err
+		}
+		id, err := a.createOccurrence(ctx, d.templateID, d.at)
+		if err != nil {
+			a.logger.Error("生成周期任务失败", "template_id", d.templateID, "err", err)
+			continue
+		}
+		a.logger.Info("已生成周期任务", "template_id", d.templateID, "task_id", id, "due_at", d.at.Format(time.RFC3339))
+	}
+	return nil
+}
+
+// nonZeroTime 将零值时间转换为 nil
+func nonZeroTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMonthlyRecurrenceClampsToMonthEnd(t *testing.T) {
+	rule, err := parseRecurrenceRule("monthly")
+	if err != nil {
+		t.Fatal(err)
+	}
+	jan31 := time.Date(2026, time.January, 31, 9, 0, 0, 0, time.UTC)
+	if got, want := rule.next(jan31), time.Date(2026, time.February, 28, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("next(Jan 31) = %v, want %v", got, want)
+	}
+	rule.day = 31
+	feb28 := time.Date(2026, time.February, 28, 9, 0, 0, 0, time.UTC)
+	if got, want := rule.next(feb28), time.Date(2026, time.March, 31, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("anchored next(Feb 28) = %v, want %v", got, want)
+	}
+	every, err := parseRecurrenceRule("every 13 months")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := every.next(jan31), time.Date(2027, time.February, 28, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("every 13 months from Jan 31 = %v, want %v", got, want)
+	}
+}
+
+func TestCronStepDayOfMonthIsUnrestricted(t *testing.T) {
+	rule, err := parseRecurrenceRule("0 9 */2 * 1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2026-10-15 为周四、15 日：*/2 视为不受限，只按周一匹配
+	after := time.Date(2026, time.October, 15, 10, 0, 0, 0, time.UTC)
+	if got, want := rule.next(after), time.Date(2026, time.October, 19, 9, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("next = %v, want %v", got, want)
+	}
+}
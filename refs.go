@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// TaskRef 表示任务关联的外部引用（提交、PR、事故、文档、构建）
+type TaskRef struct {
+	ID        int64     `json:"id"`
+	TaskID    int64     `json:"task_id"`
+	Kind      string    `json:"kind"`
+	URL       string    `json:"url"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// taskRefRequest 为挂载外部引用的请求体
+type taskRefRequest struct {
+	Kind  string `json:"kind"`
+	URL   string `json:"url"`
+	Title string `json:"title"`
+}
+
+// refInboundRequest 为 CI 回调的请求体，text 通常为构建包含的提交信息
+type refInboundRequest struct {
+	Kind  string `json:"kind"`
+	URL   string `json:"url"`
+	Title string `json:"title"`
+	Text  string `json:"text"`
+}
+
+// validRefKind 检查外部引用类型是否有效
+func validRefKind(k string) bool {
+	switch k {
+	case "commit", "pr", "incident", "doc", "build":
+		return true
+	default:
+		return false
+	}
+}
+
+// validateRef 校验引用类型、URL 与标题
+func validateRef(kind, rawURL, title string) string {
+	if !validRefKind(kind) {
+		return "invalid kind"
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return "invalid url"
+	}
+	if len([]rune(title)) > maxStructuredTextLen {
+		return "title too long"
+	}
+	return ""
+}
+
+// taskIDPattern 匹配文本中的任务编号，如 TB-42 或 #42
+var taskIDPattern = regexp.MustCompile(`(?i)(?:\bTB-|#)(\d+)\b`)
+
+// extractTaskIDs 提取文本中引用的任务编号（去重，保持出现顺序）
+func extractTaskIDs(text string) []int64 {
+	seen := map[int64]bool{}
+	var ids []int64
+	for _, m := range taskIDPattern.FindAllStringSubmatch(text, -1) {
+		id, err := parseInt64(m[1])
+		if err != nil || id <= 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// taskExists 判断任务是否存在
+func (a *App) taskExists(ctx context.Context, id int64) (bool, error) {
+	var one int
+	err := a.db.QueryRowContext(ctx, `SELECT 1 FROM tasks WHERE id = ?`, id).Scan(&one)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// attachTaskRef 为任务挂载外部引用；相同类型与 URL 的引用只保留一条
+func (a *App) attachTaskRef(ctx context.Context, taskID int64, kind, rawURL, title string) (int64, bool, error) {
+	var existing int64
+	err := a.db.QueryRowContext(ctx, `SELECT id FROM task_refs WHERE task_id = ? AND kind = ? AND url = ?`, taskID, kind, rawURL).Scan(&existing)
+	if err == nil {
+		return existing, false, nil
+	}
+	if err != sql.ErrNoRows {
+		return 0, false, err
+	}
+	now := time.Now().Format(time.RFC3339)
+	res, err := a.db.ExecContext(ctx, `
+		INSERT INTO task_refs (task_id, kind, url, title, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, taskID, kind, rawURL, title, now)
+	if err != nil {
+		return 0, false, err
+	}
+	id, _ := res.LastInsertId()
+	return id, true, nil
+}
+
+// fetchTaskRefs 查询任务的外部引用
+func (a *App) fetchTaskRefs(ctx context.Context, taskID int64) ([]TaskRef, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT id, task_id, kind, url, title, created_at
+		FROM task_refs
+		WHERE task_id = ?
+		ORDER BY id
+	`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	refs := []TaskRef{}
+	for rows.Next() {
+		var ref TaskRef
+		var created string
+		if err := rows.Scan(&ref.ID, &ref.TaskID, &ref.Kind, &ref.URL, &ref.Title, &created); err != nil {
+			return nil, err
+		}
+		ref.CreatedAt, _ = time.Parse(time.RFC3339, created)
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
+
+// handleTaskRefs 处理 /api/tasks/{id}/refs[/{refId}]：列出、挂载与解除外部引用
+func (a *App) handleTaskRefs(w http.ResponseWriter, r *http.Request, taskID int64, rest []string) {
+	ctx := r.Context()
+	if len(rest) > 0 && rest[0] != "" {
+		if r.Method != http.MethodDelete {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		refID, err := parseInt64(rest[0])
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid ref id"})
+			return
+		}
+		res, err := a.db.ExecContext(ctx, `DELETE FROM task_refs WHERE id = ? AND task_id = ?`, refID, taskID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "ref not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"id": refID, "deleted": true})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		refs, err := a.fetchTaskRefs(ctx, taskID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"items": refs})
+	case http.MethodPost:
+		var body taskRefRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		body.Kind = strings.TrimSpace(body.Kind)
+		body.URL = strings.TrimSpace(body.URL)
+		body.Title = strings.TrimSpace(body.Title)
+		if msg := validateRef(body.Kind, body.URL, body.Title); msg != "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg})
+			return
+		}
+		ok, err := a.taskExists(ctx, taskID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+			return
+		}
+		refID, _, err := a.attachTaskRef(ctx, taskID, body.Kind, body.URL, body.Title)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusCreated, map[string]any{"id": refID})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// handleRefsInbound 供 CI 调用：扫描 text 中提到的任务编号，并为每个存在的任务挂载同一引用
+func (a *App) handleRefsInbound(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var body refInboundRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	body.Kind = strings.TrimSpace(body.Kind)
+	if body.Kind == "" {
+		body.Kind = "build"
+	}
+	body.URL = strings.TrimSpace(body.URL)
+	body.Title = strings.TrimSpace(body.Title)
+	if msg := validateRef(body.Kind, body.URL, body.Title); msg != "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg})
+		return
+	}
+	attached := []int64{}
+	for _, id := range extractTaskIDs(body.Text) {
+		ok, err := a.taskExists(ctx, id)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if !ok {
+			continue
+		}
+		if _, _, err := a.attachTaskRef(ctx, id, body.Kind, body.URL, body.Title); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		attached = append(attached, id)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"attached": attached})
+}
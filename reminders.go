@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"time"
+)
+
+// ReminderConfig 为截止提醒配置：在截止前 Before 中的各个时长各发送一次提醒（webhook 与邮件），两者均未配置时关闭
+type ReminderConfig struct {
+	WebhookURL string     `yaml:"webhook_url"`
+	Before     []Duration `yaml:"before"`
+	Interval   Duration   `yaml:"interval"`
+}
+
+// reminderPayload 为发往 webhook 的提醒内容
+type reminderPayload struct {
+	Event      string    `json:"event"`
+	Task       Task      `json:"task"`
+	Lead       string    `json:"lead"`         // 命中的提前量，如 "24h0m0s"
+	DueInHours float64   `json:"due_in_hours"` // 发送时距截止的小时数
+	SentAt     time.Time `json:"sent_at"`
+}
+
+// reminderHTTPTimeout 为单次 webhook 请求的超时
+const reminderHTTPTimeout = 10 * time.Second
+
+// validate 校验提醒配置
+func (c ReminderConfig) validate() error {
+	if c.WebhookURL == "" {
+		return nil
+	}
+	u, err := url.Parse(c.WebhookURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("reminders.webhook_url 无效: %q", c.WebhookURL)
+	}
+	for _, d := range c.Before {
+		if d <= 0 {
+			return fmt.Errorf("reminders.before 必须为正数时长")
+		}
+	}
+	return nil
+}
+
+// startReminders 按配置启动截止提醒后台任务
+func (a *App) startReminders() {
+	c := a.cfg.Reminders
+	if (c.WebhookURL == "" && !a.cfg.SMTP.enabled()) || len(c.Before) == 0 {
+		return
+	}
+	interval := c.Interval.Std()
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	leads := make([]time.Duration, 0, len(c.Before))
+	for _, d := range c.Before {
+		leads = append(leads, d.Std())
+	}
+	sort.Slice(leads, func(i, j int) bool { return leads[i] < leads[j] })
+	client := &http.Client{Timeout: reminderHTTPTimeout}
+	a.logger.Info("截止提醒已启用", "before", fmt.Sprint(leads), "interval", interval.String())
+	a.startBackground("reminders", func(ctx context.Context) {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			if a.waitForHeadroom(ctx, "reminders") != nil {
+				return
+			}
+			if err := a.sendDueReminders(ctx, client, c.WebhookURL, leads, time.Now()); err != nil && ctx.Err() == nil {
+				a.logger.Error("截止提醒失败", "err", err)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// sendDueReminders 为未完成且即将截止的任务发送提醒。每个任务取当前已进入的最小提前量，
+// 同一截止时间下已发送过不大于该提前量的提醒时跳过，因此停机后恢复只补发最紧迫的一次；
+// 截止时间被修改后会重新提醒
+func (a *App) sendDueReminders(ctx context.Context, client *http.Client, webhook string, leads []time.Duration, now time.Time) error {
+	maxLead := leads[len(leads)-1]
+	tasks, err := a.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks
+		WHERE archived = 0 AND status <> '已完成' AND due_at IS NOT NULL AND due_at > ? AND due_at <= ?
+		ORDER BY due_at`,
+		now.UTC().Format(time.RFC3339), now.Add(maxLead).UTC().Format(time.RFC3339))
+	if err != nil {
+		return err
+	}
+	for _, t := range tasks {
+		until := t.DueAt.Sub(now)
+		var lead time.Duration
+		for _, l := range leads {
+			if until <= l {
+				lead = l
+				break
+			}
+		}
+		if lead == 0 {
+			continue
+		}
+		due := t.DueAt.UTC().Format(time.RFC3339)
+		var sent int
+		if err := a.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM task_reminders WHERE task_id = ? AND due_at = ? AND lead_seconds <= ?`,
+			t.ID, due, int64(lead.Seconds())).Scan(&sent); err != nil {
+			return err
+		}
+		if sent > 0 {
+			continue
+		}
+		payload := reminderPayload{Event: "task.due_soon", Task: t, Lead: lead.String(), DueInHours: until.Hours(), SentAt: now}
+		if err := a.deliverReminder(ctx, client, webhook, payload); err != nil {
+			// 所有渠道均失败的任务在下次扫描时重试
+			a.logger.Warn("发送截止提醒失败", "task_id", t.ID, "err", err)
+			continue
+		}
+		if _, err := a.db.ExecContext(ctx, `INSERT OR IGNORE INTO task_reminders (task_id, due_at, lead_seconds, sent_at) VALUES (?, ?, ?, ?)`,
+			t.ID, due, int64(lead.Seconds()), now.Format(time.RFC3339)); err != nil {
+			return err
+		}
+		a.logger.Info("已发送截止提醒", "task_id", t.ID, "due_at", due, "lead", lead.String())
+	}
+	return nil
+}
+
+// deliverReminder 通过已配置的渠道（webhook、邮件）发送提醒；任一渠道成功即视为已提醒，全部失败时返回最后的错误
+func (a *App) deliverReminder(ctx context.Context, client *http.Client, webhook string, p reminderPayload) error {
+	var lastErr error
+	delivered := false
+	if webhook != "" {
+		err := postWebhook(ctx, client, webhook, p)
+		a.deliveries.record("reminder_webhook", err)
+		if err != nil {
+			a.logger.Warn("截止提醒 webhook 发送失败", "task_id", p.Task.ID, "err", err)
+			lastErr = err
+		} else {
+			delivered = true
+		}
+	}
+	if a.cfg.SMTP.enabled() {
+		m, err := reminderMail(a.outboundLocale(), p)
+		if err == nil {
+			err = a.cfg.SMTP.sendMail(ctx, m)
+		}
+		if err != nil {
+			a.logger.Warn("截止提醒邮件发送失败", "task_id", p.Task.ID, "err", err)
+			lastErr = err
+		} else {
+			delivered = true
+		}
+	}
+	if delivered {
+		return nil
+	}
+	return lastErr
+}
+
+// postWebhook 以 JSON 形式 POST 通知，非 2xx 响应视为失败
+func postWebhook(ctx context.Context, client *http.Client, webhook string, p any) error {
+	return postJSON(ctx, client, webhook, nil, p)
+}
+
+// postJSON 以 JSON 发送 POST 请求并附加 header 中的请求头，非 2xx 响应视为失败
+func postJSON(ctx context.Context, client *http.Client, webhook string, header http.Header, p any) error {
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhook, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook 返回 %s", resp.Status)
+	}
+	return nil
+}
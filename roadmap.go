@@ -0,0 +1,107 @@
+package main
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// roadmapItem 为路线图中的任务条目，包含绘制时间线所需的最少字段
+type roadmapItem struct {
+	ID      int64      `json:"id"`
+	Title   string     `json:"title"`
+	Status  string     `json:"status"`
+	Tags    []string   `json:"tags"`
+	StartAt *time.Time `json:"start_at"`
+	DueAt   *time.Time `json:"due_at"`
+}
+
+// roadmapGroup 为某月内同一标签下的任务
+type roadmapGroup struct {
+	Tag   string        `json:"tag"`
+	Items []roadmapItem `json:"items"`
+}
+
+// roadmapMonth 为一个月份桶
+type roadmapMonth struct {
+	Month  string         `json:"month"`
+	Groups []roadmapGroup `json:"groups"`
+}
+
+// handleRoadmap 返回有开始或截止时间的任务，按月份（优先取开始时间）与标签分组。
+// 支持 from/to（YYYY-MM，含边界）与 include_archived 查询参数；多标签任务会出现在每个标签分组中，无标签任务归入空标签。
+func (a *App) handleRoadmap(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	from := strings.TrimSpace(r.URL.Query().Get("from"))
+	to := strings.TrimSpace(r.URL.Query().Get("to"))
+	for _, m := range []string{from, to} {
+		if m == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01", m); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid month, want YYYY-MM"})
+			return
+		}
+	}
+	archParam := r.URL.Query().Get("include_archived")
+	includeArchived := archParam == "1" || strings.ToLower(archParam) == "true"
+	cond := "WHERE (start_at IS NOT NULL OR due_at IS NOT NULL)"
+	if !includeArchived {
+		cond += " AND archived = 0"
+	}
+	tasks, err := a.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks `+cond+` ORDER BY COALESCE(start_at, due_at), id`)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	buckets := map[string]map[string][]roadmapItem{}
+	for _, t := range tasks {
+		anchor := t.StartAt
+		if anchor == nil {
+			anchor = t.DueAt
+		}
+		month := anchor.Local().Format("2006-01")
+		if (from != "" && month < from) || (to != "" && month > to) {
+			continue
+		}
+		item := roadmapItem{ID: t.ID, Title: t.Title, Status: t.Status, Tags: t.Tags, StartAt: t.StartAt, DueAt: t.DueAt}
+		if item.Tags == nil {
+			item.Tags = []string{}
+		}
+		groups := buckets[month]
+		if groups == nil {
+			groups = map[string][]roadmapItem{}
+			buckets[month] = groups
+		}
+		if len(t.Tags) == 0 {
+			groups[""] = append(groups[""], item)
+		}
+		for _, tag := range t.Tags {
+			groups[tag] = append(groups[tag], item)
+		}
+	}
+	months := make([]string, 0, len(buckets))
+	for m := range buckets {
+		months = append(months, m)
+	}
+	sort.Strings(months)
+	out := make([]roadmapMonth, 0, len(months))
+	for _, m := range months {
+		tags := make([]string, 0, len(buckets[m]))
+		for tag := range buckets[m] {
+			tags = append(tags, tag)
+		}
+		sort.Strings(tags)
+		rm := roadmapMonth{Month: m}
+		for _, tag := range tags {
+			rm.Groups = append(rm.Groups, roadmapGroup{Tag: tag, Items: buckets[m][tag]})
+		}
+		out = append(out, rm)
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"months": out})
+}
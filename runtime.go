@@ -0,0 +1,272 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 运行信息：GET /api/admin/runtime 返回进程本身的状态——运行时长、协程数、内存与 GC 统计、数据库连接池、
+// 各后台任务是否仍在运行以及配置指纹，便于不接调试器直接检查线上实例；启动时同样的要点以一行结构化日志输出。
+// 配置指纹为完整配置的摘要，只用于比较两个实例（或重启前后）的配置是否一致，不能还原出配置内容
+
+// 后台任务的状态
+const (
+	workerRunning  = "running"
+	workerExited   = "exited"
+	workerPanicked = "panicked"
+)
+
+// workerStatus 为一个后台任务的状态；Cursor 为事件驱动任务已处理到的 task_events 位置
+type workerStatus struct {
+	Name      string     `json:"name"`
+	State     string     `json:"state"`
+	StartedAt time.Time  `json:"started_at"`
+	ExitedAt  *time.Time `json:"exited_at,omitempty"`
+	Panic     string     `json:"panic,omitempty"`
+	Cursor    *int64     `json:"cursor,omitempty"`
+}
+
+// workerRegistry 记录由 startBackground 启动的后台任务，零值可用
+type workerRegistry struct {
+	mu      sync.Mutex
+	workers map[string]*workerStatus
+}
+
+// started 记录后台任务启动
+func (r *workerRegistry) started(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.workers == nil {
+		r.workers = map[string]*workerStatus{}
+	}
+	r.workers[name] = &workerStatus{Name: name, State: workerRunning, StartedAt: time.Now().Truncate(time.Second)}
+}
+
+// exited 记录后台任务返回；rec 非空表示因 panic 退出
+func (r *workerRegistry) exited(name string, rec any) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	st := r.workers[name]
+	if st == nil {
+		return
+	}
+	now := time.Now().Truncate(time.Second)
+	st.State, st.ExitedAt = workerExited, &now
+	if rec != nil {
+		st.State, st.Panic = workerPanicked, fmt.Sprint(rec)
+	}
+}
+
+// snapshot 按名称返回各后台任务的状态
+func (r *workerRegistry) snapshot() []workerStatus {
+	r.mu.Lock()
+	out := make([]workerStatus, 0, len(r.workers))
+	for _, st := range r.workers {
+		out = append(out, *st)
+	}
+	r.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// buildSummary 为构建信息，取自编译时嵌入的模块与版本控制信息
+type buildSummary struct {
+	GoVersion string `json:"go_version"`
+	Module    string `json:"module,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Revision  string `json:"revision,omitempty"`
+	Time      string `json:"time,omitempty"`
+	Modified  bool   `json:"modified,omitempty"`
+}
+
+// memorySummary 为内存与 GC 统计，字节数均为当前值
+type memorySummary struct {
+	HeapAlloc    uint64  `json:"heap_alloc_bytes"`
+	HeapInuse    uint64  `json:"heap_inuse_bytes"`
+	HeapObjects  uint64  `json:"heap_objects"`
+	Sys          uint64  `json:"sys_bytes"`
+	NextGC       uint64  `json:"next_gc_bytes"`
+	NumGC        uint32  `json:"num_gc"`
+	LastGC       *string `json:"last_gc,omitempty"`
+	LastPauseMS  float64 `json:"last_pause_ms"`
+	TotalPauseMS float64 `json:"total_pause_ms"`
+}
+
+// connectionSummary 为数据库连接池状态
+type connectionSummary struct {
+	MaxOpen        int     `json:"max_open"`
+	Open           int     `json:"open"`
+	InUse          int     `json:"in_use"`
+	Idle           int     `json:"idle"`
+	WaitCount      int64   `json:"wait_count"`
+	WaitDurationMS float64 `json:"wait_duration_ms"`
+}
+
+// runtimeResponse 为运行信息的响应结构；Features 为已启用的可选功能
+type runtimeResponse struct {
+	GeneratedAt       time.Time         `json:"generated_at"`
+	StartedAt         time.Time         `json:"started_at"`
+	UptimeSeconds     int64             `json:"uptime_seconds"`
+	PID               int               `json:"pid"`
+	Build             buildSummary      `json:"build"`
+	Goroutines        int               `json:"goroutines"`
+	GOMAXPROCS        int               `json:"gomaxprocs"`
+	NumCPU            int               `json:"num_cpu"`
+	Memory            memorySummary     `json:"memory"`
+	Database          connectionSummary `json:"database"`
+	Workers           []workerStatus    `json:"workers"`
+	ConfigFingerprint string            `json:"config_fingerprint"`
+	Features          []string          `json:"features"`
+}
+
+// readBuildSummary 返回构建信息；非模块方式构建时只有 Go 版本
+func readBuildSummary() buildSummary {
+	b := buildSummary{GoVersion: runtime.Version()}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	b.Module, b.Version = info.Main.Path, info.Main.Version
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			b.Revision = s.Value
+		case "vcs.time":
+			b.Time = s.Value
+		case "vcs.modified":
+			b.Modified = s.Value == "true"
+		}
+	}
+	return b
+}
+
+// readMemorySummary 读取内存统计（会短暂停止所有协程，只在请求时调用）
+func readMemorySummary() memorySummary {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	m := memorySummary{
+		HeapAlloc:    ms.HeapAlloc,
+		HeapInuse:    ms.HeapInuse,
+		HeapObjects:  ms.HeapObjects,
+		Sys:          ms.Sys,
+		NextGC:       ms.NextGC,
+		NumGC:        ms.NumGC,
+		TotalPauseMS: durationMS(time.Duration(ms.PauseTotalNs)),
+	}
+	if ms.NumGC > 0 {
+		last := time.Unix(0, int64(ms.LastGC)).Format(time.RFC3339)
+		m.LastGC = &last
+		m.LastPauseMS = durationMS(time.Duration(ms.PauseNs[(ms.NumGC+255)%256]))
+	}
+	return m
+}
+
+// configFingerprint 返回配置的指纹，配置相同的实例指纹相同
+func configFingerprint(cfg Config) string {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return ""
+	}
+	return secretFingerprint(data)
+}
+
+// enabledFeatures 返回已启用的可选功能，按名称排列
+func (a *App) enabledFeatures() []string {
+	cfg := a.cfg
+	out := []string{}
+	for _, f := range []struct {
+		name string
+		on   bool
+	}{
+		{"audit", a.audit != nil},
+		{"dev", cfg.Dev.Enabled},
+		{"guest_read_only", cfg.Guest.ReadOnly},
+		{"inbox", cfg.Inbox.Token != ""},
+		{"login", len(a.oauth) > 0},
+		{"paging", cfg.Paging.enabled()},
+		{"public_api", cfg.Public.Enabled},
+		{"quick_actions", cfg.QuickActions.Enabled},
+		{"reminders", (cfg.Reminders.WebhookURL != "" || cfg.SMTP.enabled()) && len(cfg.Reminders.Before) > 0},
+		{"smtp", cfg.SMTP.enabled()},
+		{"telegram", cfg.Telegram.enabled()},
+		{"web_push", cfg.WebPush.enabled()},
+	} {
+		if f.on {
+			out = append(out, f.name)
+		}
+	}
+	return out
+}
+
+// runtimeWorkers 返回后台任务状态，并附上事件驱动任务的游标
+func (a *App) runtimeWorkers() []workerStatus {
+	cursors := a.eventCursors.snapshot()
+	out := a.workers.snapshot()
+	for i := range out {
+		if c, ok := cursors[out[i].Name]; ok {
+			out[i].Cursor = &c
+		}
+	}
+	return out
+}
+
+// logStartupBanner 以一行结构化日志输出启动要点，便于日志系统按字段检索
+func (a *App) logStartupBanner(addr, mode string) {
+	b := readBuildSummary()
+	names := []string{}
+	for _, st := range a.workers.snapshot() {
+		names = append(names, st.Name)
+	}
+	a.logger.Info("HTTP 服务启动",
+		"addr", addr,
+		"mode", mode,
+		"version", b.Version,
+		"revision", b.Revision,
+		"go", b.GoVersion,
+		"pid", os.Getpid(),
+		"db", a.cfg.Data.dbPath(),
+		"config_fingerprint", configFingerprint(a.cfg),
+		"features", a.enabledFeatures(),
+		"workers", names,
+	)
+}
+
+// handleRuntime 处理 GET /api/admin/runtime
+func (a *App) handleRuntime(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	now := time.Now()
+	db := a.db.Stats()
+	writeJSON(w, http.StatusOK, runtimeResponse{
+		GeneratedAt:   now.Truncate(time.Second),
+		StartedAt:     a.started.Truncate(time.Second),
+		UptimeSeconds: int64(now.Sub(a.started).Seconds()),
+		PID:           os.Getpid(),
+		Build:         readBuildSummary(),
+		Goroutines:    runtime.NumGoroutine(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		NumCPU:        runtime.NumCPU(),
+		Memory:        readMemorySummary(),
+		Database: connectionSummary{
+			MaxOpen:        db.MaxOpenConnections,
+			Open:           db.OpenConnections,
+			InUse:          db.InUse,
+			Idle:           db.Idle,
+			WaitCount:      db.WaitCount,
+			WaitDurationMS: durationMS(db.WaitDuration),
+		},
+		Workers:           a.runtimeWorkers(),
+		ConfigFingerprint: configFingerprint(a.cfg),
+		Features:          a.enabledFeatures(),
+	})
+}
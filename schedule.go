@@ -0,0 +1,87 @@
+package main
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// parseTaskTime 解析任务的计划时间，支持 RFC3339 与 YYYY-MM-DD（按本地时区零点）
+func parseTaskTime(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time %q, want RFC3339 or YYYY-MM-DD", s)
+}
+
+// optionalTime 将请求中的可选时间解析为数据库值：空串表示清空（NULL）
+func optionalTime(s string) (sql.NullString, error) {
+	if strings.TrimSpace(s) == "" {
+		return sql.NullString{}, nil
+	}
+	t, err := parseTaskTime(s)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	// 统一按 UTC 存储，保证字符串比较与时间先后一致
+	return sql.NullString{String: t.UTC().Format(time.RFC3339), Valid: true}, nil
+}
+
+// nullTimeValue 将数据库中的可空时间转换为 *time.Time
+func nullTimeValue(ns sql.NullString) *time.Time {
+	if !ns.Valid || ns.String == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339, ns.String)
+	if err != nil {
+		return nil
+	}
+	return &t
+}
+
+// timeArg 将 *time.Time 转换为可写入数据库的参数
+func timeArg(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// checkScheduleOrder 校验开始时间不晚于截止时间（任一为空时不校验）
+func checkScheduleOrder(startAt, dueAt sql.NullString) error {
+	if !startAt.Valid || !dueAt.Valid {
+		return nil
+	}
+	if startAt.String > dueAt.String {
+		return fmt.Errorf("start_at must not be after due_at")
+	}
+	return nil
+}
+
+// startingWindow 将 starting 查询参数（today、this_week、next_week、this_month）转换为 UTC 时间区间 [from, to)
+func startingWindow(name string, now time.Time) (string, string, error) {
+	now = now.Local()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.Local)
+	// 以周一为一周的开始
+	weekStart := day.AddDate(0, 0, -((int(day.Weekday()) + 6) % 7))
+	var from, to time.Time
+	switch name {
+	case "today":
+		from, to = day, day.AddDate(0, 0, 1)
+	case "this_week":
+		from, to = weekStart, weekStart.AddDate(0, 0, 7)
+	case "next_week":
+		from, to = weekStart.AddDate(0, 0, 7), weekStart.AddDate(0, 0, 14)
+	case "this_month":
+		from = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.Local)
+		to = from.AddDate(0, 1, 0)
+	default:
+		return "", "", fmt.Errorf("invalid starting window %q", name)
+	}
+	return from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339), nil
+}
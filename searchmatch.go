@@ -0,0 +1,208 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// 任务搜索中普通词对标题的扩展匹配：开启 pinyin 后，词可按拼音全拼（从任一字的音节开头起，末尾可只输入部分音节）
+// 或首字母匹配中文标题，如 guihua、huazh、gh 均可找到「规划中」；含汉字的词也按拼音比较，因此同音的错别字仍能命中。
+// 开启 fuzzy 后容忍拼写错误：4 个字符以上的词允许 1 处差异（增、删、改或相邻交换），8 个以上允许 2 处。
+// 拼音不预先存储，而是由注册到每个连接上的 search_match 函数在查询时计算，写入路径与已有数据都无需迁移
+
+// SearchConfig 为任务搜索的扩展匹配配置
+type SearchConfig struct {
+	Pinyin bool `yaml:"pinyin"`
+	Fuzzy  bool `yaml:"fuzzy"`
+}
+
+// mode 返回传给 search_match 的匹配方式，未开启任何扩展时为 0
+func (c SearchConfig) mode() int {
+	m := 0
+	if c.Pinyin {
+		m |= searchMatchPinyin
+	}
+	if c.Fuzzy {
+		m |= searchMatchFuzzy
+	}
+	return m
+}
+
+// search_match 的匹配方式
+const (
+	searchMatchPinyin = 1 << iota
+	searchMatchFuzzy
+)
+
+// minFuzzyLen 为启用容错匹配的最短词长（按字符计）
+const minFuzzyLen = 4
+
+var (
+	pinyinOnce  sync.Once
+	pinyinTable map[rune]string
+)
+
+// pinyinOf 返回汉字的拼音，不在字库中时返回空串
+func pinyinOf(r rune) string {
+	pinyinOnce.Do(func() {
+		pinyinTable = make(map[rune]string, 6800)
+		for _, line := range strings.Split(strings.TrimSpace(pinyinData), "\n") {
+			syllable, chars, _ := strings.Cut(line, " ")
+			for _, c := range chars {
+				pinyinTable[c] = syllable
+			}
+		}
+	})
+	return pinyinTable[r]
+}
+
+// pinyinKey 为文本的拼音形式：full 为全拼，汉字以外的字母与数字转小写后原样保留，其余字符略去；
+// starts 为每个字在 full 中的起始位置，initials 为各字首字母组成的串
+type pinyinKey struct {
+	full     string
+	starts   []int
+	initials string
+}
+
+// newPinyinKey 计算文本的拼音形式
+func newPinyinKey(s string) pinyinKey {
+	var k pinyinKey
+	var full, initials strings.Builder
+	for _, r := range strings.ToLower(s) {
+		py := pinyinOf(r)
+		if py == "" {
+			if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+				continue
+			}
+			py = string(r)
+		}
+		k.starts = append(k.starts, full.Len())
+		full.WriteString(py)
+		initials.WriteRune([]rune(py)[0])
+	}
+	k.full, k.initials = full.String(), initials.String()
+	return k
+}
+
+// hasHan 判断文本中是否有字库内的汉字
+func hasHan(s string) bool {
+	for _, r := range s {
+		if pinyinOf(r) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// pinyinWord 返回搜索词用于拼音比较的形式：含汉字时转为全拼，否则为只含小写字母的输入，
+// ü 输入为 v 时按 u 处理；不适合拼音比较时返回空串
+func pinyinWord(word string) string {
+	if hasHan(word) {
+		return newPinyinKey(word).full
+	}
+	word = strings.ToLower(word)
+	for _, r := range word {
+		if r < 'a' || r > 'z' {
+			return ""
+		}
+	}
+	return strings.ReplaceAll(word, "v", "u")
+}
+
+// matchPinyin 判断搜索词是否按拼音匹配文本；直接输入的字母至少两个，避免单个字母命中大量标题
+func matchPinyin(k pinyinKey, word string, han bool) bool {
+	if word == "" || !han && len(word) < 2 {
+		return false
+	}
+	for _, s := range k.starts {
+		if strings.HasPrefix(k.full[s:], word) {
+			return true
+		}
+	}
+	// 首字母只对直接输入的字母生效
+	return !han && strings.Contains(k.initials, word)
+}
+
+// fuzzyEdits 返回长度为 n 的词允许的差异数
+func fuzzyEdits(n int) int {
+	switch {
+	case n < minFuzzyLen:
+		return 0
+	case n < 8:
+		return 1
+	}
+	return 2
+}
+
+// fuzzyContains 判断 text 中是否有子串与 word 的编辑距离（含相邻交换）不超过 k
+func fuzzyContains(text, word []rune, k int) bool {
+	m := len(word)
+	if m == 0 {
+		return true
+	}
+	// 各行第 j 列为 word[:j] 与以当前位置结尾的某个子串之间的最小距离，子串起点不计代价
+	prev2 := make([]int, m+1)
+	prev := make([]int, m+1)
+	cur := make([]int, m+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	if prev[m] <= k {
+		return true
+	}
+	for i, c := range text {
+		cur[0] = 0
+		for j := 1; j <= m; j++ {
+			cost := 1
+			if word[j-1] == c {
+				cost = 0
+			}
+			cur[j] = min(prev[j-1]+cost, prev[j]+1, cur[j-1]+1)
+			if i > 0 && j > 1 && word[j-1] == text[i-1] && word[j-2] == c {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		if cur[m] <= k {
+			return true
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return false
+}
+
+// searchMatch 为 SQL 函数 search_match(text, word, mode) 的实现，按 mode 以拼音或容错方式判断 word 是否匹配 text
+func searchMatch(text, word string, mode int) bool {
+	word = strings.TrimSpace(word)
+	if word == "" {
+		return false
+	}
+	var key pinyinKey
+	pyWord := ""
+	if mode&searchMatchPinyin != 0 {
+		key = newPinyinKey(text)
+		pyWord = pinyinWord(word)
+		if matchPinyin(key, pyWord, hasHan(word)) {
+			return true
+		}
+	}
+	if mode&searchMatchFuzzy != 0 {
+		w := []rune(strings.ToLower(word))
+		if k := fuzzyEdits(len(w)); k > 0 && fuzzyContains([]rune(strings.ToLower(text)), w, k) {
+			return true
+		}
+		if pyWord != "" {
+			if k := fuzzyEdits(len(pyWord)); k > 0 && fuzzyContains([]rune(key.full), []rune(pyWord), k) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// registerSearchFuncs 在新建的数据库连接上注册 search_match 函数
+func registerSearchFuncs(conn *sqlite3.SQLiteConn) error {
+	return conn.RegisterFunc("search_match", searchMatch, true)
+}
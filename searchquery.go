@@ -0,0 +1,214 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// 任务搜索的查询语法（q 参数）：以空白分隔的若干条件同时满足，如
+//
+//	status:进行中 tag:bug -tag:wontfix "database locked" created:>2024-01-01
+//
+// 普通词与双引号短语按标题、描述或标签模糊匹配；status: 与 tag: 精确匹配，多个 status: 满足其一即可；
+// created:、updated:、start:、due: 比较时间，支持 > >= < <= 前缀，值为 YYYY-MM-DD（按本地时区整天）或 RFC3339，
+// 不带比较符的日期匹配当天。前缀 - 表示排除。未知的限定词按普通词处理，如 http://example.com。
+// 普通词对标题的拼音与容错匹配见 searchmatch.go
+
+// searchDateFields 为可比较时间的限定词及对应的列
+var searchDateFields = map[string]string{
+	"created": "created_at",
+	"updated": "updated_at",
+	"start":   "start_at",
+	"due":     "due_at",
+}
+
+// searchTerm 为查询中的一个条件；field 为空表示普通词或短语
+type searchTerm struct {
+	field  string
+	op     string
+	value  string
+	negate bool
+}
+
+// searchQuery 为解析后的查询
+type searchQuery struct {
+	terms []searchTerm
+}
+
+// parseSearchQuery 解析查询字符串；限定词的值不合法时返回错误，标签值按 tags 规范化
+func parseSearchQuery(q string, tags TagsConfig) (searchQuery, error) {
+	var sq searchQuery
+	for _, tok := range tokenizeSearchQuery(q) {
+		t := searchTerm{value: tok.text}
+		if tok.text != "" && !tok.quoted && strings.HasPrefix(tok.text, "-") && len(tok.text) > 1 {
+			t.negate, t.value = true, tok.text[1:]
+		}
+		if key, val, ok := strings.Cut(t.value, ":"); ok && !tok.quoted {
+			key = strings.ToLower(key)
+			switch {
+			case key == "status":
+				if !validStatus(val) {
+					return sq, fmt.Errorf("invalid status %q", val)
+				}
+				t.field, t.value = key, val
+			case key == "tag":
+				if t.value = tags.normalize(val); t.value == "" {
+					return sq, fmt.Errorf("empty tag")
+				}
+				t.field = key
+			case searchDateFields[key] != "":
+				t.field = key
+				t.op, t.value = cutComparison(val)
+				if _, err := parseTaskTime(t.value); err != nil {
+					return sq, fmt.Errorf("%s: %w", key, err)
+				}
+			}
+		}
+		if t.field == "" && t.value == "" {
+			continue
+		}
+		sq.terms = append(sq.terms, t)
+	}
+	return sq, nil
+}
+
+// searchToken 为分词结果；quoted 表示整个词由双引号包围
+type searchToken struct {
+	text   string
+	quoted bool
+}
+
+// tokenizeSearchQuery 按空白分词，双引号内的空白保留，如 tag:"needs review"
+func tokenizeSearchQuery(q string) []searchToken {
+	var out []searchToken
+	var cur strings.Builder
+	inQuote, started, quoted := false, false, false
+	flush := func() {
+		if started {
+			out = append(out, searchToken{text: cur.String(), quoted: quoted})
+		}
+		cur.Reset()
+		inQuote, started, quoted = false, false, false
+	}
+	for _, r := range q {
+		switch {
+		case r == '"':
+			if !started {
+				quoted = true
+			}
+			started = true
+			inQuote = !inQuote
+		case unicode.IsSpace(r) && !inQuote:
+			flush()
+		default:
+			started = true
+			cur.WriteRune(r)
+		}
+	}
+	flush()
+	return out
+}
+
+// cutComparison 拆出值前的比较符，无比较符时返回 "="
+func cutComparison(v string) (string, string) {
+	for _, op := range []string{">=", "<=", ">", "<"} {
+		if rest, ok := strings.CutPrefix(v, op); ok {
+			return op, rest
+		}
+	}
+	return "=", v
+}
+
+// likeEscaper 转义 LIKE 模式中的通配符，配合 ESCAPE '\' 使用，使 100% 与 snake_case 按字面匹配
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// where 返回查询对应的 SQL 条件（不含 WHERE），无条件时返回空串；普通词按 search 配置追加拼音与容错匹配。
+// 时间列写入时的时区不一致，比较统一经过 julianday 换算
+func (sq searchQuery) where(search SearchConfig) (string, []any) {
+	var conds []string
+	var args []any
+	var statuses []string
+	var statusArgs []any
+	for _, t := range sq.terms {
+		var c string
+		switch t.field {
+		case "":
+			c = `title LIKE ? ESCAPE '\' OR description LIKE ? ESCAPE '\' OR id IN (SELECT task_id FROM task_tags WHERE tag LIKE ? ESCAPE '\')`
+			pat := "%" + likeEscaper.Replace(t.value) + "%"
+			args = append(args, pat, pat, pat)
+			if mode := search.mode(); mode != 0 {
+				c += " OR search_match(title, ?, ?)"
+				args = append(args, t.value, mode)
+			}
+			c = "(" + c + ")"
+		case "status":
+			if !t.negate {
+				statuses = append(statuses, "?")
+				statusArgs = append(statusArgs, t.value)
+				continue
+			}
+			c = "status = ?"
+			args = append(args, t.value)
+		case "tag":
+			c = "id IN (SELECT task_id FROM task_tags WHERE tag = ?)"
+			args = append(args, t.value)
+		default:
+			var dargs []any
+			c, dargs = searchDateCond(searchDateFields[t.field], t.op, t.value)
+			args = append(args, dargs...)
+		}
+		if t.negate {
+			// 计划时间为空的任务不满足任何时间条件，取反时应当保留
+			c = "NOT COALESCE(" + c + ", 0)"
+		}
+		conds = append(conds, c)
+	}
+	if len(statuses) > 0 {
+		conds = append(conds, "status IN ("+strings.Join(statuses, ", ")+")")
+		args = append(args, statusArgs...)
+	}
+	return strings.Join(conds, " AND "), args
+}
+
+// searchDateCond 返回时间列的比较条件；日期按本地时区的整天解释，如 >2024-01-01 表示 2024-01-02 零点及以后
+func searchDateCond(col, op, value string) (string, []any) {
+	t, _ := parseTaskTime(value)
+	from, to := t, t
+	if _, err := time.ParseInLocation("2006-01-02", strings.TrimSpace(value), time.Local); err == nil {
+		to = t.AddDate(0, 0, 1)
+	}
+	expr := "julianday(" + col + ")"
+	arg := func(t time.Time) any { return t.UTC().Format(time.RFC3339) }
+	switch op {
+	case ">":
+		if to.Equal(from) {
+			return expr + " > julianday(?)", []any{arg(from)}
+		}
+		return expr + " >= julianday(?)", []any{arg(to)}
+	case ">=":
+		return expr + " >= julianday(?)", []any{arg(from)}
+	case "<":
+		return expr + " < julianday(?)", []any{arg(from)}
+	case "<=":
+		if to.Equal(from) {
+			return expr + " <= julianday(?)", []any{arg(from)}
+		}
+		return expr + " < julianday(?)", []any{arg(to)}
+	}
+	if to.Equal(from) {
+		return expr + " = julianday(?)", []any{arg(from)}
+	}
+	return "(" + expr + " >= julianday(?) AND " + expr + " < julianday(?))", []any{arg(from), arg(to)}
+}
+
+// taskSearchCond 解析 q 并返回对应的 SQL 条件（不含 WHERE），q 为空时返回空串
+func (a *App) taskSearchCond(q string) (string, []any, error) {
+	sq, err := parseSearchQuery(q, a.cfg.Tags)
+	if err != nil {
+		return "", nil, err
+	}
+	cond, args := sq.where(a.cfg.Search)
+	return cond, args, nil
+}
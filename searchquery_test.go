@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSearchTermsMatchWildcardsLiterally(t *testing.T) {
+	app := newTestApp(t, nil)
+	for _, title := range []string{"coverage 100%", "coverage 1000", "rename snake_case", "rename snakeXcase"} {
+		if _, err := app.db.Exec(`INSERT INTO tasks (title, description, status, created_at, updated_at) VALUES (?, '', '规划中', '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')`, title); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, tc := range []struct {
+		q    string
+		want string
+	}{
+		{"100%", "coverage 100%"},
+		{"snake_case", "rename snake_case"},
+		{`"e 100%"`, "coverage 100%"},
+	} {
+		cond, args, err := app.taskSearchCond(tc.q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		tasks, err := app.queryTasks(context.Background(), `SELECT `+taskColumns+` FROM tasks WHERE `+cond, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(tasks) != 1 || tasks[0].Title != tc.want {
+			var got []string
+			for _, task := range tasks {
+				got = append(got, task.Title)
+			}
+			t.Errorf("search %s = %q, want [%q]", tc.q, got, tc.want)
+		}
+	}
+}
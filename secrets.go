@@ -0,0 +1,361 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 凭据轮换：签名客户端密钥、入站邮件令牌与快捷操作签名密钥除了配置文件中的值，还可以通过管理接口轮换。
+// 轮换生成新密钥并立即生效，旧密钥（含配置文件中的）在宽限期内继续有效，集成方可以从容切换。
+// 轮换产生的密钥保存在 secret_versions 表中（HMAC 校验需要原文）；配置文件中的密钥不落库，
+// 只按指纹记录其失效时间，运维之后把新密钥写回配置文件时两者指纹相同，不会重复。
+
+// 凭据类型
+const (
+	secretSigning = "signing" // 签名客户端密钥，名称为客户端 ID
+	secretInbox   = "inbox"   // 入站邮件令牌，名称固定为空串
+	secretAction  = "action"  // 快捷操作令牌的签名密钥，名称固定为空串
+)
+
+// 轮换宽限期的默认值与上限
+const (
+	defaultSecretGrace = 24 * time.Hour
+	maxSecretGrace     = 30 * 24 * time.Hour
+)
+
+// 密钥来源
+const (
+	secretFromConfig  = "config"
+	secretFromRotated = "rotated"
+)
+
+// secretVersion 为某个凭据的一个密钥；ExpiresAt 为空表示长期有效
+type secretVersion struct {
+	secret      []byte
+	Fingerprint string     `json:"fingerprint"`
+	Source      string     `json:"source"`
+	CreatedAt   *time.Time `json:"created_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// validAt 判断密钥在 now 是否有效
+func (s *secretVersion) validAt(now time.Time) bool {
+	return s.ExpiresAt == nil || now.Before(*s.ExpiresAt)
+}
+
+// secretKey 标识一个凭据
+type secretKey struct {
+	kind string
+	name string
+}
+
+// secretRing 保存各凭据当前的全部密钥；轮换宽限期内同一凭据有多个有效密钥
+type secretRing struct {
+	mu       sync.RWMutex
+	versions map[secretKey][]*secretVersion
+}
+
+// newSecretRing 创建空的密钥环
+func newSecretRing() *secretRing {
+	return &secretRing{versions: map[secretKey][]*secretVersion{}}
+}
+
+// secretFingerprint 返回密钥指纹，用于在列表与日志中区分密钥而不暴露原文
+func secretFingerprint(secret []byte) string {
+	sum := sha256.Sum256(secret)
+	return hex.EncodeToString(sum[:6])
+}
+
+// seed 添加配置文件中的密钥
+func (s *secretRing) seed(kind, name, secret string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	k := secretKey{kind, name}
+	s.versions[k] = append(s.versions[k], &secretVersion{secret: []byte(secret), Fingerprint: secretFingerprint([]byte(secret)), Source: secretFromConfig})
+}
+
+// has 判断某类凭据是否存在任一密钥（含已过期的），用于决定是否启用相应的校验
+func (s *secretRing) has(kind string) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for k := range s.versions {
+		if k.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// valid 返回凭据在 now 仍有效的密钥，最新的在前
+func (s *secretRing) valid(kind, name string, now time.Time) [][]byte {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	vs := s.versions[secretKey{kind, name}]
+	var out [][]byte
+	for i := len(vs) - 1; i >= 0; i-- {
+		if vs[i].validAt(now) {
+			out = append(out, vs[i].secret)
+		}
+	}
+	return out
+}
+
+// match 以常量时间比较判断 token 是否为凭据的任一有效密钥
+func (s *secretRing) match(kind, name, token string, now time.Time) bool {
+	ok := false
+	for _, secret := range s.valid(kind, name, now) {
+		if hmac.Equal([]byte(token), secret) {
+			ok = true
+		}
+	}
+	return ok
+}
+
+// loadSecretVersions 从数据库读取轮换产生的密钥与配置密钥的失效时间，合并到密钥环
+func (a *App) loadSecretVersions(ctx context.Context) error {
+	rows, err := a.db.QueryContext(ctx, `SELECT kind, name, fingerprint, secret, created_at, expires_at FROM secret_versions ORDER BY created_at`)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	s := a.secrets
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for rows.Next() {
+		var k secretKey
+		var fp, secret, created string
+		var expires *string
+		if err := rows.Scan(&k.kind, &k.name, &fp, &secret, &created, &expires); err != nil {
+			return err
+		}
+		var exp *time.Time
+		if expires != nil {
+			if t, err := time.Parse(time.RFC3339, *expires); err == nil {
+				exp = &t
+			}
+		}
+		if existing := findSecretVersion(s.versions[k], fp); existing != nil {
+			// 配置文件中的密钥已被轮换，或轮换出的密钥已写回配置文件
+			if secret == "" || existing.Source == secretFromConfig {
+				existing.ExpiresAt = exp
+			}
+			continue
+		}
+		if secret == "" {
+			continue // 配置文件中已不再有该密钥
+		}
+		v := &secretVersion{secret: []byte(secret), Fingerprint: fp, Source: secretFromRotated, ExpiresAt: exp}
+		if t, err := time.Parse(time.RFC3339, created); err == nil {
+			v.CreatedAt = &t
+		}
+		s.versions[k] = append(s.versions[k], v)
+	}
+	return rows.Err()
+}
+
+// findSecretVersion 按指纹查找密钥
+func findSecretVersion(vs []*secretVersion, fp string) *secretVersion {
+	for _, v := range vs {
+		if v.Fingerprint == fp {
+			return v
+		}
+	}
+	return nil
+}
+
+// secretCredential 为管理接口中的一个凭据及其有效密钥
+type secretCredential struct {
+	Kind     string          `json:"kind"`
+	Name     string          `json:"name"`
+	Versions []secretVersion `json:"versions"`
+}
+
+// secretListResponse 为凭据列表的响应结构
+type secretListResponse struct {
+	Items []secretCredential `json:"items"`
+}
+
+// secretRotateRequest 为轮换请求体；GracePeriod 为旧密钥继续有效的时长，如 "1h"，缺省 24h，"0s" 表示立即失效
+type secretRotateRequest struct {
+	GracePeriod string `json:"grace_period"`
+}
+
+// secretRotateResponse 为轮换结果；Secret 只在此处返回一次
+type secretRotateResponse struct {
+	Kind          string     `json:"kind"`
+	Name          string     `json:"name"`
+	Secret        string     `json:"secret"`
+	Fingerprint   string     `json:"fingerprint"`
+	PreviousUntil *time.Time `json:"previous_until,omitempty"` // 旧密钥在宽限期结束后失效的时间；没有需要延后失效的旧密钥时为空
+}
+
+// handleSecrets 处理 GET /api/admin/secrets，列出各凭据当前有效的密钥（只含指纹，不含原文）
+func (a *App) handleSecrets(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	now := time.Now()
+	s := a.secrets
+	s.mu.RLock()
+	items := []secretCredential{}
+	for k, vs := range s.versions {
+		c := secretCredential{Kind: k.kind, Name: k.name, Versions: []secretVersion{}}
+		for _, v := range vs {
+			if v.validAt(now) {
+				c.Versions = append(c.Versions, *v)
+			}
+		}
+		items = append(items, c)
+	}
+	s.mu.RUnlock()
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Kind != items[j].Kind {
+			return items[i].Kind < items[j].Kind
+		}
+		return items[i].Name < items[j].Name
+	})
+	writeJSON(w, http.StatusOK, secretListResponse{Items: items})
+}
+
+// handleSecretItem 处理 POST /api/admin/secrets/signing/{client}/rotate、/api/admin/secrets/inbox/rotate 与 /api/admin/secrets/action/rotate
+func (a *App) handleSecretItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/admin/secrets/"), "/"), "/")
+	var k secretKey
+	switch {
+	case len(parts) == 3 && parts[0] == secretSigning && parts[1] != "" && parts[2] == "rotate":
+		k = secretKey{secretSigning, parts[1]}
+	case len(parts) == 2 && (parts[0] == secretInbox || parts[0] == secretAction) && parts[1] == "rotate":
+		k = secretKey{parts[0], ""}
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var body secretRotateRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	grace := defaultSecretGrace
+	if body.GracePeriod != "" {
+		d, err := time.ParseDuration(body.GracePeriod)
+		if err != nil || d < 0 || d > maxSecretGrace {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "grace_period must be a duration between 0s and 720h"})
+			return
+		}
+		grace = d
+	}
+	resp, err := a.rotateSecret(ctx, k, grace, time.Now().Truncate(time.Second))
+	if errors.Is(err, errSecretNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "credential not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	addLogAttrs(r, "credential", k.kind, "name", k.name)
+	a.reqLogger(r).Info("已轮换凭据密钥", "fingerprint", resp.Fingerprint, "grace", grace.String())
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// ensureSecret 在凭据没有任何有效密钥时生成一个并保存，用于无需与外部共享、由服务自行持有的密钥
+func (a *App) ensureSecret(ctx context.Context, k secretKey, now time.Time) error {
+	if len(a.secrets.valid(k.kind, k.name, now)) > 0 {
+		return nil
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return err
+	}
+	secret := hex.EncodeToString(raw)
+	fp := secretFingerprint([]byte(secret))
+	if _, err := a.db.ExecContext(ctx, `INSERT INTO secret_versions (kind, name, fingerprint, secret, created_at) VALUES (?, ?, ?, ?, ?)`,
+		k.kind, k.name, fp, secret, now.Format(time.RFC3339)); err != nil {
+		return err
+	}
+	s := a.secrets
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.versions[k] = append(s.versions[k], &secretVersion{secret: []byte(secret), Fingerprint: fp, Source: secretFromRotated, CreatedAt: &now})
+	return nil
+}
+
+// errSecretNotFound 表示要轮换的凭据没有任何密钥（未在配置中启用）
+var errSecretNotFound = errors.New("credential not found")
+
+// rotateSecret 生成新密钥，并将凭据现有的有效密钥的失效时间设为 now+grace（已更早失效的保持不变）
+func (a *App) rotateSecret(ctx context.Context, k secretKey, grace time.Duration, now time.Time) (secretRotateResponse, error) {
+	s := a.secrets
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	vs := s.versions[k]
+	if len(vs) == 0 {
+		return secretRotateResponse{}, errSecretNotFound
+	}
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return secretRotateResponse{}, err
+	}
+	secret := hex.EncodeToString(raw)
+	resp := secretRotateResponse{Kind: k.kind, Name: k.name, Secret: secret, Fingerprint: secretFingerprint([]byte(secret))}
+	until := now.Add(grace)
+
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return resp, err
+	}
+	defer tx.Rollback()
+	var retired []*secretVersion
+	for _, v := range vs {
+		if !v.validAt(now) || (v.ExpiresAt != nil && v.ExpiresAt.Before(until)) {
+			continue
+		}
+		stored := ""
+		if v.Source == secretFromRotated {
+			stored = string(v.secret)
+		}
+		created := now
+		if v.CreatedAt != nil {
+			created = *v.CreatedAt
+		}
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO secret_versions (kind, name, fingerprint, secret, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(kind, name, fingerprint) DO UPDATE SET expires_at = excluded.expires_at
+		`, k.kind, k.name, v.Fingerprint, stored, created.Format(time.RFC3339), until.Format(time.RFC3339)); err != nil {
+			return resp, err
+		}
+		retired = append(retired, v)
+	}
+	if _, err := tx.ExecContext(ctx, `INSERT INTO secret_versions (kind, name, fingerprint, secret, created_at) VALUES (?, ?, ?, ?, ?)`,
+		k.kind, k.name, resp.Fingerprint, secret, now.Format(time.RFC3339)); err != nil {
+		return resp, err
+	}
+	if err := tx.Commit(); err != nil {
+		return resp, err
+	}
+	for _, v := range retired {
+		v.ExpiresAt = &until
+	}
+	if len(retired) > 0 {
+		resp.PreviousUntil = &until
+	}
+	s.versions[k] = append(vs, &secretVersion{secret: []byte(secret), Fingerprint: resp.Fingerprint, Source: secretFromRotated, CreatedAt: &now})
+	return resp, nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 自检结果的状态
+const (
+	checkOK       = "ok"
+	checkDegraded = "degraded" // 部分功能不可用，服务仍可启动
+	checkFailed   = "failed"   // 无法正常提供服务，启动时直接退出
+)
+
+// checkResult 为单项启动自检的结果
+type checkResult struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// requiredTables 为迁移完成后必须存在的表
+var requiredTables = []string{
+	"tasks", "task_tags", "idempotency_keys", "column_policies", "task_acceptance_criteria",
+	"task_links", "task_refs", "task_events", "task_recurrences", "task_occurrences",
+	"task_reminders", "tag_events", "column_subscriptions", "task_versions", "task_approvals",
+	"task_share_links", "board_share_links", "task_incidents", "tags", "secret_versions", "views", "validation_rules", "task_comments", "status_transitions", "action_token_uses", "api_keys", "users", "user_identities", "user_sessions", "task_relations", "user_mentions", "task_watchers", "notifications", "push_subscriptions", "teams", "team_members", "boards",
+}
+
+// checkDataDir 在打开数据库之前确认数据目录存在且可写；使用完整 DSN 时跳过
+func checkDataDir(d DataConfig) checkResult {
+	res := checkResult{Name: "data_dir", Status: checkOK}
+	if d.DSN != "" {
+		res.Message = "使用 DSN，跳过目录检查"
+		return res
+	}
+	dir := filepath.Dir(d.dbPath())
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		res.Status, res.Message = checkFailed, fmt.Sprintf("无法创建数据目录 %s: %v；请检查 DATA_DIR/DB_PATH 与挂载卷权限", dir, err)
+		return res
+	}
+	f, err := os.CreateTemp(dir, ".write-check-*")
+	if err != nil {
+		res.Status, res.Message = checkFailed, fmt.Sprintf("数据目录 %s 不可写: %v；请确认运行用户对该目录有写权限", dir, err)
+		return res
+	}
+	f.Close()
+	os.Remove(f.Name())
+	return res
+}
+
+// selfCheck 在数据库初始化后执行启动自检：数据库可读写、迁移完整、静态资源可用，以及已配置时邮件服务器可达
+func (a *App) selfCheck(ctx context.Context) []checkResult {
+	results := []checkResult{
+		a.checkDatabase(ctx),
+		a.checkMigrations(ctx),
+		a.checkStaticAssets(),
+	}
+	if a.cfg.SMTP.enabled() {
+		results = append(results, a.checkSMTP(ctx))
+	}
+	return results
+}
+
+// checkDatabase 确认数据库可写，并核对日志模式是否按配置生效
+func (a *App) checkDatabase(ctx context.Context) checkResult {
+	res := checkResult{Name: "database", Status: checkOK}
+	if _, err := a.db.ExecContext(ctx, `CREATE TEMP TABLE IF NOT EXISTS selfcheck (x); DROP TABLE temp.selfcheck`); err != nil {
+		res.Status, res.Message = checkFailed, fmt.Sprintf("数据库不可用: %v", err)
+		return res
+	}
+	var quick string
+	if err := a.db.QueryRowContext(ctx, `PRAGMA quick_check(1)`).Scan(&quick); err != nil || quick != "ok" {
+		res.Status, res.Message = checkFailed, fmt.Sprintf("数据库完整性检查未通过: %s%v；请从备份恢复或使用 export/import 重建", quick, errOrEmpty(err))
+		return res
+	}
+	var mode string
+	if err := a.db.QueryRowContext(ctx, `PRAGMA journal_mode`).Scan(&mode); err == nil {
+		want := a.cfg.Data.JournalMode
+		if want != "" && !strings.EqualFold(mode, want) {
+			res.Status, res.Message = checkDegraded, fmt.Sprintf("journal_mode 为 %s（配置为 %s），可能是文件系统不支持；并发读写性能会下降", mode, want)
+		}
+	}
+	return res
+}
+
+// checkMigrations 确认迁移后所需的表均已存在
+func (a *App) checkMigrations(ctx context.Context) checkResult {
+	res := checkResult{Name: "migrations", Status: checkOK}
+	rows, err := a.db.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table'`)
+	if err != nil {
+		res.Status, res.Message = checkFailed, err.Error()
+		return res
+	}
+	defer rows.Close()
+	have := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			res.Status, res.Message = checkFailed, err.Error()
+			return res
+		}
+		have[name] = true
+	}
+	var missing []string
+	for _, t := range requiredTables {
+		if !have[t] {
+			missing = append(missing, t)
+		}
+	}
+	if len(missing) > 0 {
+		res.Status, res.Message = checkFailed, "缺少数据表 "+strings.Join(missing, ", ")+"；请运行 migrate 子命令"
+	}
+	return res
+}
+
+// checkStaticAssets 确认前端入口页面存在；缺失时仅 API 可用
+func (a *App) checkStaticAssets() checkResult {
+	res := checkResult{Name: "static_assets", Status: checkOK, Message: "使用内嵌资源"}
+	if a.staticDir != "" {
+		res.Message = a.staticDir
+	}
+	f, err := a.staticFS().Open("/index.html")
+	if err != nil {
+		res.Status, res.Message = checkDegraded, fmt.Sprintf("找不到 index.html（%s），网页界面不可用；请检查 static_dir 或留空以使用内嵌资源", res.Message)
+		return res
+	}
+	f.Close()
+	return res
+}
+
+// errOrEmpty 将错误格式化为附加说明
+func errOrEmpty(err error) string {
+	if err == nil {
+		return ""
+	}
+	return " " + err.Error()
+}
+
+// logCheckResults 输出自检结果，返回全部失败项组成的错误
+func (a *App) logCheckResults(results []checkResult) error {
+	var failed []string
+	for _, c := range results {
+		switch c.Status {
+		case checkOK:
+			a.logger.Debug("启动自检", "check", c.Name, "status", c.Status, "detail", c.Message)
+		case checkDegraded:
+			a.logger.Warn("启动自检：功能降级", "check", c.Name, "detail", c.Message)
+		default:
+			a.logger.Error("启动自检失败", "check", c.Name, "detail", c.Message)
+			failed = append(failed, c.Name+": "+c.Message)
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("启动自检失败:\n  %s", strings.Join(failed, "\n  "))
+	}
+	return nil
+}
+
+// handleReadyz 返回就绪状态：实时确认数据库可用，并附带启动自检中的降级项；数据库不可用时返回 503
+func (a *App) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+	status, code := "ready", http.StatusOK
+	checks := append([]checkResult(nil), a.checks...)
+	if c, ok := a.loadCheck(time.Now()); ok {
+		checks = append(checks, c)
+	}
+	if err := a.db.PingContext(ctx); err != nil {
+		status, code = "unavailable", http.StatusServiceUnavailable
+		checks = append(checks, checkResult{Name: "database_ping", Status: checkFailed, Message: err.Error()})
+	} else {
+		for _, c := range checks {
+			if c.Status != checkOK {
+				status = "degraded"
+			}
+		}
+	}
+	writeJSON(w, code, map[string]any{"status": status, "checks": checks})
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"html/template"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 分享链接让看板之外的人跟踪单个任务：链接中带有随机令牌，持有链接即可查看任务的标题、状态与最后更新时间，
+// 无法看到描述、标签或其他任务。每个任务至多一个链接，删除后旧链接立即失效，重新生成即更换令牌。
+
+// shareTokenBytes 为分享令牌的随机字节数
+const shareTokenBytes = 24
+
+// TaskShareLink 为任务的分享链接
+type TaskShareLink struct {
+	TaskID    int64     `json:"task_id"`
+	Token     string    `json:"token"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// sharedTaskView 为分享页展示的内容
+type sharedTaskView struct {
+	ID        int64
+	Title     string
+	Status    string
+	Archived  bool
+	UpdatedAt time.Time
+}
+
+// newShareToken 生成随机分享令牌
+func newShareToken() string {
+	b := make([]byte, shareTokenBytes)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestOrigin 返回当前请求的协议与主机，如 https://board.example.com
+func requestOrigin(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// shareURL 根据当前请求拼出分享页的完整地址
+func shareURL(r *http.Request, token string) string {
+	return requestOrigin(r) + "/share/" + token
+}
+
+// handleTaskShare 处理 /api/tasks/{id}/share：GET 查看当前链接，POST 生成（已有链接时更换令牌），DELETE 撤销
+func (a *App) handleTaskShare(w http.ResponseWriter, r *http.Request, taskID int64) {
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodGet:
+		var link TaskShareLink
+		var created string
+		err := a.db.QueryRowContext(ctx, `SELECT token, created_at FROM task_share_links WHERE task_id = ?`, taskID).Scan(&link.Token, &created)
+		if err == sql.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "share link not found"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		link.TaskID, link.URL = taskID, shareURL(r, link.Token)
+		link.CreatedAt, _ = time.Parse(time.RFC3339, created)
+		writeJSON(w, http.StatusOK, link)
+	case http.MethodPost:
+		ok, err := a.taskExists(ctx, taskID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if !ok {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+			return
+		}
+		now := time.Now()
+		link := TaskShareLink{TaskID: taskID, Token: newShareToken(), CreatedAt: now}
+		if _, err := a.db.ExecContext(ctx, `
+			INSERT INTO task_share_links (task_id, token, created_at) VALUES (?, ?, ?)
+			ON CONFLICT(task_id) DO UPDATE SET token = excluded.token, created_at = excluded.created_at
+		`, taskID, link.Token, now.Format(time.RFC3339)); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		link.URL = shareURL(r, link.Token)
+		a.reqLogger(r).Info("已生成任务分享链接")
+		writeJSON(w, http.StatusCreated, link)
+	case http.MethodDelete:
+		res, err := a.db.ExecContext(ctx, `DELETE FROM task_share_links WHERE task_id = ?`, taskID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "share link not found"})
+			return
+		}
+		a.reqLogger(r).Info("已撤销任务分享链接")
+		writeJSON(w, http.StatusOK, map[string]any{"task_id": taskID, "deleted": true})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// handleSharePage 处理 GET /share/{token}，渲染只读的任务状态页；令牌无效时返回 404，不区分是否曾经存在
+func (a *App) handleSharePage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	token := strings.TrimPrefix(r.URL.Path, "/share/")
+	// 链接本身即凭据：禁止缓存、收录与通过 Referer 外泄
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Referrer-Policy", "no-referrer")
+	w.Header().Set("X-Robots-Tag", "noindex, nofollow")
+	var v sharedTaskView
+	var archived int
+	var updated string
+	err := a.db.QueryRowContext(ctx, `
+		SELECT t.id, t.title, t.status, t.archived, t.updated_at
+		FROM task_share_links s JOIN tasks t ON t.id = s.task_id
+		WHERE s.token = ?
+	`, token).Scan(&v.ID, &v.Title, &v.Status, &archived, &updated)
+	if err == sql.ErrNoRows {
+		http.Error(w, "链接无效或已失效", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		a.reqLogger(r).Error("读取分享任务失败", "err", err)
+		http.Error(w, "internal error", http.StatusInternalServerError)
+		return
+	}
+	v.Archived = archived != 0
+	v.UpdatedAt, _ = time.Parse(time.RFC3339, updated)
+	addLogAttrs(r, "task_id", v.ID)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := sharePageTmpl.Execute(w, v); err != nil {
+		a.reqLogger(r).Warn("渲染分享页失败", "err", err)
+	}
+}
+
+var sharePageTmpl = template.Must(template.New("share").Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex, nofollow">
+<title>TB-{{.ID}} {{.Title}}</title>
+<style>
+body{font-family:system-ui,-apple-system,"PingFang SC","Microsoft YaHei",sans-serif;margin:0;background:#f5f6f8;color:#222}
+main{max-width:560px;margin:48px auto;padding:24px;background:#fff;border-radius:8px;box-shadow:0 1px 2px rgba(0,0,0,.06)}
+h1{font-size:20px;margin:0 0 16px}
+.status{display:inline-block;background:#e0e7ff;color:#3730a3;border-radius:4px;padding:2px 10px;font-size:14px}
+.meta{color:#888;font-size:13px;margin-top:16px}
+</style>
+</head>
+<body>
+<main>
+<h1>TB-{{.ID}} {{.Title}}</h1>
+<span class="status">{{.Status}}{{if .Archived}}（已归档）{{end}}</span>
+<div class="meta">最后更新 {{.UpdatedAt.Local.Format "2006-01-02 15:04"}}</div>
+</main>
+</body>
+</html>
+`))
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// 签名请求使用的请求头
+const (
+	signKeyHeader       = "X-Taskboard-Key"
+	signTimestampHeader = "X-Taskboard-Timestamp"
+	signNonceHeader     = "X-Taskboard-Nonce"
+	signatureHeader     = "X-Taskboard-Signature"
+)
+
+// maxSignedBodyBytes 为签名请求体的大小上限（签名校验需完整读入请求体）
+const maxSignedBodyBytes = 8 << 20
+
+// SigningConfig 为机器客户端的 HMAC 请求签名配置。签名内容为
+// "时间戳\n方法\n路径?查询\nnonce\nhex(sha256(请求体))"，以客户端密钥做 HMAC-SHA256 后
+// 以 "sha256=<hex>" 形式放入 X-Taskboard-Signature；RequiredPaths 前缀下的请求必须签名
+type SigningConfig struct {
+	Clients       []SigningClient `yaml:"clients"`
+	MaxSkew       Duration        `yaml:"max_skew"`
+	RequiredPaths []string        `yaml:"required_paths"`
+}
+
+// SigningClient 为一个机器客户端的密钥
+type SigningClient struct {
+	ID     string `yaml:"id"`
+	Secret string `yaml:"secret"`
+}
+
+// requestVerifier 校验签名请求，并在时间窗口内记住已使用的签名以防重放；
+// 客户端密钥保存在密钥环中，轮换宽限期内新旧密钥签名的请求都能通过
+type requestVerifier struct {
+	keys     *secretRing
+	maxSkew  time.Duration
+	required []string
+
+	mu   sync.Mutex
+	seen map[string]time.Time // 签名 → 过期时间
+}
+
+// newRequestVerifier 根据配置创建签名校验器，并将客户端密钥加入密钥环
+func newRequestVerifier(c SigningConfig, keys *secretRing) (*requestVerifier, error) {
+	v := &requestVerifier{
+		keys:     keys,
+		maxSkew:  c.MaxSkew.Std(),
+		required: c.RequiredPaths,
+		seen:     map[string]time.Time{},
+	}
+	if v.maxSkew <= 0 {
+		v.maxSkew = 5 * time.Minute
+	}
+	seen := map[string]bool{}
+	for _, cl := range c.Clients {
+		id := strings.TrimSpace(cl.ID)
+		if id == "" || cl.Secret == "" {
+			return nil, fmt.Errorf("signing.clients: id 与 secret 不能为空")
+		}
+		if seen[id] {
+			return nil, fmt.Errorf("signing.clients: 重复的客户端 %q", id)
+		}
+		seen[id] = true
+		keys.seed(secretSigning, id, cl.Secret)
+	}
+	if len(v.required) > 0 && len(seen) == 0 {
+		return nil, fmt.Errorf("signing.required_paths 已配置但没有任何客户端密钥")
+	}
+	return v, nil
+}
+
+// isRequired 判断请求路径是否必须签名
+func (v *requestVerifier) isRequired(path string) bool {
+	for _, prefix := range v.required {
+		if prefix != "" && strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// signRequest 计算签名，客户端与服务端共用
+func signRequest(secret []byte, timestamp, method, target, nonce string, body []byte) string {
+	sum := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(timestamp + "\n" + method + "\n" + target + "\n" + nonce + "\n" + hex.EncodeToString(sum[:])))
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify 校验签名请求并恢复请求体，返回客户端 ID；失败时返回面向调用方的错误信息
+func (v *requestVerifier) verify(r *http.Request, now time.Time) (string, string) {
+	keyID := r.Header.Get(signKeyHeader)
+	secrets := v.keys.valid(secretSigning, keyID, now)
+	if len(secrets) == 0 {
+		return "", "unknown signing key"
+	}
+	ts, err := strconv.ParseInt(r.Header.Get(signTimestampHeader), 10, 64)
+	if err != nil {
+		return "", "invalid signature timestamp"
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > v.maxSkew || skew < -v.maxSkew {
+		return "", "signature expired"
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxSignedBodyBytes+1))
+	if err != nil || len(body) > maxSignedBodyBytes {
+		return "", "invalid body"
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	got := r.Header.Get(signatureHeader)
+	want := ""
+	for _, secret := range secrets {
+		sig := signRequest(secret, strconv.FormatInt(ts, 10), r.Method, r.URL.RequestURI(), r.Header.Get(signNonceHeader), body)
+		if hmac.Equal([]byte(got), []byte(sig)) {
+			want = sig
+			break
+		}
+	}
+	if want == "" {
+		return "", "invalid signature"
+	}
+	if !v.remember(want, now) {
+		return "", "signature already used"
+	}
+	return keyID, ""
+}
+
+// remember 记录签名直至其超出时间窗口；签名已出现过时返回 false
+func (v *requestVerifier) remember(sig string, now time.Time) bool {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for s, exp := range v.seen {
+		if now.After(exp) {
+			delete(v.seen, s)
+		}
+	}
+	if _, dup := v.seen[sig]; dup {
+		return false
+	}
+	// 时间戳可早于或晚于当前时间 maxSkew，签名在两倍窗口内都需记住
+	v.seen[sig] = now.Add(2 * v.maxSkew)
+	return true
+}
+
+// withSignedRequests 校验带签名头的请求；RequiredPaths 下缺少签名或任一签名无效时返回 401。
+// 是否存在签名密钥按请求判断：没有任何密钥时不校验签名头，但 RequiredPaths 下的请求一律返回 401，不会放行
+func (a *App) withSignedRequests(v *requestVerifier, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(signatureHeader) == "" || !v.keys.has(secretSigning) {
+			if v.isRequired(r.URL.Path) {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "signature required"})
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		client, msg := v.verify(r, time.Now())
+		if msg != "" {
+			addLogAttrs(r, "signing_key", r.Header.Get(signKeyHeader), "signature", msg)
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": msg})
+			return
+		}
+		addLogAttrs(r, "client", client)
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), signedClientKey{}, client)))
+	})
+}
+
+// signedClientKey 为请求上下文中保存已验证客户端 ID 的键
+type signedClientKey struct{}
+
+// signedClient 返回请求签名对应的客户端 ID；未签名的请求返回空串
+func signedClient(r *http.Request) string {
+	client, _ := r.Context().Value(signedClientKey{}).(string)
+	return client
+}
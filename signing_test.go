@@ -0,0 +1,41 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestSignedRequestsRequiredPathWithEmptyRing(t *testing.T) {
+	app := newTestApp(t, nil)
+	v := &requestVerifier{
+		keys:     newSecretRing(),
+		maxSkew:  5 * time.Minute,
+		required: []string{"/api/tasks"},
+		seen:     map[string]time.Time{},
+	}
+	handler := app.withSignedRequests(v, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+
+	for _, tc := range []struct {
+		path      string
+		signature string
+		want      int
+	}{
+		{"/api/tasks", "", http.StatusUnauthorized},
+		{"/api/tasks", "bogus", http.StatusUnauthorized},
+		{"/api/tags", "", http.StatusNoContent},
+	} {
+		req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+		if tc.signature != "" {
+			req.Header.Set(signatureHeader, tc.signature)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		if rec.Code != tc.want {
+			t.Errorf("GET %s (signature %q) = %d, want %d", tc.path, tc.signature, rec.Code, tc.want)
+		}
+	}
+}
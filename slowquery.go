@@ -0,0 +1,264 @@
+package main
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mattn/go-sqlite3"
+)
+
+// 慢查询记录：数据库连接由 timedConnector 包装，每条语句（查询计时到结果集关闭为止）超过 data.slow_query
+// 时记录一条 Warn 日志并计入按操作汇总的统计。操作名取发起查询的函数（如 (*App).queryTagStats），
+// 仅在超过阈值时才解析调用栈；参数只记录类型与长度，避免令牌等敏感值写入日志。
+
+// slowQuerySQLLen 为日志中保留的 SQL 最大长度
+const slowQuerySQLLen = 300
+
+// queryStats 为数据库操作统计：全部语句数、慢语句数及按操作名汇总的慢语句
+type queryStats struct {
+	threshold time.Duration
+	logger    *slog.Logger
+	total     atomic.Int64
+	slow      atomic.Int64
+	// interactiveNS 为请求内语句耗时的指数滑动平均（纳秒），interactiveAt 为最近一次请求内语句的 Unix 纳秒时间；
+	// 后台任务据此判断数据库是否繁忙，不计入后台任务自身的语句
+	interactiveNS atomic.Int64
+	interactiveAt atomic.Int64
+
+	mu  sync.Mutex
+	ops map[string]*slowOpStat
+}
+
+// slowOpStat 为单个操作的慢语句汇总
+type slowOpStat struct {
+	Op        string    `json:"op"`
+	Count     int64     `json:"count"`
+	TotalMS   int64     `json:"total_ms"`
+	MaxMS     int64     `json:"max_ms"`
+	LastAt    time.Time `json:"last_at"`
+	LastQuery string    `json:"last_query"`
+}
+
+// queryStatsResponse 为 GET /api/admin/db/stats 的响应结构，Operations 按慢语句数从多到少排列
+type queryStatsResponse struct {
+	ThresholdMS int64        `json:"threshold_ms"`
+	Queries     int64        `json:"queries"`
+	SlowQueries int64        `json:"slow_queries"`
+	Operations  []slowOpStat `json:"operations"`
+}
+
+// observe 记录一条语句的耗时；超过阈值时写日志并计入慢语句统计
+func (s *queryStats) observe(ctx context.Context, query string, args []driver.NamedValue, d time.Duration) {
+	s.total.Add(1)
+	if _, ok := ctx.Value(requestLogKey{}).(*requestLog); ok {
+		s.observeInteractive(d)
+	}
+	if s.threshold <= 0 || d < s.threshold {
+		return
+	}
+	s.slow.Add(1)
+	op := queryOperation()
+	query = compactSQL(query)
+	s.mu.Lock()
+	st := s.ops[op]
+	if st == nil {
+		st = &slowOpStat{Op: op}
+		s.ops[op] = st
+	}
+	st.Count++
+	st.TotalMS += d.Milliseconds()
+	st.MaxMS = max(st.MaxMS, d.Milliseconds())
+	st.LastAt, st.LastQuery = time.Now(), query
+	s.mu.Unlock()
+	logger := s.logger
+	if rl, ok := ctx.Value(requestLogKey{}).(*requestLog); ok {
+		logger = rl.l
+	}
+	logger.Warn("慢查询", "op", op, "duration_ms", d.Milliseconds(), "sql", query, "args", summarizeArgs(args))
+}
+
+// observeInteractive 将请求内语句的耗时计入滑动平均（权重 1/8）
+func (s *queryStats) observeInteractive(d time.Duration) {
+	s.interactiveAt.Store(time.Now().UnixNano())
+	for {
+		old := s.interactiveNS.Load()
+		next := int64(d)
+		if old > 0 {
+			next = old + (int64(d)-old)/8
+		}
+		if s.interactiveNS.CompareAndSwap(old, next) {
+			return
+		}
+	}
+}
+
+// interactiveLatency 返回请求内语句的近期平均耗时；within 内没有请求访问数据库时返回 0
+func (s *queryStats) interactiveLatency(now time.Time, within time.Duration) time.Duration {
+	if now.Sub(time.Unix(0, s.interactiveAt.Load())) > within {
+		return 0
+	}
+	return time.Duration(s.interactiveNS.Load())
+}
+
+// snapshot 返回当前统计
+func (s *queryStats) snapshot() queryStatsResponse {
+	resp := queryStatsResponse{
+		ThresholdMS: s.threshold.Milliseconds(),
+		Queries:     s.total.Load(),
+		SlowQueries: s.slow.Load(),
+		Operations:  []slowOpStat{},
+	}
+	s.mu.Lock()
+	for _, st := range s.ops {
+		resp.Operations = append(resp.Operations, *st)
+	}
+	s.mu.Unlock()
+	sort.Slice(resp.Operations, func(i, j int) bool {
+		if resp.Operations[i].Count != resp.Operations[j].Count {
+			return resp.Operations[i].Count > resp.Operations[j].Count
+		}
+		return resp.Operations[i].Op < resp.Operations[j].Op
+	})
+	return resp
+}
+
+// queryOperation 返回发起当前语句的应用函数名：跳过 database/sql、sync 与本文件的包装层
+func queryOperation() string {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := frames.Next()
+		name := f.Function
+		internal := strings.HasPrefix(name, "database/sql") || strings.HasPrefix(name, "sync.") ||
+			strings.HasPrefix(name, "main.(*timed") || strings.HasPrefix(name, "main.(*queryStats)")
+		if !internal {
+			return strings.TrimPrefix(name, "main.")
+		}
+		if !more {
+			return "unknown"
+		}
+	}
+}
+
+// compactSQL 合并 SQL 中的空白并截断过长的语句
+func compactSQL(q string) string {
+	q = strings.Join(strings.Fields(q), " ")
+	if r := []rune(q); len(r) > slowQuerySQLLen {
+		q = string(r[:slowQuerySQLLen]) + "…"
+	}
+	return q
+}
+
+// summarizeArgs 以类型与长度概括语句参数，如 "int64, string(24), nil"
+func summarizeArgs(args []driver.NamedValue) string {
+	parts := make([]string, len(args))
+	for i, a := range args {
+		switch v := a.Value.(type) {
+		case nil:
+			parts[i] = "nil"
+		case string:
+			parts[i] = fmt.Sprintf("string(%d)", len(v))
+		case []byte:
+			parts[i] = fmt.Sprintf("bytes(%d)", len(v))
+		default:
+			parts[i] = fmt.Sprintf("%T", v)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// timedConnector 使用 sqlite3 驱动建立连接，并包装连接以记录语句耗时
+type timedConnector struct {
+	dsn   string
+	drv   *sqlite3.SQLiteDriver
+	stats *queryStats
+}
+
+// Connect 打开一个新连接
+func (c *timedConnector) Connect(context.Context) (driver.Conn, error) {
+	conn, err := c.drv.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &timedConn{Conn: conn, stats: c.stats}, nil
+}
+
+// Driver 返回底层驱动
+func (c *timedConnector) Driver() driver.Driver { return c.drv }
+
+// timedConn 包装驱动连接，为直接执行的语句计时；事务中的语句同样经过这里
+type timedConn struct {
+	driver.Conn
+	stats *queryStats
+}
+
+// ExecContext 执行语句并计时
+func (c *timedConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	start := time.Now()
+	res, err := c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+	if err != driver.ErrSkip {
+		c.stats.observe(ctx, query, args, time.Since(start))
+	}
+	return res, err
+}
+
+// QueryContext 执行查询；sqlite3 在遍历结果时才逐行求值，因此计时到结果集关闭为止
+func (c *timedConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	start := time.Now()
+	rows, err := c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+	if err != nil {
+		if err != driver.ErrSkip {
+			c.stats.observe(ctx, query, args, time.Since(start))
+		}
+		return nil, err
+	}
+	return &timedRows{Rows: rows, done: func() { c.stats.observe(ctx, query, args, time.Since(start)) }}, nil
+}
+
+// PrepareContext 预编译语句（不计时）
+func (c *timedConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.Conn.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+}
+
+// BeginTx 开始事务
+func (c *timedConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
+
+// Ping 检查连接可用性
+func (c *timedConn) Ping(ctx context.Context) error {
+	return c.Conn.(driver.Pinger).Ping(ctx)
+}
+
+// timedRows 在结果集关闭时记录查询耗时
+type timedRows struct {
+	driver.Rows
+	once sync.Once
+	done func()
+}
+
+// Close 关闭结果集并记录耗时
+func (r *timedRows) Close() error {
+	err := r.Rows.Close()
+	r.once.Do(r.done)
+	return err
+}
+
+// handleDBStats 处理 GET /api/admin/db/stats，返回语句总数与按操作汇总的慢查询
+func (a *App) handleDBStats(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	writeJSON(w, http.StatusOK, a.queryStats.snapshot())
+}
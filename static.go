@@ -0,0 +1,127 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// webAssets 为编译进二进制的前端静态资源
+//
+//go:embed web
+var webAssets embed.FS
+
+// staticFS 返回前端资源文件系统：配置了 static_dir 时直接读取磁盘（便于开发时修改即生效），否则使用内嵌资源
+func (a *App) staticFS() http.FileSystem {
+	if a.staticDir != "" {
+		return http.Dir(a.staticDir)
+	}
+	sub, err := fs.Sub(webAssets, "web")
+	if err != nil {
+		panic(err) // web 目录在编译期已确认存在
+	}
+	return http.FS(sub)
+}
+
+// 界面语言：默认语言的资源位于 web/ 根目录，其他语言的资源位于 web/locales/<语言>/，
+// 只需放置与默认语言不同的文件，缺失的文件回退到根目录
+const (
+	defaultLocale   = "zh-CN"
+	localeCookie    = "lang"
+	localeAssetsDir = "/locales/"
+)
+
+// supportedLocales 为提供界面资源的语言
+var supportedLocales = []string{"zh-CN", "en"}
+
+// matchLocale 将语言标签匹配到支持的语言：先完全匹配，再按主语言匹配（如 en-US → en、zh-TW → zh-CN）
+func matchLocale(tag string) (string, bool) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return "", false
+	}
+	primary, _, _ := strings.Cut(tag, "-")
+	for _, l := range supportedLocales {
+		if strings.EqualFold(l, tag) {
+			return l, true
+		}
+	}
+	for _, l := range supportedLocales {
+		p, _, _ := strings.Cut(l, "-")
+		if strings.EqualFold(p, primary) {
+			return l, true
+		}
+	}
+	return "", false
+}
+
+// negotiateLocale 解析 Accept-Language，按 q 值从高到低返回首个支持的语言
+func negotiateLocale(header string) string {
+	best, bestQ := defaultLocale, -1.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if l, ok := matchLocale(tag); ok && q > 0 && q > bestQ {
+			best, bestQ = l, q
+		}
+	}
+	return best
+}
+
+// requestLocale 依次按 ?lang= 参数、语言偏好 Cookie 与 Accept-Language 选择界面语言；
+// 通过参数显式选择时写入 Cookie，之后的访问沿用该选择
+func requestLocale(w http.ResponseWriter, r *http.Request) string {
+	if l, ok := matchLocale(r.URL.Query().Get("lang")); ok {
+		http.SetCookie(w, &http.Cookie{Name: localeCookie, Value: l, Path: "/", MaxAge: 365 * 24 * 3600, SameSite: http.SameSiteLaxMode})
+		return l
+	}
+	if c, err := r.Cookie(localeCookie); err == nil {
+		if l, ok := matchLocale(c.Value); ok {
+			return l
+		}
+	}
+	return negotiateLocale(r.Header.Get("Accept-Language"))
+}
+
+// localizedFS 优先从语言目录读取文件，不存在时回退到默认资源；目录始终使用默认资源
+type localizedFS struct {
+	base   http.FileSystem
+	locale string
+}
+
+// Open 实现 http.FileSystem
+func (l localizedFS) Open(name string) (http.File, error) {
+	if l.locale != defaultLocale {
+		if f, err := l.base.Open(localeAssetsDir + l.locale + name); err == nil {
+			if st, err := f.Stat(); err == nil && !st.IsDir() {
+				return f, nil
+			}
+			f.Close()
+		}
+	}
+	return l.base.Open(name)
+}
+
+// localizedFileServer 按请求协商的语言提供前端资源，并声明响应随语言变化以免缓存串用
+func (a *App) localizedFileServer() http.Handler {
+	base := a.staticFS()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, localeAssetsDir) {
+			http.NotFound(w, r)
+			return
+		}
+		locale := requestLocale(w, r)
+		w.Header().Add("Vary", "Accept-Language, Cookie")
+		w.Header().Set("Content-Language", locale)
+		http.FileServer(localizedFS{base: base, locale: locale}).ServeHTTP(w, r)
+	})
+}
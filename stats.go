@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxStatsWindowDays 为统计时间窗口的最大天数
+const maxStatsWindowDays = 365
+
+// statusCount 为某状态的任务数量
+type statusCount struct {
+	Status string `json:"status"`
+	Count  int    `json:"count"`
+}
+
+// tagCount 为某标签的任务数量
+type tagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// dailyCount 为某天新建与完成的任务数量
+type dailyCount struct {
+	Date      string `json:"date"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+// archiveStats 为归档任务的汇总
+type archiveStats struct {
+	Total    int           `json:"total"`
+	ByStatus []statusCount `json:"by_status"`
+}
+
+// statsResponse 为看板统计的响应结构
+type statsResponse struct {
+	WindowDays  int           `json:"window_days"`
+	ActiveTotal int           `json:"active_total"`
+	ByStatus    []statusCount `json:"by_status"`
+	ByTag       []tagCount    `json:"by_tag"`
+	Daily       []dailyCount  `json:"daily"`
+	Archived    archiveStats  `json:"archived"`
+	// WIPViolations 为在板任务数超过 WIP 限制的状态列
+	WIPViolations []wipViolation `json:"wip_violations"`
+}
+
+// handleStats 返回看板统计：各状态与标签的在板任务数、窗口内每日新建/完成数、归档汇总及超出 WIP 限制的列。
+// 完成时间取进入"已完成"列的时间（status_changed_at），含已归档任务。
+func (a *App) handleStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	days := 30
+	if v := strings.TrimSpace(r.URL.Query().Get("days")); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > maxStatsWindowDays {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "days must be between 1 and 365"})
+			return
+		}
+		days = n
+	}
+	now := time.Now()
+	if etag, err := a.tasksETag(ctx, "stats|"+strconv.Itoa(days)+"|"+now.Format("2006-01-02")); err == nil && checkNotModified(w, r, etag, "no-cache") {
+		return
+	}
+	resp, err := a.computeStats(ctx, days, now)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// computeStats 汇总统计数据；状态按看板列顺序排列，标签按数量降序
+func (a *App) computeStats(ctx context.Context, days int, now time.Time) (statsResponse, error) {
+	resp := statsResponse{WindowDays: days, ByTag: []tagCount{}}
+	active := map[string]int{}
+	archived := map[string]int{}
+	rows, err := a.db.QueryContext(ctx, `SELECT status, archived, COUNT(*) FROM tasks GROUP BY status, archived`)
+	if err != nil {
+		return resp, err
+	}
+	for rows.Next() {
+		var status string
+		var arch, n int
+		if err := rows.Scan(&status, &arch, &n); err != nil {
+			rows.Close()
+			return resp, err
+		}
+		if arch != 0 {
+			archived[status] += n
+			resp.Archived.Total += n
+		} else {
+			active[status] += n
+			resp.ActiveTotal += n
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return resp, err
+	}
+	for _, s := range taskStatuses {
+		resp.ByStatus = append(resp.ByStatus, statusCount{Status: s, Count: active[s]})
+		resp.Archived.ByStatus = append(resp.Archived.ByStatus, statusCount{Status: s, Count: archived[s]})
+	}
+	cols, err := a.fetchColumns(ctx)
+	if err != nil {
+		return resp, err
+	}
+	resp.WIPViolations = wipViolations(cols, active)
+
+	rows, err = a.db.QueryContext(ctx, `
+		SELECT tt.tag, COUNT(DISTINCT tt.task_id) AS n
+		FROM task_tags tt JOIN tasks t ON t.id = tt.task_id
+		WHERE t.archived = 0
+		GROUP BY tt.tag
+		ORDER BY n DESC, tt.tag
+	`)
+	if err != nil {
+		return resp, err
+	}
+	for rows.Next() {
+		var tc tagCount
+		if err := rows.Scan(&tc.Tag, &tc.Count); err != nil {
+			rows.Close()
+			return resp, err
+		}
+		resp.ByTag = append(resp.ByTag, tc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return resp, err
+	}
+
+	// 按本地日期分桶：从 days-1 天前到今天
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	start := today.AddDate(0, 0, -(days - 1))
+	index := map[string]int{}
+	for i := 0; i < days; i++ {
+		d := start.AddDate(0, 0, i).Format("2006-01-02")
+		index[d] = i
+		resp.Daily = append(resp.Daily, dailyCount{Date: d})
+	}
+	rows, err = a.db.QueryContext(ctx, `SELECT created_at, status, status_changed_at FROM tasks`)
+	if err != nil {
+		return resp, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var created, status string
+		var changed sql.NullString
+		if err := rows.Scan(&created, &status, &changed); err != nil {
+			return resp, err
+		}
+		if t, err := time.Parse(time.RFC3339, created); err == nil {
+			if i, ok := index[t.In(now.Location()).Format("2006-01-02")]; ok {
+				resp.Daily[i].Created++
+			}
+		}
+		if status == "已完成" && changed.Valid {
+			if t, err := time.Parse(time.RFC3339, changed.String); err == nil {
+				if i, ok := index[t.In(now.Location()).Format("2006-01-02")]; ok {
+					resp.Daily[i].Completed++
+				}
+			}
+		}
+	}
+	return resp, rows.Err()
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// 结构化描述字段的数量与长度上限
+const (
+	maxAcceptanceCriteria = 50
+	maxExternalLinks      = 50
+	maxStructuredTextLen  = 500
+)
+
+// TaskLink 表示任务关联的外部链接
+type TaskLink struct {
+	Title string `json:"title"`
+	URL   string `json:"url"`
+}
+
+// normalizeAcceptanceCriteria 去除空白项并校验验收标准
+func normalizeAcceptanceCriteria(items []string) ([]string, error) {
+	out := []string{}
+	for _, item := range items {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		if len([]rune(item)) > maxStructuredTextLen {
+			return nil, fmt.Errorf("acceptance criterion too long")
+		}
+		out = append(out, item)
+	}
+	if len(out) > maxAcceptanceCriteria {
+		return nil, fmt.Errorf("too many acceptance criteria")
+	}
+	return out, nil
+}
+
+// normalizeExternalLinks 校验外部链接：标题必填，URL 需为 http(s) 绝对地址
+func normalizeExternalLinks(links []TaskLink) ([]TaskLink, error) {
+	out := []TaskLink{}
+	for _, l := range links {
+		l.Title = strings.TrimSpace(l.Title)
+		l.URL = strings.TrimSpace(l.URL)
+		if l.Title == "" {
+			return nil, fmt.Errorf("link title required")
+		}
+		if len([]rune(l.Title)) > maxStructuredTextLen {
+			return nil, fmt.Errorf("link title too long")
+		}
+		u, err := url.Parse(l.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid link url")
+		}
+		out = append(out, l)
+	}
+	if len(out) > maxExternalLinks {
+		return nil, fmt.Errorf("too many external links")
+	}
+	return out, nil
+}
+
+// fetchAcceptanceCriteria 按顺序查询任务的验收标准
+func (a *App) fetchAcceptanceCriteria(ctx context.Context, taskID int64) ([]string, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT text FROM task_acceptance_criteria WHERE task_id = ? ORDER BY position`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []string{}
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			return nil, err
+		}
+		items = append(items, text)
+	}
+	return items, rows.Err()
+}
+
+// fetchExternalLinks 按顺序查询任务的外部链接
+func (a *App) fetchExternalLinks(ctx context.Context, taskID int64) ([]TaskLink, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT title, url FROM task_links WHERE task_id = ? ORDER BY position`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	links := []TaskLink{}
+	for rows.Next() {
+		var l TaskLink
+		if err := rows.Scan(&l.Title, &l.URL); err != nil {
+			return nil, err
+		}
+		links = append(links, l)
+	}
+	return links, rows.Err()
+}
+
+// replaceAcceptanceCriteria 将任务的验收标准替换为给定列表（先清空后插入）
+func (a *App) replaceAcceptanceCriteria(ctx context.Context, taskID int64, items []string) error {
+	if _, err := a.db.ExecContext(ctx, `DELETE FROM task_acceptance_criteria WHERE task_id = ?`, taskID); err != nil {
+		return err
+	}
+	for i, item := range items {
+		if _, err := a.db.ExecContext(ctx, `INSERT INTO task_acceptance_criteria (task_id, position, text) VALUES (?, ?, ?)`, taskID, i, item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// replaceExternalLinks 将任务的外部链接替换为给定列表（先清空后插入）
+func (a *App) replaceExternalLinks(ctx context.Context, taskID int64, links []TaskLink) error {
+	if _, err := a.db.ExecContext(ctx, `DELETE FROM task_links WHERE task_id = ?`, taskID); err != nil {
+		return err
+	}
+	for i, l := range links {
+		if _, err := a.db.ExecContext(ctx, `INSERT INTO task_links (task_id, position, title, url) VALUES (?, ?, ?, ?)`, taskID, i, l.Title, l.URL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadTaskRelations 补全任务的标签与结构化描述字段
+func (a *App) loadTaskRelations(ctx context.Context, t *Task) {
+	t.Tags, _ = a.fetchTags(ctx, t.ID)
+	t.AcceptanceCriteria, _ = a.fetchAcceptanceCriteria(ctx, t.ID)
+	t.ExternalLinks, _ = a.fetchExternalLinks(ctx, t.ID)
+	t.RecurrenceOf, _ = a.fetchRecurrenceOf(ctx, t.ID)
+	t.Incident, _ = a.fetchIncident(ctx, t.ID)
+}
@@ -0,0 +1,293 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	htmltemplate "html/template"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"strings"
+	texttemplate "text/template"
+	"time"
+)
+
+// 列订阅的通知渠道
+const (
+	channelWebhook  = "webhook"  // target 为接收 POST 的 URL
+	channelEmail    = "email"    // target 为收件地址，需配置 smtp
+	channelTelegram = "telegram" // 发送到 telegram.chat_id，需配置 telegram
+)
+
+// subscriptionPollInterval 为检查任务进入被订阅列的间隔
+const subscriptionPollInterval = 5 * time.Second
+
+// ColumnSubscription 为对某个状态列的订阅：任务进入该列（新建、移入或从归档恢复）时通过指定渠道通知
+type ColumnSubscription struct {
+	ID        int64     `json:"id"`
+	Status    string    `json:"status"`
+	Channel   string    `json:"channel"`
+	Target    string    `json:"target,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// columnSubscriptionRequest 为创建列订阅的请求体
+type columnSubscriptionRequest struct {
+	Channel string `json:"channel"`
+	Target  string `json:"target"`
+}
+
+// columnSubscriptionListResponse 为列订阅列表的响应结构
+type columnSubscriptionListResponse struct {
+	Items []ColumnSubscription `json:"items"`
+}
+
+// columnEventPayload 为发往 webhook 的列通知内容
+type columnEventPayload struct {
+	Event          string    `json:"event"`
+	Column         string    `json:"column"`
+	FromStatus     string    `json:"from_status,omitempty"`
+	Task           Task      `json:"task"`
+	SubscriptionID int64     `json:"subscription_id"`
+	At             time.Time `json:"at"`
+}
+
+// validateSubscription 校验渠道与目标，返回规范化后的目标
+func (a *App) validateSubscription(channel, target string) (string, error) {
+	target = strings.TrimSpace(target)
+	switch channel {
+	case channelWebhook:
+		u, err := url.Parse(target)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "", fmt.Errorf("webhook target must be an http(s) URL")
+		}
+	case channelEmail:
+		if !a.cfg.SMTP.enabled() {
+			return "", fmt.Errorf("email channel requires smtp to be configured")
+		}
+		addr, err := mail.ParseAddress(target)
+		if err != nil {
+			return "", fmt.Errorf("invalid email address")
+		}
+		target = addr.Address
+	case channelTelegram:
+		if !a.cfg.Telegram.enabled() {
+			return "", fmt.Errorf("telegram channel requires telegram to be configured")
+		}
+		target = ""
+	default:
+		return "", fmt.Errorf("channel must be webhook, email or telegram")
+	}
+	return target, nil
+}
+
+// handleColumnSubscriptions 处理 /api/columns/{status}/subscriptions[/{id}]：列出（GET）、创建（POST）与取消（DELETE）订阅
+func (a *App) handleColumnSubscriptions(w http.ResponseWriter, r *http.Request, status, rest string) {
+	ctx := r.Context()
+	if rest != "" {
+		id, err := parseInt64(rest)
+		if err != nil {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		if r.Method != http.MethodDelete {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		res, err := a.db.ExecContext(ctx, `DELETE FROM column_subscriptions WHERE id = ? AND status = ?`, id, status)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "subscription not found"})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"id": id, "deleted": true})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		items, err := a.fetchColumnSubscriptions(ctx, status)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, columnSubscriptionListResponse{Items: items})
+	case http.MethodPost:
+		var body columnSubscriptionRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		body.Channel = strings.TrimSpace(body.Channel)
+		target, err := a.validateSubscription(body.Channel, body.Target)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		now := time.Now()
+		res, err := a.db.ExecContext(ctx, `INSERT OR IGNORE INTO column_subscriptions (status, channel, target, created_at) VALUES (?, ?, ?, ?)`,
+			status, body.Channel, target, now.Format(time.RFC3339))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "already subscribed"})
+			return
+		}
+		id, _ := res.LastInsertId()
+		addLogAttrs(r, "column", status, "channel", body.Channel)
+		a.reqLogger(r).Info("已添加列订阅", "subscription_id", id)
+		writeJSON(w, http.StatusCreated, ColumnSubscription{ID: id, Status: status, Channel: body.Channel, Target: target, CreatedAt: now})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// fetchColumnSubscriptions 返回某列的全部订阅
+func (a *App) fetchColumnSubscriptions(ctx context.Context, status string) ([]ColumnSubscription, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT id, status, channel, target, created_at FROM column_subscriptions WHERE status = ? ORDER BY id`, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ColumnSubscription{}
+	for rows.Next() {
+		var s ColumnSubscription
+		var created string
+		if err := rows.Scan(&s.ID, &s.Status, &s.Channel, &s.Target, &created); err != nil {
+			return nil, err
+		}
+		s.CreatedAt, _ = time.Parse(time.RFC3339, created)
+		items = append(items, s)
+	}
+	return items, rows.Err()
+}
+
+// startColumnNotifications 启动列订阅通知后台任务：跟踪任务事件，任务进入被订阅的列时逐个订阅发送通知。
+// 只通知启动之后发生的事件；单个订阅发送失败只记录日志，不影响其他订阅
+func (a *App) startColumnNotifications() {
+	var cursor int64
+	if err := a.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM task_events`).Scan(&cursor); err != nil {
+		a.logger.Error("列订阅通知启动失败", "err", err)
+		return
+	}
+	client := &http.Client{Timeout: reminderHTTPTimeout}
+	a.eventCursors.set("column-notifications", cursor)
+	a.startBackground("column-notifications", func(ctx context.Context) {
+		ticker := time.NewTicker(subscriptionPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			next, err := a.notifyColumnSubscribers(ctx, client, cursor)
+			if err != nil && ctx.Err() == nil {
+				a.logger.Error("列订阅通知失败", "err", err)
+			}
+			cursor = next
+			a.eventCursors.set("column-notifications", cursor)
+		}
+	})
+}
+
+// notifyColumnSubscribers 处理 cursor 之后进入被订阅列的事件，返回新的游标
+func (a *App) notifyColumnSubscribers(ctx context.Context, client *http.Client, cursor int64) (int64, error) {
+	// 先确定本轮处理的事件范围，没有订阅匹配的事件同样视为已处理
+	next := cursor
+	if err := a.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), ?) FROM task_events`, cursor).Scan(&next); err != nil {
+		return cursor, err
+	}
+	if next == cursor {
+		return cursor, nil
+	}
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT e.id, e.task_id, e.from_status, e.to_status, e.created_at, s.id, s.channel, s.target
+		FROM task_events e JOIN column_subscriptions s ON s.status = e.to_status
+		WHERE e.id > ? AND e.id <= ? AND e.kind IN ('created', 'status', 'restored')
+		ORDER BY e.id, s.id`, cursor, next)
+	if err != nil {
+		return cursor, err
+	}
+	type delivery struct {
+		eventID, taskID, subID int64
+		from, to, at           string
+		channel, target        string
+	}
+	var pending []delivery
+	for rows.Next() {
+		var d delivery
+		if err := rows.Scan(&d.eventID, &d.taskID, &d.from, &d.to, &d.at, &d.subID, &d.channel, &d.target); err != nil {
+			rows.Close()
+			return cursor, err
+		}
+		pending = append(pending, d)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return cursor, err
+	}
+	for _, d := range pending {
+		t, err := a.fetchTaskDetail(ctx, d.taskID)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return cursor, err
+		}
+		at, _ := time.Parse(time.RFC3339, d.at)
+		p := columnEventPayload{Event: "task.entered_column", Column: d.to, FromStatus: d.from, Task: t, SubscriptionID: d.subID, At: at}
+		err = a.deliverColumnEvent(ctx, client, d.channel, d.target, p)
+		a.deliveries.record("column_"+d.channel, err)
+		if err != nil {
+			a.logger.Warn("列订阅通知发送失败", "subscription_id", d.subID, "channel", d.channel, "task_id", d.taskID, "err", err)
+			continue
+		}
+		a.logger.Info("已发送列订阅通知", "subscription_id", d.subID, "channel", d.channel, "task_id", d.taskID, "column", d.to)
+	}
+	return next, nil
+}
+
+// deliverColumnEvent 通过订阅的渠道发送一条列通知
+func (a *App) deliverColumnEvent(ctx context.Context, client *http.Client, channel, target string, p columnEventPayload) error {
+	switch channel {
+	case channelWebhook:
+		return postWebhook(ctx, client, target, p)
+	case channelEmail:
+		locale := a.outboundLocale()
+		m, err := renderMail(locale, translatef(locale, "[看板] TB-%d 进入「%s」：%s", p.Task.ID, p.Column, p.Task.Title), columnTextTmpl, columnHTMLTmpl, p)
+		if err != nil {
+			return err
+		}
+		m.To = []string{target}
+		return a.cfg.SMTP.sendMail(ctx, m)
+	case channelTelegram:
+		bot := &telegramBot{cfg: a.cfg.Telegram, client: client}
+		locale := a.outboundLocale()
+		text := translatef(locale, "TB-%d「%s」进入「%s」", p.Task.ID, p.Task.Title, p.Column)
+		if p.FromStatus != "" && p.FromStatus != p.Column {
+			text += translatef(locale, "（来自「%s」）", p.FromStatus)
+		}
+		return bot.send(ctx, text)
+	}
+	return fmt.Errorf("未知渠道 %q", channel)
+}
+
+var columnTextTmpl = texttemplate.Must(texttemplate.New("column").Funcs(mailFuncs).Parse(
+	`{{if .FromStatus}}{{tf "任务 TB-%d「%s」进入「%s」（来自「%s」）。" .Task.ID .Task.Title .Column .FromStatus}}{{else}}{{tf "任务 TB-%d「%s」进入「%s」。" .Task.ID .Task.Title .Column}}{{end}}
+{{with .Task.Tags}}{{t "标签："}}{{range $i, $t := .}}{{if $i}}{{t "、"}}{{end}}{{$t}}{{end}}
+{{end}}{{with .Task.Description}}
+{{.}}
+{{end}}`))
+
+var columnHTMLTmpl = htmltemplate.Must(htmltemplate.New("column").Funcs(mailFuncs).Parse(
+	`<p><strong>TB-{{.Task.ID}} {{.Task.Title}}</strong></p>
+<p>{{if .FromStatus}}{{tf "进入「%s」（来自「%s」）。" .Column .FromStatus}}{{else}}{{tf "进入「%s」。" .Column}}{{end}}</p>
+{{with .Task.Tags}}<p>{{t "标签："}}{{range $i, $t := .}}{{if $i}}{{t "、"}}{{end}}{{$t}}{{end}}</p>{{end}}
+{{with .Task.Description}}<pre style="white-space:pre-wrap;font-family:inherit">{{.}}</pre>{{end}}`))
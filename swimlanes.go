@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+)
+
+// 泳道分组：任务列表带 group_by 参数时，服务端按维度将任务分组并附带各组计数，看板可直接按组渲染泳道。
+// 目前支持按标签分组：有多个标签的任务出现在每个标签的泳道中，没有标签的任务归入 key 为空的泳道（排在最后）；
+// 其余泳道按任务数降序、同数量按标签名排列
+
+// 支持的分组维度
+const groupByTag = "tag"
+
+// taskGroup 为一条泳道；ByStatus 按看板列顺序给出各列的任务数
+type taskGroup struct {
+	Key      string        `json:"key"`
+	Count    int           `json:"count"`
+	ByStatus []statusCount `json:"by_status"`
+	Items    []Task        `json:"items"`
+}
+
+// groupedTaskListResponse 为分组任务列表的响应结构
+type groupedTaskListResponse struct {
+	GroupBy string      `json:"group_by"`
+	Total   int         `json:"total"`
+	Groups  []taskGroup `json:"groups"`
+	Columns []Column    `json:"columns"`
+}
+
+// validGroupBy 校验分组维度
+func validGroupBy(groupBy string) error {
+	if groupBy != groupByTag {
+		return fmt.Errorf("group_by must be %s", groupByTag)
+	}
+	return nil
+}
+
+// groupTasks 按维度将任务分组，任务保持原有顺序
+func groupTasks(tasks []Task, groupBy string) []taskGroup {
+	index := map[string]int{}
+	var groups []taskGroup
+	add := func(key string, t Task) {
+		i, ok := index[key]
+		if !ok {
+			i = len(groups)
+			index[key] = i
+			groups = append(groups, taskGroup{Key: key})
+		}
+		groups[i].Items = append(groups[i].Items, t)
+	}
+	for _, t := range tasks {
+		if len(t.Tags) == 0 {
+			add("", t)
+		}
+		for _, tag := range t.Tags {
+			add(tag, t)
+		}
+	}
+	for i := range groups {
+		g := &groups[i]
+		g.Count = len(g.Items)
+		counts := map[string]int{}
+		for _, t := range g.Items {
+			counts[t.Status]++
+		}
+		for _, s := range taskStatuses {
+			g.ByStatus = append(g.ByStatus, statusCount{Status: s, Count: counts[s]})
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		if (groups[i].Key == "") != (groups[j].Key == "") {
+			return groups[j].Key == ""
+		}
+		if groups[i].Count != groups[j].Count {
+			return groups[i].Count > groups[j].Count
+		}
+		return groups[i].Key < groups[j].Key
+	})
+	if groups == nil {
+		groups = []taskGroup{}
+	}
+	return groups
+}
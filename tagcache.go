@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// tagCacheTTL 为标签查询缓存的有效期；写操作会立即使缓存失效，TTL 仅作兜底
+const tagCacheTTL = 30 * time.Second
+
+// tagCacheMaxEntries 为缓存的查询关键字数量上限，超过后整体清空
+const tagCacheMaxEntries = 512
+
+// tagCache 为 /api/tags 的进程内缓存，按排序方式与查询关键字存放结果；gen 在每次写操作后递增
+type tagCache struct {
+	mu      sync.Mutex
+	gen     uint64
+	entries map[string]tagCacheEntry
+}
+
+// tagCacheEntry 为一条缓存的标签查询结果
+type tagCacheEntry struct {
+	items   []tagSuggestion
+	etag    string
+	expires time.Time
+}
+
+// get 返回未过期的缓存结果，以及当前代数（查询前记录，写入缓存时用于丢弃过期结果）
+func (c *tagCache) get(q string, now time.Time) (tagCacheEntry, uint64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[q]
+	if !ok || now.After(e.expires) {
+		return tagCacheEntry{}, c.gen, false
+	}
+	return e, c.gen, true
+}
+
+// put 写入查询结果；若查询期间发生过写操作（代数已变化）则丢弃
+func (c *tagCache) put(q string, gen uint64, items []tagSuggestion, now time.Time) tagCacheEntry {
+	parts := []string{"tags", q}
+	for _, s := range items {
+		parts = append(parts, s.Tag, strconv.Itoa(s.Count), s.LastUsedAt.Format(time.RFC3339), s.Color, s.Description)
+	}
+	e := tagCacheEntry{items: items, etag: weakETag(parts...), expires: now.Add(tagCacheTTL)}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if gen != c.gen {
+		return e
+	}
+	if c.entries == nil || len(c.entries) >= tagCacheMaxEntries {
+		c.entries = map[string]tagCacheEntry{}
+	}
+	c.entries[q] = e
+	return e
+}
+
+// invalidate 清空缓存并递增代数
+func (c *tagCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.gen++
+	c.entries = nil
+}
+
+// withCacheInvalidation 在每个写请求（非 GET/HEAD/OPTIONS）处理完成后使进程内缓存失效
+func (a *App) withCacheInvalidation(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+		default:
+			a.tagCache.invalidate()
+		}
+	})
+}
@@ -0,0 +1,263 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// 标签历史由 task_tags 上的触发器记录，覆盖所有写入路径（HTTP、导入、周期任务、归档分区迁移），
+// 标签从所有任务上移除后历史仍然保留。触发器无法得知操作者，经接口修改、重命名或合并标签以及删除任务时，
+// 由 withTagActor 在同一事务中补记操作者（与审计日志的 actor 相同）；其他写入路径的事件 actor 为空。
+
+// 标签事件类型
+const (
+	tagEventCreated  = "created"  // 标签首次出现在任务上
+	tagEventAttached = "attached" // 标签被添加到某个任务
+	tagEventDetached = "detached" // 标签从某个任务移除（含任务被删除或迁入归档分区）
+	tagEventRemoved  = "removed"  // 标签已不在任何任务上
+	tagEventRenamed  = "renamed"  // 标签被重命名，detail 为新名称
+	tagEventMerged   = "merged"   // 标签被合并到已有标签，detail 为目标标签
+)
+
+// tagEventTriggers 为记录标签事件的触发器；重命名按“从旧标签移除、添加到新标签”记录
+const tagEventTriggers = `
+	CREATE TRIGGER IF NOT EXISTS trg_tag_events_insert AFTER INSERT ON task_tags
+	BEGIN
+		INSERT INTO tag_events (tag, kind, task_id, created_at)
+		SELECT NEW.tag, 'created', NULL, strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE (SELECT COUNT(*) FROM task_tags WHERE tag = NEW.tag) = 1;
+		INSERT INTO tag_events (tag, kind, task_id, created_at)
+		VALUES (NEW.tag, 'attached', NEW.task_id, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'));
+	END;
+	CREATE TRIGGER IF NOT EXISTS trg_tag_events_delete AFTER DELETE ON task_tags
+	BEGIN
+		INSERT INTO tag_events (tag, kind, task_id, created_at)
+		VALUES (OLD.tag, 'detached', OLD.task_id, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'));
+		INSERT INTO tag_events (tag, kind, task_id, created_at)
+		SELECT OLD.tag, 'removed', NULL, strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE NOT EXISTS (SELECT 1 FROM task_tags WHERE tag = OLD.tag);
+	END;
+	CREATE TRIGGER IF NOT EXISTS trg_tag_events_update AFTER UPDATE OF tag ON task_tags
+	WHEN OLD.tag <> NEW.tag
+	BEGIN
+		INSERT INTO tag_events (tag, kind, task_id, created_at)
+		VALUES (OLD.tag, 'detached', OLD.task_id, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'));
+		INSERT INTO tag_events (tag, kind, task_id, created_at)
+		SELECT OLD.tag, 'removed', NULL, strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE NOT EXISTS (SELECT 1 FROM task_tags WHERE tag = OLD.tag);
+		INSERT INTO tag_events (tag, kind, task_id, created_at)
+		SELECT NEW.tag, 'created', NULL, strftime('%Y-%m-%dT%H:%M:%SZ', 'now')
+		WHERE (SELECT COUNT(*) FROM task_tags WHERE tag = NEW.tag) = 1;
+		INSERT INTO tag_events (tag, kind, task_id, created_at)
+		VALUES (NEW.tag, 'attached', NEW.task_id, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'));
+	END;
+`
+
+// migrateTagEvents 创建标签事件触发器，并为尚无事件的旧标签补写近似历史：
+// 以使用该标签的最早任务的创建时间为标签创建时间，以任务创建时间为添加时间
+func (a *App) migrateTagEvents() error {
+	if _, err := a.db.Exec(tagEventTriggers); err != nil {
+		return err
+	}
+	_, err := a.db.Exec(`
+		INSERT INTO tag_events (tag, kind, task_id, created_at)
+		SELECT tt.tag, 'created', NULL, MIN(t.created_at)
+		FROM task_tags tt JOIN tasks t ON t.id = tt.task_id
+		WHERE tt.tag NOT IN (SELECT tag FROM tag_events)
+		GROUP BY tt.tag;
+		INSERT INTO tag_events (tag, kind, task_id, created_at)
+		SELECT tt.tag, 'attached', tt.task_id, t.created_at
+		FROM task_tags tt JOIN tasks t ON t.id = tt.task_id
+		WHERE NOT EXISTS (
+			SELECT 1 FROM tag_events e WHERE e.tag = tt.tag AND e.task_id = tt.task_id AND e.kind = 'attached'
+		);
+	`)
+	return err
+}
+
+// TagEvent 为一条标签历史记录
+type TagEvent struct {
+	ID        int64     `json:"id"`
+	Tag       string    `json:"tag"`
+	Kind      string    `json:"kind"`
+	TaskID    *int64    `json:"task_id,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	Actor     string    `json:"actor,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// tagEventListResponse 为标签历史的响应结构
+type tagEventListResponse struct {
+	Items []TagEvent `json:"items"`
+}
+
+// tagRenameRequest 为重命名或合并标签的请求体
+type tagRenameRequest struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// tagRenameResponse 为重命名或合并标签的结果
+type tagRenameResponse struct {
+	Kind  string `json:"kind"`  // renamed 或 merged
+	Tasks int64  `json:"tasks"` // 受影响的任务数
+}
+
+// handleTagHistory 返回标签历史：指定 tag 时返回该标签（含重命名、合并到它的记录）的全部事件，否则返回最近的事件
+func (a *App) handleTagHistory(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	limit := int64(100)
+	if v, err := parseInt64(r.URL.Query().Get("limit")); err == nil && v > 0 && v <= 1000 {
+		limit = v
+	}
+	items, err := a.queryTagEvents(ctx, a.cfg.Tags.normalize(r.URL.Query().Get("tag")), limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, tagEventListResponse{Items: items})
+}
+
+// queryTagEvents 按时间倒序查询标签事件
+func (a *App) queryTagEvents(ctx context.Context, tag string, limit int64) ([]TagEvent, error) {
+	query := `SELECT id, tag, kind, task_id, detail, actor, created_at FROM tag_events`
+	args := []any{}
+	if tag != "" {
+		query += ` WHERE tag = ? OR (detail = ? AND kind IN ('renamed', 'merged'))`
+		args = append(args, tag, tag)
+	}
+	query += ` ORDER BY id DESC LIMIT ?`
+	args = append(args, limit)
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TagEvent{}
+	for rows.Next() {
+		var e TagEvent
+		var taskID sql.NullInt64
+		var at string
+		if err := rows.Scan(&e.ID, &e.Tag, &e.Kind, &taskID, &e.Detail, &e.Actor, &at); err != nil {
+			return nil, err
+		}
+		if taskID.Valid {
+			e.TaskID = &taskID.Int64
+		}
+		e.CreatedAt, _ = time.Parse(time.RFC3339, at)
+		items = append(items, e)
+	}
+	return items, rows.Err()
+}
+
+// handleTagRename 将标签重命名；目标标签已存在时合并到目标标签，同时拥有两者的任务只保留目标标签
+func (a *App) handleTagRename(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var body tagRenameRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return
+	}
+	body.From, body.To = a.cfg.Tags.normalize(body.From), a.cfg.Tags.normalize(body.To)
+	if body.From == "" || body.To == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from and to are required"})
+		return
+	}
+	if body.From == body.To {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "from and to must differ"})
+		return
+	}
+	res, err := a.renameTag(ctx, body.From, body.To, auditActor(r))
+	if err == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "tag not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	addLogAttrs(r, "tag", body.From, "to", body.To)
+	a.reqLogger(r).Info("标签已重命名", "kind", res.Kind, "tasks", res.Tasks)
+	writeJSON(w, http.StatusOK, res)
+}
+
+// renameTag 在一个事务中重命名或合并标签并记录事件，actor 为操作者；来源标签不存在时返回 sql.ErrNoRows
+func (a *App) renameTag(ctx context.Context, from, to, actor string) (tagRenameResponse, error) {
+	var res tagRenameResponse
+	err := a.withTagActor(ctx, actor, func(tx *sql.Tx) error {
+		var fromCount, toCount int64
+		if err := tx.QueryRowContext(ctx, `SELECT
+			(SELECT COUNT(DISTINCT task_id) FROM task_tags WHERE tag = ?),
+			(SELECT COUNT(*) FROM task_tags WHERE tag = ?)`, from, to).Scan(&fromCount, &toCount); err != nil {
+			return err
+		}
+		if fromCount == 0 {
+			return sql.ErrNoRows
+		}
+		res.Kind, res.Tasks = tagEventRenamed, fromCount
+		if toCount > 0 {
+			res.Kind = tagEventMerged
+		}
+		now := time.Now()
+		// 先记录重命名/合并，使其排在触发器产生的移除、添加事件之前
+		if _, err := tx.ExecContext(ctx, `INSERT INTO tag_events (tag, kind, task_id, detail, created_at) VALUES (?, ?, NULL, ?, ?)`,
+			from, res.Kind, to, now.UTC().Format(time.RFC3339)); err != nil {
+			return err
+		}
+		// 受影响的任务视为已修改：递增版本使持有旧版本的客户端重新加载，并使列表 ETag 失效
+		if _, err := tx.ExecContext(ctx, `UPDATE tasks SET version = version + 1, updated_at = ? WHERE id IN (SELECT task_id FROM task_tags WHERE tag = ?)`,
+			now.Format(time.RFC3339), from); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM task_tags WHERE tag = ? AND task_id IN (SELECT task_id FROM task_tags WHERE tag = ?)`, from, to); err != nil {
+			return err
+		}
+		// 同一任务上重复的来源标签只保留一条
+		if _, err := tx.ExecContext(ctx, `DELETE FROM task_tags WHERE tag = ? AND id NOT IN (SELECT MIN(id) FROM task_tags WHERE tag = ? GROUP BY task_id)`, from, from); err != nil {
+			return err
+		}
+		// 元数据随标签迁移；合并时目标标签已有记录，保留目标的元数据
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO tags (name, color, description, created_at) SELECT ?, color, description, created_at FROM tags WHERE name = ?`, to, from); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE task_tags SET tag = ? WHERE tag = ?`, to, from); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM tags WHERE name = ?`, from)
+		return err
+	})
+	return res, err
+}
+
+// withTagActor 在一个事务中执行 fn，并把其间产生的标签事件（含触发器写入的）记为 actor 所为；actor 为空时不记录
+func (a *App) withTagActor(ctx context.Context, actor string, fn func(tx *sql.Tx) error) error {
+	tx, err := a.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	var last int64
+	if err := tx.QueryRowContext(ctx, `SELECT COALESCE(MAX(id), 0) FROM tag_events`).Scan(&last); err != nil {
+		return err
+	}
+	if err := fn(tx); err != nil {
+		return err
+	}
+	if actor != "" {
+		if _, err := tx.ExecContext(ctx, `UPDATE tag_events SET actor = ? WHERE id > ?`, actor, last); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestTagHistoryRecordsActor(t *testing.T) {
+	app := newTestApp(t, nil)
+	res, err := app.db.Exec(`INSERT INTO tasks (title, description, status, created_at, updated_at) VALUES ('t', '', '规划中', '2026-01-01T00:00:00Z', '2026-01-01T00:00:00Z')`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id, _ := res.LastInsertId()
+	if _, err := app.db.Exec(`INSERT INTO task_tags (task_id, tag) VALUES (?, 'old')`, id); err != nil {
+		t.Fatal(err)
+	}
+	do := func(method, path, body string) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req = req.WithContext(context.WithValue(req.Context(), signedClientKey{}, "ci"))
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s %s = %d: %s", method, path, rec.Code, rec.Body.String())
+		}
+	}
+	do(http.MethodPost, "/api/tags/rename", `{"from":"old","to":"new"}`)
+	do(http.MethodDelete, "/api/tasks/"+strconv.FormatInt(id, 10), "")
+
+	items, err := app.queryTagEvents(context.Background(), "new", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kinds := map[string]string{}
+	for _, e := range items {
+		kinds[e.Kind] = e.Actor
+	}
+	for _, kind := range []string{tagEventRenamed, tagEventDetached, tagEventRemoved} {
+		if actor, ok := kinds[kind]; !ok || actor != "client:ci" {
+			out, _ := json.Marshal(items)
+			t.Errorf("%s event actor = %q (present %v), want client:ci; events %s", kind, actor, ok, out)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// tags 表保存标签的颜色、说明与创建时间，task_tags.tag 按名称引用 tags.name。
+// 标签首次被任务使用时由触发器登记，因此所有写入路径（HTTP、导入、周期任务）都会自动补齐；
+// 元数据在标签不再被任何任务使用后仍然保留，重命名时随标签迁移，合并时保留目标标签的元数据。
+
+// tagRegistryTriggers 在任务使用新标签时登记到 tags 表
+const tagRegistryTriggers = `
+	CREATE TRIGGER IF NOT EXISTS trg_tags_register_insert AFTER INSERT ON task_tags
+	BEGIN
+		INSERT OR IGNORE INTO tags (name, created_at) VALUES (NEW.tag, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'));
+	END;
+	CREATE TRIGGER IF NOT EXISTS trg_tags_register_update AFTER UPDATE OF tag ON task_tags
+	BEGIN
+		INSERT OR IGNORE INTO tags (name, created_at) VALUES (NEW.tag, strftime('%Y-%m-%dT%H:%M:%SZ', 'now'));
+	END;
+`
+
+// maxTagDescriptionLen 为标签说明的最大长度
+const maxTagDescriptionLen = 500
+
+// tagColorPattern 匹配 #rgb 或 #rrggbb 形式的颜色
+var tagColorPattern = regexp.MustCompile(`^#([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Tag 为标签及其元数据；Color 为空表示使用界面默认颜色
+type Tag struct {
+	Name        string    `json:"name"`
+	Color       string    `json:"color"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// tagUpdateRequest 为更新标签元数据的请求体，未提供的字段保持不变
+type tagUpdateRequest struct {
+	Color       *string `json:"color"`
+	Description *string `json:"description"`
+}
+
+// migrateTagRegistry 创建登记触发器，并为已有标签补写记录：创建时间取标签历史中的首次出现时间
+func (a *App) migrateTagRegistry() error {
+	if _, err := a.db.Exec(tagRegistryTriggers); err != nil {
+		return err
+	}
+	_, err := a.db.Exec(`
+		INSERT OR IGNORE INTO tags (name, created_at)
+		SELECT tt.tag, COALESCE(
+			(SELECT MIN(e.created_at) FROM tag_events e WHERE e.tag = tt.tag AND e.kind = 'created'),
+			strftime('%Y-%m-%dT%H:%M:%SZ', 'now'))
+		FROM task_tags tt GROUP BY tt.tag
+	`)
+	return err
+}
+
+// normalizeTagColor 校验颜色并统一为小写的 #rrggbb；空串表示清除颜色
+func normalizeTagColor(c string) (string, bool) {
+	c = strings.TrimSpace(c)
+	if c == "" {
+		return "", true
+	}
+	if !tagColorPattern.MatchString(c) {
+		return "", false
+	}
+	c = strings.ToLower(c)
+	if len(c) == 4 {
+		c = string([]byte{'#', c[1], c[1], c[2], c[2], c[3], c[3]})
+	}
+	return c, true
+}
+
+// fetchTag 返回标签及其元数据
+func (a *App) fetchTag(ctx context.Context, name string) (Tag, error) {
+	t := Tag{Name: name}
+	var created string
+	err := a.db.QueryRowContext(ctx, `SELECT color, description, created_at FROM tags WHERE name = ?`, name).Scan(&t.Color, &t.Description, &created)
+	t.CreatedAt, _ = time.Parse(time.RFC3339, created)
+	return t, err
+}
+
+// handleTagItem 处理 /api/tags/{name}：GET 返回标签元数据，PUT/PATCH 设置颜色与说明（标签尚未使用时一并创建）
+func (a *App) handleTagItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	name := a.cfg.Tags.normalize(strings.TrimPrefix(r.URL.Path, "/api/tags/"))
+	if name == "" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		t, err := a.fetchTag(ctx, name)
+		if err == sql.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "tag not found"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, t)
+	case http.MethodPut, http.MethodPatch:
+		var body tagUpdateRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		t, err := a.fetchTag(ctx, name)
+		if err != nil && err != sql.ErrNoRows {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if err == sql.ErrNoRows {
+			t.CreatedAt = time.Now().UTC().Truncate(time.Second)
+		}
+		if body.Color != nil {
+			color, ok := normalizeTagColor(*body.Color)
+			if !ok {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "color must be #rgb or #rrggbb"})
+				return
+			}
+			t.Color = color
+		}
+		if body.Description != nil {
+			t.Description = strings.TrimSpace(*body.Description)
+			if len([]rune(t.Description)) > maxTagDescriptionLen {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "description too long"})
+				return
+			}
+		}
+		if _, err := a.db.ExecContext(ctx, `
+			INSERT INTO tags (name, color, description, created_at) VALUES (?, ?, ?, ?)
+			ON CONFLICT(name) DO UPDATE SET color = excluded.color, description = excluded.description
+		`, t.Name, t.Color, t.Description, t.CreatedAt.Format(time.RFC3339)); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		addLogAttrs(r, "tag", name)
+		a.reqLogger(r).Info("已更新标签元数据", "color", t.Color)
+		writeJSON(w, http.StatusOK, t)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
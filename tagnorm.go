@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/runes"
+	"golang.org/x/text/transform"
+	"golang.org/x/text/unicode/norm"
+)
+
+// TagsConfig 为标签规范化配置：写入时标签总是去除首尾空白、合并内部连续空白并转为 NFC；
+// CaseFold 为 true 时还会统一转为小写，使 "Backend" 与 "BACKEND" 成为同一标签
+type TagsConfig struct {
+	CaseFold bool `yaml:"case_fold"`
+}
+
+// normalize 规范化单个标签，结果为空串表示应丢弃
+func (c TagsConfig) normalize(tag string) string {
+	tag = strings.Join(strings.Fields(norm.NFC.String(tag)), " ")
+	if c.CaseFold {
+		tag = strings.ToLower(tag)
+	}
+	return tag
+}
+
+// normalizeAll 规范化一组标签并去重，保持首次出现的顺序；总是返回非 nil 切片
+func (c TagsConfig) normalizeAll(tags []string) []string {
+	out := []string{}
+	seen := map[string]bool{}
+	for _, tag := range tags {
+		if tag = c.normalize(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			out = append(out, tag)
+		}
+	}
+	return out
+}
+
+// tagSearchKey 返回标签搜索用的比较键：去除重音等组合附加符号并转为小写，使 "cafe" 能匹配 "Café"
+func tagSearchKey(s string) string {
+	t := transform.Chain(norm.NFD, runes.Remove(runes.In(unicode.Mn)), norm.NFC)
+	if out, _, err := transform.String(t, s); err == nil {
+		s = out
+	}
+	return strings.ToLower(strings.TrimSpace(s))
+}
+
+// migrateTagNormalization 将现有标签改写为规范形式；规范化后同名的标签按合并处理，
+// 并与手动合并一样记录标签事件、递增受影响任务的版本。已是规范形式时不做任何修改
+func (a *App) migrateTagNormalization() error {
+	rows, err := a.db.Query(`SELECT tag FROM task_tags UNION SELECT name FROM tags`)
+	if err != nil {
+		return err
+	}
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return err
+		}
+		names = append(names, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+	for _, from := range names {
+		to := a.cfg.Tags.normalize(from)
+		if to == from || to == "" {
+			continue
+		}
+		res, err := a.renameTag(context.Background(), from, to, "")
+		if err == sql.ErrNoRows {
+			// 只在 tags 表中登记、已不在任何任务上的标签：只迁移元数据
+			if _, err := a.db.Exec(`INSERT OR IGNORE INTO tags (name, color, description, created_at) SELECT ?, color, description, created_at FROM tags WHERE name = ?`, to, from); err != nil {
+				return err
+			}
+			if _, err := a.db.Exec(`DELETE FROM tags WHERE name = ?`, from); err != nil {
+				return err
+			}
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		a.logger.Info("已规范化标签", "from", from, "to", to, "kind", res.Kind, "tasks", res.Tasks)
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"time"
+)
+
+// tagSuggestion 为标签自动补全的一条建议
+type tagSuggestion struct {
+	Tag        string    `json:"tag"`
+	Count      int       `json:"count"`        // 使用该标签的任务数（含归档）
+	LastUsedAt time.Time `json:"last_used_at"` // 使用该标签的任务的最近更新时间
+	// 标签元数据，见 tags 表
+	Color       string `json:"color,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// tagRecencyPeriod 为使用次数按最近使用时间衰减的周期：距最近使用 n 个周期时权重为 1/(1+n)
+const tagRecencyPeriod = 30 * 24 * time.Hour
+
+// queryTagSuggestions 返回带使用次数的标签建议，q 的匹配忽略大小写与重音。sortBy 为 name 时按名称排序；
+// 否则按相关度排序：前缀匹配优先，其次为随最近使用时间衰减的使用次数
+func (a *App) queryTagSuggestions(ctx context.Context, q, sortBy string, now time.Time) ([]tagSuggestion, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT tt.tag, COUNT(DISTINCT tt.task_id), MAX(t.updated_at), COALESCE(MAX(g.color), ''), COALESCE(MAX(g.description), '')
+		FROM task_tags tt JOIN tasks t ON t.id = tt.task_id LEFT JOIN tags g ON g.name = tt.tag
+		GROUP BY tt.tag
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	lq := tagSearchKey(q)
+	out := []tagSuggestion{}
+	for rows.Next() {
+		var s tagSuggestion
+		var last string
+		if err := rows.Scan(&s.Tag, &s.Count, &last, &s.Color, &s.Description); err != nil {
+			return nil, err
+		}
+		// SQLite 的 LIKE 只对 ASCII 忽略大小写，匹配在这里按去除重音的小写形式进行
+		if !strings.Contains(tagSearchKey(s.Tag), lq) {
+			continue
+		}
+		s.LastUsedAt, _ = time.Parse(time.RFC3339, last)
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if sortBy == "name" {
+		sort.Slice(out, func(i, j int) bool { return out[i].Tag < out[j].Tag })
+		return out, nil
+	}
+	score := func(s tagSuggestion) float64 {
+		age := now.Sub(s.LastUsedAt)
+		if age < 0 {
+			age = 0
+		}
+		return float64(s.Count) / (1 + float64(age)/float64(tagRecencyPeriod))
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		pi := lq != "" && strings.HasPrefix(tagSearchKey(out[i].Tag), lq)
+		pj := lq != "" && strings.HasPrefix(tagSearchKey(out[j].Tag), lq)
+		if pi != pj {
+			return pi
+		}
+		si, sj := score(out[i]), score(out[j])
+		if si != sj {
+			return si > sj
+		}
+		return out[i].Tag < out[j].Tag
+	})
+	return out, nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// tagStat 为单个标签的使用统计；Active 为在板未完成、Completed 为在板已完成、Archived 为已归档的任务数。
+// LastUsedAt 为最近一次被添加到任务的时间，从未使用过的标签取登记时间
+type tagStat struct {
+	Tag        string    `json:"tag"`
+	Active     int       `json:"active"`
+	Completed  int       `json:"completed"`
+	Archived   int       `json:"archived"`
+	Total      int       `json:"total"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// tagStatsResponse 为标签统计的响应结构，按最近使用时间从早到晚排列，便于发现长期未用的标签
+type tagStatsResponse struct {
+	Items []tagStat `json:"items"`
+}
+
+// handleTagStats 处理 GET /api/tags/stats。
+// unused_days=N 时只返回没有未完成任务且超过 N 天未被添加到任务的标签，即可以清理的候选
+func (a *App) handleTagStats(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	var unusedDays int64
+	if v := r.URL.Query().Get("unused_days"); v != "" {
+		n, err := parseInt64(v)
+		if err != nil || n < 0 {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid unused_days"})
+			return
+		}
+		unusedDays = n
+	}
+	stats, err := a.queryTagStats(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	items := []tagStat{}
+	cutoff := time.Now().AddDate(0, 0, -int(unusedDays))
+	for _, s := range stats {
+		if unusedDays > 0 && (s.Active > 0 || s.LastUsedAt.After(cutoff)) {
+			continue
+		}
+		items = append(items, s)
+	}
+	writeJSON(w, http.StatusOK, tagStatsResponse{Items: items})
+}
+
+// queryTagStats 统计 tags 表中每个标签的使用情况，含已不在任何任务上的标签
+func (a *App) queryTagStats(ctx context.Context) ([]tagStat, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT g.name,
+			COALESCE(SUM(t.archived = 0 AND t.status <> '已完成'), 0),
+			COALESCE(SUM(t.archived = 0 AND t.status = '已完成'), 0),
+			COALESCE(SUM(t.archived = 1), 0),
+			COUNT(t.id),
+			COALESCE((SELECT MAX(e.created_at) FROM tag_events e WHERE e.tag = g.name AND e.kind = 'attached'), g.created_at)
+		FROM tags g
+		LEFT JOIN task_tags tt ON tt.tag = g.name
+		LEFT JOIN tasks t ON t.id = tt.task_id
+		GROUP BY g.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []tagStat
+	for rows.Next() {
+		var s tagStat
+		var last string
+		if err := rows.Scan(&s.Tag, &s.Active, &s.Completed, &s.Archived, &s.Total, &last); err != nil {
+			return nil, err
+		}
+		s.LastUsedAt, _ = time.Parse(time.RFC3339, last)
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	// 各写入路径的时间戳时区不一致，按解析后的时间排序而不是在 SQL 中按字符串排序
+	sort.SliceStable(out, func(i, j int) bool {
+		if !out[i].LastUsedAt.Equal(out[j].LastUsedAt) {
+			return out[i].LastUsedAt.Before(out[j].LastUsedAt)
+		}
+		return out[i].Tag < out[j].Tag
+	})
+	return out, nil
+}
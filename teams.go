@@ -0,0 +1,589 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 团队与看板成员：登录用户可以创建团队并管理成员（owner 可改名、增删成员与调整角色，member 只能查看），
+// 看板归属某个团队后，只有该团队的成员才能访问看板接口，未登录返回 401，非成员返回 403；
+// 命令行创建的 API 密钥与签名客户端视为服务账号，不受成员限制，登录用户创建的密钥按创建者的角色处理。
+// 看板归属由 /api/admin/board 设置。
+// 未配置任何登录提供方时没有用户身份，团队接口不区分调用方，与之前的单用户部署行为一致。
+// 当前每个实例只有一个看板，多个团队共用一个实例需分别部署
+
+// 团队成员角色
+const (
+	teamRoleOwner  = "owner"
+	teamRoleMember = "member"
+)
+
+// boardID 为当前实例唯一看板的 ID
+const boardID = 1
+
+// Team 为一个团队；Members 仅在详情中返回
+type Team struct {
+	ID          int64        `json:"id"`
+	Name        string       `json:"name"`
+	MemberCount int          `json:"member_count"`
+	Role        string       `json:"role,omitempty"` // 当前用户在团队中的角色
+	CreatedAt   time.Time    `json:"created_at"`
+	Members     []TeamMember `json:"members,omitempty"`
+}
+
+// TeamMember 为团队中的一个成员
+type TeamMember struct {
+	UserID   int64     `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Role     string    `json:"role"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+// teamRequest 为创建与重命名团队的请求体
+type teamRequest struct {
+	Name string `json:"name"`
+}
+
+// teamMemberRequest 为添加成员与调整角色的请求体；添加时以 user_id 或 email 指定已登录过的用户，role 缺省为 member
+type teamMemberRequest struct {
+	UserID int64  `json:"user_id"`
+	Email  string `json:"email"`
+	Role   string `json:"role"`
+}
+
+// teamListResponse 为团队列表的响应结构
+type teamListResponse struct {
+	Items []Team `json:"items"`
+}
+
+// boardSettings 为看板归属设置；TeamID 为空表示不限制访问
+type boardSettings struct {
+	ID        int64      `json:"id"`
+	TeamID    *int64     `json:"team_id"`
+	Team      *Team      `json:"team,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// boardSettingsRequest 为设置看板归属的请求体，team_id 为 null 表示取消归属
+type boardSettingsRequest struct {
+	TeamID *int64 `json:"team_id"`
+}
+
+// validTeamRole 判断是否为合法的成员角色
+func validTeamRole(role string) bool {
+	return role == teamRoleOwner || role == teamRoleMember
+}
+
+// isServiceRequest 判断请求是否来自服务账号（没有创建者的 API 密钥或签名客户端），或实例未开启登录；
+// 登录用户创建的密钥按创建者的角色处理
+func (a *App) isServiceRequest(r *http.Request) bool {
+	k, byKey := r.Context().Value(apiKeyCtxKey{}).(APIKey)
+	return (byKey && k.CreatedBy == nil) || signedClient(r) != "" || len(a.oauth) == 0
+}
+
+// teamRole 返回用户在团队中的角色，不是成员时返回空串
+func (a *App) teamRole(ctx context.Context, teamID, userID int64) (string, error) {
+	var role string
+	err := a.db.QueryRowContext(ctx, `SELECT role FROM team_members WHERE team_id = ? AND user_id = ?`, teamID, userID).Scan(&role)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return role, err
+}
+
+// boardTeamID 返回看板所属团队，未设置时返回 0
+func (a *App) boardTeamID(ctx context.Context) (int64, error) {
+	var teamID sql.NullInt64
+	err := a.db.QueryRowContext(ctx, `SELECT team_id FROM boards WHERE id = ?`, boardID).Scan(&teamID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return teamID.Int64, err
+}
+
+// boardMembershipExempt 判断路径是否不受看板成员限制：非接口页面、登录与团队管理本身、公开接口与自带令牌校验的接口
+func boardMembershipExempt(path string) bool {
+	if !strings.HasPrefix(path, "/api/") {
+		return true
+	}
+	return path == "/api/me" || path == "/api/teams" || strings.HasPrefix(path, "/api/teams/") || !apiKeyRequired(path)
+}
+
+// withBoardMembership 在看板归属团队时只允许团队成员与服务账号访问看板接口
+func (a *App) withBoardMembership(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if boardMembershipExempt(r.URL.Path) || a.isServiceRequest(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		teamID, err := a.boardTeamID(r.Context())
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if teamID == 0 {
+			next.ServeHTTP(w, r)
+			return
+		}
+		userID := currentUserID(r)
+		if userID == 0 {
+			if a.guestReadable(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "login required"})
+			return
+		}
+		role, err := a.teamRole(r.Context(), teamID, userID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if role == "" {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "not a member of the board's team"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// fetchTeam 读取团队及成员数；userID 非 0 时附带该用户的角色
+func (a *App) fetchTeam(ctx context.Context, id, userID int64) (Team, error) {
+	var t Team
+	var created string
+	err := a.db.QueryRowContext(ctx, `SELECT t.id, t.name, t.created_at, (SELECT COUNT(*) FROM team_members m WHERE m.team_id = t.id),
+		COALESCE((SELECT role FROM team_members m WHERE m.team_id = t.id AND m.user_id = ?), '')
+		FROM teams t WHERE t.id = ?`, userID, id).Scan(&t.ID, &t.Name, &created, &t.MemberCount, &t.Role)
+	t.CreatedAt, _ = time.Parse(time.RFC3339, created)
+	return t, err
+}
+
+// fetchTeamMembers 返回团队成员，owner 在前
+func (a *App) fetchTeamMembers(ctx context.Context, teamID int64) ([]TeamMember, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT u.id, u.name, u.email, m.role, m.created_at
+		FROM team_members m JOIN users u ON u.id = m.user_id WHERE m.team_id = ?
+		ORDER BY m.role = 'owner' DESC, m.created_at, u.id`, teamID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []TeamMember{}
+	for rows.Next() {
+		var m TeamMember
+		var joined string
+		if err := rows.Scan(&m.UserID, &m.Name, &m.Email, &m.Role, &joined); err != nil {
+			return nil, err
+		}
+		m.JoinedAt, _ = time.Parse(time.RFC3339, joined)
+		out = append(out, m)
+	}
+	return out, rows.Err()
+}
+
+// decodeTeamName 读取并校验团队名称
+func decodeTeamName(w http.ResponseWriter, r *http.Request) (string, bool) {
+	var body teamRequest
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+		return "", false
+	}
+	name := strings.TrimSpace(body.Name)
+	if name == "" || len([]rune(name)) > 100 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name must be 1-100 characters"})
+		return "", false
+	}
+	return name, true
+}
+
+// handleTeams 处理 /api/teams：GET 列出团队（登录用户只看到自己所在的团队），POST 创建团队，创建者成为 owner
+func (a *App) handleTeams(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	service, userID := a.isServiceRequest(r), currentUserID(r)
+	if !service && userID == 0 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "login required"})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		query := `SELECT id FROM teams ORDER BY name`
+		var args []any
+		if !service {
+			query = `SELECT t.id FROM teams t JOIN team_members m ON m.team_id = t.id WHERE m.user_id = ? ORDER BY t.name`
+			args = append(args, userID)
+		}
+		ids, err := a.queryIDs(ctx, query, args...)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		items := []Team{}
+		for _, id := range ids {
+			t, err := a.fetchTeam(ctx, id, userID)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			items = append(items, t)
+		}
+		writeJSON(w, http.StatusOK, teamListResponse{Items: items})
+	case http.MethodPost:
+		name, ok := decodeTeamName(w, r)
+		if !ok {
+			return
+		}
+		now := time.Now().Format(time.RFC3339)
+		tx, err := a.db.BeginTx(ctx, nil)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		defer tx.Rollback()
+		res, err := tx.ExecContext(ctx, `INSERT INTO teams (name, created_at) VALUES (?, ?)`, name, now)
+		if err != nil {
+			if strings.Contains(err.Error(), "UNIQUE") {
+				writeJSON(w, http.StatusConflict, map[string]string{"error": "team name already exists"})
+				return
+			}
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		id, _ := res.LastInsertId()
+		if userID != 0 {
+			if _, err := tx.ExecContext(ctx, `INSERT INTO team_members (team_id, user_id, role, created_at) VALUES (?, ?, ?, ?)`, id, userID, teamRoleOwner, now); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		a.reqLogger(r).Info("已创建团队", "team_id", id, "name", name)
+		a.writeTeam(w, r, id, http.StatusCreated)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// queryIDs 执行只返回一列 ID 的查询
+func (a *App) queryIDs(ctx context.Context, query string, args ...any) ([]int64, error) {
+	rows, err := a.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// writeTeam 返回团队详情（含成员）
+func (a *App) writeTeam(w http.ResponseWriter, r *http.Request, id int64, status int) {
+	t, err := a.fetchTeam(r.Context(), id, currentUserID(r))
+	if err == nil {
+		t.Members, err = a.fetchTeamMembers(r.Context(), id)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, status, t)
+}
+
+// handleTeamItem 处理 /api/teams/{id} 与 /api/teams/{id}/members[/{user_id}]。
+// 成员可以查看，owner 可以重命名、删除团队与管理成员；团队至少保留一名 owner
+func (a *App) handleTeamItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/teams/"), "/"), "/")
+	id, err := parseInt64(parts[0])
+	if err != nil || len(parts) > 3 || (len(parts) > 1 && parts[1] != "members") {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	addLogAttrs(r, "team_id", id)
+	service, userID := a.isServiceRequest(r), currentUserID(r)
+	if !service && userID == 0 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "login required"})
+		return
+	}
+	t, err := a.fetchTeam(ctx, id, userID)
+	if err == sql.ErrNoRows || (err == nil && !service && t.Role == "") {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "team not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if r.Method != http.MethodGet && !service && t.Role != teamRoleOwner {
+		writeJSON(w, http.StatusForbidden, map[string]string{"error": "team owner required"})
+		return
+	}
+	switch {
+	case len(parts) == 1:
+		a.handleTeam(w, r, t)
+	case len(parts) == 2:
+		a.handleTeamMembers(w, r, t)
+	default:
+		memberID, err := parseInt64(parts[2])
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid user id"})
+			return
+		}
+		a.handleTeamMember(w, r, t, memberID)
+	}
+}
+
+// handleTeam 处理单个团队：GET 详情，PATCH 重命名，DELETE 删除（看板归属该团队时一并取消）
+func (a *App) handleTeam(w http.ResponseWriter, r *http.Request, t Team) {
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodGet:
+		a.writeTeam(w, r, t.ID, http.StatusOK)
+	case http.MethodPatch, http.MethodPut:
+		name, ok := decodeTeamName(w, r)
+		if !ok {
+			return
+		}
+		if _, err := a.db.ExecContext(ctx, `UPDATE teams SET name = ? WHERE id = ?`, name, t.ID); err != nil {
+			if strings.Contains(err.Error(), "UNIQUE") {
+				writeJSON(w, http.StatusConflict, map[string]string{"error": "team name already exists"})
+				return
+			}
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		a.writeTeam(w, r, t.ID, http.StatusOK)
+	case http.MethodDelete:
+		tx, err := a.db.BeginTx(ctx, nil)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		defer tx.Rollback()
+		for _, stmt := range []string{
+			`UPDATE boards SET team_id = NULL WHERE team_id = ?`,
+			`DELETE FROM team_members WHERE team_id = ?`,
+			`DELETE FROM teams WHERE id = ?`,
+		} {
+			if _, err := tx.ExecContext(ctx, stmt, t.ID); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		a.reqLogger(r).Info("已删除团队", "name", t.Name)
+		writeJSON(w, http.StatusOK, map[string]any{"id": t.ID, "deleted": true})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// handleTeamMembers 处理 /api/teams/{id}/members：GET 列出成员，POST 按 user_id 或 email 添加成员
+func (a *App) handleTeamMembers(w http.ResponseWriter, r *http.Request, t Team) {
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodGet:
+		members, err := a.fetchTeamMembers(ctx, t.ID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"items": members})
+	case http.MethodPost:
+		var body teamMemberRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		if body.Role == "" {
+			body.Role = teamRoleMember
+		}
+		if !validTeamRole(body.Role) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "role must be owner or member"})
+			return
+		}
+		userID := body.UserID
+		var err error
+		switch {
+		case userID != 0:
+			err = a.db.QueryRowContext(ctx, `SELECT id FROM users WHERE id = ?`, userID).Scan(&userID)
+		case strings.TrimSpace(body.Email) != "":
+			err = a.db.QueryRowContext(ctx, `SELECT id FROM users WHERE email = ? COLLATE NOCASE ORDER BY id LIMIT 1`, strings.TrimSpace(body.Email)).Scan(&userID)
+		default:
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "user_id or email required"})
+			return
+		}
+		if err == sql.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "user not found; users appear after their first login"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		res, err := a.db.ExecContext(ctx, `INSERT OR IGNORE INTO team_members (team_id, user_id, role, created_at) VALUES (?, ?, ?, ?)`,
+			t.ID, userID, body.Role, time.Now().Format(time.RFC3339))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if n, _ := res.RowsAffected(); n == 0 {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "already a member"})
+			return
+		}
+		a.reqLogger(r).Info("已添加团队成员", "user_id", userID, "role", body.Role)
+		a.writeTeam(w, r, t.ID, http.StatusCreated)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// handleTeamMember 处理 /api/teams/{id}/members/{user_id}：PATCH 调整角色，DELETE 移除成员；不能移除或降级最后一名 owner
+func (a *App) handleTeamMember(w http.ResponseWriter, r *http.Request, t Team, memberID int64) {
+	ctx := r.Context()
+	role, err := a.teamRole(ctx, t.ID, memberID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if role == "" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "member not found"})
+		return
+	}
+	newRole := ""
+	switch r.Method {
+	case http.MethodPatch, http.MethodPut:
+		var body teamMemberRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		if !validTeamRole(body.Role) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "role must be owner or member"})
+			return
+		}
+		newRole = body.Role
+	case http.MethodDelete:
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	if role == teamRoleOwner && newRole != teamRoleOwner {
+		var owners int
+		if err := a.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM team_members WHERE team_id = ? AND role = ?`, t.ID, teamRoleOwner).Scan(&owners); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		if owners <= 1 {
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "team must keep at least one owner"})
+			return
+		}
+	}
+	if newRole == "" {
+		_, err = a.db.ExecContext(ctx, `DELETE FROM team_members WHERE team_id = ? AND user_id = ?`, t.ID, memberID)
+	} else {
+		_, err = a.db.ExecContext(ctx, `UPDATE team_members SET role = ? WHERE team_id = ? AND user_id = ?`, newRole, t.ID, memberID)
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	a.reqLogger(r).Info("已更新团队成员", "user_id", memberID, "role", newRole)
+	a.writeTeam(w, r, t.ID, http.StatusOK)
+}
+
+// handleBoardSettings 处理 /api/admin/board：GET 返回看板归属，PUT 设置归属团队（登录用户须为原团队与新团队的 owner）
+func (a *App) handleBoardSettings(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	current, err := a.boardTeamID(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPut:
+		var body boardSettingsRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		var next int64
+		if body.TeamID != nil {
+			next = *body.TeamID
+			if _, err := a.fetchTeam(ctx, next, 0); err == sql.ErrNoRows {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "team not found"})
+				return
+			} else if err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+		}
+		if !a.isServiceRequest(r) {
+			for _, teamID := range []int64{current, next} {
+				if teamID == 0 {
+					continue
+				}
+				role, err := a.teamRole(ctx, teamID, currentUserID(r))
+				if err != nil {
+					writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+					return
+				}
+				if role != teamRoleOwner {
+					writeJSON(w, http.StatusForbidden, map[string]string{"error": "team owner required"})
+					return
+				}
+			}
+			if currentUserID(r) == 0 {
+				writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "login required"})
+				return
+			}
+		}
+		var teamID any
+		if next != 0 {
+			teamID = next
+		}
+		if _, err := a.db.ExecContext(ctx, `INSERT INTO boards (id, team_id, updated_at) VALUES (?, ?, ?)
+			ON CONFLICT(id) DO UPDATE SET team_id = excluded.team_id, updated_at = excluded.updated_at`,
+			boardID, teamID, time.Now().Format(time.RFC3339)); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		a.reqLogger(r).Info("已设置看板归属团队", "from", current, "to", next)
+		current = next
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	resp := boardSettings{ID: boardID}
+	var updated sql.NullString
+	if err := a.db.QueryRowContext(ctx, `SELECT updated_at FROM boards WHERE id = ?`, boardID).Scan(&updated); err != nil && err != sql.ErrNoRows {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	resp.UpdatedAt = parseOptionalTime(updated)
+	if current != 0 {
+		t, err := a.fetchTeam(ctx, current, currentUserID(r))
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		resp.TeamID, resp.Team = &current, &t
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
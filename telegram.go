@@ -0,0 +1,340 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TelegramConfig 为 Telegram 机器人配置：token 非空时启用，向 chat_id 播报看板事件，
+// 并响应该会话中的 /add、/list 命令；其他会话的消息一律忽略
+type TelegramConfig struct {
+	Token         string   `yaml:"token"`
+	ChatID        int64    `yaml:"chat_id"`
+	APIURL        string   `yaml:"api_url"`
+	PollTimeout   Duration `yaml:"poll_timeout"`
+	EventInterval Duration `yaml:"event_interval"`
+}
+
+// telegramListLimit 为 /list 单次回复的最大任务数
+const telegramListLimit = 30
+
+// enabled 判断是否启用 Telegram 机器人
+func (c TelegramConfig) enabled() bool { return c.Token != "" }
+
+// validate 校验 Telegram 配置
+func (c TelegramConfig) validate() error {
+	if !c.enabled() {
+		return nil
+	}
+	if c.ChatID == 0 {
+		return fmt.Errorf("telegram.chat_id 不能为空")
+	}
+	u, err := url.Parse(c.APIURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("telegram.api_url 无效: %q", c.APIURL)
+	}
+	return nil
+}
+
+// telegramBot 为 Telegram Bot API 客户端
+type telegramBot struct {
+	cfg    TelegramConfig
+	client *http.Client
+}
+
+// telegramUpdate 为 getUpdates 返回的一条更新（仅保留用到的字段）
+type telegramUpdate struct {
+	UpdateID int64 `json:"update_id"`
+	Message  *struct {
+		Text string `json:"text"`
+		Chat struct {
+			ID int64 `json:"id"`
+		} `json:"chat"`
+	} `json:"message"`
+}
+
+// call 调用 Bot API 方法，并将 result 解码到 out（可为 nil）
+func (b *telegramBot) call(ctx context.Context, method string, params any, out any) error {
+	raw, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	endpoint := strings.TrimRight(b.cfg.APIURL, "/") + "/bot" + b.cfg.Token + "/" + method
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(raw))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := b.client.Do(req)
+	if err != nil {
+		// 错误信息中的 URL 含有 token，不原样输出
+		return fmt.Errorf("telegram %s 请求失败", method)
+	}
+	defer resp.Body.Close()
+	var envelope struct {
+		OK          bool            `json:"ok"`
+		Description string          `json:"description"`
+		Result      json.RawMessage `json:"result"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&envelope); err != nil {
+		return fmt.Errorf("telegram %s 返回 %s", method, resp.Status)
+	}
+	if !envelope.OK {
+		return fmt.Errorf("telegram %s 失败: %s", method, envelope.Description)
+	}
+	if out != nil {
+		return json.Unmarshal(envelope.Result, out)
+	}
+	return nil
+}
+
+// send 向配置的会话发送一条文本消息
+func (b *telegramBot) send(ctx context.Context, text string) error {
+	return b.call(ctx, "sendMessage", map[string]any{"chat_id": b.cfg.ChatID, "text": text}, nil)
+}
+
+// startTelegram 按配置启动 Telegram 机器人：一个后台任务长轮询命令，另一个播报任务事件
+func (a *App) startTelegram() {
+	c := a.cfg.Telegram
+	if !c.enabled() {
+		return
+	}
+	if c.PollTimeout <= 0 {
+		c.PollTimeout = Duration(30 * time.Second)
+	}
+	if c.EventInterval <= 0 {
+		c.EventInterval = Duration(5 * time.Second)
+	}
+	bot := &telegramBot{cfg: c, client: &http.Client{Timeout: c.PollTimeout.Std() + 10*time.Second}}
+	var cursor int64
+	if err := a.db.QueryRow(`SELECT COALESCE(MAX(id), 0) FROM task_events`).Scan(&cursor); err != nil {
+		a.logger.Error("Telegram 机器人启动失败", "err", err)
+		return
+	}
+	a.logger.Info("Telegram 机器人已启用", "chat_id", c.ChatID)
+	a.eventCursors.set("telegram-events", cursor)
+	a.startBackground("telegram-commands", func(ctx context.Context) {
+		a.pollTelegram(ctx, bot)
+	})
+	a.startBackground("telegram-events", func(ctx context.Context) {
+		ticker := time.NewTicker(c.EventInterval.Std())
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			next, err := a.announceTaskEvents(ctx, bot, cursor)
+			if err != nil && ctx.Err() == nil {
+				a.logger.Warn("Telegram 事件播报失败", "err", err)
+			}
+			cursor = next
+			a.eventCursors.set("telegram-events", cursor)
+		}
+	})
+}
+
+// pollTelegram 长轮询机器人收到的消息并执行命令，出错后等待片刻重试
+func (a *App) pollTelegram(ctx context.Context, bot *telegramBot) {
+	api := a.withAudit(nil, a.withCacheInvalidation(a.routes()))
+	ctx = withAuditActor(ctx, "telegram")
+	var offset int64
+	for ctx.Err() == nil {
+		var updates []telegramUpdate
+		err := bot.call(ctx, "getUpdates", map[string]any{
+			"offset":          offset,
+			"timeout":         int(bot.cfg.PollTimeout.Std().Seconds()),
+			"allowed_updates": []string{"message"},
+		}, &updates)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			a.logger.Warn("Telegram 拉取消息失败", "err", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+		for _, u := range updates {
+			offset = u.UpdateID + 1
+			if u.Message == nil || !strings.HasPrefix(u.Message.Text, "/") {
+				continue
+			}
+			if u.Message.Chat.ID != bot.cfg.ChatID {
+				a.logger.Debug("忽略其他会话的 Telegram 命令", "chat_id", u.Message.Chat.ID)
+				continue
+			}
+			reply := a.telegramCommand(ctx, api, u.UpdateID, u.Message.Text)
+			if err := bot.send(ctx, reply); err != nil && ctx.Err() == nil {
+				a.logger.Warn("Telegram 回复失败", "err", err)
+			}
+		}
+	}
+}
+
+// telegramCommand 执行一条命令并返回回复文本。命令通过应用自身的 HTTP 处理器调用现有任务 API，
+// 与网页端共享校验、数量上限与缓存失效逻辑
+func (a *App) telegramCommand(ctx context.Context, api http.Handler, updateID int64, text string) string {
+	cmd, arg, _ := strings.Cut(strings.TrimSpace(text), " ")
+	cmd, _, _ = strings.Cut(cmd, "@") // 群组中的命令形如 /add@board_bot
+	arg = strings.TrimSpace(arg)
+	switch cmd {
+	case "/add":
+		var title []string
+		tags := []string{}
+		for _, word := range strings.Fields(arg) {
+			if tag := strings.TrimPrefix(word, "#"); tag != word && tag != "" {
+				tags = append(tags, tag)
+				continue
+			}
+			title = append(title, word)
+		}
+		if len(title) == 0 {
+			return "用法：/add 任务标题 #标签"
+		}
+		body := taskCreateRequest{Title: strings.Join(title, " "), Tags: tags}
+		// 以更新编号作为幂等键，重复投递的消息不会重复创建任务
+		status, raw := callAPI(ctx, api, http.MethodPost, "/api/tasks", body, "telegram-"+strconv.FormatInt(updateID, 10))
+		var res struct {
+			ID    int64  `json:"id"`
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(raw, &res)
+		if status != http.StatusCreated {
+			return "创建失败：" + res.Error
+		}
+		return fmt.Sprintf("已创建 TB-%d「%s」", res.ID, body.Title)
+	case "/list":
+		if arg != "" && !validStatus(arg) {
+			return "未知状态「" + arg + "」，可选：" + strings.Join(taskStatuses, "、")
+		}
+		status, raw := callAPI(ctx, api, http.MethodGet, "/api/tasks", nil, "")
+		var res struct {
+			Items []Task `json:"items"`
+			Error string `json:"error"`
+		}
+		_ = json.Unmarshal(raw, &res)
+		if status != http.StatusOK {
+			return "查询失败：" + res.Error
+		}
+		return formatTelegramList(res.Items, arg)
+	case "/start", "/help":
+		return "命令：\n/add 任务标题 #标签 — 新建任务\n/list [状态] — 列出任务，状态可选：" + strings.Join(taskStatuses, "、")
+	default:
+		return "未知命令，发送 /help 查看用法"
+	}
+}
+
+// formatTelegramList 将任务列表格式化为回复文本，status 为空时按状态分组列出全部任务
+func formatTelegramList(tasks []Task, status string) string {
+	var b strings.Builder
+	n := 0
+	for _, s := range taskStatuses {
+		if status != "" && s != status {
+			continue
+		}
+		var lines []string
+		for _, t := range tasks {
+			if t.Status != s {
+				continue
+			}
+			if n++; n > telegramListLimit {
+				continue
+			}
+			line := fmt.Sprintf("TB-%d %s", t.ID, t.Title)
+			if len(t.Tags) > 0 {
+				line += " #" + strings.Join(t.Tags, " #")
+			}
+			lines = append(lines, line)
+		}
+		if len(lines) > 0 {
+			fmt.Fprintf(&b, "【%s】\n%s\n", s, strings.Join(lines, "\n"))
+		}
+	}
+	if n == 0 {
+		return "没有任务"
+	}
+	if n > telegramListLimit {
+		fmt.Fprintf(&b, "……共 %d 个，仅显示前 %d 个", n, telegramListLimit)
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// callAPI 以进程内请求调用应用的 HTTP 接口，返回状态码与响应体
+func callAPI(ctx context.Context, api http.Handler, method, path string, body any, idemKey string) (int, []byte) {
+	var raw []byte
+	if body != nil {
+		raw, _ = json.Marshal(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, path, bytes.NewReader(raw))
+	if err != nil {
+		return http.StatusInternalServerError, []byte(`{"error":"` + err.Error() + `"}`)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if idemKey != "" {
+		req.Header.Set("Idempotency-Key", idemKey)
+	}
+	buf := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+	api.ServeHTTP(buf, req)
+	return buf.status, buf.body.Bytes()
+}
+
+// announceTaskEvents 将 cursor 之后的任务事件播报到会话，返回新的游标；发送失败时停在失败的事件处下次重试
+func (a *App) announceTaskEvents(ctx context.Context, bot *telegramBot, cursor int64) (int64, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT e.id, e.task_id, e.kind, e.from_status, e.to_status, COALESCE(t.title, '')
+		FROM task_events e LEFT JOIN tasks t ON t.id = e.task_id
+		WHERE e.id > ? ORDER BY e.id LIMIT 50`, cursor)
+	if err != nil {
+		return cursor, err
+	}
+	type event struct {
+		id, taskID           int64
+		kind, from, to, name string
+	}
+	var events []event
+	for rows.Next() {
+		var e event
+		if err := rows.Scan(&e.id, &e.taskID, &e.kind, &e.from, &e.to, &e.name); err != nil {
+			rows.Close()
+			return cursor, err
+		}
+		events = append(events, e)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return cursor, err
+	}
+	locale := a.outboundLocale()
+	for _, e := range events {
+		var text string
+		switch e.kind {
+		case taskEventCreated:
+			text = translatef(locale, "新任务 TB-%d「%s」（%s）", e.taskID, e.name, e.to)
+		case taskEventStatus:
+			text = translatef(locale, "TB-%d「%s」：%s → %s", e.taskID, e.name, e.from, e.to)
+		case taskEventArchived:
+			text = translatef(locale, "TB-%d「%s」已归档", e.taskID, e.name)
+		case taskEventRestored:
+			text = translatef(locale, "TB-%d「%s」已恢复", e.taskID, e.name)
+		}
+		if text != "" {
+			if err := bot.send(ctx, text); err != nil {
+				return cursor, err
+			}
+		}
+		cursor = e.id
+	}
+	return cursor, nil
+}
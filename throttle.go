@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// 后台任务自动让路：数据库繁忙（最近一分钟内请求语句的平均耗时超过 db_latency）或数据目录所在磁盘剩余空间
+// 低于 min_free_disk_mb 时，定时任务（自动归档、停滞评论、周期任务、截止提醒、每日摘要）推迟执行，
+// 按 5s 起、至多 1m 的间隔退避重查，压力解除后立即执行；推迟超过 max_wait 后照常执行，避免任务长期饿死。
+// 当前压力同时作为 load 检查项出现在 /readyz 中
+
+// ThrottleConfig 为后台任务让路的配置
+type ThrottleConfig struct {
+	Enabled       bool     `yaml:"enabled"`
+	DBLatency     Duration `yaml:"db_latency"`       // 请求语句平均耗时阈值，0 表示不检查
+	MinFreeDiskMB int      `yaml:"min_free_disk_mb"` // 磁盘剩余空间阈值（MB），0 表示不检查
+	MaxWait       Duration `yaml:"max_wait"`         // 单次最多推迟的时长，0 表示一直等待
+}
+
+// 压力检查的退避间隔与请求语句耗时的统计窗口
+const (
+	throttleMinBackoff = 5 * time.Second
+	throttleMaxBackoff = time.Minute
+	throttleWindow     = time.Minute
+)
+
+// loadPressure 返回当前的负载压力说明，没有压力时返回空串
+func (a *App) loadPressure(now time.Time) string {
+	c := a.cfg.Throttle
+	var reasons []string
+	if limit := c.DBLatency.Std(); limit > 0 && a.queryStats != nil {
+		if lat := a.queryStats.interactiveLatency(now, throttleWindow); lat > limit {
+			reasons = append(reasons, fmt.Sprintf("数据库平均耗时 %s 超过 %s", lat.Round(time.Millisecond), limit))
+		}
+	}
+	if c.MinFreeDiskMB > 0 && a.cfg.Data.DSN == "" {
+		if free, err := diskFree(filepath.Dir(a.cfg.Data.dbPath())); err == nil && free < uint64(c.MinFreeDiskMB)<<20 {
+			reasons = append(reasons, fmt.Sprintf("磁盘剩余 %d MB 低于 %d MB", free>>20, c.MinFreeDiskMB))
+		}
+	}
+	return strings.Join(reasons, "；")
+}
+
+// loadCheck 返回 /readyz 中的负载检查项；未启用让路时返回 false
+func (a *App) loadCheck(now time.Time) (checkResult, bool) {
+	if !a.cfg.Throttle.Enabled {
+		return checkResult{}, false
+	}
+	if reason := a.loadPressure(now); reason != "" {
+		return checkResult{Name: "load", Status: checkDegraded, Message: reason + "，后台任务推迟执行"}, true
+	}
+	return checkResult{Name: "load", Status: checkOK}, true
+}
+
+// waitForHeadroom 在负载压力下推迟后台任务 job，直到压力解除或超过 max_wait；ctx 取消时返回其错误
+func (a *App) waitForHeadroom(ctx context.Context, job string) error {
+	c := a.cfg.Throttle
+	if !c.Enabled {
+		return nil
+	}
+	start := time.Now()
+	backoff := throttleMinBackoff
+	for {
+		reason := a.loadPressure(time.Now())
+		waited := time.Since(start).Round(time.Second)
+		if reason == "" {
+			if waited > 0 {
+				a.logger.Info("负载压力解除，后台任务恢复执行", "job", job, "waited", waited.String())
+			}
+			return nil
+		}
+		if maxWait := c.MaxWait.Std(); maxWait > 0 && waited >= maxWait {
+			a.logger.Warn("负载压力持续，后台任务照常执行", "job", job, "reason", reason, "waited", waited.String())
+			return nil
+		}
+		if waited == 0 {
+			a.logger.Info("负载压力较高，后台任务推迟执行", "job", job, "reason", reason)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, throttleMaxBackoff)
+	}
+}
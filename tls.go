@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// validate 检查配置组合是否合法
+func (s TLSConfig) validate() error {
+	if (s.CertFile == "") != (s.KeyFile == "") {
+		return fmt.Errorf("TLS_CERT 与 TLS_KEY 需同时设置")
+	}
+	if s.CertFile != "" && len(s.AutocertDomains) > 0 {
+		return fmt.Errorf("证书文件与自动证书模式不能同时启用")
+	}
+	return nil
+}
+
+// mode 返回当前的服务模式：http、tls 或 autocert
+func (s TLSConfig) mode() string {
+	switch {
+	case s.CertFile != "":
+		return "tls"
+	case len(s.AutocertDomains) > 0:
+		return "autocert"
+	default:
+		return "http"
+	}
+}
+
+// prepareServer 按 TLS 配置准备服务器，返回启动函数；
+// 自动证书模式下还会返回处理 ACME 挑战并将其余请求重定向到 HTTPS 的辅助服务器
+func (s TLSConfig) prepareServer(srv *http.Server) (serve func() error, challenge *http.Server) {
+	switch s.mode() {
+	case "tls":
+		return func() error { return srv.ListenAndServeTLS(s.CertFile, s.KeyFile) }, nil
+	case "autocert":
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			HostPolicy: autocert.HostWhitelist(s.AutocertDomains...),
+			Cache:      autocert.DirCache(s.AutocertCache),
+			Email:      s.AutocertEmail,
+		}
+		srv.TLSConfig = &tls.Config{GetCertificate: m.GetCertificate, MinVersion: tls.VersionTLS12, NextProtos: []string{"h2", "http/1.1", "acme-tls/1"}}
+		challenge = &http.Server{
+			Addr:         s.HTTPAddr,
+			Handler:      m.HTTPHandler(nil),
+			ReadTimeout:  srv.ReadTimeout,
+			WriteTimeout: srv.WriteTimeout,
+		}
+		return func() error { return srv.ListenAndServeTLS("", "") }, challenge
+	default:
+		return srv.ListenAndServe, nil
+	}
+}
@@ -0,0 +1,316 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// 状态流转规则：管理员按「来源列 → 目标列」登记规则，禁止某些流转或要求填写原因，在变更状态时检查。
+// 来源或目标可用 * 表示任意列，匹配时取最具体的规则：精确匹配 > 来源精确 > 目标精确 > 全通配；
+// 没有匹配的规则时允许流转。例如先登记「* → *」禁止，再逐条放行相邻列，即构成只允许按顺序推进的状态机。
+// 填写的原因以评论形式记录在任务上（kind 为 status_reason）
+
+// transitionAny 为匹配任意列的通配符
+const transitionAny = "*"
+
+// maxTransitionReasonLen 为流转原因的最大长度
+const maxTransitionReasonLen = 1000
+
+// TransitionRule 为一条状态流转规则；Allowed 为 false 时禁止流转，RequireReason 为 true 时须在请求中填写 reason
+type TransitionRule struct {
+	ID            int64     `json:"id"`
+	FromStatus    string    `json:"from_status"`
+	ToStatus      string    `json:"to_status"`
+	Allowed       bool      `json:"allowed"`
+	RequireReason bool      `json:"require_reason"`
+	Message       string    `json:"message"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// transitionRuleRequest 为创建与更新规则的请求体；更新时未提供的字段保持不变
+type transitionRuleRequest struct {
+	FromStatus    *string `json:"from_status"`
+	ToStatus      *string `json:"to_status"`
+	Allowed       *bool   `json:"allowed"`
+	RequireReason *bool   `json:"require_reason"`
+	Message       *string `json:"message"`
+}
+
+// transitionRuleListResponse 为规则列表的响应结构
+type transitionRuleListResponse struct {
+	Items []TransitionRule `json:"items"`
+}
+
+// transitionErrorResponse 为流转被规则拒绝时的 422 响应结构；Error 会按请求的语言翻译，
+// 客户端应以 ReasonRequired 区分「需要填写原因」与「不允许流转」
+type transitionErrorResponse struct {
+	Error          string `json:"error"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	RuleID         int64  `json:"rule_id"`
+	ReasonRequired bool   `json:"reason_required"`
+	Message        string `json:"message"`
+	// messageFormat 与 messageArgs 为规则未设置提示时生成 Message 所用的格式与参数，用于按请求的语言重新生成
+	messageFormat string
+	messageArgs   []any
+}
+
+// transitionRuleColumns 为查询规则时的列
+const transitionRuleColumns = `id, from_status, to_status, allowed, require_reason, message, created_at, updated_at`
+
+// scanTransitionRule 扫描一行规则记录
+func scanTransitionRule(row rowScanner) (TransitionRule, error) {
+	var t TransitionRule
+	var allowed, reason int
+	var created, updated string
+	err := row.Scan(&t.ID, &t.FromStatus, &t.ToStatus, &allowed, &reason, &t.Message, &created, &updated)
+	t.Allowed = allowed != 0
+	t.RequireReason = reason != 0
+	t.CreatedAt, _ = time.Parse(time.RFC3339, created)
+	t.UpdatedAt, _ = time.Parse(time.RFC3339, updated)
+	return t, err
+}
+
+// fetchTransitionRules 按来源、目标列顺序返回全部规则，通配规则排在最后
+func (a *App) fetchTransitionRules(ctx context.Context) ([]TransitionRule, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT `+transitionRuleColumns+` FROM status_transitions ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []TransitionRule{}
+	for rows.Next() {
+		t, err := scanTransitionRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	order := func(s string) int {
+		for i, st := range taskStatuses {
+			if st == s {
+				return i
+			}
+		}
+		return len(taskStatuses)
+	}
+	sort.SliceStable(items, func(i, j int) bool {
+		if fi, fj := order(items[i].FromStatus), order(items[j].FromStatus); fi != fj {
+			return fi < fj
+		}
+		return order(items[i].ToStatus) < order(items[j].ToStatus)
+	})
+	return items, nil
+}
+
+// matchTransitionRule 返回适用于 from → to 的最具体规则，没有时返回 nil
+func matchTransitionRule(rules []TransitionRule, from, to string) *TransitionRule {
+	for _, want := range [][2]string{{from, to}, {from, transitionAny}, {transitionAny, to}, {transitionAny, transitionAny}} {
+		for i := range rules {
+			if rules[i].FromStatus == want[0] && rules[i].ToStatus == want[1] {
+				return &rules[i]
+			}
+		}
+	}
+	return nil
+}
+
+// checkTransitionRules 检查 from → to 是否被流转规则允许；不允许或缺少原因时写入 422 并返回 false
+func (a *App) checkTransitionRules(ctx context.Context, w http.ResponseWriter, from, to, reason string) bool {
+	if from == "" || from == to {
+		return true
+	}
+	rules, err := a.fetchTransitionRules(ctx)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return false
+	}
+	rule := matchTransitionRule(rules, from, to)
+	switch {
+	case rule == nil:
+		return true
+	case !rule.Allowed:
+		resp := transitionErrorResponse{Error: "transition not allowed", From: from, To: to, RuleID: rule.ID, Message: rule.Message}
+		if resp.Message == "" {
+			resp.messageFormat, resp.messageArgs = "不允许从「%s」直接变更为「%s」", []any{from, to}
+			resp.Message = fmt.Sprintf(resp.messageFormat, resp.messageArgs...)
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, resp)
+		return false
+	case rule.RequireReason && strings.TrimSpace(reason) == "":
+		resp := transitionErrorResponse{Error: "reason required", From: from, To: to, RuleID: rule.ID, ReasonRequired: true, Message: rule.Message}
+		if resp.Message == "" {
+			resp.messageFormat, resp.messageArgs = "从「%s」变更为「%s」需要填写原因（reason）", []any{from, to}
+			resp.Message = fmt.Sprintf(resp.messageFormat, resp.messageArgs...)
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, resp)
+		return false
+	}
+	return true
+}
+
+// recordTransitionReason 将状态变更的原因记录为任务评论
+func (a *App) recordTransitionReason(ctx context.Context, r *http.Request, taskID int64, from, to, reason string) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return
+	}
+	body := fmt.Sprintf("「%s」→「%s」：%s", from, to, reason)
+	if _, err := a.addTaskComment(ctx, TaskComment{TaskID: taskID, Author: signedClient(r), Kind: commentKindStatusReason, Body: body, CreatedAt: time.Now()}); err != nil {
+		a.reqLogger(r).Error("记录状态变更原因失败", "err", err)
+	}
+}
+
+// validTransitionStatus 判断是否为合法的规则来源或目标
+func validTransitionStatus(s string) bool {
+	return s == transitionAny || validStatus(s)
+}
+
+// applyTransitionRuleRequest 将请求合并到规则并校验
+func applyTransitionRuleRequest(t *TransitionRule, body transitionRuleRequest) error {
+	if body.FromStatus != nil {
+		t.FromStatus = strings.TrimSpace(*body.FromStatus)
+	}
+	if body.ToStatus != nil {
+		t.ToStatus = strings.TrimSpace(*body.ToStatus)
+	}
+	if body.Allowed != nil {
+		t.Allowed = *body.Allowed
+	}
+	if body.RequireReason != nil {
+		t.RequireReason = *body.RequireReason
+	}
+	if body.Message != nil {
+		t.Message = strings.TrimSpace(*body.Message)
+	}
+	if !validTransitionStatus(t.FromStatus) || !validTransitionStatus(t.ToStatus) {
+		return fmt.Errorf("from_status and to_status must be a status or *")
+	}
+	if t.FromStatus == t.ToStatus && t.FromStatus != transitionAny {
+		return fmt.Errorf("from_status and to_status must differ")
+	}
+	if len([]rune(t.Message)) > maxValidationMessageLen {
+		return fmt.Errorf("message too long")
+	}
+	return nil
+}
+
+// handleTransitionRules 处理 /api/admin/transitions：GET 列出规则，POST 新增规则（同一来源与目标已有规则时返回 409）
+func (a *App) handleTransitionRules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodGet:
+		items, err := a.fetchTransitionRules(ctx)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, transitionRuleListResponse{Items: items})
+	case http.MethodPost:
+		var body transitionRuleRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		t := TransitionRule{Allowed: true}
+		if err := applyTransitionRuleRequest(&t, body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		a.saveTransitionRule(w, r, t, http.StatusCreated)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// saveTransitionRule 写入新规则（t.ID 为 0）或更新已有规则，并返回保存后的规则
+func (a *App) saveTransitionRule(w http.ResponseWriter, r *http.Request, t TransitionRule, status int) {
+	ctx := r.Context()
+	var n int
+	if err := a.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM status_transitions WHERE from_status = ? AND to_status = ? AND id <> ?`, t.FromStatus, t.ToStatus, t.ID).Scan(&n); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if n > 0 {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "rule for this transition already exists"})
+		return
+	}
+	now := time.Now().Format(time.RFC3339)
+	if t.ID == 0 {
+		res, err := a.db.ExecContext(ctx, `INSERT INTO status_transitions (from_status, to_status, allowed, require_reason, message, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			t.FromStatus, t.ToStatus, boolToInt(t.Allowed), boolToInt(t.RequireReason), t.Message, now, now)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		t.ID, _ = res.LastInsertId()
+		a.reqLogger(r).Info("已添加状态流转规则", "rule_id", t.ID, "from", t.FromStatus, "to", t.ToStatus)
+	} else {
+		if _, err := a.db.ExecContext(ctx, `UPDATE status_transitions SET from_status = ?, to_status = ?, allowed = ?, require_reason = ?, message = ?, updated_at = ? WHERE id = ?`,
+			t.FromStatus, t.ToStatus, boolToInt(t.Allowed), boolToInt(t.RequireReason), t.Message, now, t.ID); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		a.reqLogger(r).Info("已更新状态流转规则", "rule_id", t.ID, "from", t.FromStatus, "to", t.ToStatus)
+	}
+	saved, err := scanTransitionRule(a.db.QueryRowContext(ctx, `SELECT `+transitionRuleColumns+` FROM status_transitions WHERE id = ?`, t.ID))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, status, saved)
+}
+
+// handleTransitionRuleItem 处理 /api/admin/transitions/{id}：GET 返回规则，PUT/PATCH 更新，DELETE 删除
+func (a *App) handleTransitionRuleItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := parseInt64(strings.TrimPrefix(r.URL.Path, "/api/admin/transitions/"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	addLogAttrs(r, "rule_id", id)
+	t, err := scanTransitionRule(a.db.QueryRowContext(ctx, `SELECT `+transitionRuleColumns+` FROM status_transitions WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "rule not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, t)
+	case http.MethodPut, http.MethodPatch:
+		var body transitionRuleRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		if err := applyTransitionRuleRequest(&t, body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		a.saveTransitionRule(w, r, t, http.StatusOK)
+	case http.MethodDelete:
+		if _, err := a.db.ExecContext(ctx, `DELETE FROM status_transitions WHERE id = ?`, id); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		a.reqLogger(r).Info("已删除状态流转规则", "from", t.FromStatus, "to", t.ToStatus)
+		writeJSON(w, http.StatusOK, map[string]any{"id": id, "deleted": true})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
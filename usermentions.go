@@ -0,0 +1,188 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// 描述与评论中的 @ 提及：与编号引用一起在保存描述或添加评论时解析，@ 后为用户邮箱或邮箱 @ 之前的部分
+// （不区分大小写，多个用户的邮箱前缀相同时都算被提及），结果记入 user_mentions（按来源分别记录）。
+// 再次保存时只增删有变化的记录，已有的提及保持原编号，站内通知按新增的记录生成，不会因编辑描述重复提醒。
+// 前端输入 @ 时通过 /api/users/suggest 补全
+
+// userMentionPattern 匹配文本中的 @ 提及，如 @alice 或 @alice@example.com
+var userMentionPattern = regexp.MustCompile(`(?:^|[^\w.@])@([\w.+-]+(?:@[\w-]+(?:\.[\w-]+)+)?)`)
+
+// userSuggestLimit 为补全结果的缺省与最大条数
+const (
+	userSuggestLimit    = 10
+	maxUserSuggestLimit = 50
+)
+
+// userSuggestion 为一个补全候选；Handle 为 @ 之后应填写的内容，邮箱前缀与他人重复时为完整邮箱
+type userSuggestion struct {
+	ID        int64  `json:"id"`
+	Name      string `json:"name"`
+	Handle    string `json:"handle"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+}
+
+// userSuggestResponse 为 /api/users/suggest 的响应结构
+type userSuggestResponse struct {
+	Items []userSuggestion `json:"items"`
+}
+
+// mentionHandles 返回文本中 @ 提到的标识（小写、去重）
+func mentionHandles(text string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, m := range userMentionPattern.FindAllStringSubmatch(text, -1) {
+		h := strings.ToLower(strings.TrimRight(m[1], "."))
+		if h != "" && !seen[h] {
+			seen[h] = true
+			out = append(out, h)
+		}
+	}
+	return out
+}
+
+// mentionedUserIDs 将文本中的 @ 提及解析为用户编号，未对应任何用户的标识被忽略
+func (a *App) mentionedUserIDs(ctx context.Context, text string) ([]int64, error) {
+	var out []int64
+	seen := map[int64]bool{}
+	for _, h := range mentionHandles(text) {
+		q := `SELECT id FROM users WHERE email <> '' AND lower(substr(email, 1, instr(email, '@') - 1)) = ?`
+		if strings.Contains(h, "@") {
+			q = `SELECT id FROM users WHERE lower(email) = ?`
+		}
+		ids, err := a.queryIDs(ctx, q, h)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				out = append(out, id)
+			}
+		}
+	}
+	return out, nil
+}
+
+// syncUserMentions 使某一来源的 @ 提及记录与文本一致：删除不再提及的用户，补充新提及的用户
+func (a *App) syncUserMentions(ctx context.Context, taskID int64, origin string, originID int64, text string) error {
+	ids, err := a.mentionedUserIDs(ctx, text)
+	if err != nil {
+		return err
+	}
+	keep := map[int64]bool{}
+	for _, id := range ids {
+		keep[id] = true
+	}
+	existing, err := a.queryIDs(ctx, `SELECT user_id FROM user_mentions WHERE task_id = ? AND origin = ? AND origin_id = ?`, taskID, origin, originID)
+	if err != nil {
+		return err
+	}
+	for _, id := range existing {
+		if keep[id] {
+			delete(keep, id)
+			continue
+		}
+		if _, err := a.db.ExecContext(ctx, `DELETE FROM user_mentions WHERE task_id = ? AND origin = ? AND origin_id = ? AND user_id = ?`,
+			taskID, origin, originID, id); err != nil {
+			return err
+		}
+	}
+	now := time.Now().Format(time.RFC3339)
+	for _, id := range ids {
+		if !keep[id] {
+			continue
+		}
+		if _, err := a.db.ExecContext(ctx, `INSERT OR IGNORE INTO user_mentions (task_id, user_id, origin, origin_id, created_at) VALUES (?, ?, ?, ?, ?)`,
+			taskID, id, origin, originID, now); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// handleUserSuggest 处理 GET /api/users/suggest?q=&limit=：补全 @ 提及，返回姓名包含 q 或邮箱以 q 开头的用户，需登录；
+// 姓名或邮箱以 q 开头的排在前面，其余按最近登录排列
+func (a *App) handleUserSuggest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	if currentUserID(r) == 0 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "not logged in"})
+		return
+	}
+	q := strings.ToLower(strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(r.URL.Query().Get("q")), "@")))
+	limit := int64(userSuggestLimit)
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := parseInt64(v)
+		if err != nil || n <= 0 || n > maxUserSuggestLimit {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid limit"})
+			return
+		}
+		limit = n
+	}
+	escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(q)
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT id, name, email, avatar_url,
+			(lower(name) LIKE ? ESCAPE '\' OR lower(email) LIKE ? ESCAPE '\') AS prefix
+		FROM users
+		WHERE email <> '' AND (lower(name) LIKE ? ESCAPE '\' OR lower(email) LIKE ? ESCAPE '\')
+		ORDER BY prefix DESC, COALESCE(last_login_at, created_at) DESC, id
+		LIMIT ?`,
+		escaped+"%", escaped+"%", "%"+escaped+"%", escaped+"%", limit)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	type candidate struct {
+		s     userSuggestion
+		email string
+	}
+	var found []candidate
+	for rows.Next() {
+		var c candidate
+		var prefix bool
+		if err := rows.Scan(&c.s.ID, &c.s.Name, &c.email, &c.s.AvatarURL, &prefix); err != nil {
+			rows.Close()
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		found = append(found, c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	resp := userSuggestResponse{Items: []userSuggestion{}}
+	for _, c := range found {
+		email := strings.ToLower(c.email)
+		local, _, _ := strings.Cut(email, "@")
+		var n int
+		if err := a.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE lower(substr(email, 1, instr(email, '@') - 1)) = ?`, local).Scan(&n); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		c.s.Handle = local
+		if n > 1 {
+			c.s.Handle = email
+		}
+		resp.Items = append(resp.Items, c.s)
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// mentionedTaskIDs 返回描述或评论中 @ 提到该用户的任务
+func (a *App) mentionedTaskIDs(ctx context.Context, userID int64) ([]int64, error) {
+	return a.queryIDs(ctx, `SELECT DISTINCT task_id FROM user_mentions WHERE user_id = ?`, userID)
+}
@@ -0,0 +1,359 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// 自定义校验规则：管理员登记的表达式（语法见 celexpr.go）在任务创建、更新与变更状态时求值，
+// 结果不为 true 即视为违反，请求以 422 返回各规则的字段错误。表达式可见的变量：
+//
+//	task    { id, title, description, status, tags, start_at, due_at, acceptance_criteria, external_links, created_at }
+//	action  "create"、"update" 或 "status"
+//	now     当前时间
+//
+// 例如「带 release 标签的任务必须有截止时间」：!("release" in task.tags) || task.due_at != null。
+// 求值出错（如对 null 比较大小）同样视为违反，并记录警告日志，便于管理员修正规则。
+
+// 规则名称与提示信息的最大长度
+const (
+	maxValidationNameLen    = 100
+	maxValidationMessageLen = 500
+)
+
+// validationFields 为规则可以关联的任务字段，空串表示不针对具体字段
+var validationFields = []string{"", "title", "description", "status", "tags", "start_at", "due_at", "acceptance_criteria", "external_links"}
+
+// ValidationRule 为一条自定义校验规则；Field 为违反时报告的字段
+type ValidationRule struct {
+	ID         int64     `json:"id"`
+	Name       string    `json:"name"`
+	Expression string    `json:"expression"`
+	Field      string    `json:"field"`
+	Message    string    `json:"message"`
+	Enabled    bool      `json:"enabled"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// validationRuleRequest 为创建与更新规则的请求体；更新时未提供的字段保持不变
+type validationRuleRequest struct {
+	Name       *string `json:"name"`
+	Expression *string `json:"expression"`
+	Field      *string `json:"field"`
+	Message    *string `json:"message"`
+	Enabled    *bool   `json:"enabled"`
+}
+
+// validationRuleListResponse 为规则列表的响应结构
+type validationRuleListResponse struct {
+	Items []ValidationRule `json:"items"`
+}
+
+// fieldError 为一条字段校验错误
+type fieldError struct {
+	Field   string `json:"field,omitempty"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// validationErrorResponse 为违反校验规则时的 422 响应结构
+type validationErrorResponse struct {
+	Error  string       `json:"error"`
+	Fields []fieldError `json:"fields"`
+}
+
+// validationSampleTask 为保存规则时试算表达式所用的任务，各字段均有值
+var validationSampleTask = Task{
+	ID: 1, Title: "示例任务", Description: "说明", Status: "规划中", Tags: []string{"release"},
+	StartAt: &time.Time{}, DueAt: &time.Time{},
+	AcceptanceCriteria: []string{"通过评审"}, ExternalLinks: []TaskLink{{Title: "文档", URL: "https://example.com"}},
+}
+
+// taskExprValue 将任务转换为表达式中的 task 变量
+func taskExprValue(t Task) map[string]any {
+	list := func(items []string) []any {
+		out := make([]any, len(items))
+		for i, s := range items {
+			out[i] = s
+		}
+		return out
+	}
+	optional := func(p *time.Time) any {
+		if p == nil {
+			return nil
+		}
+		return *p
+	}
+	links := make([]any, len(t.ExternalLinks))
+	for i, l := range t.ExternalLinks {
+		links[i] = map[string]any{"title": l.Title, "url": l.URL}
+	}
+	return map[string]any{
+		"id":                  t.ID,
+		"title":               t.Title,
+		"description":         t.Description,
+		"status":              t.Status,
+		"tags":                list(t.Tags),
+		"start_at":            optional(t.StartAt),
+		"due_at":              optional(t.DueAt),
+		"acceptance_criteria": list(t.AcceptanceCriteria),
+		"external_links":      links,
+		"created_at":          t.CreatedAt,
+	}
+}
+
+// validationEnv 返回规则求值的变量
+func validationEnv(action string, t Task, now time.Time) exprEnv {
+	return exprEnv{"task": taskExprValue(t), "action": action, "now": now}
+}
+
+// fetchValidationRules 按编号返回规则，enabledOnly 为 true 时只返回启用的规则
+func (a *App) fetchValidationRules(ctx context.Context, enabledOnly bool) ([]ValidationRule, error) {
+	q := `SELECT id, name, expression, field, message, enabled, created_at, updated_at FROM validation_rules`
+	if enabledOnly {
+		q += ` WHERE enabled = 1`
+	}
+	rows, err := a.db.QueryContext(ctx, q+` ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []ValidationRule{}
+	for rows.Next() {
+		v, err := scanValidationRule(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, rows.Err()
+}
+
+// scanValidationRule 扫描一行规则记录
+func scanValidationRule(row rowScanner) (ValidationRule, error) {
+	var v ValidationRule
+	var enabled int
+	var created, updated string
+	err := row.Scan(&v.ID, &v.Name, &v.Expression, &v.Field, &v.Message, &enabled, &created, &updated)
+	v.Enabled = enabled != 0
+	v.CreatedAt, _ = time.Parse(time.RFC3339, created)
+	v.UpdatedAt, _ = time.Parse(time.RFC3339, updated)
+	return v, err
+}
+
+// evalValidationRules 对任务求值给定的规则，返回违反的规则
+func evalValidationRules(logger *slog.Logger, rules []ValidationRule, action string, t Task) []fieldError {
+	env := validationEnv(action, t, time.Now())
+	var errs []fieldError
+	for _, rule := range rules {
+		node, err := compileExpr(rule.Expression)
+		var v any
+		if err == nil {
+			v, err = node.eval(env)
+		}
+		if err != nil {
+			logger.Warn("校验规则求值失败", "rule", rule.Name, "err", err)
+		}
+		if ok, _ := v.(bool); !ok || err != nil {
+			errs = append(errs, fieldError{Field: rule.Field, Rule: rule.Name, Message: rule.Message})
+		}
+	}
+	return errs
+}
+
+// checkValidationRules 在写入前对任务求值启用的规则；有违反时写入 422 并返回 false。
+// build 仅在存在启用的规则时调用，用于构造写入后的任务
+func (a *App) checkValidationRules(w http.ResponseWriter, r *http.Request, action string, build func() (Task, error)) bool {
+	rules, err := a.fetchValidationRules(r.Context(), true)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return false
+	}
+	if len(rules) == 0 {
+		return true
+	}
+	t, err := build()
+	if err == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return false
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return false
+	}
+	if errs := evalValidationRules(a.reqLogger(r), rules, action, t); len(errs) > 0 {
+		writeJSON(w, http.StatusUnprocessableEntity, validationErrorResponse{Error: "validation failed", Fields: errs})
+		return false
+	}
+	return true
+}
+
+// applyValidationRuleRequest 将请求合并到规则并校验；表达式会在示例任务上试算，须得到 bool
+func applyValidationRuleRequest(v *ValidationRule, body validationRuleRequest) error {
+	if body.Name != nil {
+		v.Name = strings.TrimSpace(*body.Name)
+	}
+	if body.Expression != nil {
+		v.Expression = strings.TrimSpace(*body.Expression)
+	}
+	if body.Field != nil {
+		v.Field = strings.TrimSpace(*body.Field)
+	}
+	if body.Message != nil {
+		v.Message = strings.TrimSpace(*body.Message)
+	}
+	if body.Enabled != nil {
+		v.Enabled = *body.Enabled
+	}
+	if v.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len([]rune(v.Name)) > maxValidationNameLen {
+		return fmt.Errorf("name too long")
+	}
+	valid := false
+	for _, f := range validationFields {
+		valid = valid || f == v.Field
+	}
+	if !valid {
+		return fmt.Errorf("field must be one of %s", strings.Join(validationFields[1:], ", "))
+	}
+	if v.Message == "" {
+		v.Message = "violates rule " + v.Name
+	}
+	if len([]rune(v.Message)) > maxValidationMessageLen {
+		return fmt.Errorf("message too long")
+	}
+	if v.Expression == "" {
+		return fmt.Errorf("expression is required")
+	}
+	node, err := compileExpr(v.Expression)
+	if err != nil {
+		return fmt.Errorf("expression: %w", err)
+	}
+	res, err := node.eval(validationEnv("create", validationSampleTask, time.Now()))
+	if err != nil {
+		return fmt.Errorf("expression: %w", err)
+	}
+	if _, ok := res.(bool); !ok {
+		return fmt.Errorf("expression must evaluate to bool, got %s", exprTypeName(res))
+	}
+	return nil
+}
+
+// handleValidationRules 处理 /api/admin/validation-rules：GET 列出规则，POST 新增规则（名称重复返回 409）
+func (a *App) handleValidationRules(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodGet:
+		items, err := a.fetchValidationRules(ctx, false)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, validationRuleListResponse{Items: items})
+	case http.MethodPost:
+		var body validationRuleRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		v := ValidationRule{Enabled: true}
+		if err := applyValidationRuleRequest(&v, body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		a.saveValidationRule(w, r, v, http.StatusCreated)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// saveValidationRule 写入新规则（v.ID 为 0）或更新已有规则，并返回保存后的规则
+func (a *App) saveValidationRule(w http.ResponseWriter, r *http.Request, v ValidationRule, status int) {
+	ctx := r.Context()
+	var n int
+	if err := a.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM validation_rules WHERE name = ? AND id <> ?`, v.Name, v.ID).Scan(&n); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if n > 0 {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "rule name already exists"})
+		return
+	}
+	now := time.Now().Format(time.RFC3339)
+	if v.ID == 0 {
+		res, err := a.db.ExecContext(ctx, `INSERT INTO validation_rules (name, expression, field, message, enabled, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?, ?)`,
+			v.Name, v.Expression, v.Field, v.Message, boolToInt(v.Enabled), now, now)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		v.ID, _ = res.LastInsertId()
+		a.reqLogger(r).Info("已添加校验规则", "rule_id", v.ID, "name", v.Name)
+	} else {
+		if _, err := a.db.ExecContext(ctx, `UPDATE validation_rules SET name = ?, expression = ?, field = ?, message = ?, enabled = ?, updated_at = ? WHERE id = ?`,
+			v.Name, v.Expression, v.Field, v.Message, boolToInt(v.Enabled), now, v.ID); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		a.reqLogger(r).Info("已更新校验规则", "rule_id", v.ID, "name", v.Name)
+	}
+	saved, err := scanValidationRule(a.db.QueryRowContext(ctx, `SELECT id, name, expression, field, message, enabled, created_at, updated_at FROM validation_rules WHERE id = ?`, v.ID))
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, status, saved)
+}
+
+// handleValidationRuleItem 处理 /api/admin/validation-rules/{id}：GET 返回规则，PUT/PATCH 更新，DELETE 删除
+func (a *App) handleValidationRuleItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	id, err := parseInt64(strings.TrimPrefix(r.URL.Path, "/api/admin/validation-rules/"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	addLogAttrs(r, "rule_id", id)
+	v, err := scanValidationRule(a.db.QueryRowContext(ctx, `SELECT id, name, expression, field, message, enabled, created_at, updated_at FROM validation_rules WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "rule not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, v)
+	case http.MethodPut, http.MethodPatch:
+		var body validationRuleRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		if err := applyValidationRuleRequest(&v, body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		a.saveValidationRule(w, r, v, http.StatusOK)
+	case http.MethodDelete:
+		if _, err := a.db.ExecContext(ctx, `DELETE FROM validation_rules WHERE id = ?`, id); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		a.reqLogger(r).Info("已删除校验规则", "name", v.Name)
+		writeJSON(w, http.StatusOK, map[string]any{"id": id, "deleted": true})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
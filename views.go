@@ -0,0 +1,433 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// maxViewNameLen 为视图名称的最大长度
+const maxViewNameLen = 100
+
+// viewSortColumns 为视图可用的排序字段及对应的列；计划时间为空的任务总是排在最后
+var viewSortColumns = map[string]string{
+	"id":                "id",
+	"title":             "title",
+	"created_at":        "created_at",
+	"updated_at":        "updated_at",
+	"start_at":          "start_at",
+	"due_at":            "due_at",
+	"status_changed_at": "status_changed_at",
+}
+
+// ViewFilters 为视图的过滤条件，各条件同时满足。Tags 要求任务带有全部标签，Q 支持任务搜索的查询语法；
+// Starting 与 Due 取 today、this_week、next_week、this_month，Due 另支持 overdue（已过截止时间且未完成）
+type ViewFilters struct {
+	Statuses []string `json:"statuses,omitempty"`
+	Tags     []string `json:"tags,omitempty"`
+	Q        string   `json:"q,omitempty"`
+	Archived bool     `json:"archived,omitempty"`
+	Starting string   `json:"starting,omitempty"`
+	Due      string   `json:"due,omitempty"`
+}
+
+// ViewSort 为视图的排序方式，Order 为 asc 或 desc，同值时按 id 倒序
+type ViewSort struct {
+	Field string `json:"field"`
+	Order string `json:"order"`
+}
+
+// View 为保存的视图：命名的过滤条件与排序组合，如「紧急后端」
+type View struct {
+	ID        int64       `json:"id"`
+	Name      string      `json:"name"`
+	Filters   ViewFilters `json:"filters"`
+	Sort      ViewSort    `json:"sort"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// viewRequest 为创建与更新视图的请求体；更新时未提供的字段保持不变
+type viewRequest struct {
+	Name    *string      `json:"name"`
+	Filters *ViewFilters `json:"filters"`
+	Sort    *ViewSort    `json:"sort"`
+}
+
+// viewListResponse 为视图列表的响应结构
+type viewListResponse struct {
+	Items []View `json:"items"`
+}
+
+// viewTasksResponse 为执行视图的响应结构，分页参数与归档列表一致
+type viewTasksResponse struct {
+	View     View   `json:"view"`
+	Items    []Task `json:"items"`
+	Total    int64  `json:"total"`
+	Page     int64  `json:"page"`
+	PageSize int64  `json:"page_size"`
+	HasMore  bool   `json:"has_more"`
+}
+
+// normalize 校验并规范化过滤条件：去除空白、规范化标签并去重
+func (f *ViewFilters) normalize(tags TagsConfig) error {
+	var statuses []string
+	seen := map[string]bool{}
+	for _, s := range f.Statuses {
+		s = strings.TrimSpace(s)
+		if !validStatus(s) {
+			return fmt.Errorf("invalid status %q", s)
+		}
+		if !seen[s] {
+			seen[s] = true
+			statuses = append(statuses, s)
+		}
+	}
+	f.Statuses = statuses
+	if f.Tags = tags.normalizeAll(f.Tags); len(f.Tags) == 0 {
+		f.Tags = nil
+	}
+	f.Q = strings.TrimSpace(f.Q)
+	if _, err := parseSearchQuery(f.Q, tags); err != nil {
+		return fmt.Errorf("invalid query: %w", err)
+	}
+	f.Starting = strings.TrimSpace(f.Starting)
+	if f.Starting != "" {
+		if _, _, err := startingWindow(f.Starting, time.Now()); err != nil {
+			return err
+		}
+	}
+	f.Due = strings.TrimSpace(f.Due)
+	if f.Due != "" && f.Due != "overdue" {
+		if _, _, err := startingWindow(f.Due, time.Now()); err != nil {
+			return fmt.Errorf("invalid due window %q", f.Due)
+		}
+	}
+	return nil
+}
+
+// normalize 校验排序方式并补齐默认值（id 倒序，与看板列表一致）
+func (s *ViewSort) normalize() error {
+	s.Field = strings.TrimSpace(s.Field)
+	if s.Field == "" {
+		s.Field = "id"
+	}
+	if _, ok := viewSortColumns[s.Field]; !ok {
+		return fmt.Errorf("invalid sort field %q", s.Field)
+	}
+	s.Order = strings.ToLower(strings.TrimSpace(s.Order))
+	switch s.Order {
+	case "":
+		s.Order = "desc"
+	case "asc", "desc":
+	default:
+		return fmt.Errorf("sort order must be asc or desc")
+	}
+	return nil
+}
+
+// taskQuery 将视图转换为任务查询的条件与排序子句
+func (v View) taskQuery(tags TagsConfig, search SearchConfig, now time.Time) (cond string, args []any, order string) {
+	f := v.Filters
+	cond = "WHERE archived = ?"
+	args = []any{boolToInt(f.Archived)}
+	if len(f.Statuses) > 0 {
+		cond += " AND status IN (?" + strings.Repeat(", ?", len(f.Statuses)-1) + ")"
+		for _, s := range f.Statuses {
+			args = append(args, s)
+		}
+	}
+	if len(f.Tags) > 0 {
+		cond += " AND id IN (SELECT task_id FROM task_tags WHERE tag IN (?" + strings.Repeat(", ?", len(f.Tags)-1) + ") GROUP BY task_id HAVING COUNT(DISTINCT tag) = ?)"
+		for _, tag := range f.Tags {
+			args = append(args, tag)
+		}
+		args = append(args, len(f.Tags))
+	}
+	if f.Q != "" {
+		// 查询在保存时已校验
+		sq, _ := parseSearchQuery(f.Q, tags)
+		if qc, qargs := sq.where(search); qc != "" {
+			cond += " AND " + qc
+			args = append(args, qargs...)
+		}
+	}
+	// 窗口名称在保存时已校验
+	if f.Starting != "" {
+		from, to, _ := startingWindow(f.Starting, now)
+		cond += " AND start_at >= ? AND start_at < ?"
+		args = append(args, from, to)
+	}
+	switch f.Due {
+	case "":
+	case "overdue":
+		cond += " AND due_at < ? AND status <> ?"
+		args = append(args, now.UTC().Format(time.RFC3339), "已完成")
+	default:
+		from, to, _ := startingWindow(f.Due, now)
+		cond += " AND due_at >= ? AND due_at < ?"
+		args = append(args, from, to)
+	}
+	col := viewSortColumns[v.Sort.Field]
+	order = "ORDER BY "
+	if col == "start_at" || col == "due_at" {
+		order += col + " IS NULL, "
+	}
+	order += col + " " + strings.ToUpper(v.Sort.Order)
+	if col != "id" {
+		order += ", id DESC"
+	}
+	return cond, args, order
+}
+
+// scanView 扫描一行视图记录
+func scanView(row rowScanner) (View, error) {
+	var v View
+	var filters, created, updated string
+	if err := row.Scan(&v.ID, &v.Name, &filters, &v.Sort.Field, &v.Sort.Order, &created, &updated); err != nil {
+		return v, err
+	}
+	if err := json.Unmarshal([]byte(filters), &v.Filters); err != nil {
+		return v, fmt.Errorf("view %d: invalid filters: %w", v.ID, err)
+	}
+	v.CreatedAt, _ = time.Parse(time.RFC3339, created)
+	v.UpdatedAt, _ = time.Parse(time.RFC3339, updated)
+	return v, nil
+}
+
+// viewColumns 为视图查询的列，顺序与 scanView 一致
+const viewColumns = `id, name, filters, sort_field, sort_order, created_at, updated_at`
+
+// fetchView 返回单个视图
+func (a *App) fetchView(ctx context.Context, id int64) (View, error) {
+	return scanView(a.db.QueryRowContext(ctx, `SELECT `+viewColumns+` FROM views WHERE id = ?`, id))
+}
+
+// fetchViews 按创建顺序返回全部视图，即界面上视图标签页的顺序
+func (a *App) fetchViews(ctx context.Context) ([]View, error) {
+	rows, err := a.db.QueryContext(ctx, `SELECT `+viewColumns+` FROM views ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := []View{}
+	for rows.Next() {
+		v, err := scanView(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, v)
+	}
+	return items, rows.Err()
+}
+
+// applyViewRequest 将请求合并到视图并校验；返回的错误信息可直接作为 400 响应
+func (a *App) applyViewRequest(v *View, body viewRequest) error {
+	if body.Name != nil {
+		v.Name = strings.TrimSpace(*body.Name)
+	}
+	if v.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len([]rune(v.Name)) > maxViewNameLen {
+		return fmt.Errorf("name too long")
+	}
+	if body.Filters != nil {
+		v.Filters = *body.Filters
+	}
+	if err := v.Filters.normalize(a.cfg.Tags); err != nil {
+		return err
+	}
+	if body.Sort != nil {
+		v.Sort = *body.Sort
+	}
+	return v.Sort.normalize()
+}
+
+// viewNameTaken 检查名称是否已被其他视图使用
+func (a *App) viewNameTaken(ctx context.Context, name string, exceptID int64) (bool, error) {
+	var n int
+	err := a.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM views WHERE name = ? AND id <> ?`, name, exceptID).Scan(&n)
+	return n > 0, err
+}
+
+// handleViews 处理 /api/views：GET 列出视图，POST 保存新视图（名称重复返回 409）
+func (a *App) handleViews(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	switch r.Method {
+	case http.MethodGet:
+		items, err := a.fetchViews(ctx)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, viewListResponse{Items: items})
+	case http.MethodPost:
+		var body viewRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		var v View
+		if err := a.applyViewRequest(&v, body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		a.saveView(w, r, v, http.StatusCreated)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// saveView 写入新视图（v.ID 为 0）或更新已有视图，并返回保存后的视图
+func (a *App) saveView(w http.ResponseWriter, r *http.Request, v View, status int) {
+	ctx := r.Context()
+	taken, err := a.viewNameTaken(ctx, v.Name, v.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if taken {
+		writeJSON(w, http.StatusConflict, map[string]string{"error": "view name already exists"})
+		return
+	}
+	filters, err := json.Marshal(v.Filters)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	now := time.Now().Format(time.RFC3339)
+	if v.ID == 0 {
+		res, err := a.db.ExecContext(ctx, `INSERT INTO views (name, filters, sort_field, sort_order, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)`,
+			v.Name, string(filters), v.Sort.Field, v.Sort.Order, now, now)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		v.ID, _ = res.LastInsertId()
+		a.reqLogger(r).Info("已保存视图", "view_id", v.ID, "name", v.Name)
+	} else {
+		if _, err := a.db.ExecContext(ctx, `UPDATE views SET name = ?, filters = ?, sort_field = ?, sort_order = ?, updated_at = ? WHERE id = ?`,
+			v.Name, string(filters), v.Sort.Field, v.Sort.Order, now, v.ID); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		a.reqLogger(r).Info("已更新视图", "view_id", v.ID, "name", v.Name)
+	}
+	saved, err := a.fetchView(ctx, v.ID)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, status, saved)
+}
+
+// handleViewItem 处理 /api/views/{id}：GET 返回视图，PUT/PATCH 更新，DELETE 删除；
+// /api/views/{id}/tasks 执行视图并返回匹配的任务
+func (a *App) handleViewItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	idStr, sub, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/api/views/"), "/")
+	id, err := parseInt64(idStr)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid id"})
+		return
+	}
+	addLogAttrs(r, "view_id", id)
+	if sub != "" && sub != "tasks" {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "not found"})
+		return
+	}
+	v, err := a.fetchView(ctx, id)
+	if err == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "view not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if sub == "tasks" {
+		if r.Method != http.MethodGet {
+			writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+			return
+		}
+		a.handleViewTasks(w, r, v)
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, v)
+	case http.MethodPut, http.MethodPatch:
+		var body viewRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		if err := a.applyViewRequest(&v, body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		a.saveView(w, r, v, http.StatusOK)
+	case http.MethodDelete:
+		if _, err := a.db.ExecContext(ctx, `DELETE FROM views WHERE id = ?`, id); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		a.reqLogger(r).Info("已删除视图", "name", v.Name)
+		writeJSON(w, http.StatusOK, map[string]any{"id": id, "deleted": true})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// handleViewTasks 执行视图：按保存的条件与排序分页返回任务，在板任务附带老化信息
+func (a *App) handleViewTasks(w http.ResponseWriter, r *http.Request, v View) {
+	ctx := r.Context()
+	page, size := int64(1), int64(50)
+	if p := strings.TrimSpace(r.URL.Query().Get("page")); p != "" {
+		if n, err := parseInt64(p); err == nil && n > 0 {
+			page = n
+		}
+	}
+	if s := strings.TrimSpace(r.URL.Query().Get("page_size")); s != "" {
+		if n, err := parseInt64(s); err == nil && n > 0 && n <= 200 {
+			size = n
+		}
+	}
+	offset := (page - 1) * size
+	now := time.Now()
+	cond, args, order := v.taskQuery(a.cfg.Tags, a.cfg.Search, now)
+	var total int64
+	if err := a.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM tasks "+cond, args...).Scan(&total); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	items, err := a.queryTasks(ctx, `SELECT `+taskColumns+` FROM tasks `+cond+` `+order+` LIMIT ? OFFSET ?`, append(args, size, offset)...)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	if items == nil {
+		items = []Task{}
+	}
+	if !v.Filters.Archived {
+		cols, err := a.fetchColumns(ctx)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		annotateTaskAges(items, cols, now)
+	}
+	writeJSON(w, http.StatusOK, viewTasksResponse{
+		View:     v,
+		Items:    items,
+		Total:    total,
+		Page:     page,
+		PageSize: size,
+		HasMore:  offset+int64(len(items)) < total,
+	})
+}
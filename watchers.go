@@ -0,0 +1,282 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	htmltemplate "html/template"
+	"net/http"
+	"sort"
+	texttemplate "text/template"
+	"time"
+)
+
+// 任务关注者：GET /api/tasks/{id} 返回任务详情及关注者列表；配置 smtp 后，关注的任务状态变化、归档或恢复、
+// 标题或描述被修改以及新增评论时，按轮次汇总为一封邮件发给每位关注者（用户须有邮箱）。
+// 关注与取消关注见 /api/tasks/{id}/watch
+
+// watcherPollInterval 为检查关注任务变更的间隔，同一轮内的多项变更合并为一封邮件
+const watcherPollInterval = 30 * time.Second
+
+// watcherCommentExcerpt 为通知邮件中评论摘录的最大字符数
+const watcherCommentExcerpt = 200
+
+// TaskWatcher 为任务的一位关注者
+type TaskWatcher struct {
+	UserID    int64     `json:"user_id"`
+	Name      string    `json:"name"`
+	AvatarURL string    `json:"avatar_url,omitempty"`
+	Since     time.Time `json:"since"`
+}
+
+// taskDetailResponse 为 GET /api/tasks/{id} 的响应结构
+type taskDetailResponse struct {
+	Task
+	Watchers []TaskWatcher `json:"watchers"`
+}
+
+// watcherChange 为关注的任务上的一项变更
+type watcherChange struct {
+	TaskID int64
+	Title  string
+	Detail string
+	At     time.Time
+}
+
+// watcherMail 为发给一位关注者的通知内容
+type watcherMail struct {
+	Name    string
+	Changes []watcherChange
+}
+
+// watcherCursors 为变更通知在事件、历史版本、评论与 @ 提及记录四处的处理位置；关注邮件不使用提及记录
+type watcherCursors struct {
+	Event, Version, Comment, Mention int64
+}
+
+// latestWatcherCursors 返回各来源当前的最大位置，来源为空时沿用 cur
+func (a *App) latestWatcherCursors(ctx context.Context, cur watcherCursors) (watcherCursors, error) {
+	next := cur
+	err := a.db.QueryRowContext(ctx, `SELECT
+		(SELECT COALESCE(MAX(id), ?) FROM task_events),
+		(SELECT COALESCE(MAX(rowid), ?) FROM task_versions),
+		(SELECT COALESCE(MAX(id), ?) FROM task_comments),
+		(SELECT COALESCE(MAX(id), ?) FROM user_mentions)`, cur.Event, cur.Version, cur.Comment, cur.Mention).
+		Scan(&next.Event, &next.Version, &next.Comment, &next.Mention)
+	return next, err
+}
+
+// fetchTaskWatchers 返回任务的关注者，按关注时间排列
+func (a *App) fetchTaskWatchers(ctx context.Context, taskID int64) ([]TaskWatcher, error) {
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT u.id, u.name, u.avatar_url, w.created_at
+		FROM task_watchers w JOIN users u ON u.id = w.user_id
+		WHERE w.task_id = ? ORDER BY w.created_at, u.id`, taskID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []TaskWatcher{}
+	for rows.Next() {
+		var tw TaskWatcher
+		var since string
+		if err := rows.Scan(&tw.UserID, &tw.Name, &tw.AvatarURL, &since); err != nil {
+			return nil, err
+		}
+		tw.Since, _ = time.Parse(time.RFC3339, since)
+		out = append(out, tw)
+	}
+	return out, rows.Err()
+}
+
+// handleTaskDetail 处理 GET /api/tasks/{id}：任务详情及关注者
+func (a *App) handleTaskDetail(w http.ResponseWriter, r *http.Request, id int64) {
+	ctx := r.Context()
+	t, err := a.fetchTaskDetail(ctx, id)
+	if err == sql.ErrNoRows {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "task not found"})
+		return
+	}
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	watchers, err := a.fetchTaskWatchers(ctx, id)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, taskDetailResponse{Task: t, Watchers: watchers})
+}
+
+// startWatcherNotifications 启动关注通知后台任务，只通知启动之后发生的变更；未配置 smtp 时不启动
+func (a *App) startWatcherNotifications() {
+	if !a.cfg.SMTP.enabled() {
+		return
+	}
+	cur, err := a.latestWatcherCursors(context.Background(), watcherCursors{})
+	if err != nil {
+		a.logger.Error("关注通知启动失败", "err", err)
+		return
+	}
+	a.eventCursors.set("watcher-notifications", cur.Event)
+	a.startBackground("watcher-notifications", func(ctx context.Context) {
+		ticker := time.NewTicker(watcherPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+			next, err := a.notifyTaskWatchers(ctx, cur)
+			if err != nil && ctx.Err() == nil {
+				a.logger.Error("关注通知失败", "err", err)
+			}
+			cur = next
+			a.eventCursors.set("watcher-notifications", cur.Event)
+		}
+	})
+}
+
+// notifyTaskWatchers 汇总 cur 之后关注任务上的变更并逐位关注者发送邮件，返回新的游标；
+// 单封邮件发送失败只记录日志，不重发
+func (a *App) notifyTaskWatchers(ctx context.Context, cur watcherCursors) (watcherCursors, error) {
+	next, err := a.latestWatcherCursors(ctx, cur)
+	if err != nil {
+		return cur, err
+	}
+	if next == cur {
+		return cur, nil
+	}
+	// 各来源统一为 (任务, 类型, 内容一, 内容二, 时间)，只取有关注者的任务
+	rows, err := a.db.QueryContext(ctx, `
+		SELECT task_id, kind, from_status, to_status, created_at FROM task_events
+		WHERE id > ? AND id <= ? AND kind IN ('status', 'archived', 'restored')
+			AND task_id IN (SELECT task_id FROM task_watchers)
+		UNION ALL
+		SELECT task_id, 'edited', '', '', replaced_at FROM task_versions
+		WHERE rowid > ? AND rowid <= ? AND task_id IN (SELECT task_id FROM task_watchers)
+		UNION ALL
+		SELECT task_id, 'comment', author, body, created_at FROM task_comments
+		WHERE id > ? AND id <= ? AND task_id IN (SELECT task_id FROM task_watchers)`,
+		cur.Event, next.Event, cur.Version, next.Version, cur.Comment, next.Comment)
+	if err != nil {
+		return cur, err
+	}
+	locale := a.outboundLocale()
+	changes := map[int64][]watcherChange{}
+	for rows.Next() {
+		var c watcherChange
+		var kind, x, y, at string
+		if err := rows.Scan(&c.TaskID, &kind, &x, &y, &at); err != nil {
+			rows.Close()
+			return cur, err
+		}
+		c.At, _ = time.Parse(time.RFC3339, at)
+		c.Detail = describeWatcherChange(kind, x, y).text(locale)
+		changes[c.TaskID] = append(changes[c.TaskID], c)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return cur, err
+	}
+	if len(changes) == 0 {
+		return next, nil
+	}
+	mails := map[string]*watcherMail{}
+	for taskID, list := range changes {
+		var title string
+		if err := a.db.QueryRowContext(ctx, `SELECT title FROM tasks WHERE id = ?`, taskID).Scan(&title); err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			return cur, err
+		}
+		for i := range list {
+			list[i].Title = title
+		}
+		wrows, err := a.db.QueryContext(ctx, `SELECT u.name, u.email FROM task_watchers w JOIN users u ON u.id = w.user_id
+			WHERE w.task_id = ? AND u.email <> ''`, taskID)
+		if err != nil {
+			return cur, err
+		}
+		for wrows.Next() {
+			var name, email string
+			if err := wrows.Scan(&name, &email); err != nil {
+				wrows.Close()
+				return cur, err
+			}
+			m := mails[email]
+			if m == nil {
+				m = &watcherMail{Name: name}
+				mails[email] = m
+			}
+			m.Changes = append(m.Changes, list...)
+		}
+		wrows.Close()
+		if err := wrows.Err(); err != nil {
+			return cur, err
+		}
+	}
+	for email, m := range mails {
+		sort.Slice(m.Changes, func(i, j int) bool {
+			if !m.Changes[i].At.Equal(m.Changes[j].At) {
+				return m.Changes[i].At.Before(m.Changes[j].At)
+			}
+			return m.Changes[i].TaskID < m.Changes[j].TaskID
+		})
+		subject := translatef(locale, "[看板] 你关注的任务有 %d 项变更", len(m.Changes))
+		if c := m.Changes[0]; len(m.Changes) == 1 {
+			subject = translatef(locale, "[看板] TB-%d %s：%s", c.TaskID, c.Title, c.Detail)
+		}
+		msg, err := renderMail(locale, subject, watcherTextTmpl, watcherHTMLTmpl, m)
+		if err == nil {
+			msg.To = []string{email}
+			err = a.cfg.SMTP.sendMail(ctx, msg)
+		}
+		a.deliveries.record("watcher_email", err)
+		if err != nil {
+			a.logger.Warn("关注通知发送失败", "to", email, "err", err)
+			continue
+		}
+		a.logger.Info("已发送关注通知", "to", email, "changes", len(m.Changes))
+	}
+	return next, nil
+}
+
+// describeWatcherChange 将一项变更描述为一句话
+func describeWatcherChange(kind, x, y string) localMessage {
+	switch kind {
+	case taskEventStatus:
+		return localMessage{"从「%s」移到「%s」", []string{x, y}}
+	case taskEventArchived:
+		return localMessage{Format: "已归档"}
+	case taskEventRestored:
+		return localMessage{Format: "已从归档恢复"}
+	case "edited":
+		return localMessage{Format: "标题或描述已修改"}
+	case "comment":
+		return localMessage{"%s 评论：%s", []string{x, commentExcerpt(y)}}
+	}
+	return localMessage{"%s", []string{kind}}
+}
+
+// commentExcerpt 截取评论开头用于通知
+func commentExcerpt(body string) string {
+	r := []rune(body)
+	if len(r) > watcherCommentExcerpt {
+		return string(r[:watcherCommentExcerpt]) + "…"
+	}
+	return body
+}
+
+var watcherTextTmpl = texttemplate.Must(texttemplate.New("watcher").Funcs(mailFuncs).Parse(
+	`{{if .Name}}{{tf "%s，你关注的任务有以下变更：" .Name}}{{else}}{{t "你关注的任务有以下变更："}}{{end}}
+{{range .Changes}}
+- {{.At.Local.Format "01-02 15:04"}} {{tf "TB-%d「%s」%s" .TaskID .Title .Detail}}{{end}}
+`))
+
+var watcherHTMLTmpl = htmltemplate.Must(htmltemplate.New("watcher").Funcs(mailFuncs).Parse(
+	`<p>{{if .Name}}{{tf "%s，你关注的任务有以下变更：" .Name}}{{else}}{{t "你关注的任务有以下变更："}}{{end}}</p>
+<ul>{{range .Changes}}
+<li>{{.At.Local.Format "01-02 15:04"}} <strong>TB-{{.TaskID}} {{.Title}}</strong> {{.Detail}}</li>{{end}}
+</ul>`))
@@ -0,0 +1,19 @@
+// 浏览器推送的 Service Worker：显示服务端推送的提及与截止提醒，点击通知后回到看板
+self.addEventListener("push", (event) => {
+  const data = event.data ? event.data.json() : {};
+  event.waitUntil(self.registration.showNotification(data.title || "Task board", {
+    body: data.body || "",
+    tag: data.tag,
+    data: { url: data.url || "/" },
+  }));
+});
+
+self.addEventListener("notificationclick", (event) => {
+  event.notification.close();
+  event.waitUntil(clients.matchAll({ type: "window", includeUncontrolled: true }).then((list) => {
+    for (const c of list) {
+      if ("focus" in c) return c.focus();
+    }
+    return clients.openWindow(event.notification.data.url);
+  }));
+});
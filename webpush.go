@@ -0,0 +1,445 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/hkdf"
+)
+
+// 浏览器推送（Web Push）：前端通过 Service Worker 订阅后把订阅信息提交到 /api/push/subscriptions，
+// 站内通知中的提及（mention）与截止提醒（due_soon）生成时同时推送到该用户订阅的浏览器，标签页关闭时也能收到。
+// 推送内容按 RFC 8291 加密（aes128gcm），并以 RFC 8292 的 VAPID 签名向推送服务表明身份；
+// 推送服务返回 404 或 410 表示订阅已失效，随即删除。密钥对可通过 vapid-keys 命令生成
+
+// webPushRecordSize 为加密内容的记录大小，推送内容须在一条记录内
+const webPushRecordSize = 4096
+
+// webPushMaxBody 为推送正文的最大字符数，保证加密后不超过一条记录
+const webPushMaxBody = 500
+
+// webPushJWTLifetime 为 VAPID 签名的有效期，规范要求不超过 24 小时
+const webPushJWTLifetime = 12 * time.Hour
+
+// WebPushConfig 为浏览器推送配置：VAPID 密钥对为 base64url 编码（无填充）的 P-256 公钥与私钥，
+// Subject 为推送服务联系运维用的 mailto: 或 https: 地址；TTL 为推送服务在浏览器离线时保留消息的时长
+type WebPushConfig struct {
+	PublicKey  string   `yaml:"vapid_public_key"`
+	PrivateKey string   `yaml:"vapid_private_key"`
+	Subject    string   `yaml:"subject"`
+	TTL        Duration `yaml:"ttl"`
+}
+
+// enabled 判断是否启用浏览器推送
+func (c WebPushConfig) enabled() bool { return c.PrivateKey != "" || c.PublicKey != "" }
+
+// validate 校验浏览器推送配置：密钥对须匹配
+func (c WebPushConfig) validate() error {
+	if !c.enabled() {
+		return nil
+	}
+	if _, err := c.signingKey(); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(c.Subject, "mailto:") && !strings.HasPrefix(c.Subject, "https://") {
+		return fmt.Errorf("web_push.subject 须为 mailto: 或 https:// 地址: %q", c.Subject)
+	}
+	if c.TTL.Std() < 0 {
+		return errors.New("web_push.ttl 不能为负数")
+	}
+	return nil
+}
+
+// signingKey 解析 VAPID 私钥并确认与公钥匹配
+func (c WebPushConfig) signingKey() (*ecdsa.PrivateKey, error) {
+	d, err := base64.RawURLEncoding.DecodeString(c.PrivateKey)
+	if err != nil || len(d) != 32 {
+		return nil, errors.New("web_push.vapid_private_key 须为 base64url 编码的 32 字节私钥")
+	}
+	priv, err := ecdh.P256().NewPrivateKey(d)
+	if err != nil {
+		return nil, fmt.Errorf("web_push.vapid_private_key 无效: %w", err)
+	}
+	pub := priv.PublicKey().Bytes()
+	if base64.RawURLEncoding.EncodeToString(pub) != c.PublicKey {
+		return nil, errors.New("web_push.vapid_public_key 与私钥不匹配")
+	}
+	return &ecdsa.PrivateKey{
+		PublicKey: ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(pub[1:33]), Y: new(big.Int).SetBytes(pub[33:])},
+		D:         new(big.Int).SetBytes(d),
+	}, nil
+}
+
+// PushSubscription 为一个浏览器的推送订阅
+type PushSubscription struct {
+	ID        int64     `json:"id"`
+	Endpoint  string    `json:"endpoint"`
+	UserAgent string    `json:"user_agent"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// pushSubscriptionRequest 为前端提交的订阅，与浏览器 PushSubscription.toJSON() 的结构一致
+type pushSubscriptionRequest struct {
+	Endpoint string `json:"endpoint"`
+	Keys     struct {
+		P256DH string `json:"p256dh"`
+		Auth   string `json:"auth"`
+	} `json:"keys"`
+}
+
+// pushKeyResponse 为 GET /api/push/key 的响应结构
+type pushKeyResponse struct {
+	PublicKey string `json:"public_key"`
+}
+
+// pushMessage 为推送给 Service Worker 的内容
+type pushMessage struct {
+	Title string `json:"title"`
+	Body  string `json:"body"`
+	Tag   string `json:"tag"`
+	URL   string `json:"url"`
+}
+
+// decodePushKey 解析订阅中的 base64url 密钥，浏览器可能带有填充
+func decodePushKey(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// validate 校验订阅：端点须为 https 地址，p256dh 为未压缩的 P-256 公钥，auth 为 16 字节
+func (req pushSubscriptionRequest) validate() string {
+	u, err := url.Parse(req.Endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" || len(req.Endpoint) > 1024 {
+		return "invalid endpoint"
+	}
+	if k, err := decodePushKey(req.Keys.P256DH); err != nil || len(k) != 65 {
+		return "invalid p256dh key"
+	} else if _, err := ecdh.P256().NewPublicKey(k); err != nil {
+		return "invalid p256dh key"
+	}
+	if k, err := decodePushKey(req.Keys.Auth); err != nil || len(k) != 16 {
+		return "invalid auth key"
+	}
+	return ""
+}
+
+// handlePushKey 处理 GET /api/push/key：返回订阅所需的 VAPID 公钥，未配置推送时返回 404
+func (a *App) handlePushKey(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+		return
+	}
+	if !a.cfg.WebPush.enabled() {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "web push not configured"})
+		return
+	}
+	writeJSON(w, http.StatusOK, pushKeyResponse{PublicKey: a.cfg.WebPush.PublicKey})
+}
+
+// handlePushSubscriptions 处理 /api/push/subscriptions：GET 列出当前用户的订阅，POST 登记订阅
+// （同一用户重复提交同一端点时更新密钥，端点已属于其他用户时返回 409），DELETE 按请求体中的 endpoint 取消订阅
+func (a *App) handlePushSubscriptions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	userID := currentUserID(r)
+	if userID == 0 {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "not logged in"})
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		rows, err := a.db.QueryContext(ctx, `SELECT id, endpoint, user_agent, created_at FROM push_subscriptions WHERE user_id = ? ORDER BY id`, userID)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		defer rows.Close()
+		items := []PushSubscription{}
+		for rows.Next() {
+			var s PushSubscription
+			var created string
+			if err := rows.Scan(&s.ID, &s.Endpoint, &s.UserAgent, &created); err != nil {
+				writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+				return
+			}
+			s.CreatedAt, _ = time.Parse(time.RFC3339, created)
+			items = append(items, s)
+		}
+		if err := rows.Err(); err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"items": items})
+	case http.MethodPost:
+		if !a.cfg.WebPush.enabled() {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "web push not configured"})
+			return
+		}
+		var body pushSubscriptionRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		if msg := body.validate(); msg != "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": msg})
+			return
+		}
+		ua := r.UserAgent()
+		if len(ua) > 200 {
+			ua = ua[:200]
+		}
+		var id int64
+		err := a.db.QueryRowContext(ctx, `
+			INSERT INTO push_subscriptions (user_id, endpoint, p256dh, auth, user_agent, created_at) VALUES (?, ?, ?, ?, ?, ?)
+			ON CONFLICT(endpoint) DO UPDATE SET p256dh = excluded.p256dh, auth = excluded.auth, user_agent = excluded.user_agent
+			WHERE push_subscriptions.user_id = excluded.user_id
+			RETURNING id`,
+			userID, body.Endpoint, body.Keys.P256DH, body.Keys.Auth, ua, time.Now().UTC().Format(time.RFC3339)).Scan(&id)
+		if err == sql.ErrNoRows {
+			// 端点已由其他用户订阅，不允许接管
+			writeJSON(w, http.StatusConflict, map[string]string{"error": "endpoint already subscribed by another user"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusCreated, idResponse{ID: id})
+	case http.MethodDelete:
+		var body struct {
+			Endpoint string `json:"endpoint"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.Endpoint == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid json"})
+			return
+		}
+		var id int64
+		err := a.db.QueryRowContext(ctx, `DELETE FROM push_subscriptions WHERE user_id = ? AND endpoint = ? RETURNING id`, userID, body.Endpoint).Scan(&id)
+		if err == sql.ErrNoRows {
+			writeJSON(w, http.StatusNotFound, map[string]string{"error": "subscription not found"})
+			return
+		}
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]any{"id": id, "deleted": true})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, map[string]string{"error": "method not allowed"})
+	}
+}
+
+// pushNotifications 将新生成的提及与截止提醒推送到对应用户订阅的浏览器；发送失败只记录日志
+func (a *App) pushNotifications(ctx context.Context, list []pendingNotification) {
+	if !a.cfg.WebPush.enabled() || len(list) == 0 {
+		return
+	}
+	key, err := a.cfg.WebPush.signingKey()
+	if err != nil {
+		a.logger.Error("浏览器推送密钥无效", "err", err)
+		return
+	}
+	locale := a.outboundLocale()
+	client := &http.Client{Timeout: reminderHTTPTimeout}
+	for _, n := range list {
+		msg := pushMessage{Body: n.Body.text(locale), Tag: "tb-" + n.Key, URL: "/"}
+		var title string
+		if err := a.db.QueryRowContext(ctx, `SELECT title FROM tasks WHERE id = ?`, n.TaskID).Scan(&title); err == sql.ErrNoRows {
+			continue
+		} else if err != nil {
+			a.logger.Warn("浏览器推送失败", "err", err)
+			return
+		}
+		msg.Title = fmt.Sprintf("TB-%d %s", n.TaskID, title)
+		if r := []rune(msg.Body); len(r) > webPushMaxBody {
+			msg.Body = string(r[:webPushMaxBody]) + "…"
+		}
+		payload, _ := json.Marshal(msg)
+		rows, err := a.db.QueryContext(ctx, `SELECT id, endpoint, p256dh, auth FROM push_subscriptions WHERE user_id = ?`, n.UserID)
+		if err != nil {
+			a.logger.Warn("浏览器推送失败", "err", err)
+			return
+		}
+		type target struct {
+			id                     int64
+			endpoint, p256dh, auth string
+		}
+		var targets []target
+		for rows.Next() {
+			var t target
+			if err := rows.Scan(&t.id, &t.endpoint, &t.p256dh, &t.auth); err == nil {
+				targets = append(targets, t)
+			}
+		}
+		rows.Close()
+		for _, t := range targets {
+			gone, err := sendWebPush(ctx, client, a.cfg.WebPush, key, t.endpoint, t.p256dh, t.auth, payload)
+			a.deliveries.record("web_push", err)
+			if gone {
+				_, _ = a.db.ExecContext(ctx, `DELETE FROM push_subscriptions WHERE id = ?`, t.id)
+				a.logger.Info("浏览器推送订阅已失效，已删除", "subscription", t.id)
+				continue
+			}
+			if err != nil {
+				a.logger.Warn("浏览器推送失败", "subscription", t.id, "err", err)
+			}
+		}
+	}
+}
+
+// sendWebPush 加密内容并发送到推送服务；gone 为 true 表示订阅已失效
+func sendWebPush(ctx context.Context, client *http.Client, c WebPushConfig, key *ecdsa.PrivateKey, endpoint, p256dh, auth string, payload []byte) (gone bool, err error) {
+	body, err := encryptWebPush(p256dh, auth, payload)
+	if err != nil {
+		return false, err
+	}
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return false, err
+	}
+	jwt, err := vapidToken(key, u.Scheme+"://"+u.Host, c.Subject, time.Now())
+	if err != nil {
+		return false, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Encoding", "aes128gcm")
+	req.Header.Set("TTL", strconv.Itoa(int(c.TTL.Std().Seconds())))
+	req.Header.Set("Authorization", "vapid t="+jwt+", k="+c.PublicKey)
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	switch {
+	case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+		return true, nil
+	case resp.StatusCode >= 300:
+		return false, fmt.Errorf("推送服务返回 %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return false, nil
+}
+
+// encryptWebPush 按 RFC 8291 加密推送内容：以临时密钥与浏览器公钥协商出共享密钥，
+// 结合 auth 派生内容密钥与随机数，输出 aes128gcm 编码（RFC 8188）的单条记录
+func encryptWebPush(p256dh, auth string, payload []byte) ([]byte, error) {
+	uaRaw, err := decodePushKey(p256dh)
+	if err != nil {
+		return nil, err
+	}
+	uaPub, err := ecdh.P256().NewPublicKey(uaRaw)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := decodePushKey(auth)
+	if err != nil {
+		return nil, err
+	}
+	local, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	shared, err := local.ECDH(uaPub)
+	if err != nil {
+		return nil, err
+	}
+	asPub := local.PublicKey().Bytes()
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, err
+	}
+	info := append(append([]byte("WebPush: info\x00"), uaRaw...), asPub...)
+	ikm, err := hkdfBytes(hkdf.Extract(sha256.New, shared, secret), info, 32)
+	if err != nil {
+		return nil, err
+	}
+	prk := hkdf.Extract(sha256.New, ikm, salt)
+	cek, err := hkdfBytes(prk, []byte("Content-Encoding: aes128gcm\x00"), 16)
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := hkdfBytes(prk, []byte("Content-Encoding: nonce\x00"), 12)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(cek)
+	if err != nil {
+		return nil, err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	// 单条记录以 0x02 结尾表示最后一条，不加填充
+	plain := append(append([]byte{}, payload...), 0x02)
+	if len(plain)+gcm.Overhead() > webPushRecordSize {
+		return nil, errors.New("推送内容过长")
+	}
+	header := make([]byte, 0, 21+len(asPub))
+	header = append(header, salt...)
+	header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+	header = append(header, byte(len(asPub)))
+	header = append(header, asPub...)
+	return gcm.Seal(header, nonce, plain, nil), nil
+}
+
+// hkdfBytes 从伪随机密钥按 info 派生 n 字节
+func hkdfBytes(prk, info []byte, n int) ([]byte, error) {
+	out := make([]byte, n)
+	_, err := io.ReadFull(hkdf.Expand(sha256.New, prk, info), out)
+	return out, err
+}
+
+// vapidToken 生成 VAPID 使用的 ES256 JWT，aud 为推送服务的源
+func vapidToken(key *ecdsa.PrivateKey, audience, subject string, now time.Time) (string, error) {
+	enc := base64.RawURLEncoding
+	header := enc.EncodeToString([]byte(`{"typ":"JWT","alg":"ES256"}`))
+	claims, err := json.Marshal(map[string]any{"aud": audience, "exp": now.Add(webPushJWTLifetime).Unix(), "sub": subject})
+	if err != nil {
+		return "", err
+	}
+	signing := header + "." + enc.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(signing))
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		return "", err
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	return signing + "." + enc.EncodeToString(sig), nil
+}
+
+// runVAPIDKeys 生成一对 VAPID 密钥并打印配置片段
+func runVAPIDKeys() error {
+	priv, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	enc := base64.RawURLEncoding
+	fmt.Printf("web_push:\n  vapid_public_key: %s\n  vapid_private_key: %s\n  subject: mailto:admin@example.com\n",
+		enc.EncodeToString(priv.PublicKey().Bytes()), enc.EncodeToString(priv.Bytes()))
+	return nil
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestPushSubscriptionEndpointCannotBeTakenOver(t *testing.T) {
+	app := newTestApp(t, func(c *Config) { c.WebPush.PublicKey = "test" })
+	for _, id := range []int{1, 2} {
+		if _, err := app.db.Exec(`INSERT INTO users (id, name, created_at) VALUES (?, 'u', '2026-01-01T00:00:00Z')`, id); err != nil {
+			t.Fatal(err)
+		}
+	}
+	key, err := ecdh.P256().GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	auth := make([]byte, 16)
+	rand.Read(auth)
+	body, _ := json.Marshal(map[string]any{
+		"endpoint": "https://push.example.com/sub/1",
+		"keys": map[string]string{
+			"p256dh": base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+			"auth":   base64.RawURLEncoding.EncodeToString(auth),
+		},
+	})
+	subscribe := func(userID int64) int {
+		req := httptest.NewRequest(http.MethodPost, "/api/push/subscriptions", strings.NewReader(string(body)))
+		req = req.WithContext(context.WithValue(req.Context(), userCtxKey{}, userID))
+		rec := httptest.NewRecorder()
+		app.handlePushSubscriptions(rec, req)
+		return rec.Code
+	}
+	if code := subscribe(1); code != http.StatusCreated {
+		t.Fatalf("first subscribe = %d, want 201", code)
+	}
+	if code := subscribe(2); code != http.StatusConflict {
+		t.Errorf("subscribe by another user = %d, want 409", code)
+	}
+	if code := subscribe(1); code != http.StatusCreated {
+		t.Errorf("resubscribe by owner = %d, want 201", code)
+	}
+	var owner int64
+	if err := app.db.QueryRow(`SELECT user_id FROM push_subscriptions WHERE endpoint = 'https://push.example.com/sub/1'`).Scan(&owner); err != nil || owner != 1 {
+		t.Errorf("owner = %d (%v), want 1", owner, err)
+	}
+}
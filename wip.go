@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// 在制品（WIP）限制：每个状态列可设置 wip_limit（0 表示不限制）。任务通过状态变更、审批通过或恢复进入该列后，
+// 若列中在板任务数将超过限制，wip_mode 为 reject 时拒绝（422），为 warn 时照常变更并在响应中附带警告头。
+// 代码推送、事故联动等自动流转不受限制，但超限情况同样会出现在 /api/stats 的 wip_violations 中
+
+// WIP 超限时的处理方式
+const (
+	wipModeReject = "reject"
+	wipModeWarn   = "warn"
+)
+
+// wipViolation 为一个超出 WIP 限制的状态列
+type wipViolation struct {
+	Status string `json:"status"`
+	Limit  int    `json:"limit"`
+	Count  int    `json:"count"`
+}
+
+// validWIPMode 判断是否为合法的 WIP 处理方式
+func validWIPMode(mode string) bool {
+	return mode == wipModeReject || mode == wipModeWarn
+}
+
+// columnWIP 返回状态列的 WIP 限制与处理方式，未配置时限制为 0
+func (a *App) columnWIP(ctx context.Context, status string) (int, string, error) {
+	cols, err := a.fetchColumns(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+	for _, c := range cols {
+		if c.Status == status {
+			return c.WIPLimit, c.WIPMode, nil
+		}
+	}
+	return 0, wipModeReject, nil
+}
+
+// checkWIPLimit 检查任务 taskID 进入 status 列后是否超过 WIP 限制；任务已在该列时不计入新增。
+// 超限且为 reject 时写入 422 并返回 false，为 warn 时附加警告头
+func (a *App) checkWIPLimit(ctx context.Context, w http.ResponseWriter, status string, taskID int64) bool {
+	limit, mode, err := a.columnWIP(ctx, status)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return false
+	}
+	if limit <= 0 {
+		return true
+	}
+	var count int
+	if err := a.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM tasks WHERE archived = 0 AND status = ? AND id <> ?`, status, taskID).Scan(&count); err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": err.Error()})
+		return false
+	}
+	if count+1 <= limit {
+		return true
+	}
+	if mode == wipModeWarn {
+		w.Header().Add(warningHeader, fmt.Sprintf("wip %s %d exceeds limit %d", status, count+1, limit))
+		return true
+	}
+	writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "wip limit reached", "status": status, "limit": limit, "count": count})
+	return false
+}
+
+// wipViolations 返回在板任务数超过 WIP 限制的状态列；active 为各状态的在板任务数
+func wipViolations(cols []Column, active map[string]int) []wipViolation {
+	out := []wipViolation{}
+	for _, c := range cols {
+		if c.WIPLimit > 0 && active[c.Status] > c.WIPLimit {
+			out = append(out, wipViolation{Status: c.Status, Limit: c.WIPLimit, Count: active[c.Status]})
+		}
+	}
+	return out
+}